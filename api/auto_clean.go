@@ -0,0 +1,185 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	pdfPkg "pdf_editor/pdf"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Response shapes of the auto-clean endpoint, selected with the Accept header
+const (
+	mimeMultipartMixed = "multipart/mixed"
+	mimeJSON           = "application/json"
+	mimePDF            = "application/pdf"
+)
+
+// HandleAutoClean analyzes an uploaded PDF and removes the confident candidates in one
+// request. The response carries both the analysis and the cleaned PDF: a multipart/mixed
+// body by default, a JSON envelope with the PDF in base64 for "Accept: application/json",
+// or just the PDF (outcome in headers) for "Accept: application/pdf".
+func HandleAutoClean(c *gin.Context, config *Config) {
+	format := c.NegotiateFormat(mimeMultipartMixed, mimeJSON, mimePDF)
+	if format == "" {
+		c.JSON(http.StatusNotAcceptable, gin.H{"error": "Accept must allow multipart/mixed, application/json or application/pdf"})
+		return
+	}
+
+	minConfidence, notes, err := parseMinConfidence(c, config)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	topN := 0
+	if value := c.PostForm("top_n"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "top_n must be a non-negative integer"})
+			return
+		}
+		topN = parsed
+	}
+
+	opts := pdfPkg.AutoCleanOptions{
+		Analysis: pdfPkg.AnalysisOptions{
+			DeepMatch:         c.PostForm("deep_match") == "true",
+			DetectBlankPages:  c.PostForm("detect_blank_pages") == "true",
+			EarlyExit:         c.PostForm("early_exit") == "true",
+			ClassifyPlacement: c.PostForm("classify_placement") == "true",
+			PrefixFilter:      parsePrefixFilter(c),
+		},
+		Removal: pdfPkg.RemovalOptions{
+			PreserveDimensions:  c.PostForm("preserve_placement") == "true",
+			OptimizeAfter:       c.DefaultPostForm("optimize_after", "true") == "true",
+			ProtectedObjects:    config.ProtectedObjects,
+			MaxImageOccurrences: config.MaxImageOccurrences,
+			MatchMode:           c.PostForm("match_mode"),
+		},
+		MinConfidence: minConfidence,
+		TopN:          topN,
+		Verify:        c.PostForm("verify") == "true",
+	}
+	if err := pdfPkg.ValidateMatchMode(opts.Removal.MatchMode); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	auditDir, err := parseAuditDir(c, config)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	opts.Removal.AuditDir = auditDir
+
+	inFile, uniqueID, ok := saveUploadedPDF(c, config, "autoclean_")
+	if !ok {
+		return
+	}
+	defer os.Remove(inFile)
+	outFile := filepath.Join(config.TempDir, "autoclean_"+uniqueID+"_cleaned.pdf")
+	defer os.Remove(outFile)
+
+	result, err := pdfPkg.AutoClean(inFile, outFile, opts)
+	if err != nil {
+		log.Printf("Auto-clean error: %v", err)
+		c.JSON(errorStatus(err), errorResponse(err, "Auto-clean failed"))
+		return
+	}
+	result.Notes = notes
+	data, err := os.ReadFile(outFile)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read cleaned file"})
+		return
+	}
+	filename := "document_cleaned.pdf"
+
+	switch format {
+	case mimeJSON:
+		c.JSON(http.StatusOK, gin.H{
+			"analysis":    result.Analysis,
+			"removed_ids": result.RemovedIDs,
+			"report":      result.Report,
+			"diff":        result.Diff,
+			"notes":       result.Notes,
+			"filename":    filename,
+			"pdf_base64":  base64.StdEncoding.EncodeToString(data),
+		})
+	case mimePDF:
+		c.Header("X-Removed-Images", strconv.Itoa(result.Report.Removed))
+		c.Header("X-Removed-Elements", strconv.Itoa(len(result.RemovedIDs)))
+		c.Header("X-Min-Confidence", strconv.FormatFloat(minConfidence, 'g', -1, 64))
+		if result.Diff != nil {
+			c.Header("X-Eliminated-Candidates", strconv.Itoa(len(result.Diff.Eliminated)))
+			c.Header("X-Introduced-Candidates", strconv.Itoa(len(result.Diff.Introduced)))
+		}
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		c.Data(http.StatusOK, mimePDF, data)
+	default:
+		if err := writeMultipartMixed(c, result, data, filename); err != nil {
+			log.Printf("Auto-clean response error: %v", err)
+		}
+	}
+}
+
+// writeMultipartMixed sends the auto-clean result as a JSON part followed by the PDF part
+func writeMultipartMixed(c *gin.Context, result *pdfPkg.AutoCleanResult, pdfData []byte, filename string) error {
+	summary, err := json.Marshal(result)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode analysis"})
+		return err
+	}
+
+	mw := multipart.NewWriter(c.Writer)
+	c.Header("Content-Type", mimeMultipartMixed+"; boundary="+mw.Boundary())
+	c.Status(http.StatusOK)
+
+	jsonPart, err := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {mimeJSON}})
+	if err != nil {
+		return err
+	}
+	if _, err := jsonPart.Write(summary); err != nil {
+		return err
+	}
+
+	pdfPart, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":        {mimePDF},
+		"Content-Disposition": {fmt.Sprintf("attachment; filename=%q", filename)},
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := pdfPart.Write(pdfData); err != nil {
+		return err
+	}
+	return mw.Close()
+}
+
+// parseMinConfidence reads the "min_confidence" field, DefaultAutoCleanMinConfidence if
+// absent. Misconfigured clients must not remove more than the server allows, so values
+// below config.MinConfidenceFloor are raised to it with a note saying so.
+func parseMinConfidence(c *gin.Context, config *Config) (float64, []string, error) {
+	minConfidence := pdfPkg.DefaultAutoCleanMinConfidence
+	if value := c.PostForm("min_confidence"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed < 0 || parsed > 1 {
+			return 0, nil, fmt.Errorf("min_confidence must be a number between 0 and 1")
+		}
+		minConfidence = parsed
+	}
+	var notes []string
+	if minConfidence < config.MinConfidenceFloor {
+		notes = append(notes, fmt.Sprintf("min_confidence %g raised to the server's floor of %g", minConfidence, config.MinConfidenceFloor))
+		minConfidence = config.MinConfidenceFloor
+	}
+	return minConfidence, notes, nil
+}
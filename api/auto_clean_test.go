@@ -0,0 +1,128 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	pdfPkg "pdf_editor/pdf"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAutoCleanMultipartResponse(t *testing.T) {
+	result := &pdfPkg.AutoCleanResult{
+		Analysis:   &pdfPkg.UnwantedElementsAnalysis{TotalPages: 3},
+		RemovedIDs: []string{"img_1", "blank_page_3"},
+		Report:     pdfPkg.RemovalReport{Removed: 3},
+		Notes:      []string{"min_confidence 0.1 raised to the server's floor of 0.5"},
+	}
+	pdfData := []byte("%PDF-1.7\ncleaned\n%%EOF\n")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	if err := writeMultipartMixed(c, result, pdfData, "document_cleaned.pdf"); err != nil {
+		t.Fatalf("writeMultipartMixed: %v", err)
+	}
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d, want 200", w.Code)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	if err != nil || mediaType != mimeMultipartMixed || params["boundary"] == "" {
+		t.Fatalf("Content-Type %q (%v), want multipart/mixed with a boundary", w.Header().Get("Content-Type"), err)
+	}
+	mr := multipart.NewReader(w.Body, params["boundary"])
+
+	// The JSON part comes first and decodes back to the result
+	part, err := mr.NextPart()
+	if err != nil {
+		t.Fatalf("first part: %v", err)
+	}
+	if ct := part.Header.Get("Content-Type"); ct != mimeJSON {
+		t.Errorf("first part Content-Type %q, want %q", ct, mimeJSON)
+	}
+	var decoded pdfPkg.AutoCleanResult
+	if err := json.NewDecoder(part).Decode(&decoded); err != nil {
+		t.Fatalf("decoding the JSON part: %v", err)
+	}
+	if !slices.Equal(decoded.RemovedIDs, result.RemovedIDs) || decoded.Report.Removed != 3 ||
+		decoded.Analysis == nil || decoded.Analysis.TotalPages != 3 || len(decoded.Notes) != 1 {
+		t.Errorf("JSON part = %+v, want %+v", decoded, *result)
+	}
+
+	// Then the PDF, unchanged, as an attachment
+	part, err = mr.NextPart()
+	if err != nil {
+		t.Fatalf("second part: %v", err)
+	}
+	if ct := part.Header.Get("Content-Type"); ct != mimePDF {
+		t.Errorf("second part Content-Type %q, want %q", ct, mimePDF)
+	}
+	if part.FileName() != "document_cleaned.pdf" {
+		t.Errorf("second part filename %q, want document_cleaned.pdf", part.FileName())
+	}
+	data, err := io.ReadAll(part)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != string(pdfData) {
+		t.Errorf("PDF part = %q, want %q", data, pdfData)
+	}
+
+	if _, err := mr.NextPart(); err != io.EOF {
+		t.Errorf("unexpected third part: %v", err)
+	}
+}
+
+func TestAutoCleanRejectsUnacceptableFormat(t *testing.T) {
+	r := gin.New()
+	SetupRoutes(r, &Config{MaxFileSize: 1 << 20, TempDir: t.TempDir()})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/pdf/auto-clean", nil)
+	req.Header.Set("Accept", "text/html")
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusNotAcceptable {
+		t.Errorf("status %d for Accept: text/html, want 406", w.Code)
+	}
+}
+
+func TestParseMinConfidence(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		floor    float64
+		want     float64
+		wantNote bool
+		wantErr  bool
+	}{
+		{name: "clamped to the floor", body: "min_confidence=0.3", floor: 0.6, want: 0.6, wantNote: true},
+		{name: "above the floor", body: "min_confidence=0.8", floor: 0.6, want: 0.8},
+		{name: "at the floor", body: "min_confidence=0.6", floor: 0.6, want: 0.6},
+		{name: "default above the floor", body: "", floor: 0.6, want: pdfPkg.DefaultAutoCleanMinConfidence},
+		{name: "default below the floor", body: "", floor: 0.9, want: 0.9, wantNote: true},
+		{name: "no floor", body: "min_confidence=0.3", want: 0.3},
+		{name: "out of range", body: "min_confidence=1.5", floor: 0.6, wantErr: true},
+		{name: "not a number", body: "min_confidence=high", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, notes, err := parseMinConfidence(formContext(tt.body), &Config{MinConfidenceFloor: tt.floor})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("min confidence %g, want %g", got, tt.want)
+			}
+			if (len(notes) > 0) != tt.wantNote {
+				t.Errorf("notes %q, want a note %v", notes, tt.wantNote)
+			}
+		})
+	}
+}
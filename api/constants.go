@@ -6,10 +6,108 @@ const (
 	// FileCleanupDelay is the delay before cleaning up temp files after response is sent
 	FileCleanupDelay = 2 * time.Second
 	
-	// AnalysisCleanupDelay is the delay before cleaning up analysis temp files
+	// AnalysisCleanupDelay is the delay before cleaning up the input of a failed analysis
 	AnalysisCleanupDelay = 1 * time.Second
 	
 	// DefaultFilePermissions for temp directory creation
 	DefaultFilePermissions = 0755
+
+	// OutputRetention is how long a processed output stays downloadable by its file ID
+	OutputRetention = 5 * time.Minute
+
+	// UploadDedupWindow is how long an upload is returned again for identical content when
+	// UPLOAD_DEDUP is enabled
+	UploadDedupWindow = 10 * time.Minute
+
+	// AnalysisSessionTTL is how long the input of an analysis stays available to previews
+	// and removal by its session_id, unless the session is deleted earlier
+	AnalysisSessionTTL = 30 * time.Minute
+
+	// MaxAnalysisSessions is the maximum number of analysis sessions kept at once
+	MaxAnalysisSessions = 200
+
+	// MaxAnalysisSessionBytes is the maximum total size of the files kept by analysis sessions
+	MaxAnalysisSessionBytes = 2 * 1024 * 1024 * 1024
+
+	// MinAnalysisSessionAge is how long a new session is protected from eviction; when
+	// the store is full of younger sessions, new analyses are refused with a 503
+	MinAnalysisSessionAge = 1 * time.Minute
+
+	// MaxRunningJobs is the maximum number of background analysis jobs running at once
+	MaxRunningJobs = 10
+
+	// JobResultTTL is how long the status and result of a finished job stay available
+	JobResultTTL = 30 * time.Minute
+
+	// JobCancelWait is how long cancelling a job waits for its analysis to stop
+	JobCancelWait = 10 * time.Second
+
+	// DebugLogTTL is how long the debug logs of an analysis stay available from /debug-logs
+	DebugLogTTL = 5 * time.Minute
+
+	// PreviewTTL is how long an extracted preview is kept on disk
+	PreviewTTL = 5 * time.Minute
+
+	// MaxPreviewEntries is the maximum number of previews kept on disk at once
+	MaxPreviewEntries = 500
+
+	// MaxPreviewBytes is the maximum total size of previews kept on disk
+	MaxPreviewBytes = 200 * 1024 * 1024
+
+	// MaxPreviewMemoryBytes is the maximum total size of previews kept in memory
+	MaxPreviewMemoryBytes = 64 * 1024 * 1024
+
+	// MaxMemoryPreviewSize is the largest preview kept in memory; larger previews stay on disk
+	MaxMemoryPreviewSize = 1024 * 1024
+
+	// DefaultMaxAnalysisResponseSize is the default MaxAnalysisResponseSize (10MB)
+	DefaultMaxAnalysisResponseSize = 10 * 1024 * 1024
+
+	// MaxZIPSize is the maximum total size of the files packed into one ZIP response
+	MaxZIPSize = 500 * 1024 * 1024
+
+	// MaxMergeFiles is the maximum number of PDFs accepted by one merge request
+	MaxMergeFiles = 20
+
+	// MetricsWindow is the number of recent requests per operation kept for timing statistics
+	MetricsWindow = 50
+
+	// MinEstimateSamples is the number of timed requests of an operation needed before
+	// /estimate uses them instead of the default per-page cost
+	MinEstimateSamples = 3
+
+	// EstimateOverhead is the fixed cost assumed for every request when estimating from defaults
+	EstimateOverhead = 500 * time.Millisecond
+
+	// RemoteFetchTimeout bounds one remote PDF download, redirects included
+	RemoteFetchTimeout = 30 * time.Second
+
+	// MaxRemoteFetchRedirects is the number of redirects followed when fetching a remote PDF
+	MaxRemoteFetchRedirects = 3
+
+	// PDFHeaderScanBytes is how far into a file the %PDF- header is searched for; PDF
+	// readers accept a header after leading whitespace, a BOM or other junk
+	PDFHeaderScanBytes = 1024
+
+	// MinPDFFileSize is the size below which a file cannot be a PDF
+	MinPDFFileSize = 8
+
+	// MaxElementIDLength is the longest element_id accepted by the preview endpoint
+	MaxElementIDLength = 256
+
+	// ErrorCodeTimeout is the error "code" sent with a 504 when a PDF operation times out
+	ErrorCodeTimeout = "timeout"
+
+	// ErrorCodeUnsupportedEncryption is the error "code" for PDFs encrypted with a cipher pdfcpu cannot read
+	ErrorCodeUnsupportedEncryption = "unsupported_encryption"
+
+	// ErrorCodePasswordRequired is the error "code" for encrypted PDFs with a missing or wrong password
+	ErrorCodePasswordRequired = "password_required"
+
+	// ErrorCodeIncorrectPassword is the error "code" when a password given to decrypt a PDF is wrong
+	ErrorCodeIncorrectPassword = "incorrect_password"
+
+	// ErrorCodeNotEncrypted is the error "code" for decrypting a PDF that is not encrypted
+	ErrorCodeNotEncrypted = "not_encrypted"
 )
 
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// debugLogStore keeps the debug logs of analyses run with separate_debug_logs, keyed by
+// pdf_file_id, for DebugLogTTL
+type debugLogStore struct {
+	mu   sync.Mutex
+	logs map[string][]string
+}
+
+var debugLogs = &debugLogStore{logs: make(map[string][]string)}
+
+// Add stores the logs of one analysis and schedules their removal
+func (s *debugLogStore) Add(fileID string, logs []string) {
+	s.mu.Lock()
+	s.logs[fileID] = logs
+	s.mu.Unlock()
+
+	go func() {
+		time.Sleep(DebugLogTTL)
+		s.mu.Lock()
+		delete(s.logs, fileID)
+		s.mu.Unlock()
+	}()
+}
+
+// Get returns the logs stored for fileID
+func (s *debugLogStore) Get(fileID string) ([]string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	logs, ok := s.logs[fileID]
+	return logs, ok
+}
+
+// separateDebugLogs drops the debug logs from an analysis response and, when debug is
+// on, stores them for /debug-logs
+func separateDebugLogs(response gin.H, fileID string, logs []string, debug bool) {
+	delete(response, "debug_logs")
+	if debug {
+		debugLogs.Add(fileID, logs)
+	}
+}
+
+// HandleDebugLogs returns the debug logs of an analysis run with separate_debug_logs=true.
+// Registered behind debugOnly.
+func HandleDebugLogs(c *gin.Context) {
+	fileID := c.Query("pdf_file_id")
+	if !fileIDPattern.MatchString(fileID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid pdf_file_id"})
+		return
+	}
+	logs, ok := debugLogs.Get(fileID)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No debug logs for this analysis; they are kept for a limited time"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"pdf_file_id": fileID, "debug_logs": logs})
+}
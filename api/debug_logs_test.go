@@ -0,0 +1,76 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSeparateDebugLogs(t *testing.T) {
+	logs := []string{"[DEBUG] found 3 images", "[DEBUG] 1 candidate"}
+	tests := []struct {
+		name   string
+		debug  bool
+		status int
+	}{
+		{name: "debug on", debug: true, status: http.StatusOK},
+		{name: "debug off", debug: false, status: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fileID := generateUniqueID()
+			response := gin.H{"status": "ok", "debug_logs": logs, "pdf_file_id": fileID}
+			separateDebugLogs(response, fileID, logs, tt.debug)
+			if _, ok := response["debug_logs"]; ok {
+				t.Error("the response still includes debug_logs")
+			}
+			if response["pdf_file_id"] != fileID {
+				t.Error("other fields of the response were dropped")
+			}
+
+			r := gin.New()
+			SetupRoutes(r, &Config{TempDir: t.TempDir(), Debug: tt.debug})
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/pdf/debug-logs?pdf_file_id="+fileID, nil))
+			if w.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			var body struct {
+				DebugLogs []string `json:"debug_logs"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(body.DebugLogs, logs) {
+				t.Errorf("debug_logs = %q, want %q", body.DebugLogs, logs)
+			}
+		})
+	}
+}
+
+func TestDebugLogsRequests(t *testing.T) {
+	r := gin.New()
+	SetupRoutes(r, &Config{TempDir: t.TempDir(), Debug: true})
+	tests := []struct {
+		query  string
+		status int
+	}{
+		{query: "pdf_file_id=../etc", status: http.StatusBadRequest},
+		{query: "", status: http.StatusBadRequest},
+		{query: "pdf_file_id=" + generateUniqueID(), status: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/pdf/debug-logs?"+tt.query, nil))
+		if w.Code != tt.status {
+			t.Errorf("%q: status %d, want %d", tt.query, w.Code, tt.status)
+		}
+	}
+}
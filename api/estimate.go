@@ -0,0 +1,101 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+
+	pdfPkg "pdf_editor/pdf"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultSecondsPerPage is the assumed cost of each operation per page, used until enough
+// requests have been timed. Analysis runs several pdfcpu passes per page.
+var defaultSecondsPerPage = map[string]float64{
+	"resave":                    0.02,
+	"repair":                    0.03,
+	"rotate":                    0.02,
+	"banner":                    0.03,
+	"remove-pages":              0.02,
+	"nup":                       0.05,
+	"booklet":                   0.05,
+	"ocr":                       2.0,
+	"remove-elements":           0.05,
+	"remove-selected-elements":  0.3,
+	"analyze-unwanted-elements": 0.25,
+	"auto-clean":                0.4,
+	"clean":                     0.3,
+	"split-by-bookmarks":        0.05,
+	"validate":                  0.02,
+	"fonts":                     0.02,
+}
+
+// HandleEstimate returns a best-effort estimate of how long an operation will take on a
+// file, for progress display. The file is either uploaded as "pdf" or referenced by the
+// "file_id" of a stored upload. Once MinEstimateSamples requests of the operation have
+// been timed, the estimate scales their average time per byte to the file size; before
+// that it assumes defaultSecondsPerPage.
+func HandleEstimate(c *gin.Context, config *Config) {
+	operation := c.PostForm("operation")
+	if _, ok := defaultSecondsPerPage[operation]; !ok {
+		operations := make([]string, 0, len(defaultSecondsPerPage))
+		for name := range defaultSecondsPerPage {
+			operations = append(operations, name)
+		}
+		sort.Strings(operations)
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown operation: %q", operation), "operations": operations})
+		return
+	}
+
+	var path string
+	if fileID := c.PostForm("file_id"); fileID != "" {
+		stored, err := findUploadedFile(config, fileID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+			return
+		}
+		path = stored
+	} else {
+		saved, _, ok := saveUploadedPDF(c, config, "estimate_")
+		if !ok {
+			return
+		}
+		defer os.Remove(saved)
+		path = saved
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read file"})
+		return
+	}
+	pages, err := pdfPkg.PageCount(path)
+	if err != nil {
+		c.JSON(errorStatus(err), errorResponse(err, "Failed to read page count"))
+		return
+	}
+
+	seconds, basis, samples := estimateSeconds(operation, pages, info.Size())
+	c.JSON(http.StatusOK, gin.H{
+		"operation":         operation,
+		"pages":             pages,
+		"file_size":         info.Size(),
+		"estimated_seconds": seconds,
+		"basis":             basis,
+		"samples":           samples,
+		"note":              "Best-effort estimate; actual time depends on document content and server load",
+	})
+}
+
+// estimateSeconds returns the estimated duration of operation on a file of pages pages and
+// size bytes, whether it is based on the "default" per-page cost or timing "history", and
+// the number of timed requests behind it. operation must be in defaultSecondsPerPage.
+func estimateSeconds(operation string, pages int, size int64) (float64, string, int) {
+	rate, samples := metrics.secondsPerByte(operation)
+	if samples >= MinEstimateSamples {
+		return rate * float64(size), "history", samples
+	}
+	return EstimateOverhead.Seconds() + defaultSecondsPerPage[operation]*float64(pages), "default", samples
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// useMetrics replaces the process-wide timing store for the test
+func useMetrics(t *testing.T) *operationMetrics {
+	t.Helper()
+	previous := metrics
+	metrics = &operationMetrics{samples: make(map[string][]operationSample)}
+	t.Cleanup(func() { metrics = previous })
+	return metrics
+}
+
+func TestEstimateScalesWithPageCount(t *testing.T) {
+	useMetrics(t)
+	overhead := EstimateOverhead.Seconds()
+	for operation, perPage := range defaultSecondsPerPage {
+		previous := 0.0
+		for _, pages := range []int{1, 10, 100, 1000} {
+			seconds, basis, samples := estimateSeconds(operation, pages, 1<<20)
+			if basis != "default" || samples != 0 {
+				t.Errorf("%s: basis %q with %d samples, want default", operation, basis, samples)
+			}
+			if want := overhead + perPage*float64(pages); math.Abs(seconds-want) > 1e-9 {
+				t.Errorf("%s, %d pages: %.3fs, want %.3fs", operation, pages, seconds, want)
+			}
+			if seconds <= previous {
+				t.Errorf("%s: %d pages estimated at %.3fs, not more than fewer pages (%.3fs)", operation, pages, seconds, previous)
+			}
+			previous = seconds
+		}
+	}
+}
+
+func TestEstimateFromHistory(t *testing.T) {
+	m := useMetrics(t)
+	// Two seconds per megabyte, but not yet enough samples to rely on
+	for i := 0; i < MinEstimateSamples-1; i++ {
+		m.record("resave", operationSample{duration: 2 * time.Second, bytes: 1 << 20})
+	}
+	if _, basis, _ := estimateSeconds("resave", 10, 1<<20); basis != "default" {
+		t.Errorf("basis %q with %d samples, want default", basis, MinEstimateSamples-1)
+	}
+
+	m.record("resave", operationSample{duration: 4 * time.Second, bytes: 2 << 20})
+	seconds, basis, samples := estimateSeconds("resave", 10, 5<<20)
+	if basis != "history" || samples != MinEstimateSamples {
+		t.Fatalf("basis %q with %d samples, want history with %d", basis, samples, MinEstimateSamples)
+	}
+	if math.Abs(seconds-10) > 1e-9 {
+		t.Errorf("5MB estimated at %.3fs, want 10s at the recorded 2s/MB", seconds)
+	}
+	if _, basis, _ := estimateSeconds("rotate", 10, 5<<20); basis != "default" {
+		t.Errorf("other operations use %q timings, want default", basis)
+	}
+}
+
+func TestEstimateUnknownOperation(t *testing.T) {
+	r := gin.New()
+	SetupRoutes(r, &Config{MaxFileSize: 1 << 20, TempDir: t.TempDir()})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/pdf/estimate", strings.NewReader("operation=transmogrify"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"operations":[`) {
+		t.Errorf("response %d %s, want 400 listing the operations", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+const storedFileID = "1700000000000000000_0a1b2c3d"
+
+// storedFileRouter serves the API over a temp dir holding one stored upload
+func storedFileRouter(t *testing.T) (*gin.Engine, []byte) {
+	t.Helper()
+	config := &Config{TempDir: t.TempDir(), MaxFileSize: 1 << 20}
+	data := []byte("%PDF-1.7\nstored upload\n%%EOF\n")
+	if err := os.WriteFile(filepath.Join(config.TempDir, storedFileID+"_report.pdf"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	r := gin.New()
+	SetupRoutes(r, config)
+	return r, data
+}
+
+func TestStoredFileHead(t *testing.T) {
+	r, data := storedFileRouter(t)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodHead, "/api/pdf/files/"+storedFileID, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d, want 200", w.Code)
+	}
+	headers := map[string]string{
+		"Content-Type":        "application/pdf",
+		"Content-Length":      strconv.Itoa(len(data)),
+		"Content-Disposition": `attachment; filename="report.pdf"`,
+		"Accept-Ranges":       "bytes",
+	}
+	for name, want := range headers {
+		if got := w.Header().Get(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("HEAD returned a %d byte body", w.Body.Len())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/pdf/files/"+storedFileID, nil))
+	if w.Code != http.StatusOK || w.Body.String() != string(data) {
+		t.Errorf("GET = %d %q, want the stored file", w.Code, w.Body.String())
+	}
+}
+
+func TestStoredFileNotFound(t *testing.T) {
+	r, _ := storedFileRouter(t)
+	for _, id := range []string{"1700000000000000000_ffff", "..", "%2e%2e%2fsecret", "1700000000000000000_0a1b2c3d*", "report"} {
+		for _, method := range []string{http.MethodHead, http.MethodGet} {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(method, "/api/pdf/files/"+id, nil))
+			if w.Code != http.StatusNotFound {
+				t.Errorf("%s %s: status %d, want 404", method, id, w.Code)
+			}
+		}
+	}
+}
+
+func TestStoredFileRanges(t *testing.T) {
+	r, data := storedFileRouter(t)
+	get := func(rangeHeader string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/pdf/files/"+storedFileID, nil)
+		req.Header.Set("Range", rangeHeader)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// Overlapping single ranges, as a viewer fetching the header and then the first page
+	tests := []struct {
+		rangeHeader string
+		start, end  int // end exclusive
+	}{
+		{"bytes=0-9", 0, 10},
+		{"bytes=5-14", 5, 15},
+		{"bytes=8-", 8, len(data)},
+		{"bytes=-6", len(data) - 6, len(data)},
+		{"bytes=0-9", 0, 10}, // the same range again
+	}
+	for _, tt := range tests {
+		w := get(tt.rangeHeader)
+		if w.Code != http.StatusPartialContent {
+			t.Errorf("%s: status %d, want 206", tt.rangeHeader, w.Code)
+			continue
+		}
+		if got, want := w.Body.String(), string(data[tt.start:tt.end]); got != want {
+			t.Errorf("%s: body %q, want %q", tt.rangeHeader, got, want)
+		}
+		wantRange := "bytes " + strconv.Itoa(tt.start) + "-" + strconv.Itoa(tt.end-1) + "/" + strconv.Itoa(len(data))
+		if got := w.Header().Get("Content-Range"); got != wantRange {
+			t.Errorf("%s: Content-Range %q, want %q", tt.rangeHeader, got, wantRange)
+		}
+		if got := w.Header().Get("Content-Type"); got != "application/pdf" {
+			t.Errorf("%s: Content-Type %q, want application/pdf", tt.rangeHeader, got)
+		}
+	}
+
+	// Overlapping ranges in one request come back as a multipart response
+	w := get("bytes=0-9,5-14")
+	if w.Code != http.StatusPartialContent || !strings.HasPrefix(w.Header().Get("Content-Type"), "multipart/byteranges") {
+		t.Errorf("two ranges: status %d, Content-Type %q, want 206 multipart/byteranges", w.Code, w.Header().Get("Content-Type"))
+	}
+
+	if w := get("bytes=1000-2000"); w.Code != http.StatusRequestedRangeNotSatisfiable {
+		t.Errorf("range past the end: status %d, want 416", w.Code)
+	}
+
+	// The file is still complete after the range requests
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/pdf/files/"+storedFileID, nil))
+	if w.Code != http.StatusOK || w.Body.String() != string(data) {
+		t.Errorf("full GET after ranges = %d %q", w.Code, w.Body.String())
+	}
+}
@@ -1,8 +1,12 @@
 package api
 
 import (
+	"bytes"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -10,8 +14,12 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
+	"unicode"
 
 	pdfPkg "pdf_editor/pdf"
 
@@ -50,20 +58,484 @@ func HandleUpload(c *gin.Context, config *Config) {
 	}
 	defer out.Close()
 
-	_, err = out.ReadFrom(file)
+	// The content is hashed while it is written, for UPLOAD_DEDUP
+	hash := sha256.New()
+	_, err = out.ReadFrom(io.TeeReader(file, hash))
 	if err != nil {
 		os.Remove(filename) // Clean up on error
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"filename": header.Filename, "path": filename})
+	if config.UploadDedup {
+		digest := hex.EncodeToString(hash.Sum(nil))
+		// The new copy is only dropped once the stored one is found; otherwise it is kept
+		if existingID, existingPath, ok := recentUploads.Find(config, digest); ok {
+			out.Close()
+			os.Remove(filename)
+			c.JSON(http.StatusOK, gin.H{"filename": header.Filename, "path": existingPath, "file_id": existingID, "deduplicated": true})
+			return
+		}
+		recentUploads.Add(digest, uniqueID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"filename": header.Filename, "path": filename, "file_id": uniqueID})
+}
+
+// HandleStoredFile serves a file stored by HandleUpload by its file_id. Registered for both
+// GET and HEAD; HEAD is answered with Content-Length and Content-Type only, and Range
+// requests get 206 partial content.
+func HandleStoredFile(c *gin.Context, config *Config) {
+	path, err := findUploadedFile(config, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+
+	originalName := strings.TrimPrefix(filepath.Base(path), c.Param("id")+"_")
+	c.Header("Content-Type", "application/pdf")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", sanitizeFilename(originalName)))
+	serveFile(c, path)
+}
+
+// HandlePage returns one page of a stored upload as a standalone PDF, for clients that
+// render pages lazily. Extracted pages are cached like previews.
+func HandlePage(c *gin.Context, config *Config) {
+	fileID := c.Query("file_id")
+	path, err := findUploadedFile(config, fileID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+	page, err := strconv.Atoi(c.Query("page"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "page must be a positive number"})
+		return
+	}
+
+	filename := fmt.Sprintf("page_%d.pdf", page)
+	c.Header("Content-Type", "application/pdf")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	key := previewKey(fileID, filename)
+	if pagePath, ok := previews.Get(key); ok {
+		serveFile(c, pagePath)
+		return
+	}
+
+	// Concurrent requests for the same page wait for one extraction
+	pagePath, err := pageExtractions.Do(key, func() (string, error) {
+		if pagePath, ok := previews.Get(key); ok {
+			return pagePath, nil
+		}
+		pageDir := filepath.Join(config.TempDir, "previews", fileID)
+		if err := os.MkdirAll(pageDir, DefaultFilePermissions); err != nil {
+			return "", fmt.Errorf("failed to create page directory: %w", err)
+		}
+		pagePath := filepath.Join(pageDir, filename)
+		if err := extractPage(path, pagePath, strconv.Itoa(page)); err != nil {
+			os.Remove(pagePath)
+			os.Remove(pageDir) // only succeeds once the directory is empty
+			return "", err
+		}
+		previews.Add(key, pagePath)
+
+		go func() {
+			time.Sleep(PreviewTTL)
+			previews.Remove(key, pagePath)
+			os.Remove(pageDir) // only succeeds once the directory is empty
+		}()
+		return pagePath, nil
+	})
+	if err != nil {
+		c.Header("Content-Disposition", "")
+		c.JSON(errorStatus(err), errorResponse(err, err.Error()))
+		return
+	}
+	serveFile(c, pagePath)
+}
+
+// extractPage writes one page of a stored upload for /api/pdf/page; tests replace it to
+// run without pdfcpu
+var extractPage = pdfPkg.ExtractPages
+
+// HandleWordBoxes returns the words of one page of a stored upload with their bounding
+// boxes, for clients that highlight text under the pointer
+func HandleWordBoxes(c *gin.Context, config *Config) {
+	path, err := findUploadedFile(config, c.Query("file_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+	page, err := strconv.Atoi(c.Query("page"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "page must be a positive number"})
+		return
+	}
+
+	words, err := pdfPkg.ExtractWordBoxes(path, page)
+	if err != nil {
+		log.Printf("Word box extraction error: %v", err)
+		c.JSON(errorStatus(err), errorResponse(err, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"page":  page,
+		"words": words,
+	})
 }
 
 func HandleResave(c *gin.Context, config *Config) {
 	handlePDFFile(c, config, pdfPkg.ResavePDF, "resaved")
 }
 
+func HandleRepair(c *gin.Context, config *Config) {
+	handlePDFFile(c, config, pdfPkg.RepairPDF, "repaired")
+}
+
+func HandleBanner(c *gin.Context, config *Config) {
+	text := strings.TrimSpace(c.PostForm("text"))
+	if text == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No banner text specified"})
+		return
+	}
+
+	position := c.DefaultPostForm("position", pdfPkg.BannerPositionTop)
+	if err := pdfPkg.ValidateBannerPosition(position); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	handlePDFFile(c, config, func(inFile, outFile string) error {
+		return pdfPkg.AddBanner(inFile, outFile, text, position)
+	}, "banner")
+}
+
+// HandleEncrypt password-protects the uploaded PDF. The passwords are passed to pdfcpu
+// only and are never logged.
+func HandleEncrypt(c *gin.Context, config *Config) {
+	userPw := c.PostForm("user_password")
+	ownerPw := c.PostForm("owner_password")
+	if userPw == "" && ownerPw == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user_password or owner_password is required"})
+		return
+	}
+	perms := c.PostForm("permissions")
+	if err := pdfPkg.ValidatePermissions(perms); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	handlePDFFile(c, config, func(inFile, outFile string) error {
+		return pdfPkg.EncryptPDF(inFile, outFile, userPw, ownerPw, perms)
+	}, "encrypted")
+}
+
+// HandleDecrypt removes the password protection of the uploaded PDF. The password is
+// passed to pdfcpu only and is never logged.
+func HandleDecrypt(c *gin.Context, config *Config) {
+	password := c.PostForm("password")
+	if password == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "password is required"})
+		return
+	}
+
+	handlePDFFile(c, config, func(inFile, outFile string) error {
+		return pdfPkg.DecryptPDF(inFile, outFile, password)
+	}, "decrypted")
+}
+
+// HandleWatermark stamps a text watermark on every page of the uploaded PDF
+func HandleWatermark(c *gin.Context, config *Config) {
+	text := strings.TrimSpace(c.PostForm("text"))
+	if text == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No watermark text specified"})
+		return
+	}
+
+	opts := pdfPkg.DefaultWatermarkOptions()
+	if value := c.PostForm("font_size"); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "font_size must be a whole number of points"})
+			return
+		}
+		opts.FontSize = size
+	}
+	opts.Color = c.DefaultPostForm("color", opts.Color)
+	if err := parseWatermarkOptions(c, &opts); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	handlePDFFile(c, config, func(inFile, outFile string) error {
+		return pdfPkg.AddTextWatermark(inFile, outFile, text, opts)
+	}, "watermarked")
+}
+
+// HandleImageWatermark stamps an uploaded PNG or JPEG logo on every page of the uploaded PDF
+func HandleImageWatermark(c *gin.Context, config *Config) {
+	logoFile, ok := saveUploadedLogo(c, config)
+	if !ok {
+		return
+	}
+	defer os.Remove(logoFile)
+
+	// A logo is placed upright unless a rotation is given
+	opts := pdfPkg.DefaultWatermarkOptions()
+	opts.Diagonal = false
+	if err := parseWatermarkOptions(c, &opts); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	handlePDFFile(c, config, func(inFile, outFile string) error {
+		return pdfPkg.AddImageWatermark(inFile, logoFile, outFile, opts)
+	}, "watermarked")
+}
+
+// parseWatermarkOptions applies the "position", "scale", "rotation" and "opacity" form
+// fields to opts and validates the result. A rotation replaces the diagonal placement.
+func parseWatermarkOptions(c *gin.Context, opts *pdfPkg.WatermarkOptions) error {
+	opts.Position = c.DefaultPostForm("position", opts.Position)
+	if value := c.PostForm("scale"); value != "" {
+		scale, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("scale must be a fraction of the page width")
+		}
+		opts.Scale = scale
+	}
+	if value := c.PostForm("rotation"); value != "" {
+		rotation, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("rotation must be a number of degrees")
+		}
+		opts.Diagonal = false
+		opts.Rotation = rotation
+	}
+	if value := c.PostForm("opacity"); value != "" {
+		opacity, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("opacity must be a number between 0 and 1")
+		}
+		opts.Opacity = opacity
+	}
+	return pdfPkg.ValidateWatermarkOptions(*opts)
+}
+
+// saveUploadedLogo saves the "logo" upload as a temp file named after its image type;
+// it answers the request itself on failure
+func saveUploadedLogo(c *gin.Context, config *Config) (string, bool) {
+	file, header, err := c.Request.FormFile("logo")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No logo image provided"})
+		return "", false
+	}
+	defer file.Close()
+
+	ext, err := validateImageFile(file, header, config.MaxFileSize)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return "", false
+	}
+	if err := ensureTempDir(config.TempDir); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create temp directory"})
+		return "", false
+	}
+
+	path := filepath.Join(config.TempDir, "logo_"+generateUniqueID()+ext)
+	trackTempFile(c, path)
+	out, err := os.Create(path)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save logo"})
+		return "", false
+	}
+	_, err = out.ReadFrom(file)
+	out.Close()
+	if err != nil {
+		os.Remove(path)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save logo"})
+		return "", false
+	}
+	return path, true
+}
+
+func HandleRotate(c *gin.Context, config *Config) {
+	var specs []pdfPkg.RotateSpec
+	if err := json.Unmarshal([]byte(c.PostForm("rotations")), &specs); err != nil || len(specs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "rotations must be a JSON list of {\"pages\", \"degrees\"} objects"})
+		return
+	}
+
+	handlePDFFile(c, config, func(inFile, outFile string) error {
+		return pdfPkg.RotatePagesMulti(inFile, outFile, specs)
+	}, "rotated")
+}
+
+// HandleAutoOrient rotates the pages of the uploaded PDF that do not match the target orientation
+func HandleAutoOrient(c *gin.Context, config *Config) {
+	target := c.DefaultPostForm("target", pdfPkg.OrientationPortrait)
+	if target != pdfPkg.OrientationPortrait && target != pdfPkg.OrientationLandscape {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "target must be portrait or landscape"})
+		return
+	}
+
+	handlePDFFile(c, config, func(inFile, outFile string) error {
+		return pdfPkg.AutoOrient(inFile, outFile, target)
+	}, "oriented")
+}
+
+// HandleOrientation reports the portrait and landscape pages of the uploaded PDF
+func HandleOrientation(c *gin.Context, config *Config) {
+	inFile, _, ok := saveUploadedPDF(c, config, "orientation_")
+	if !ok {
+		return
+	}
+	defer os.Remove(inFile)
+
+	report, err := pdfPkg.DetectOrientation(inFile)
+	if err != nil {
+		log.Printf("Orientation detection error: %v", err)
+		c.JSON(errorStatus(err), errorResponse(err, "Failed to detect page orientation"))
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+func HandleOCR(c *gin.Context, config *Config) {
+	if !config.OCREnabled {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "OCR engine is not available on this server"})
+		return
+	}
+
+	lang := c.DefaultPostForm("lang", "eng")
+	if err := pdfPkg.ValidateOCRLanguage(lang); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	handlePDFFile(c, config, func(inFile, outFile string) error {
+		return pdfPkg.OCRPDF(inFile, outFile, lang)
+	}, "ocr")
+}
+
+// HandleNUp places several pages on each output sheet
+func HandleNUp(c *gin.Context, config *Config) {
+	handleNUpOperation(c, config, "4", pdfPkg.NUpPDF, "nup")
+}
+
+// HandleBooklet arranges the pages for printing as a folded booklet
+func HandleBooklet(c *gin.Context, config *Config) {
+	handleNUpOperation(c, config, "2", pdfPkg.BookletPDF, "booklet")
+}
+
+// handleNUpOperation reads the shared n and paper_size form fields of the N-up and booklet endpoints
+func handleNUpOperation(c *gin.Context, config *Config, defaultN string, operation func(inFile, outFile string, n int, paperSize string) error, suffix string) {
+	n, err := strconv.Atoi(c.DefaultPostForm("n", defaultN))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "n must be a number"})
+		return
+	}
+	paperSize := c.PostForm("paper_size")
+	if err := pdfPkg.ValidatePaperSize(paperSize); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	handlePDFFile(c, config, func(inFile, outFile string) error {
+		return operation(inFile, outFile, n, paperSize)
+	}, suffix)
+}
+
+// HandleMerge merges several uploaded PDFs ("pdfs" form files) into one, optionally taking
+// only some pages of each input in a custom order (JSON "selection" form field)
+func HandleMerge(c *gin.Context, config *Config) {
+	form, err := c.MultipartForm()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No PDF files provided"})
+		return
+	}
+	// Inputs may be sent as repeated "pdfs" or, like the single-file endpoints, "pdf" parts
+	headers := append(form.File["pdfs"], form.File["pdf"]...)
+	if len(headers) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No PDF files provided"})
+		return
+	}
+	if len(headers) > MaxMergeFiles {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("at most %d files can be merged at once", MaxMergeFiles)})
+		return
+	}
+
+	var selections []pdfPkg.MergeSelection
+	if selection := c.PostForm("selection"); selection != "" {
+		if err := json.Unmarshal([]byte(selection), &selections); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "selection must be a JSON list of {\"file_index\", \"pages\"} objects"})
+			return
+		}
+	}
+
+	if err := ensureTempDir(config.TempDir); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create temp directory"})
+		return
+	}
+
+	uniqueID := generateUniqueID()
+	inFiles := make([]string, 0, len(headers))
+	defer func() {
+		for _, inFile := range inFiles {
+			os.Remove(inFile)
+		}
+	}()
+	for i, header := range headers {
+		file, err := header.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded file"})
+			return
+		}
+		if err := validatePDFFile(file, header, config.MaxFileSize); err != nil {
+			file.Close()
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s: %v", header.Filename, err)})
+			return
+		}
+
+		path := filepath.Join(config.TempDir, fmt.Sprintf("merge_%s_%d.pdf", uniqueID, i))
+		trackTempFile(c, path)
+		out, err := os.Create(path)
+		if err != nil {
+			file.Close()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create temp file"})
+			return
+		}
+		inFiles = append(inFiles, path)
+		_, err = out.ReadFrom(file)
+		out.Close()
+		file.Close()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save input file"})
+			return
+		}
+	}
+
+	outFile := filepath.Join(config.TempDir, fmt.Sprintf("merge_%s_merged.pdf", uniqueID))
+	trackTempFile(c, outFile)
+	if err := pdfPkg.MergeSelected(inFiles, selections, outFile); err != nil {
+		os.Remove(outFile)
+		log.Printf("PDF merge error: %v", err)
+		c.JSON(errorStatus(err), errorResponse(err, err.Error()))
+		return
+	}
+
+	c.Header("Content-Type", "application/pdf")
+	c.Header("Content-Disposition", `attachment; filename="merged.pdf"`)
+	serveFile(c, outFile)
+
+	go func() {
+		time.Sleep(FileCleanupDelay)
+		os.Remove(outFile)
+	}()
+}
+
 func HandleRemovePages(c *gin.Context, config *Config) {
 	pagesParam := c.PostForm("pages")
 	if pagesParam == "" {
@@ -78,12 +550,21 @@ func HandleRemovePages(c *gin.Context, config *Config) {
 
 func HandleRemoveElements(c *gin.Context, config *Config) {
 	elementType := c.PostForm("type")
+	opts := pdfPkg.RemovalOptions{
+		OptimizeAfter: c.DefaultPostForm("optimize_after", "true") == "true",
+	}
 	handlePDFFile(c, config, func(inFile, outFile string) error {
-		return pdfPkg.RemoveElementFromPDF(inFile, outFile, elementType)
+		return pdfPkg.RemoveElementsByIDsWithOptions(inFile, outFile, elementType, nil, opts)
 	}, "elements_removed")
 }
 
 func HandleAnalyzeUnwantedElements(c *gin.Context, config *Config) {
+	format := c.DefaultPostForm("format", "json")
+	if format != "json" && format != "csv" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or csv"})
+		return
+	}
+
 	file, header, err := c.Request.FormFile("pdf")
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "No PDF file provided"})
@@ -105,6 +586,7 @@ func HandleAnalyzeUnwantedElements(c *gin.Context, config *Config) {
 
 	uniqueID := generateUniqueID()
 	inFile := filepath.Join(config.TempDir, "analysis_"+uniqueID+".pdf")
+	trackTempFile(c, inFile)
 
 	out, err := os.Create(inFile)
 	if err != nil {
@@ -121,7 +603,22 @@ func HandleAnalyzeUnwantedElements(c *gin.Context, config *Config) {
 	}
 
 	// Perform unwanted elements analysis
-	analysis, err := pdfPkg.AnalyzeUnwantedElements(inFile)
+	opts, err := parseAnalysisOptions(c)
+	if err != nil {
+		os.Remove(inFile)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var pageRanges [][2]int
+	if pagesParam := c.PostForm("pages"); pagesParam != "" {
+		pageRanges, err = pdfPkg.ParsePageRanges(pagesParam)
+		if err != nil {
+			os.Remove(inFile)
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid pages: %v", err)})
+			return
+		}
+	}
+	analysis, err := pdfPkg.AnalyzeUnwantedElementsWithOptions(inFile, opts)
 
 	if err != nil {
 		// Clean up temp file on error
@@ -129,159 +626,812 @@ func HandleAnalyzeUnwantedElements(c *gin.Context, config *Config) {
 			time.Sleep(AnalysisCleanupDelay)
 			os.Remove(inFile)
 		}()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Unwanted elements analysis failed"})
+		c.JSON(errorStatus(err), errorResponse(err, "Unwanted elements analysis failed"))
 		return
 	}
 
+	// The session keeps every candidate; only the response is limited to the requested pages
+	result := analysis
+	if pageRanges != nil {
+		result = analysis.FilterByPages(pageRanges)
+	}
+
 	// Add PDF file ID to response so frontend can request previews
 	// The uniqueID is already generated above, use it as the file identifier
 	response := gin.H{
-		"total_pages":        analysis.TotalPages,
-		"image_candidates":   analysis.ImageCandidates,
-		"text_candidates":    analysis.TextCandidates,
-		"overall_confidence": analysis.OverallConfidence,
-		"recommendations":    analysis.Recommendations,
-		"debug_logs":         analysis.DebugLogs,
-		"pdf_file_id":        uniqueID, // Include file ID for preview requests
+		"schema_version":         analysis.SchemaVersion,
+		"status":                 analysis.Status,
+		"total_pages":            analysis.TotalPages,
+		"image_candidates":       result.ImageCandidates,
+		"text_candidates":        result.TextCandidates,
+		"blank_page_candidates":  result.BlankPageCandidates,
+		"document_type":          analysis.DocumentType,
+		"overall_confidence":     analysis.OverallConfidence,
+		"recommendations":        analysis.Recommendations,
+		"recommendation_details": analysis.RecommendationDetails,
+		"debug_logs":             analysis.DebugLogs,
+		"pdf_file_id":            uniqueID, // Include file ID for preview requests
+		"session_id":             uniqueID,
+	}
+	if opts.IncludeHeatmap {
+		response["per_page_candidate_counts"] = analysis.PerPageCandidateCounts
+	}
+	// Debug logs can be large; with separate_debug_logs they are served by /debug-logs instead
+	if c.PostForm("separate_debug_logs") == "true" {
+		separateDebugLogs(response, uniqueID, analysis.DebugLogs, config.Debug)
 	}
 
-	c.JSON(http.StatusOK, response)
+	// Keep the input for previews and removal until the session expires or is deleted
+	if err := sessions.Add(uniqueID, inFile, analysis); err != nil {
+		os.Remove(inFile)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("X-Session-Id", uniqueID)
 
-	// Clean up temp file after response is sent
-	// Wait for response to be sent first to avoid race condition
-	defer func() {
-		// Small delay to ensure file is not being read
-		go func() {
-			time.Sleep(AnalysisCleanupDelay)
-			os.Remove(inFile)
-		}()
+	if format == "csv" {
+		var buf bytes.Buffer
+		if err := result.WriteCSV(&buf); err != nil {
+			log.Printf("Analysis CSV export error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export analysis as CSV"})
+		} else {
+			filename := strings.TrimSuffix(header.Filename, filepath.Ext(header.Filename)) + "_analysis.csv"
+			c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", sanitizeFilename(filename)))
+			c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+		}
+	} else {
+		capAnalysisResponse(response, config.MaxAnalysisResponseSize)
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+func HandlePreviewImage(c *gin.Context, config *Config) {
+	// Get parameters
+	elementID := c.Query("element_id")
+	if elementID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "session_id and element_id are required"})
+		return
+	}
+	if !validElementID(elementID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid element_id"})
+		return
+	}
+	pdfFileID, session, ok := findSession(c)
+	if !ok {
+		return
+	}
+
+	// Serve a cached preview without re-analyzing the PDF
+	key := previewKey(pdfFileID, elementID)
+	if data, contentType, ok := previews.GetData(key); ok {
+		c.Data(http.StatusOK, contentType, data)
+		return
+	}
+	if previewPath, ok := previews.Get(key); ok {
+		c.File(previewPath)
+		return
+	}
+	pdfFile := session.path
+
+	// Find the element in the session's analysis, so its ID means what the client was shown
+	var elementMetadata map[string]string
+	for _, candidate := range session.analysis.ImageCandidates {
+		if candidate.ID == elementID {
+			elementMetadata = candidate.Metadata
+			break
+		}
+	}
+
+	if elementMetadata == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Element not found in analysis"})
+		return
+	}
+
+	// Extract image preview (one directory per PDF, so equal element IDs of different files don't collide)
+	previewDir := filepath.Join(config.TempDir, "previews", pdfFileID)
+	previewPath, err := pdfPkg.ExtractImagePreview(pdfFile, previewDir, elementID, elementMetadata)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to extract image: %v", err)})
+		return
+	}
+
+	// Small previews are kept in memory, so repeated requests never touch the disk
+	if config.PreviewMemoryCache {
+		data, contentType, inMemory, err := previews.AddFileData(key, previewPath)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to read image: %v", err)})
+			return
+		}
+		if inMemory {
+			c.Data(http.StatusOK, contentType, data)
+			return
+		}
+	}
+	previews.Add(key, previewPath)
+
+	// Serve the image file
+	c.File(previewPath)
+
+	// Clean up after a delay (image should be loaded by browser by then)
+	go func() {
+		time.Sleep(PreviewTTL)
+		previews.Remove(key, previewPath)
+		os.Remove(previewDir) // only succeeds once the directory is empty
 	}()
 }
 
-func HandlePreviewImage(c *gin.Context, config *Config) {
-	// Get parameters
-	pdfFileID := c.Query("pdf_file_id")
-	elementID := c.Query("element_id")
+// HandlePreviewDiff shows what removing one image candidate of an analysis session changes
+// on a page: a two-page PDF of the candidate's region before and after removal, cropped to
+// its bounding box, or the whole page when the box is not known. The region is sent in
+// X-Diff-Region as "x,y,width,height" in PDF points.
+func HandlePreviewDiff(c *gin.Context, config *Config) {
+	elementID := c.Query("element_id")
+	if !validElementID(elementID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid element_id"})
+		return
+	}
+	page, err := strconv.Atoi(c.Query("page"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "page must be a positive number"})
+		return
+	}
+	sessionID, session, ok := findSession(c)
+	if !ok {
+		return
+	}
+
+	var candidate *pdfPkg.UnwantedElementCandidate
+	for i := range session.analysis.ImageCandidates {
+		if session.analysis.ImageCandidates[i].ID == elementID {
+			candidate = &session.analysis.ImageCandidates[i]
+			break
+		}
+	}
+	if candidate == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Element not found in analysis"})
+		return
+	}
+	pages := candidate.Pages
+	if len(pages) == 0 {
+		pages = []int{candidate.Page}
+	}
+	if !slices.Contains(pages, page) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Element %s does not occur on page %d", elementID, page)})
+		return
+	}
+
+	if err := ensureTempDir(config.TempDir); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create temp directory"})
+		return
+	}
+	workDir, err := os.MkdirTemp(config.TempDir, "diff_"+sessionID+"_")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create temp directory"})
+		return
+	}
+	defer os.RemoveAll(workDir)
+
+	resultFile := filepath.Join(workDir, "result.pdf")
+	opts := pdfPkg.RemovalOptions{
+		ProtectedObjects:    config.ProtectedObjects,
+		MaxImageOccurrences: config.MaxImageOccurrences,
+		Analysis:            session.analysis,
+	}
+	if err := pdfPkg.RemoveElementsByIDsWithOptions(session.path, resultFile, "image", []string{elementID}, opts); err != nil {
+		log.Printf("Preview diff removal error: %v", err)
+		c.JSON(errorStatus(err), errorResponse(err, err.Error()))
+		return
+	}
+	diffFile := filepath.Join(workDir, "diff.pdf")
+	region, err := pdfPkg.PreviewDiff(session.path, resultFile, diffFile, *candidate, page)
+	if err != nil {
+		log.Printf("Preview diff error: %v", err)
+		c.JSON(errorStatus(err), errorResponse(err, err.Error()))
+		return
+	}
+
+	c.Header("Content-Type", "application/pdf")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("preview_diff_%d.pdf", page)))
+	c.Header("X-Diff-Region", fmt.Sprintf("%g,%g,%g,%g", region.BBox.X, region.BBox.Y, region.BBox.Width, region.BBox.Height))
+	c.Header("X-Diff-Full-Page", strconv.FormatBool(region.FullPage))
+	serveFile(c, diffFile)
+}
+
+// HandleDistinctImages lists the distinct image objects of an uploaded PDF, each with a
+// preview URL, so the review UI can render every image once instead of per candidate
+func HandleDistinctImages(c *gin.Context, config *Config) {
+	inFile, uniqueID, ok := saveUploadedPDF(c, config, "distinct_")
+	if !ok {
+		return
+	}
+	defer os.Remove(inFile)
+
+	previewDir := filepath.Join(config.TempDir, "previews", "distinct_"+uniqueID)
+	images, err := pdfPkg.ListDistinctImages(inFile, previewDir)
+	if err != nil {
+		os.RemoveAll(previewDir)
+		log.Printf("Distinct images listing error: %v", err)
+		c.JSON(errorStatus(err), errorResponse(err, "Failed to list images"))
+		return
+	}
+
+	response := make([]gin.H, len(images))
+	for i, img := range images {
+		previewURL := ""
+		if img.PreviewFile != "" {
+			previewURL = fmt.Sprintf("/api/pdf/distinct-image-preview?file_id=%s&name=%s", uniqueID, filepath.Base(img.PreviewFile))
+		}
+		response[i] = gin.H{
+			"object":      img.Object,
+			"id":          img.ID,
+			"width":       img.Width,
+			"height":      img.Height,
+			"size":        img.Size,
+			"color_space": img.ColorSpace,
+			"occurrences": img.Occurrences,
+			"pages":       img.Pages,
+			"preview_url": previewURL,
+		}
+	}
+
+	// The previews of one PDF are cached as a single entry
+	key := previewKey(uniqueID, "distinct")
+	previews.Add(key, previewDir)
+
+	c.JSON(http.StatusOK, gin.H{"file_id": uniqueID, "images": response})
+
+	// Previews stay available as long as single-candidate previews do
+	go func() {
+		time.Sleep(PreviewTTL)
+		previews.Remove(key, previewDir)
+	}()
+}
+
+// HandleFingerprint returns the file and content fingerprint of an uploaded PDF
+func HandleFingerprint(c *gin.Context, config *Config) {
+	inFile, _, ok := saveUploadedPDF(c, config, "fingerprint_")
+	if !ok {
+		return
+	}
+	defer os.Remove(inFile)
+
+	fingerprint, err := pdfPkg.Fingerprint(inFile)
+	if err != nil {
+		log.Printf("Fingerprint error: %v", err)
+		c.JSON(errorStatus(err), errorResponse(err, "Failed to fingerprint PDF"))
+		return
+	}
+
+	fileHash, contentHash, _ := strings.Cut(fingerprint, ":")
+	c.JSON(http.StatusOK, gin.H{
+		"fingerprint":  fingerprint,
+		"file_sha256":  fileHash,
+		"content_hash": contentHash,
+	})
+}
+
+// HandleValidate runs a strict structural validation of an uploaded PDF and lists the issues
+func HandleValidate(c *gin.Context, config *Config) {
+	inFile, _, ok := saveUploadedPDF(c, config, "validate_")
+	if !ok {
+		return
+	}
+	defer os.Remove(inFile)
+
+	issues, err := pdfPkg.ValidateVerbose(inFile)
+	if err != nil {
+		log.Printf("Validation error: %v", err)
+		c.JSON(errorStatus(err), errorResponse(err, "Failed to validate PDF"))
+		return
+	}
+
+	valid := true
+	for _, issue := range issues {
+		if issue.Severity == pdfPkg.IssueSeverityError {
+			valid = false
+			break
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"valid": valid, "issues": issues})
+}
+
+// HandleFonts lists the fonts used by an uploaded PDF and whether they are embedded
+func HandleFonts(c *gin.Context, config *Config) {
+	inFile, _, ok := saveUploadedPDF(c, config, "fonts_")
+	if !ok {
+		return
+	}
+	defer os.Remove(inFile)
+
+	fonts, err := pdfPkg.ListFonts(inFile)
+	if err != nil {
+		log.Printf("Font listing error: %v", err)
+		c.JSON(errorStatus(err), errorResponse(err, "Failed to list fonts"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"fonts": fonts})
+}
+
+// HandleInfo returns the page count and basic metadata of an uploaded PDF, without the
+// cost of an analysis
+func HandleInfo(c *gin.Context, config *Config) {
+	inFile, _, ok := saveUploadedPDF(c, config, "info_")
+	if !ok {
+		return
+	}
+	defer os.Remove(inFile)
+
+	info, err := pdfPkg.GetDocumentInfo(inFile)
+	if err != nil {
+		log.Printf("Document info error: %v", err)
+		c.JSON(errorStatus(err), errorResponse(err, "Failed to read document info"))
+		return
+	}
+
+	c.JSON(http.StatusOK, info)
+}
+
+// HandleColorProfiles lists the ICC color profiles used by the images of an uploaded PDF
+func HandleColorProfiles(c *gin.Context, config *Config) {
+	inFile, _, ok := saveUploadedPDF(c, config, "profiles_")
+	if !ok {
+		return
+	}
+	defer os.Remove(inFile)
 
-	if pdfFileID == "" || elementID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "pdf_file_id and element_id are required"})
+	profiles, err := pdfPkg.ListColorProfiles(inFile)
+	if err != nil {
+		log.Printf("Color profile listing error: %v", err)
+		c.JSON(errorStatus(err), errorResponse(err, "Failed to list color profiles"))
 		return
 	}
 
-	// Find the uploaded PDF file by ID
-	// Look for files matching the pattern: analysis_{pdfFileID}.pdf in temp directory
-	// The file is saved as analysis_{uniqueID}.pdf in HandleAnalyzeUnwantedElements
-	pdfFile := filepath.Join(config.TempDir, "analysis_"+pdfFileID+".pdf")
+	c.JSON(http.StatusOK, gin.H{"color_profiles": profiles})
+}
 
-	// Check if file exists
-	if _, err := os.Stat(pdfFile); os.IsNotExist(err) {
-		c.JSON(http.StatusNotFound, gin.H{"error": "PDF file not found"})
+// HandleDistinctImagePreview serves a preview extracted by HandleDistinctImages
+func HandleDistinctImagePreview(c *gin.Context, config *Config) {
+	fileID := c.Query("file_id")
+	name := c.Query("name")
+	if !fileIDPattern.MatchString(fileID) || name == "" || name != filepath.Base(name) || !strings.HasPrefix(name, "obj_") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file_id or name"})
 		return
 	}
 
-	// Re-analyze to get metadata for the element
-	analysis, err := pdfPkg.AnalyzeUnwantedElements(pdfFile)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to analyze PDF"})
+	previewPath := filepath.Join(config.TempDir, "previews", "distinct_"+fileID, name)
+	if _, err := os.Stat(previewPath); os.IsNotExist(err) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Preview not found or expired"})
 		return
 	}
+	c.File(previewPath)
+}
 
-	// Find the element in the analysis
-	var elementMetadata map[string]string
-	for _, candidate := range analysis.ImageCandidates {
-		if candidate.ID == elementID {
-			elementMetadata = candidate.Metadata
-			break
-		}
+// HandleImageObject returns the original bytes of one image object from an analyzed PDF,
+// so users can confirm exactly what will be removed
+func HandleImageObject(c *gin.Context, config *Config) {
+	fileID := c.Query("file_id")
+	objNr := c.Query("obj")
+	if fileID == "" || objNr == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file_id and obj are required"})
+		return
+	}
+	if !fileIDPattern.MatchString(fileID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file_id"})
+		return
+	}
+	if _, err := strconv.Atoi(objNr); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "obj must be an object number"})
+		return
 	}
 
-	if elementMetadata == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Element not found in analysis"})
+	// file_id is the analysis session ID
+	session, ok := sessions.Get(fileID)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "PDF file not found"})
 		return
 	}
 
-	// Extract image preview
-	previewDir := filepath.Join(config.TempDir, "previews")
-	previewPath, err := pdfPkg.ExtractImagePreview(pdfFile, previewDir, elementID, elementMetadata)
+	data, mimeType, err := pdfPkg.ExtractImageBytes(session.path, objNr)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to extract image: %v", err)})
+		log.Printf("Image object extraction error: %v", err)
+		c.JSON(errorStatus(err), errorResponse(err, err.Error()))
 		return
 	}
 
-	// Serve the image file
-	c.File(previewPath)
-
-	// Clean up after a delay (image should be loaded by browser by then)
-	go func() {
-		time.Sleep(5 * time.Minute)
-		os.Remove(previewPath)
-	}()
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "image_obj"+objNr))
+	c.Data(http.StatusOK, mimeType, data)
 }
 
 func HandleRemoveSelectedElements(c *gin.Context, config *Config) {
-	elementsParam := c.PostForm("elements")
-	if elementsParam == "" {
+	elementIDs := parseElementIDs(c)
+	if len(elementIDs) == 0 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "No elements selected for removal"})
 		return
 	}
 
-	// Parse selected element IDs
-	elementIDs := strings.Split(elementsParam, ",")
-	for i := range elementIDs {
-		elementIDs[i] = strings.TrimSpace(elementIDs[i])
+	opts, err := parseRemovalOptions(c, config)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
+	opts.Report = &pdfPkg.RemovalReport{}
+
+	// Blank page candidates are dropped as whole pages after the other elements are removed
+	blankPages, otherIDs := pdfPkg.SplitBlankPageIDs(elementIDs)
 
 	// handlePDFFile already sends the file for download
 	handlePDFFile(c, config, func(inFile, outFile string) error {
-		// Try removing as images first (selective removal)
-		// If that fails, fall back to watermark removal (removes all pdfcpu watermarks)
-		err := pdfPkg.RemoveElementsByIDs(inFile, outFile, "image", elementIDs)
-		if err != nil {
-			// If image removal fails, try watermark removal as fallback
+		if len(otherIDs) == 0 {
+			return pdfPkg.RemoveBlankPages(inFile, outFile, blankPages)
+		}
+
+		elementsOut := outFile
+		if len(blankPages) > 0 {
+			elementsOut = strings.TrimSuffix(outFile, ".pdf") + "_elements.pdf"
+			defer os.Remove(elementsOut)
+		}
+
+		// Try removing as images first (selective removal). Only if no selected candidate
+		// matches an image, fall back to watermark removal (removes all pdfcpu watermarks);
+		// every other failure, such as ErrTooManyImages or a failed audit copy, is final
+		method := "image"
+		err := pdfPkg.RemoveElementsByIDsWithOptions(inFile, elementsOut, "image", otherIDs, opts)
+		if errors.Is(err, pdfPkg.ErrNoMatchingImages) {
 			log.Printf("Image removal failed: %v, trying watermark removal...", err)
-			return pdfPkg.RemoveElementFromPDF(inFile, outFile, "watermark")
+			if err := pdfPkg.RemoveElementsByIDsWithOptions(inFile, elementsOut, "watermark", nil, opts); err != nil {
+				return err
+			}
+			method = "watermark"
+			opts.Report.Removed = 0
+		} else if err != nil {
+			return err
+		}
+
+		// Report the outcome in headers, since the body is the PDF itself
+		c.Header("X-Removal-Method", method)
+		c.Header("X-Removed-Images", strconv.Itoa(opts.Report.Removed))
+		if len(opts.Report.SkippedProtected) > 0 {
+			c.Header("X-Skipped-Protected-Objects", strings.Join(opts.Report.SkippedProtected, ","))
+		}
+		if len(opts.Report.SkippedUnresolved) > 0 {
+			c.Header("X-Skipped-Unresolved-Images", strings.Join(opts.Report.SkippedUnresolved, ","))
+		}
+		if len(opts.Report.SkippedText) > 0 {
+			c.Header("X-Skipped-Text-Elements", strings.Join(opts.Report.SkippedText, ","))
+		}
+
+		if len(blankPages) > 0 {
+			return pdfPkg.RemoveBlankPages(elementsOut, outFile, blankPages)
 		}
 		return nil
 	}, "unwanted_elements_removed")
 }
 
-func handlePDFFile(c *gin.Context, config *Config, operation func(string, string) error, suffix string) {
-	file, header, err := c.Request.FormFile("pdf")
+// parseRemovalOptions reads the image removal form fields shared by the removal endpoints
+func parseRemovalOptions(c *gin.Context, config *Config) (pdfPkg.RemovalOptions, error) {
+	opts := pdfPkg.RemovalOptions{
+		PreserveDimensions:  c.PostForm("preserve_placement") == "true",
+		OptimizeAfter:       c.DefaultPostForm("optimize_after", "true") == "true",
+		ProtectedObjects:    config.ProtectedObjects,
+		MaxImageOccurrences: config.MaxImageOccurrences,
+		MatchMode:           c.PostForm("match_mode"),
+	}
+	if err := pdfPkg.ValidateMatchMode(opts.MatchMode); err != nil {
+		return opts, err
+	}
+	auditDir, err := parseAuditDir(c, config)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No PDF file provided"})
-		return
+		return opts, err
 	}
-	defer file.Close()
+	opts.AuditDir = auditDir
+	opts.Analysis = sessionAnalysis(c)
+	if c.PostForm("redact") == "true" {
+		fill, err := pdfPkg.ParseRedactColor(c.DefaultPostForm("redact_color", pdfPkg.DefaultRedactColor))
+		if err != nil {
+			return opts, err
+		}
+		opts.RedactColor = &fill
+	}
+	return opts, nil
+}
 
-	// Validate PDF file
-	if err := validatePDFFile(file, header, config.MaxFileSize); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+// sessionAnalysis returns the analysis of the session a removal request works on, given by
+// the "session_id" field when no "pdf" file is uploaded, so the selected IDs are matched
+// against the analysis they were chosen from (nil without a session)
+func sessionAnalysis(c *gin.Context) *pdfPkg.UnwantedElementsAnalysis {
+	if _, err := c.FormFile("pdf"); err == nil {
+		return nil
+	}
+	session, ok := sessions.Get(c.PostForm("session_id"))
+	if !ok {
+		return nil
+	}
+	return session.analysis
+}
+
+// parseAuditDir returns the directory the removed images of this request are saved to
+// when the "audit" field is "true", or "" without it. The directory is named after the
+// request's session_id, or a new ID, which is sent back in X-Audit-Id.
+func parseAuditDir(c *gin.Context, config *Config) (string, error) {
+	if c.PostForm("audit") != "true" {
+		return "", nil
+	}
+	if config.AuditDir == "" {
+		return "", fmt.Errorf("audit is not enabled on this server (AUDIT_DIR is not set)")
+	}
+	auditID := c.PostForm("session_id")
+	if !fileIDPattern.MatchString(auditID) {
+		auditID = generateUniqueID()
+	}
+	c.Header("X-Audit-Id", auditID)
+	return filepath.Join(config.AuditDir, auditID), nil
+}
+
+// HandleClean removes pages and elements from one upload in a single request, e.g. a
+// cover page and a watermark. Page and element selections are checked before the upload
+// is processed; the PDF itself is the response, with the outcome in headers.
+func HandleClean(c *gin.Context, config *Config) {
+	pages := strings.TrimSpace(c.PostForm("remove_pages"))
+	elementIDs := parseIDList(c, "remove_elements")
+	if pages == "" && len(elementIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Specify remove_pages, remove_elements or both"})
 		return
 	}
+	for _, id := range elementIDs {
+		if !validElementID(id) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid element ID in remove_elements: %q", id)})
+			return
+		}
+	}
+	if pages != "" {
+		// End-relative pages such as "7-" are checked once the page count is known
+		if _, err := pdfPkg.ParsePageSpecifier(pages); err != nil && !errors.Is(err, pdfPkg.ErrPageTotalUnknown) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid remove_pages: %v", err)})
+			return
+		}
+	}
 
-	// Create temp input file
-	if err := ensureTempDir(config.TempDir); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create temp directory"})
+	opts, err := parseRemovalOptions(c, config)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	uniqueID := generateUniqueID()
-	inFile := filepath.Join(config.TempDir, "input_"+uniqueID+".pdf")
-	outFile := filepath.Join(config.TempDir, "output_"+uniqueID+"_"+suffix+".pdf")
+	handlePDFFile(c, config, func(inFile, outFile string) error {
+		report, err := pdfPkg.Clean(inFile, outFile, pages, elementIDs, opts)
+		if err != nil {
+			return err
+		}
 
-	out, err := os.Create(inFile)
+		removedPages := make([]string, len(report.RemovedPages))
+		for i, page := range report.RemovedPages {
+			removedPages[i] = strconv.Itoa(page)
+		}
+		c.Header("X-Removed-Pages", strings.Join(removedPages, ","))
+		c.Header("X-Removed-Images", strconv.Itoa(report.Removal.Removed))
+		if len(report.Removal.SkippedProtected) > 0 {
+			c.Header("X-Skipped-Protected-Objects", strings.Join(report.Removal.SkippedProtected, ","))
+		}
+		if len(report.Removal.SkippedUnresolved) > 0 {
+			c.Header("X-Skipped-Unresolved-Images", strings.Join(report.Removal.SkippedUnresolved, ","))
+		}
+		if len(report.Removal.SkippedText) > 0 {
+			c.Header("X-Skipped-Text-Elements", strings.Join(report.Removal.SkippedText, ","))
+		}
+		return nil
+	}, "cleaned")
+}
+
+// HandleConfig returns the effective non-secret configuration for troubleshooting
+// Values are listed explicitly so that secrets added to Config are never exposed by accident
+func HandleConfig(c *gin.Context, config *Config) {
+	c.JSON(http.StatusOK, gin.H{
+		"max_file_size":     config.MaxFileSize,
+		"temp_dir":          config.TempDir,
+		"protected_objects": config.ProtectedObjects,
+		"pdfcpu_config_dir": config.PdfcpuConfigDir,
+		"concurrency": gin.H{
+			"max_cli_processes": pdfPkg.MaxConcurrentCLI(),
+		},
+		"timeouts": gin.H{
+			"cli_seconds":              pdfPkg.DefaultCLITimeout.Seconds(),
+			"analysis_seconds":         pdfPkg.AnalysisTimeout.Seconds(),
+			"file_cleanup_seconds":     FileCleanupDelay.Seconds(),
+			"analysis_cleanup_seconds": AnalysisCleanupDelay.Seconds(),
+			"analysis_session_seconds": AnalysisSessionTTL.Seconds(),
+		},
+		"detection": gin.H{
+			"min_page_coverage":     pdfPkg.MinPageCoverageThreshold,
+			"min_watermark_size_kb": pdfPkg.MinWatermarkFileSizeKB,
+			"max_deep_match_images": pdfPkg.DefaultMaxDeepMatchImages,
+			"full_page_coverage":    pdfPkg.FullPageCoverageThreshold,
+			"max_tracked_groups":    pdfPkg.DefaultMaxTrackedGroups,
+		},
+	})
+}
+
+// HandlePdfcpuCommand returns the pdfcpu command line an operation would run for the
+// submitted parameters, without running it, so a problem can be reproduced with pdfcpu
+// directly. The input and output files are shown as in.pdf and out.pdf.
+func HandlePdfcpuCommand(c *gin.Context) {
+	operation := c.PostForm("operation")
+	params := pdfPkg.CommandParams{
+		InFile:    "in.pdf",
+		OutFile:   "out.pdf",
+		Pages:     c.PostForm("pages"),
+		Text:      c.PostForm("text"),
+		Watermark: pdfPkg.DefaultWatermarkOptions(),
+	}
+	if value := c.PostForm("degrees"); value != "" {
+		degrees, err := strconv.Atoi(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "degrees must be a whole number"})
+			return
+		}
+		params.Degrees = degrees
+	}
+	if operation == "watermark" {
+		if value := c.PostForm("font_size"); value != "" {
+			size, err := strconv.Atoi(value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "font_size must be a whole number of points"})
+				return
+			}
+			params.Watermark.FontSize = size
+		}
+		params.Watermark.Color = c.DefaultPostForm("color", params.Watermark.Color)
+		if err := parseWatermarkOptions(c, &params.Watermark); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	args, err := pdfPkg.PdfcpuCommand(operation, params)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create temp file"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	_, err = out.ReadFrom(file)
-	out.Close()
+	// Passwords from PDFCPU_GLOBAL_FLAGS are never shown
+	quoted := make([]string, len(args))
+	for i := range args {
+		if i > 0 && (args[i-1] == "-upw" || args[i-1] == "-opw") {
+			args[i] = "REDACTED"
+		}
+		quoted[i] = shellQuote(args[i])
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"operation": operation,
+		"args":      args,
+		"command":   strings.Join(quoted, " "),
+	})
+}
+
+// shellQuote quotes arg for a POSIX shell unless it is made of characters that need no quoting
+func shellQuote(arg string) string {
+	if arg != "" && strings.Trim(arg, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_.,:/=+@%") == "" {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// parseAnalysisOptions reads the analysis options of an analyze request from its form
+func parseAnalysisOptions(c *gin.Context) (pdfPkg.AnalysisOptions, error) {
+	opts := pdfPkg.AnalysisOptions{}
+	if minDPI := c.PostForm("min_dpi"); minDPI != "" {
+		value, err := strconv.ParseFloat(minDPI, 64)
+		if err != nil || value < 0 {
+			return opts, fmt.Errorf("min_dpi must be a non-negative number")
+		}
+		opts.MinDPI = value
+	}
+	opts.DeepMatch = c.PostForm("deep_match") == "true"
+	opts.DetectBlankPages = c.PostForm("detect_blank_pages") == "true"
+	opts.IncludeHeatmap = c.PostForm("heatmap") == "true"
+	opts.EarlyExit = c.PostForm("early_exit") == "true"
+	opts.ClassifyPlacement = c.PostForm("classify_placement") == "true"
+	opts.PrefixFilter = parsePrefixFilter(c)
+	if maxInk := c.PostForm("blank_page_max_ink"); maxInk != "" {
+		value, err := strconv.ParseFloat(maxInk, 64)
+		if err != nil || value < 0 || value > 1 {
+			return opts, fmt.Errorf("blank_page_max_ink must be a number between 0 and 1")
+		}
+		opts.BlankPageMaxInk = value
+	}
+	return opts, nil
+}
+
+// parsePrefixFilter reads the comma-separated image ID prefixes of the "prefix" field
+func parsePrefixFilter(c *gin.Context) []string {
+	var prefixes []string
+	for _, prefix := range strings.Split(c.PostForm("prefix"), ",") {
+		if prefix = strings.TrimSpace(prefix); prefix != "" {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	return prefixes
+}
+
+// parseElementIDs reads the selected element IDs from the "elements" form field
+func parseElementIDs(c *gin.Context) []string {
+	return parseIDList(c, "elements")
+}
+
+// parseIDList reads a list of IDs from the form field named field
+// Accepts repeated field / field[] fields as well as a single comma-separated field
+func parseIDList(c *gin.Context, field string) []string {
+	values := c.PostFormArray(field)
+	if len(values) <= 1 {
+		if bracketed := c.PostFormArray(field + "[]"); len(bracketed) > 0 {
+			values = append(values, bracketed...)
+		}
+	}
+
+	// A single value may itself be a comma-joined list
+	if len(values) == 1 {
+		values = strings.Split(values[0], ",")
+	}
+
+	elementIDs := []string{}
+	for _, value := range values {
+		if id := strings.TrimSpace(value); id != "" {
+			elementIDs = append(elementIDs, id)
+		}
+	}
+	return elementIDs
+}
+
+func handlePDFFile(c *gin.Context, config *Config, operation func(string, string) error, suffix string) {
+	file, header, err := c.Request.FormFile("pdf")
+	var inFile, uniqueID string
 	if err != nil {
-		os.Remove(inFile) // Clean up on error
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save input file"})
-		return
+		var ok bool
+		if sessionID := c.PostForm("session_id"); sessionID != "" {
+			inFile, uniqueID, ok = saveSessionPDF(c, config, "input_", sessionID)
+		} else if remoteURL := c.PostForm("url"); remoteURL != "" && config.RemoteFetch {
+			inFile, uniqueID, ok = saveRemotePDF(c, config, "input_", remoteURL)
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "No PDF file provided"})
+		}
+		if !ok {
+			return
+		}
+	} else {
+		defer file.Close()
+
+		// Validate PDF file
+		if err := validatePDFFile(file, header, config.MaxFileSize); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Create temp input file
+		if err := ensureTempDir(config.TempDir); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create temp directory"})
+			return
+		}
+
+		uniqueID = generateUniqueID()
+		inFile = filepath.Join(config.TempDir, "input_"+uniqueID+".pdf")
+		trackTempFile(c, inFile)
+
+		out, err := os.Create(inFile)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create temp file"})
+			return
+		}
+
+		_, err = out.ReadFrom(file)
+		out.Close()
+		if err != nil {
+			os.Remove(inFile) // Clean up on error
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save input file"})
+			return
+		}
 	}
+	outFile := filepath.Join(config.TempDir, "output_"+uniqueID+"_"+suffix+".pdf")
+	trackTempFile(c, outFile)
 
 	// Perform operation
 	err = operation(inFile, outFile)
@@ -301,7 +1451,7 @@ func handlePDFFile(c *gin.Context, config *Config, operation func(string, string
 				errorMsg = errStr
 			}
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errorMsg})
+		c.JSON(errorStatus(err), errorResponse(err, errorMsg))
 		return
 	}
 
@@ -330,8 +1480,18 @@ func handlePDFFile(c *gin.Context, config *Config, operation func(string, string
 
 	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
 
+	// Keep the output addressable under a file ID for OutputRetention, so viewers can
+	// fetch byte ranges of it from /api/pdf/files/:id after this response
+	outputID := generateUniqueID()
+	storedFile := filepath.Join(config.TempDir, outputID+"_"+filename)
+	if err := os.Rename(outFile, storedFile); err == nil {
+		outFile = storedFile
+		trackTempFile(c, outFile)
+		c.Header("X-File-Id", outputID)
+	}
+
 	// Return the processed file for download
-	c.File(outFile)
+	serveFile(c, outFile)
 
 	// Clean up temp files after response is sent to avoid race conditions
 	// Use defer with goroutine to wait for file transfer completion
@@ -340,11 +1500,148 @@ func handlePDFFile(c *gin.Context, config *Config, operation func(string, string
 			// Wait a bit to ensure file transfer completes
 			time.Sleep(FileCleanupDelay)
 			os.Remove(inFile)
+		}()
+		go func() {
+			time.Sleep(OutputRetention)
 			os.Remove(outFile)
 		}()
 	}()
 }
 
+// serveFile sends a file with byte-range support: http.ServeContent answers Range
+// requests with 206 and advertises Accept-Ranges, so PDF viewers can render the first
+// page before the whole file arrives. The Content-Type set by the caller is kept.
+func serveFile(c *gin.Context, path string) {
+	f, err := os.Open(path)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read file"})
+		return
+	}
+	c.Header("Accept-Ranges", "bytes")
+	http.ServeContent(c.Writer, c.Request, filepath.Base(path), info.ModTime(), f)
+}
+
+// saveUploadedPDF validates the "pdf" form file and saves it to the temp directory as
+// <prefix><uniqueID>.pdf. On failure the error response has been sent and ok is false.
+func saveUploadedPDF(c *gin.Context, config *Config, prefix string) (path string, uniqueID string, ok bool) {
+	file, header, err := c.Request.FormFile("pdf")
+	if err != nil {
+		if sessionID := c.PostForm("session_id"); sessionID != "" {
+			return saveSessionPDF(c, config, prefix, sessionID)
+		}
+		if remoteURL := c.PostForm("url"); remoteURL != "" && config.RemoteFetch {
+			return saveRemotePDF(c, config, prefix, remoteURL)
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No PDF file provided"})
+		return "", "", false
+	}
+	defer file.Close()
+
+	if err := validatePDFFile(file, header, config.MaxFileSize); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return "", "", false
+	}
+
+	if err := ensureTempDir(config.TempDir); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create temp directory"})
+		return "", "", false
+	}
+
+	uniqueID = generateUniqueID()
+	path = filepath.Join(config.TempDir, prefix+uniqueID+".pdf")
+	trackTempFile(c, path)
+
+	out, err := os.Create(path)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create temp file"})
+		return "", "", false
+	}
+
+	_, err = out.ReadFrom(file)
+	out.Close()
+	if err != nil {
+		os.Remove(path) // Clean up on error
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save input file"})
+		return "", "", false
+	}
+
+	return path, uniqueID, true
+}
+
+// saveRemotePDF is saveUploadedPDF for a PDF given by the "url" form field
+func saveRemotePDF(c *gin.Context, config *Config, prefix, remoteURL string) (path string, uniqueID string, ok bool) {
+	if err := ensureTempDir(config.TempDir); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create temp directory"})
+		return "", "", false
+	}
+
+	uniqueID = generateUniqueID()
+	path = filepath.Join(config.TempDir, prefix+uniqueID+".pdf")
+	trackTempFile(c, path)
+
+	if err := fetchRemotePDF(c.Request.Context(), config, remoteURL, path); err != nil {
+		log.Printf("Remote fetch error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return "", "", false
+	}
+	return path, uniqueID, true
+}
+
+// errorResponse builds the JSON error body for err, adding a machine-readable code for
+// errors clients may want to handle specially (e.g. retry a timeout with a smaller file)
+func errorResponse(err error, message string) gin.H {
+	response := gin.H{"error": message}
+	switch {
+	case errors.Is(err, pdfPkg.ErrCommandTimeout):
+		response["code"] = ErrorCodeTimeout
+	case errors.Is(err, pdfPkg.ErrUnsupportedEncryption):
+		// The generic message would hide that the password is not the problem
+		response["error"] = pdfPkg.ErrUnsupportedEncryption.Error()
+		response["code"] = ErrorCodeUnsupportedEncryption
+	case errors.Is(err, pdfPkg.ErrPasswordRequired):
+		response["error"] = pdfPkg.ErrPasswordRequired.Error()
+		response["code"] = ErrorCodePasswordRequired
+	case errors.Is(err, pdfPkg.ErrIncorrectPassword):
+		response["error"] = pdfPkg.ErrIncorrectPassword.Error()
+		response["code"] = ErrorCodeIncorrectPassword
+	case errors.Is(err, pdfPkg.ErrNotEncrypted):
+		response["error"] = pdfPkg.ErrNotEncrypted.Error()
+		response["code"] = ErrorCodeNotEncrypted
+	}
+	return response
+}
+
+// errorStatus maps errors returned by the pdf package to HTTP status codes
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, pdfPkg.ErrCorruptPDF):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, pdfPkg.ErrWouldEmptyDocument), errors.Is(err, pdfPkg.ErrPageOutOfRange):
+		return http.StatusBadRequest
+	case errors.Is(err, pdfPkg.ErrImageObjectNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, pdfPkg.ErrCommandTimeout):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, pdfPkg.ErrUnsupportedEncryption), errors.Is(err, pdfPkg.ErrTooManyImages), errors.Is(err, pdfPkg.ErrAllImagesProtected):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, pdfPkg.ErrPasswordRequired):
+		return http.StatusForbidden
+	case errors.Is(err, pdfPkg.ErrIncorrectPassword):
+		return http.StatusUnauthorized
+	case errors.Is(err, pdfPkg.ErrNotEncrypted), errors.Is(err, pdfPkg.ErrNothingToOCR):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 // ensureTempDir creates the temp directory if it doesn't exist
 func ensureTempDir(tempDir string) error {
 	return os.MkdirAll(tempDir, DefaultFilePermissions)
@@ -371,6 +1668,45 @@ func sanitizeFilename(filename string) string {
 	return filename
 }
 
+// findUploadedFile returns the path of the file stored by HandleUpload under fileID
+// The ID is validated and the result is checked to lie directly inside TempDir
+func findUploadedFile(config *Config, fileID string) (string, error) {
+	if !fileIDPattern.MatchString(fileID) {
+		return "", fmt.Errorf("invalid file ID")
+	}
+
+	matches, err := filepath.Glob(filepath.Join(config.TempDir, fileID+"_*"))
+	if err != nil || len(matches) == 0 {
+		return "", fmt.Errorf("file not found")
+	}
+
+	tempDir, err := filepath.Abs(config.TempDir)
+	if err != nil {
+		return "", err
+	}
+	path, err := filepath.Abs(matches[0])
+	if err != nil || filepath.Dir(path) != tempDir {
+		return "", fmt.Errorf("file not found")
+	}
+	if info, err := os.Stat(path); err != nil || !info.Mode().IsRegular() {
+		return "", fmt.Errorf("file not found")
+	}
+	return path, nil
+}
+
+// fileIDPattern matches IDs produced by generateUniqueID
+var fileIDPattern = regexp.MustCompile(`^\d+_[0-9a-f]+$`)
+
+// validElementID reports whether a client-supplied candidate ID is safe to use in file
+// names: not too long, no path separators or "..", and no control characters. Candidate
+// IDs embed image names and sizes from the PDF, so a stricter pattern would reject real ones.
+func validElementID(id string) bool {
+	if id == "" || len(id) > MaxElementIDLength || strings.ContainsAny(id, `/\`) || strings.Contains(id, "..") {
+		return false
+	}
+	return !strings.ContainsFunc(id, unicode.IsControl)
+}
+
 // generateUniqueID generates a unique identifier for temp files
 func generateUniqueID() string {
 	// Use timestamp + random bytes for uniqueness
@@ -380,25 +1716,66 @@ func generateUniqueID() string {
 	return fmt.Sprintf("%d_%s", timestamp, hex.EncodeToString(b))
 }
 
-// validatePDFFile checks if the file is a valid PDF by reading the header
-func validatePDFFile(file multipart.File, header *multipart.FileHeader, maxSize int64) error {
+// validateImageFile checks the size and magic bytes of an uploaded PNG or JPEG image and
+// returns the file extension matching its type
+func validateImageFile(file multipart.File, header *multipart.FileHeader, maxSize int64) (string, error) {
 	if header.Size > maxSize {
-		return fmt.Errorf("file size %d exceeds maximum allowed %d bytes", header.Size, maxSize)
+		return "", fmt.Errorf("file size %d exceeds maximum allowed %d bytes", header.Size, maxSize)
+	}
+
+	buffer := make([]byte, 8)
+	n, err := io.ReadFull(file, buffer)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", fmt.Errorf("failed to read file header: %v", err)
+	}
+
+	var ext string
+	switch {
+	case bytes.HasPrefix(buffer[:n], []byte("\x89PNG\r\n\x1a\n")):
+		ext = ".png"
+	case bytes.HasPrefix(buffer[:n], []byte{0xFF, 0xD8, 0xFF}):
+		ext = ".jpg"
+	default:
+		return "", fmt.Errorf("invalid image file: only PNG and JPEG are supported")
+	}
+
+	// Seek back to beginning for subsequent reads
+	if _, err := file.Seek(0, 0); err != nil {
+		return "", fmt.Errorf("failed to reset file position: %v", err)
 	}
+	return ext, nil
+}
 
-	// Read first 4 bytes to check PDF header
-	buffer := make([]byte, 4)
-	n, err := file.Read(buffer)
-	if err != nil && err != io.EOF {
+// checkPDFSignature reads the start of a file and checks that it is at least MinPDFFileSize
+// bytes long and has the %PDF- header within its first PDFHeaderScanBytes bytes
+func checkPDFSignature(r io.Reader) error {
+	buffer := make([]byte, PDFHeaderScanBytes)
+	n, err := io.ReadFull(r, buffer)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
 		return fmt.Errorf("failed to read file header: %v", err)
 	}
 
-	if n >= 4 && string(buffer[:4]) != "%PDF" {
+	if n < MinPDFFileSize {
+		return fmt.Errorf("invalid PDF file: only %d bytes", n)
+	}
+	if !bytes.Contains(buffer[:n], []byte("%PDF-")) {
 		return fmt.Errorf("invalid PDF file: header does not match")
 	}
+	return nil
+}
+
+// validatePDFFile checks if the file is a valid PDF by reading the header
+func validatePDFFile(file multipart.File, header *multipart.FileHeader, maxSize int64) error {
+	if header.Size > maxSize {
+		return fmt.Errorf("file size %d exceeds maximum allowed %d bytes", header.Size, maxSize)
+	}
+
+	if err := checkPDFSignature(file); err != nil {
+		return err
+	}
 
 	// Seek back to beginning for subsequent reads
-	_, err = file.Seek(0, 0)
+	_, err := file.Seek(0, 0)
 	if err != nil {
 		return fmt.Errorf("failed to reset file position: %v", err)
 	}
@@ -0,0 +1,309 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	pdfPkg "pdf_editor/pdf"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// memFile is an uploaded file held in memory
+type memFile struct {
+	*bytes.Reader
+}
+
+func (memFile) Close() error { return nil }
+
+func upload(data []byte) (multipart.File, *multipart.FileHeader) {
+	return memFile{bytes.NewReader(data)}, &multipart.FileHeader{Filename: "upload", Size: int64(len(data))}
+}
+
+func TestValidateImageFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		maxSize int64
+		wantExt string
+		wantErr bool
+	}{
+		{name: "png", data: []byte("\x89PNG\r\n\x1a\n rest of the image"), maxSize: 1024, wantExt: ".png"},
+		{name: "jpeg", data: []byte{0xFF, 0xD8, 0xFF, 0xE0, 0, 0x10, 'J', 'F', 'I', 'F'}, maxSize: 1024, wantExt: ".jpg"},
+		{name: "gif", data: []byte("GIF89a......"), maxSize: 1024, wantErr: true},
+		{name: "pdf", data: []byte("%PDF-1.7\n%%EOF\n"), maxSize: 1024, wantErr: true},
+		{name: "truncated png", data: []byte("\x89PNG"), maxSize: 1024, wantErr: true},
+		{name: "empty", data: nil, maxSize: 1024, wantErr: true},
+		{name: "too large", data: []byte("\x89PNG\r\n\x1a\n rest of the image"), maxSize: 8, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, header := upload(tt.data)
+			ext, err := validateImageFile(file, header, tt.maxSize)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if ext != tt.wantExt {
+				t.Errorf("ext = %q, want %q", ext, tt.wantExt)
+			}
+			if err == nil {
+				if pos, _ := file.Seek(0, 1); pos != 0 {
+					t.Errorf("file left at offset %d, want 0", pos)
+				}
+			}
+		})
+	}
+}
+
+func TestValidatePDFFile(t *testing.T) {
+	minimal := []byte("%PDF-1.7\n1 0 obj << /Type /Catalog >> endobj\ntrailer << /Root 1 0 R >>\n%%EOF\n")
+	tests := []struct {
+		name    string
+		data    []byte
+		maxSize int64
+		wantErr bool
+	}{
+		{name: "minimal pdf", data: minimal, maxSize: 1024},
+		{name: "10-byte junk prefix", data: append([]byte("0123456789"), minimal...), maxSize: 1024},
+		{name: "byte order mark and whitespace", data: append([]byte("\xEF\xBB\xBF \r\n"), minimal...), maxSize: 1024},
+		{name: "2-byte file", data: []byte("%P"), maxSize: 1024, wantErr: true},
+		{name: "header only", data: []byte("%PDF-"), maxSize: 1024, wantErr: true},
+		{name: "empty", data: nil, maxSize: 1024, wantErr: true},
+		{name: "no header", data: []byte("just some text, not a PDF at all"), maxSize: 1024, wantErr: true},
+		{name: "header past the scanned bytes", data: append(bytes.Repeat([]byte(" "), PDFHeaderScanBytes), minimal...), maxSize: 4096, wantErr: true},
+		{name: "too large", data: minimal, maxSize: 8, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, header := upload(tt.data)
+			err := validatePDFFile(file, header, tt.maxSize)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil {
+				if pos, _ := file.Seek(0, 1); pos != 0 {
+					t.Errorf("file left at offset %d, want 0", pos)
+				}
+			}
+		})
+	}
+}
+
+// formContext returns a gin context for a POST of the url-encoded form body
+func formContext(body string) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return c
+}
+
+func TestParseElementIDs(t *testing.T) {
+	want := []string{"img_1", "img_2", "text_3"}
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"comma-separated", "elements=img_1,img_2,text_3"},
+		{"comma-separated with spaces", "elements=img_1,+img_2+,text_3,"},
+		{"repeated", "elements=img_1&elements=img_2&elements=text_3"},
+		{"repeated with brackets", "elements%5B%5D=img_1&elements%5B%5D=img_2&elements%5B%5D=text_3"},
+		{"one plain and brackets", "elements=img_1&elements%5B%5D=img_2&elements%5B%5D=text_3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseElementIDs(formContext(tt.body)); !slices.Equal(got, want) {
+				t.Errorf("parseElementIDs = %q, want %q", got, want)
+			}
+		})
+	}
+
+	if got := parseElementIDs(formContext("other=1")); len(got) != 0 {
+		t.Errorf("parseElementIDs without elements = %q, want none", got)
+	}
+	body := "remove_elements%5B%5D=img_1&remove_elements%5B%5D=img_2&remove_elements%5B%5D=text_3&elements=other"
+	if got := parseIDList(formContext(body), "remove_elements"); !slices.Equal(got, want) {
+		t.Errorf("parseIDList(remove_elements) = %q, want %q", got, want)
+	}
+}
+
+func TestCleanRejectsInvalidElementIDs(t *testing.T) {
+	config := &Config{MaxFileSize: 1 << 20, TempDir: t.TempDir()}
+	r := gin.New()
+	SetupRoutes(r, config)
+
+	for _, body := range []string{
+		"remove_elements=img_1,../../etc/passwd",
+		"remove_elements=img_1&remove_elements=a%2Fb",
+		"remove_elements%5B%5D=img_1&remove_elements%5B%5D=bad%00id",
+		"remove_pages=1&remove_elements=" + strings.Repeat("x", MaxElementIDLength+1),
+	} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/pdf/clean", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "Invalid element ID") {
+			t.Errorf("%s: status %d %s, want 400 for the element ID", body, w.Code, w.Body)
+		}
+	}
+	if entries, _ := os.ReadDir(config.TempDir); len(entries) != 0 {
+		t.Errorf("rejected requests left files: %v", entries)
+	}
+}
+
+func TestConfigEndpointOmitsSecrets(t *testing.T) {
+	const secret = "Bearer s3cr3t-token"
+	config := &Config{
+		MaxFileSize: 1 << 20,
+		TempDir:     t.TempDir(),
+		Debug:       true,
+		RemoteFetchHeaders: map[string]map[string]string{
+			"docs.example.com": {"authorization": secret},
+		},
+	}
+	r := gin.New()
+	SetupRoutes(r, config)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/pdf/config", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d, want 200", w.Code)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, `"max_file_size":1048576`) {
+		t.Errorf("limits missing from %s", body)
+	}
+	if want := fmt.Sprintf(`"concurrency":{"max_cli_processes":%d}`, pdfPkg.MaxConcurrentCLI()); !strings.Contains(body, want) {
+		t.Errorf("concurrency limit missing from %s, want %s", body, want)
+	}
+	for _, leaked := range []string{secret, "s3cr3t", "docs.example.com"} {
+		if strings.Contains(body, leaked) {
+			t.Errorf("configuration exposes %q: %s", leaked, body)
+		}
+	}
+
+	config.Debug = false
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/pdf/config", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status %d without debug mode, want 404", w.Code)
+	}
+}
+
+func TestTimeoutMapsTo504(t *testing.T) {
+	// As returned by an operation whose pdfcpu call ran out of time
+	err := fmt.Errorf("pdfcpu optimize failed: %w", fmt.Errorf("%w after 30s", pdfPkg.ErrCommandTimeout))
+
+	if status := errorStatus(err); status != http.StatusGatewayTimeout {
+		t.Errorf("errorStatus = %d, want 504", status)
+	}
+	if code := errorResponse(err, "Failed to resave PDF")["code"]; code != ErrorCodeTimeout {
+		t.Errorf("code = %v, want %q", code, ErrorCodeTimeout)
+	}
+	if status := errorStatus(errors.New("pdfcpu optimize failed: exit status 1")); status != http.StatusInternalServerError {
+		t.Errorf("other failures map to %d, want 500", status)
+	}
+}
+
+func TestEncryptionErrorCodes(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{pdfPkg.ErrUnsupportedEncryption, http.StatusUnprocessableEntity, ErrorCodeUnsupportedEncryption},
+		{pdfPkg.ErrPasswordRequired, http.StatusForbidden, ErrorCodePasswordRequired},
+		{pdfPkg.ErrIncorrectPassword, http.StatusUnauthorized, ErrorCodeIncorrectPassword},
+		{pdfPkg.ErrNotEncrypted, http.StatusUnprocessableEntity, ErrorCodeNotEncrypted},
+	}
+	for _, tt := range tests {
+		err := fmt.Errorf("command failed: %w", tt.err)
+		if status := errorStatus(err); status != tt.status {
+			t.Errorf("%v: status %d, want %d", tt.err, status, tt.status)
+		}
+		response := errorResponse(err, "Failed to decrypt PDF")
+		if response["code"] != tt.code {
+			t.Errorf("%v: code %v, want %q", tt.err, response["code"], tt.code)
+		}
+		// The specific message replaces the generic one, so users see it is not their password
+		if response["error"] != tt.err.Error() {
+			t.Errorf("%v: error %q, want the specific message", tt.err, response["error"])
+		}
+	}
+}
+
+func TestParsePrefixFilter(t *testing.T) {
+	tests := []struct {
+		body string
+		want []string
+	}{
+		{body: "prefix=Im0", want: []string{"Im0"}},
+		{body: "prefix=Im0,+Logo+,", want: []string{"Im0", "Logo"}},
+		{body: "prefix=", want: nil},
+		{body: "other=1", want: nil},
+	}
+	for _, tt := range tests {
+		if got := parsePrefixFilter(formContext(tt.body)); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: parsePrefixFilter = %q, want %q", tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestPdfcpuCommandEndpoint(t *testing.T) {
+	config := &Config{TempDir: t.TempDir(), Debug: true}
+	r := gin.New()
+	SetupRoutes(r, config)
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/pdf/debug/command", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post("operation=remove-pages&pages=1-2")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	var body struct {
+		Args    []string `json:"args"`
+		Command string   `json:"command"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"pdfcpu", "pages", "remove", "-p", "1,2", "--", "in.pdf", "out.pdf"}; !slices.Equal(body.Args, want) {
+		t.Errorf("args %q, want %q", body.Args, want)
+	}
+	if body.Command != "pdfcpu pages remove -p 1,2 -- in.pdf out.pdf" {
+		t.Errorf("command %q", body.Command)
+	}
+
+	// Passwords from the global flags are redacted
+	if err := pdfPkg.SetPdfcpuGlobalFlags([]string{"-opw", "s3cret"}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pdfPkg.SetPdfcpuGlobalFlags(nil) })
+	if w := post("operation=resave"); strings.Contains(w.Body.String(), "s3cret") || !strings.Contains(w.Body.String(), "REDACTED") {
+		t.Errorf("password not redacted: %s", w.Body)
+	}
+
+	if w := post("operation=remove-pages&pages=x"); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid pages: status %d, want 400", w.Code)
+	}
+	config.Debug = false
+	if w := post("operation=resave"); w.Code != http.StatusNotFound {
+		t.Errorf("status %d without debug mode, want 404", w.Code)
+	}
+}
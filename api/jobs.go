@@ -0,0 +1,251 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	pdfPkg "pdf_editor/pdf"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Analysis job statuses reported by /api/pdf/jobs/:id
+const (
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusFailed    = "failed"
+	JobStatusCancelled = "cancelled"
+)
+
+// analysisJob is an analysis running in the background. Its input is removed when it fails
+// or is cancelled; a successful job hands the input to the analysis session of its ID.
+type analysisJob struct {
+	status   string
+	cancel   context.CancelFunc
+	done     chan struct{} // closed once the analysis has returned and its input is handled
+	path     string        // analysis_<job_id>.pdf in the temp directory
+	analysis *pdfPkg.UnwantedElementsAnalysis
+	err      string
+}
+
+// errTooManyJobs is returned by jobStore.Start when MaxRunningJobs jobs are running
+var errTooManyJobs = errors.New("too many running analysis jobs, try again later")
+
+// jobStore holds the analysis jobs, keyed by job ID. A finished job is forgotten after
+// JobResultTTL; the session of a successful one follows its own AnalysisSessionTTL.
+type jobStore struct {
+	mu   sync.Mutex
+	jobs map[string]*analysisJob
+}
+
+var jobs = newJobStore()
+
+func newJobStore() *jobStore {
+	return &jobStore{jobs: make(map[string]*analysisJob)}
+}
+
+// analyzeForJob runs the analysis of a job; tests replace it to run without pdfcpu
+var analyzeForJob = pdfPkg.AnalyzeUnwantedElementsContext
+
+// Start runs the analysis of the file at path in the background. The job's context is
+// cancelled by Cancel, which stops the running pdfcpu process.
+func (s *jobStore) Start(jobID, path string, opts pdfPkg.AnalysisOptions) error {
+	s.mu.Lock()
+	running := 0
+	for _, job := range s.jobs {
+		if job.status == JobStatusRunning {
+			running++
+		}
+	}
+	if running >= MaxRunningJobs {
+		s.mu.Unlock()
+		return errTooManyJobs
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &analysisJob{status: JobStatusRunning, cancel: cancel, done: make(chan struct{}), path: path}
+	s.jobs[jobID] = job
+	s.mu.Unlock()
+
+	go func() {
+		defer close(job.done)
+		defer cancel()
+		analysis, err := analyzeForJob(ctx, path, opts)
+		s.finish(jobID, job, analysis, err)
+	}()
+	return nil
+}
+
+// finish records the outcome of a job's analysis and disposes of its input
+func (s *jobStore) finish(jobID string, job *analysisJob, analysis *pdfPkg.UnwantedElementsAnalysis, err error) {
+	if err == nil {
+		// The session keeps the input for previews and removal, as after /analyze-unwanted-elements
+		if s.status(job) != JobStatusCancelled {
+			err = sessions.Add(jobID, job.path, analysis)
+		}
+	}
+
+	s.mu.Lock()
+	switch {
+	case job.status == JobStatusCancelled:
+		if err == nil {
+			// Finished just as it was cancelled: the cancellation wins
+			sessions.Delete(jobID)
+		}
+	case err != nil:
+		job.status = JobStatusFailed
+		job.err = err.Error()
+	default:
+		job.status = JobStatusSucceeded
+		job.analysis = analysis
+	}
+	status := job.status
+	s.mu.Unlock()
+
+	if status != JobStatusSucceeded {
+		os.Remove(job.path)
+	}
+	log.Printf("Analysis job %s %s", jobID, status)
+	time.AfterFunc(JobResultTTL, func() {
+		s.mu.Lock()
+		delete(s.jobs, jobID)
+		s.mu.Unlock()
+	})
+}
+
+// status returns the current status of job
+func (s *jobStore) status(job *analysisJob) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return job.status
+}
+
+// Get returns a snapshot of the job with jobID, if it is known
+func (s *jobStore) Get(jobID string) (analysisJob, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[jobID]
+	if !ok {
+		return analysisJob{}, false
+	}
+	return *job, true
+}
+
+// Cancel marks a running job cancelled and cancels its context. It returns the job's
+// status afterwards and its done channel, which is closed once the input is removed.
+func (s *jobStore) Cancel(jobID string) (string, <-chan struct{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[jobID]
+	if !ok {
+		return "", nil, false
+	}
+	if job.status == JobStatusRunning {
+		job.status = JobStatusCancelled
+		job.cancel()
+	}
+	return job.status, job.done, true
+}
+
+// HandleStartJob starts an analysis in the background and answers with its job_id at
+// once; the form fields are those of /api/pdf/analyze-unwanted-elements
+func HandleStartJob(c *gin.Context, config *Config) {
+	file, header, err := c.Request.FormFile("pdf")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No PDF file provided"})
+		return
+	}
+	defer file.Close()
+	if err := validatePDFFile(file, header, config.MaxFileSize); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	opts, err := parseAnalysisOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := ensureTempDir(config.TempDir); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create temp directory"})
+		return
+	}
+
+	// The job outlives the request, so its input is not tracked as a request temp file
+	jobID := generateUniqueID()
+	inFile := filepath.Join(config.TempDir, "analysis_"+jobID+".pdf")
+	out, err := os.Create(inFile)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create temp file"})
+		return
+	}
+	_, err = out.ReadFrom(file)
+	out.Close()
+	if err != nil {
+		os.Remove(inFile)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save input file"})
+		return
+	}
+
+	if err := jobs.Start(jobID, inFile, opts); err != nil {
+		os.Remove(inFile)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Location", "/api/pdf/jobs/"+jobID)
+	c.JSON(http.StatusAccepted, gin.H{"job_id": jobID, "status": JobStatusRunning})
+}
+
+// HandleJobStatus reports the status of a job and, once it succeeded, its analysis and
+// the session_id for previews and removal
+func HandleJobStatus(c *gin.Context) {
+	jobID := c.Param("id")
+	if !fileIDPattern.MatchString(jobID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job_id"})
+		return
+	}
+	job, ok := jobs.Get(jobID)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found or expired"})
+		return
+	}
+	response := gin.H{"job_id": jobID, "status": job.status}
+	switch job.status {
+	case JobStatusFailed:
+		response["error"] = job.err
+	case JobStatusSucceeded:
+		response["session_id"] = jobID
+		response["analysis"] = job.analysis
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// HandleCancelJob cancels a running job, killing its pdfcpu process, and answers once its
+// input has been removed (or after JobCancelWait). Finished jobs cannot be cancelled.
+func HandleCancelJob(c *gin.Context) {
+	jobID := c.Param("id")
+	if !fileIDPattern.MatchString(jobID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job_id"})
+		return
+	}
+	status, done, ok := jobs.Cancel(jobID)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found or expired"})
+		return
+	}
+	if status != JobStatusCancelled {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Job already %s", status), "job_id": jobID, "status": status})
+		return
+	}
+	select {
+	case <-done:
+	case <-time.After(JobCancelWait):
+		log.Printf("Analysis job %s still stopping after %v", jobID, JobCancelWait)
+	}
+	c.JSON(http.StatusOK, gin.H{"job_id": jobID, "status": status})
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	pdfPkg "pdf_editor/pdf"
+
+	"github.com/gin-gonic/gin"
+)
+
+// useJobAnalysis makes jobs run analyze instead of the pdfcpu analysis
+func useJobAnalysis(t *testing.T, analyze func(ctx context.Context, path string, opts pdfPkg.AnalysisOptions) (*pdfPkg.UnwantedElementsAnalysis, error)) {
+	t.Helper()
+	previous := analyzeForJob
+	analyzeForJob = analyze
+	t.Cleanup(func() { analyzeForJob = previous })
+}
+
+// startJob posts a PDF to /api/pdf/jobs and returns the job_id
+func startJob(t *testing.T, r *gin.Engine) string {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, _ := mw.CreateFormFile("pdf", "large.pdf")
+	part.Write([]byte("%PDF-1.7\n%%EOF\n"))
+	mw.Close()
+	req := httptest.NewRequest(http.MethodPost, "/api/pdf/jobs", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("start job: status %d: %s", w.Code, w.Body)
+	}
+	var response struct {
+		JobID  string `json:"job_id"`
+		Status string `json:"status"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Status != JobStatusRunning || w.Header().Get("Location") != "/api/pdf/jobs/"+response.JobID {
+		t.Errorf("start job: status %q, Location %q", response.Status, w.Header().Get("Location"))
+	}
+	return response.JobID
+}
+
+// jobRequest sends method to /api/pdf/jobs/:id and returns the status code and response
+func jobRequest(t *testing.T, r *gin.Engine, method, jobID string) (int, map[string]any) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(method, "/api/pdf/jobs/"+jobID, nil))
+	var response map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("%s job: %v: %s", method, err, w.Body)
+	}
+	return w.Code, response
+}
+
+func TestCancelRunningJob(t *testing.T) {
+	started := make(chan string, 1)
+	stopped := make(chan struct{})
+	useJobAnalysis(t, func(ctx context.Context, path string, opts pdfPkg.AnalysisOptions) (*pdfPkg.UnwantedElementsAnalysis, error) {
+		// A long analysis that only ends when its pdfcpu process is killed, leaving a
+		// partial file behind like an interrupted extraction
+		os.WriteFile(filepath.Join(filepath.Dir(path), "partial.txt"), nil, 0644)
+		started <- path
+		<-ctx.Done()
+		os.Remove(filepath.Join(filepath.Dir(path), "partial.txt"))
+		close(stopped)
+		return nil, pdfPkg.ErrCommandCancelled
+	})
+	config := &Config{MaxFileSize: 1 << 20, TempDir: t.TempDir()}
+	r := gin.New()
+	SetupRoutes(r, config)
+
+	jobID := startJob(t, r)
+	inFile := <-started
+	if code, response := jobRequest(t, r, http.MethodGet, jobID); code != http.StatusOK || response["status"] != JobStatusRunning {
+		t.Fatalf("status %d %v, want 200 running", code, response)
+	}
+
+	code, response := jobRequest(t, r, http.MethodDelete, jobID)
+	if code != http.StatusOK || response["status"] != JobStatusCancelled {
+		t.Fatalf("cancel: status %d %v, want 200 cancelled", code, response)
+	}
+	select {
+	case <-stopped:
+	default:
+		t.Fatal("cancel answered before the analysis stopped")
+	}
+	if _, err := os.Stat(inFile); !os.IsNotExist(err) {
+		t.Errorf("input of the cancelled job kept: %v", err)
+	}
+	if entries, _ := os.ReadDir(config.TempDir); len(entries) != 0 {
+		t.Errorf("files left after cancelling: %v", entries)
+	}
+	if _, ok := sessions.Get(jobID); ok {
+		t.Error("cancelled job started a session")
+	}
+
+	if code, response := jobRequest(t, r, http.MethodGet, jobID); code != http.StatusOK || response["status"] != JobStatusCancelled {
+		t.Errorf("status after cancelling %d %v, want 200 cancelled", code, response)
+	}
+	if code, _ := jobRequest(t, r, http.MethodDelete, generateUniqueID()); code != http.StatusNotFound {
+		t.Errorf("cancelling an unknown job: status %d, want 404", code)
+	}
+	if code, _ := jobRequest(t, r, http.MethodDelete, "not-a-job"); code != http.StatusBadRequest {
+		t.Errorf("cancelling an invalid job ID: status %d, want 400", code)
+	}
+}
+
+func TestFinishedJobCannotBeCancelled(t *testing.T) {
+	useJobAnalysis(t, func(ctx context.Context, path string, opts pdfPkg.AnalysisOptions) (*pdfPkg.UnwantedElementsAnalysis, error) {
+		return &pdfPkg.UnwantedElementsAnalysis{Status: pdfPkg.AnalysisStatusNoneFound, TotalPages: 2}, nil
+	})
+	config := &Config{MaxFileSize: 1 << 20, TempDir: t.TempDir()}
+	r := gin.New()
+	SetupRoutes(r, config)
+
+	jobID := startJob(t, r)
+	t.Cleanup(func() { sessions.Delete(jobID) })
+	var response map[string]any
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		_, response = jobRequest(t, r, http.MethodGet, jobID)
+		if response["status"] != JobStatusRunning || time.Now().After(deadline) {
+			break
+		}
+	}
+	if response["status"] != JobStatusSucceeded || response["session_id"] != jobID || response["analysis"] == nil {
+		t.Fatalf("job response %v, want succeeded with the analysis and session", response)
+	}
+	if _, ok := sessions.Get(jobID); !ok {
+		t.Error("succeeded job has no session")
+	}
+
+	if code, response := jobRequest(t, r, http.MethodDelete, jobID); code != http.StatusConflict || response["status"] != JobStatusSucceeded {
+		t.Errorf("cancelling a finished job: status %d %v, want 409", code, response)
+	}
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// operationSample is one timed request of an operation
+type operationSample struct {
+	duration time.Duration
+	bytes    int64 // request body size
+}
+
+// operationMetrics keeps the most recent MetricsWindow samples of every operation
+type operationMetrics struct {
+	mu      sync.Mutex
+	samples map[string][]operationSample
+}
+
+// metrics is the process-wide operation timing store
+var metrics = &operationMetrics{samples: make(map[string][]operationSample)}
+
+// record adds a sample, dropping the oldest once the window is full
+func (m *operationMetrics) record(operation string, sample operationSample) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	samples := append(m.samples[operation], sample)
+	if len(samples) > MetricsWindow {
+		samples = samples[len(samples)-MetricsWindow:]
+	}
+	m.samples[operation] = samples
+}
+
+// secondsPerByte returns the average processing time per request byte of an operation
+// over the window, and the number of samples it is based on
+func (m *operationMetrics) secondsPerByte(operation string) (float64, int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var seconds float64
+	var bytes int64
+	for _, sample := range m.samples[operation] {
+		seconds += sample.duration.Seconds()
+		bytes += sample.bytes
+	}
+	if bytes == 0 {
+		return 0, 0
+	}
+	return seconds / float64(bytes), len(m.samples[operation])
+}
+
+// recordTimings times successful uploads to each endpoint, keyed by operation name (the
+// route path without the /api/pdf/ prefix)
+func recordTimings() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		if c.Request.Method != http.MethodPost || c.Request.ContentLength <= 0 || c.Writer.Status() >= http.StatusBadRequest {
+			return
+		}
+		operation := strings.TrimPrefix(c.FullPath(), "/api/pdf/")
+		metrics.record(operation, operationSample{duration: time.Since(start), bytes: c.Request.ContentLength})
+	}
+}
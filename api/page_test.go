@@ -0,0 +1,146 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestPageEndpoint(t *testing.T) {
+	r, _ := storedFileRouter(t)
+
+	// A page extracted earlier is served from the preview cache
+	page := []byte("%PDF-1.7\npage 2 only\n%%EOF\n")
+	pagePath := filepath.Join(t.TempDir(), "page_2.pdf")
+	if err := os.WriteFile(pagePath, page, 0644); err != nil {
+		t.Fatal(err)
+	}
+	key := previewKey(storedFileID, "page_2.pdf")
+	previews.Add(key, pagePath)
+	t.Cleanup(func() { previews.Remove(key, pagePath) })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/pdf/page?file_id="+storedFileID+"&page=2", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d, want 200: %s", w.Code, w.Body.String())
+	}
+	if w.Body.String() != string(page) {
+		t.Errorf("body = %q, want the single-page PDF", w.Body.String())
+	}
+	if got := w.Header().Get("Content-Type"); got != "application/pdf" {
+		t.Errorf("Content-Type = %q, want application/pdf", got)
+	}
+	if got := w.Header().Get("Content-Disposition"); got != `attachment; filename="page_2.pdf"` {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"file_id=" + storedFileID + "&page=0", http.StatusBadRequest},
+		{"file_id=" + storedFileID + "&page=-1", http.StatusBadRequest},
+		{"file_id=" + storedFileID + "&page=two", http.StatusBadRequest},
+		{"file_id=" + storedFileID, http.StatusBadRequest},
+		{"file_id=1700000000000000000_ffffffff&page=1", http.StatusNotFound},
+		{"file_id=../etc&page=1", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/pdf/page?"+tt.query, nil))
+		if w.Code != tt.want {
+			t.Errorf("%s: status %d, want %d", tt.query, w.Code, tt.want)
+		}
+	}
+}
+
+// useExtractPage makes /api/pdf/page extract pages with extract instead of pdfcpu
+func useExtractPage(t *testing.T, extract func(inFile, outFile, pages string) error) {
+	t.Helper()
+	previous := extractPage
+	extractPage = extract
+	t.Cleanup(func() { extractPage = previous })
+}
+
+func TestPageEndpointSharesExtraction(t *testing.T) {
+	r, _ := storedFileRouter(t)
+	key := previewKey(storedFileID, "page_3.pdf")
+	t.Cleanup(func() { previews.Remove(key, "") })
+
+	var extractions atomic.Int32
+	release := make(chan struct{})
+	useExtractPage(t, func(inFile, outFile, pages string) error {
+		extractions.Add(1)
+		<-release
+		return os.WriteFile(outFile, []byte("%PDF-1.7\npage "+pages+"\n%%EOF\n"), 0644)
+	})
+
+	const requests = 8
+	var wg sync.WaitGroup
+	responses := make([]*httptest.ResponseRecorder, requests)
+	for i := range responses {
+		responses[i] = httptest.NewRecorder()
+		wg.Add(1)
+		go func(w *httptest.ResponseRecorder) {
+			defer wg.Done()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/pdf/page?file_id="+storedFileID+"&page=3", nil))
+		}(responses[i])
+	}
+	// Let every request reach the extraction before it finishes
+	for extractions.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := extractions.Load(); n != 1 {
+		t.Errorf("%d extractions for %d concurrent requests, want 1", n, requests)
+	}
+	for i, w := range responses {
+		if w.Code != http.StatusOK || w.Body.String() != "%PDF-1.7\npage 3\n%%EOF\n" {
+			t.Errorf("request %d: status %d, body %q", i, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestPageEndpointFailureRemovesDirectory(t *testing.T) {
+	config := &Config{TempDir: t.TempDir(), MaxFileSize: 1 << 20}
+	if err := os.WriteFile(filepath.Join(config.TempDir, storedFileID+"_report.pdf"), []byte("%PDF-1.7\n%%EOF\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r := gin.New()
+	SetupRoutes(r, config)
+	var extractions atomic.Int32
+	useExtractPage(t, func(inFile, outFile, pages string) error {
+		extractions.Add(1)
+		os.WriteFile(outFile, []byte("partial"), 0644)
+		return errors.New("pdfcpu trim failed")
+	})
+
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/pdf/page?file_id="+storedFileID+"&page=3", nil))
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("status %d, want 500: %s", w.Code, w.Body)
+		}
+		if got := w.Header().Get("Content-Disposition"); got != "" {
+			t.Errorf("error response has Content-Disposition %q", got)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(config.TempDir, "previews", storedFileID)); !os.IsNotExist(err) {
+		t.Errorf("page directory kept after a failed extraction: %v", err)
+	}
+	// Failures are not cached, so the next request tries again
+	if n := extractions.Load(); n != 2 {
+		t.Errorf("%d extractions for 2 failing requests, want 2", n)
+	}
+}
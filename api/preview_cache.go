@@ -0,0 +1,237 @@
+package api
+
+import (
+	"container/list"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// previewCache bounds the previews kept on disk or, with PREVIEW_MEMORY_CACHE, in memory.
+// Entries are evicted least recently used first once MaxPreviewEntries or MaxPreviewBytes
+// (MaxPreviewMemoryBytes for the previews in memory) is exceeded, in addition to the
+// PreviewTTL cleanup scheduled for every preview file. Previews in memory expire lazily.
+type previewCache struct {
+	mu             sync.Mutex
+	maxEntries     int
+	maxBytes       int64
+	maxMemoryBytes int64
+	totalBytes     int64
+	memoryBytes    int64
+	order          *list.List // front is most recently used
+	entries        map[string]*list.Element
+}
+
+// previewEntry is a cached preview file, directory of previews or preview kept in memory
+type previewEntry struct {
+	key  string
+	path string
+	size int64
+
+	// data is set for previews kept in memory, which have no path
+	data        []byte
+	contentType string
+	expires     time.Time
+}
+
+// previews is the process-wide preview cache
+var previews = newPreviewCache(MaxPreviewEntries, MaxPreviewBytes, MaxPreviewMemoryBytes)
+
+func newPreviewCache(maxEntries int, maxBytes, maxMemoryBytes int64) *previewCache {
+	return &previewCache{
+		maxEntries:     maxEntries,
+		maxBytes:       maxBytes,
+		maxMemoryBytes: maxMemoryBytes,
+		order:          list.New(),
+		entries:        make(map[string]*list.Element),
+	}
+}
+
+// previewKey builds the cache key for a preview of element in file
+func previewKey(fileID, elementID string) string {
+	return fileID + "|" + elementID
+}
+
+// Get returns the path cached under key and marks it recently used
+func (pc *previewCache) Get(key string) (string, bool) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	elem, ok := pc.entries[key]
+	if !ok {
+		return "", false
+	}
+	entry := elem.Value.(*previewEntry)
+	if entry.data != nil {
+		return "", false
+	}
+	if _, err := os.Stat(entry.path); err != nil {
+		pc.removeElement(elem)
+		return "", false
+	}
+	pc.order.MoveToFront(elem)
+	return entry.path, true
+}
+
+// GetData returns the preview kept in memory under key with its content type and marks it
+// recently used
+func (pc *previewCache) GetData(key string) ([]byte, string, bool) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	elem, ok := pc.entries[key]
+	if !ok {
+		return nil, "", false
+	}
+	entry := elem.Value.(*previewEntry)
+	if entry.data == nil {
+		return nil, "", false
+	}
+	if time.Now().After(entry.expires) {
+		pc.removeElement(elem)
+		return nil, "", false
+	}
+	pc.order.MoveToFront(elem)
+	return entry.data, entry.contentType, true
+}
+
+// Add registers a preview file or directory under key and evicts the least recently used
+// previews until the cache is within its limits again
+func (pc *previewCache) Add(key, path string) {
+	size := diskUsage(path)
+
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	if elem, ok := pc.entries[key]; ok {
+		pc.removeElement(elem)
+	}
+	pc.entries[key] = pc.order.PushFront(&previewEntry{key: key, path: path, size: size})
+	pc.totalBytes += size
+	pc.evict()
+}
+
+// AddData keeps a preview in memory under key for PreviewTTL and evicts the least recently
+// used previews until the cache is within its limits again
+func (pc *previewCache) AddData(key string, data []byte, contentType string) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	if elem, ok := pc.entries[key]; ok {
+		pc.removeElement(elem)
+	}
+	size := int64(len(data))
+	pc.entries[key] = pc.order.PushFront(&previewEntry{
+		key:         key,
+		size:        size,
+		data:        data,
+		contentType: contentType,
+		expires:     time.Now().Add(PreviewTTL),
+	})
+	pc.totalBytes += size
+	pc.memoryBytes += size
+	pc.evict()
+}
+
+// AddFileData moves a preview file of at most MaxMemoryPreviewSize bytes into memory with
+// AddData, removing the file and, once empty, its directory. Larger previews are left on
+// disk and inMemory is false.
+func (pc *previewCache) AddFileData(key, path string) (data []byte, contentType string, inMemory bool, err error) {
+	if info, err := os.Stat(path); err != nil || info.Size() > MaxMemoryPreviewSize {
+		return nil, "", false, nil
+	}
+	data, err = os.ReadFile(path)
+	os.Remove(path)
+	os.Remove(filepath.Dir(path)) // only succeeds once the directory is empty
+	if err != nil {
+		return nil, "", false, err
+	}
+	contentType = http.DetectContentType(data)
+	pc.AddData(key, data, contentType)
+	return data, contentType, true, nil
+}
+
+// evict drops the least recently used previews while the cache is over a limit. The newest
+// entry is never evicted. pc.mu must be held.
+func (pc *previewCache) evict() {
+	for pc.order.Len() > 1 && (pc.order.Len() > pc.maxEntries || pc.totalBytes > pc.maxBytes || pc.memoryBytes > pc.maxMemoryBytes) {
+		pc.removeElement(pc.order.Back())
+	}
+}
+
+// Remove deletes the preview cached under key if it still points at path
+func (pc *previewCache) Remove(key, path string) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	if elem, ok := pc.entries[key]; ok && elem.Value.(*previewEntry).path == path {
+		pc.removeElement(elem)
+	}
+}
+
+// removeElement drops an entry and its files; pc.mu must be held
+func (pc *previewCache) removeElement(elem *list.Element) {
+	entry := elem.Value.(*previewEntry)
+	pc.order.Remove(elem)
+	delete(pc.entries, entry.key)
+	pc.totalBytes -= entry.size
+	if entry.data != nil {
+		pc.memoryBytes -= entry.size
+		return
+	}
+	os.RemoveAll(entry.path)
+}
+
+// diskUsage returns the total size of a file or of all files below a directory
+func diskUsage(path string) int64 {
+	var size int64
+	filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+// previewFlights lets concurrent requests for a preview that is not cached yet share one
+// extraction instead of each running pdfcpu into the same file
+type previewFlights struct {
+	mu      sync.Mutex
+	flights map[string]*previewFlight
+}
+
+// previewFlight is an extraction in progress; path and err are set before done is closed
+type previewFlight struct {
+	done chan struct{}
+	path string
+	err  error
+}
+
+// pageExtractions are the in-progress extractions of /api/pdf/page, keyed like previews
+var pageExtractions = &previewFlights{flights: make(map[string]*previewFlight)}
+
+// Do runs extract for key unless an extraction for key is already running, in which case
+// it waits for that one; either way it returns the extraction's path and error
+func (f *previewFlights) Do(key string, extract func() (string, error)) (string, error) {
+	f.mu.Lock()
+	if flight, ok := f.flights[key]; ok {
+		f.mu.Unlock()
+		<-flight.done
+		return flight.path, flight.err
+	}
+	flight := &previewFlight{done: make(chan struct{})}
+	f.flights[key] = flight
+	f.mu.Unlock()
+
+	defer func() {
+		f.mu.Lock()
+		delete(f.flights, key)
+		f.mu.Unlock()
+		close(flight.done)
+	}()
+	flight.path, flight.err = extract()
+	return flight.path, flight.err
+}
@@ -0,0 +1,236 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	pdfPkg "pdf_editor/pdf"
+
+	"github.com/gin-gonic/gin"
+)
+
+// writePreview writes a preview file of size bytes
+func writePreview(t *testing.T, dir, name string, size int) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPreviewCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	dir := t.TempDir()
+	pc := newPreviewCache(3, 1<<20, 1<<20)
+	paths := make(map[string]string)
+	for _, id := range []string{"a", "b", "c"} {
+		paths[id] = writePreview(t, dir, id+".png", 100)
+		pc.Add(previewKey("file", id), paths[id])
+	}
+
+	// Using "a" makes "b" the least recently used when a fourth preview exceeds the cap
+	if _, ok := pc.Get(previewKey("file", "a")); !ok {
+		t.Fatal("cached preview a not found")
+	}
+	paths["d"] = writePreview(t, dir, "d.png", 100)
+	pc.Add(previewKey("file", "d"), paths["d"])
+
+	for id, wantCached := range map[string]bool{"a": true, "b": false, "c": true, "d": true} {
+		_, cached := pc.Get(previewKey("file", id))
+		_, statErr := os.Stat(paths[id])
+		if cached != wantCached || (statErr == nil) != wantCached {
+			t.Errorf("preview %s: cached %v, file exists %v, want %v", id, cached, statErr == nil, wantCached)
+		}
+	}
+	if pc.order.Len() != 3 || pc.totalBytes != 300 {
+		t.Errorf("%d entries of %d bytes, want 3 of 300", pc.order.Len(), pc.totalBytes)
+	}
+}
+
+func TestPreviewCacheByteLimit(t *testing.T) {
+	dir := t.TempDir()
+	pc := newPreviewCache(100, 1000, 1000)
+	first := writePreview(t, dir, "first.png", 400)
+	pc.Add(previewKey("file", "first"), first)
+	second := writePreview(t, dir, "second.png", 400)
+	pc.Add(previewKey("file", "second"), second)
+
+	// Directories count with everything below them
+	pagesDir := filepath.Join(dir, "pages")
+	os.Mkdir(pagesDir, 0755)
+	writePreview(t, pagesDir, "1.png", 150)
+	writePreview(t, pagesDir, "2.png", 150)
+	pc.Add(previewKey("file", "pages"), pagesDir)
+
+	if _, err := os.Stat(first); !os.IsNotExist(err) {
+		t.Errorf("oldest preview not evicted at the byte limit: %v", err)
+	}
+	if pc.totalBytes != 700 {
+		t.Errorf("totalBytes = %d, want 700", pc.totalBytes)
+	}
+
+	// A preview larger than the limit on its own is still kept as the newest entry
+	large := writePreview(t, dir, "large.png", 5000)
+	pc.Add(previewKey("file", "large"), large)
+	if path, ok := pc.Get(previewKey("file", "large")); !ok || path != large {
+		t.Error("newest preview was evicted")
+	}
+	if pc.order.Len() != 1 {
+		t.Errorf("%d entries left, want only the newest", pc.order.Len())
+	}
+}
+
+func TestPreviewCacheRemoveAndVanishedFile(t *testing.T) {
+	dir := t.TempDir()
+	pc := newPreviewCache(10, 1<<20, 1<<20)
+	path := writePreview(t, dir, "a.png", 10)
+	key := previewKey("file", "a")
+	pc.Add(key, path)
+
+	// Removing with a stale path leaves a newer preview alone
+	pc.Remove(key, filepath.Join(dir, "old.png"))
+	if _, ok := pc.Get(key); !ok {
+		t.Fatal("Remove with another path dropped the preview")
+	}
+	// The scheduled cleanup deleted the file: the entry is dropped on the next Get
+	os.Remove(path)
+	if _, ok := pc.Get(key); ok {
+		t.Error("preview whose file is gone was returned")
+	}
+	if pc.totalBytes != 0 {
+		t.Errorf("totalBytes = %d, want 0", pc.totalBytes)
+	}
+}
+
+func TestPreviewServedFromMemory(t *testing.T) {
+	config := &Config{MaxFileSize: 1 << 20, TempDir: t.TempDir(), PreviewMemoryCache: true}
+	r := gin.New()
+	SetupRoutes(r, config)
+
+	sessionID := generateUniqueID()
+	const elementID = "fullpage_watermark_Im0_39KB"
+	sessionPDF := filepath.Join(t.TempDir(), "analysis.pdf")
+	os.WriteFile(sessionPDF, []byte("%PDF-1.7\n%%EOF\n"), 0644)
+	analysis := &pdfPkg.UnwantedElementsAnalysis{ImageCandidates: []pdfPkg.UnwantedElementCandidate{{Type: "image", ID: elementID}}}
+	if err := sessions.Add(sessionID, sessionPDF, analysis); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sessions.Delete(sessionID) })
+
+	// The first request extracted this preview into the session's preview directory
+	previewDir := filepath.Join(config.TempDir, "previews", sessionID)
+	os.MkdirAll(previewDir, 0755)
+	png := append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 100)...)
+	previewPath := filepath.Join(previewDir, elementID+".png")
+	if err := os.WriteFile(previewPath, png, 0644); err != nil {
+		t.Fatal(err)
+	}
+	key := previewKey(sessionID, elementID)
+	t.Cleanup(func() { previews.Remove(key, "") })
+	data, contentType, inMemory, err := previews.AddFileData(key, previewPath)
+	if err != nil || !inMemory || !bytes.Equal(data, png) || contentType != "image/png" {
+		t.Fatalf("AddFileData = %d bytes, %q, %v, %v", len(data), contentType, inMemory, err)
+	}
+	if _, err := os.Stat(previewDir); !os.IsNotExist(err) {
+		t.Errorf("preview directory kept after moving the preview to memory: %v", err)
+	}
+
+	// The second request is served from memory without touching the disk
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/pdf/preview-image?session_id="+sessionID+"&element_id="+elementID, nil))
+	if w.Code != http.StatusOK || !bytes.Equal(w.Body.Bytes(), png) {
+		t.Fatalf("status %d with %d bytes, want 200 with the preview", w.Code, w.Body.Len())
+	}
+	if got := w.Header().Get("Content-Type"); got != "image/png" {
+		t.Errorf("Content-Type = %q, want image/png", got)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(config.TempDir, "previews")); len(entries) != 0 {
+		t.Errorf("files written for a preview in memory: %v", entries)
+	}
+}
+
+func TestLargePreviewStaysOnDisk(t *testing.T) {
+	pc := newPreviewCache(10, 1<<30, 1<<30)
+	path := writePreview(t, t.TempDir(), "large.png", MaxMemoryPreviewSize+1)
+	if _, _, inMemory, err := pc.AddFileData("large", path); inMemory || err != nil {
+		t.Fatalf("AddFileData of a large preview: in memory %v, err %v", inMemory, err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("large preview file removed: %v", err)
+	}
+	if _, _, ok := pc.GetData("large"); ok {
+		t.Error("large preview kept in memory")
+	}
+}
+
+func TestPreviewMemoryLimits(t *testing.T) {
+	pc := newPreviewCache(10, 1<<20, 1000)
+	pc.AddData("a", make([]byte, 600), "image/png")
+	pc.AddData("b", make([]byte, 600), "image/png")
+	if _, _, ok := pc.GetData("a"); ok {
+		t.Error("oldest preview in memory not evicted at the memory limit")
+	}
+	if _, _, ok := pc.GetData("b"); !ok {
+		t.Error("newest preview in memory evicted")
+	}
+	if pc.memoryBytes != 600 || pc.totalBytes != 600 {
+		t.Errorf("memoryBytes %d, totalBytes %d, want 600", pc.memoryBytes, pc.totalBytes)
+	}
+
+	// Previews in memory are not files
+	if _, ok := pc.Get("b"); ok {
+		t.Error("Get returned a path for a preview in memory")
+	}
+
+	// Expired previews are dropped when read
+	pc.entries["b"].Value.(*previewEntry).expires = time.Now().Add(-time.Second)
+	if _, _, ok := pc.GetData("b"); ok {
+		t.Error("expired preview served")
+	}
+	if pc.order.Len() != 0 || pc.memoryBytes != 0 {
+		t.Errorf("%d entries of %d bytes left, want none", pc.order.Len(), pc.memoryBytes)
+	}
+}
+
+func TestPreviewRejectsTraversal(t *testing.T) {
+	config := &Config{MaxFileSize: 1 << 20, TempDir: t.TempDir()}
+	r := gin.New()
+	SetupRoutes(r, config)
+
+	// A file the traversals below would reach if the IDs were used in paths unchecked
+	secret := filepath.Join(config.TempDir, "analysis_secret.pdf")
+	os.WriteFile(secret, []byte("%PDF-1.7\n%%EOF\n"), 0644)
+	validID := generateUniqueID()
+
+	tests := []struct {
+		name  string
+		query url.Values
+	}{
+		{name: "session_id with ..", query: url.Values{"session_id": {"../analysis_secret"}, "element_id": {"img_1"}}},
+		{name: "pdf_file_id with ..", query: url.Values{"pdf_file_id": {"../../etc/passwd"}, "element_id": {"img_1"}}},
+		{name: "pdf_file_id with a separator", query: url.Values{"pdf_file_id": {validID + "/x"}, "element_id": {"img_1"}}},
+		{name: "pdf_file_id not hex", query: url.Values{"pdf_file_id": {"123_secret"}, "element_id": {"img_1"}}},
+		{name: "element_id with ..", query: url.Values{"session_id": {validID}, "element_id": {"../../analysis_secret"}}},
+		{name: "element_id with a backslash", query: url.Values{"session_id": {validID}, "element_id": {`..\secret`}}},
+		{name: "element_id with a separator", query: url.Values{"session_id": {validID}, "element_id": {"img/1"}}},
+		{name: "element_id with a NUL", query: url.Values{"session_id": {validID}, "element_id": {"img\x00.png"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/pdf/preview-image?"+tt.query.Encode(), nil))
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("status %d, want 400: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+	if _, err := os.Stat(secret); err != nil {
+		t.Errorf("file outside the session was touched: %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	pdfPkg "pdf_editor/pdf"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestPreviewDiffRejectsBadRequests(t *testing.T) {
+	config := &Config{MaxFileSize: 1 << 20, TempDir: t.TempDir()}
+	r := gin.New()
+	SetupRoutes(r, config)
+
+	sessionID := generateUniqueID()
+	sessionPDF := filepath.Join(t.TempDir(), "analysis.pdf")
+	os.WriteFile(sessionPDF, []byte("%PDF-1.7\n%%EOF\n"), 0644)
+	analysis := &pdfPkg.UnwantedElementsAnalysis{ImageCandidates: []pdfPkg.UnwantedElementCandidate{
+		{Type: "image", ID: "repeated_Im0", Pages: []int{1, 2}},
+		{Type: "image", ID: "corner_Logo", Page: 3},
+	}}
+	if err := sessions.Add(sessionID, sessionPDF, analysis); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sessions.Delete(sessionID) })
+
+	tests := []struct {
+		name  string
+		query string
+		want  int
+	}{
+		{"invalid element_id", "file_id=" + sessionID + "&element_id=../x&page=1", http.StatusBadRequest},
+		{"missing page", "file_id=" + sessionID + "&element_id=repeated_Im0", http.StatusBadRequest},
+		{"invalid file_id", "file_id=x&element_id=repeated_Im0&page=1", http.StatusBadRequest},
+		{"unknown session", "file_id=" + generateUniqueID() + "&element_id=repeated_Im0&page=1", http.StatusNotFound},
+		{"unknown element", "file_id=" + sessionID + "&element_id=other&page=1", http.StatusNotFound},
+		{"page without the element", "file_id=" + sessionID + "&element_id=repeated_Im0&page=3", http.StatusBadRequest},
+		{"page of a single-page element", "file_id=" + sessionID + "&element_id=corner_Logo&page=1", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/pdf/preview-diff?"+tt.query, nil))
+			if w.Code != tt.want {
+				t.Errorf("status %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+	if entries, _ := os.ReadDir(config.TempDir); len(entries) != 0 {
+		t.Errorf("rejected requests left files: %v", entries)
+	}
+}
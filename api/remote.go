@@ -0,0 +1,164 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"syscall"
+)
+
+// errRemoteHostBlocked is returned when a remote URL resolves to a non-public address
+var errRemoteHostBlocked = errors.New("remote host is not allowed")
+
+// remoteFetchUserAgent is sent unless the configured headers for a host set their own
+const remoteFetchUserAgent = "pdf_editor"
+
+// ParseRemoteFetchHeaders parses the REMOTE_FETCH_HEADERS setting: a JSON object mapping
+// host names to the headers sent when fetching from that host, e.g.
+// {"docs.example.com": {"Authorization": "Bearer ...", "User-Agent": "archiver/1.0"}}
+func ParseRemoteFetchHeaders(value string) (map[string]map[string]string, error) {
+	headers := map[string]map[string]string{}
+	if strings.TrimSpace(value) == "" {
+		return headers, nil
+	}
+	if err := json.Unmarshal([]byte(value), &headers); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %v", err)
+	}
+	normalized := make(map[string]map[string]string, len(headers))
+	for host, hostHeaders := range headers {
+		for name := range hostHeaders {
+			if strings.EqualFold(name, "Host") || strings.TrimSpace(name) == "" {
+				return nil, fmt.Errorf("header %q cannot be configured for %s", name, host)
+			}
+		}
+		normalized[strings.ToLower(host)] = hostHeaders
+	}
+	return normalized, nil
+}
+
+// isPublicIP reports whether ip is a globally routable address. Loopback, private,
+// link-local (including cloud metadata at 169.254.169.254), multicast and unspecified
+// addresses are not.
+func isPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified())
+}
+
+// remoteAddressAllowed decides which resolved addresses remote fetches may connect to;
+// tests replace it to fetch from a local server
+var remoteAddressAllowed = isPublicIP
+
+// remoteFetchClient returns an HTTP client that only connects to public addresses. The
+// check runs on the resolved address of every connection, redirects included, so DNS
+// names pointing at internal hosts are refused as well.
+func remoteFetchClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: RemoteFetchTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !remoteAddressAllowed(ip) {
+				return fmt.Errorf("%w: %s", errRemoteHostBlocked, host)
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: RemoteFetchTimeout,
+		Transport: &http.Transport{
+			Proxy:               nil, // a proxy would bypass the address check
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: RemoteFetchTimeout,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= MaxRemoteFetchRedirects {
+				return fmt.Errorf("stopped after %d redirects", MaxRemoteFetchRedirects)
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("redirect to unsupported scheme %q", req.URL.Scheme)
+			}
+			// Configured credentials belong to the original host only
+			if !strings.EqualFold(req.URL.Hostname(), via[0].URL.Hostname()) {
+				for name := range via[0].Header {
+					if name != "User-Agent" {
+						req.Header.Del(name)
+					}
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// fetchRemotePDF downloads a PDF from rawURL into dest. Only http(s) URLs on public
+// addresses are fetched, and only the headers configured for the URL's host are sent;
+// nothing from the client's own request is forwarded.
+func fetchRemotePDF(ctx context.Context, config *Config, rawURL, dest string) error {
+	target, err := url.Parse(rawURL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return fmt.Errorf("url must be an absolute http or https URL")
+	}
+	if target.User != nil {
+		return fmt.Errorf("url must not contain credentials")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, RemoteFetchTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return fmt.Errorf("invalid url: %v", err)
+	}
+	req.Header.Set("User-Agent", remoteFetchUserAgent)
+	for name, value := range config.RemoteFetchHeaders[strings.ToLower(target.Hostname())] {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := remoteFetchClient().Do(req)
+	if err != nil {
+		if errors.Is(err, errRemoteHostBlocked) {
+			return errRemoteHostBlocked
+		}
+		return fmt.Errorf("failed to fetch url: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("remote server responded with %s", resp.Status)
+	}
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %v", err)
+	}
+	written, err := io.Copy(out, io.LimitReader(resp.Body, config.MaxFileSize+1))
+	out.Close()
+	if err == nil && written > config.MaxFileSize {
+		err = fmt.Errorf("remote file exceeds maximum allowed %d bytes", config.MaxFileSize)
+	}
+	if err == nil {
+		err = checkPDFHeader(dest)
+	}
+	if err != nil {
+		os.Remove(dest)
+		return err
+	}
+	return nil
+}
+
+// checkPDFHeader verifies that a file has the PDF signature, as checkPDFSignature
+func checkPDFHeader(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return checkPDFSignature(file)
+}
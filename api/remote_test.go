@@ -0,0 +1,161 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// allowLocalFetches lets remote fetches of the test connect to loopback servers
+func allowLocalFetches(t *testing.T) {
+	t.Helper()
+	previous := remoteAddressAllowed
+	remoteAddressAllowed = func(ip net.IP) bool { return ip.IsLoopback() }
+	t.Cleanup(func() { remoteAddressAllowed = previous })
+}
+
+func TestIsPublicIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{ip: "93.184.216.34", want: true},
+		{ip: "2606:4700::1111", want: true},
+		{ip: "127.0.0.1"},
+		{ip: "::1"},
+		{ip: "10.1.2.3"},
+		{ip: "172.16.0.1"},
+		{ip: "192.168.1.1"},
+		{ip: "fd00::1"},
+		{ip: "169.254.169.254"},
+		{ip: "fe80::1"},
+		{ip: "224.0.0.1"},
+		{ip: "0.0.0.0"},
+	}
+	for _, tt := range tests {
+		if got := isPublicIP(net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("isPublicIP(%s) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestFetchBlocksPrivateHosts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("request reached a loopback server: %s", r.URL)
+	}))
+	defer server.Close()
+	config := &Config{
+		MaxFileSize: 1 << 20,
+		RemoteFetchHeaders: map[string]map[string]string{
+			"127.0.0.1": {"Authorization": "Bearer token"},
+		},
+	}
+
+	// Configured headers for a host do not make it reachable
+	dest := filepath.Join(t.TempDir(), "remote.pdf")
+	if err := fetchRemotePDF(context.Background(), config, server.URL+"/doc.pdf", dest); !errors.Is(err, errRemoteHostBlocked) {
+		t.Errorf("err = %v, want errRemoteHostBlocked", err)
+	}
+	if _, err := os.Stat(dest); !os.IsNotExist(err) {
+		t.Errorf("a file was written for a blocked host: %v", err)
+	}
+
+	for _, rawURL := range []string{"file:///etc/passwd", "ftp://example.com/a.pdf", "http://user:pw@example.com/a.pdf", "/relative.pdf"} {
+		if err := fetchRemotePDF(context.Background(), config, rawURL, dest); err == nil || errors.Is(err, errRemoteHostBlocked) {
+			t.Errorf("%s: err = %v, want the URL refused", rawURL, err)
+		}
+	}
+}
+
+func TestFetchSendsConfiguredHeaders(t *testing.T) {
+	allowLocalFetches(t)
+	var got http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Write([]byte("%PDF-1.7\n%%EOF\n"))
+	}))
+	defer server.Close()
+
+	config := &Config{
+		MaxFileSize: 1 << 20,
+		RemoteFetchHeaders: map[string]map[string]string{
+			"127.0.0.1": {"Authorization": "Bearer token", "User-Agent": "archiver/1.0"},
+			"localhost": {"X-Other-Host": "1"},
+		},
+	}
+	dest := filepath.Join(t.TempDir(), "remote.pdf")
+	if err := fetchRemotePDF(context.Background(), config, server.URL+"/doc.pdf", dest); err != nil {
+		t.Fatal(err)
+	}
+	if got.Get("Authorization") != "Bearer token" || got.Get("User-Agent") != "archiver/1.0" {
+		t.Errorf("configured headers not sent: %v", got)
+	}
+	if got.Get("X-Other-Host") != "" {
+		t.Error("headers of another host were sent")
+	}
+	if data, _ := os.ReadFile(dest); !strings.HasPrefix(string(data), "%PDF-") {
+		t.Errorf("fetched file %q", data)
+	}
+
+	// Without configured headers only the default user agent is sent
+	config.RemoteFetchHeaders = nil
+	if err := fetchRemotePDF(context.Background(), config, server.URL+"/doc.pdf", dest); err != nil {
+		t.Fatal(err)
+	}
+	if got.Get("User-Agent") != remoteFetchUserAgent || got.Get("Authorization") != "" {
+		t.Errorf("headers %v, want only the default user agent", got)
+	}
+}
+
+func TestFetchRedirectDropsCredentials(t *testing.T) {
+	allowLocalFetches(t)
+	var got http.Header
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Write([]byte("%PDF-1.7\n%%EOF\n"))
+	}))
+	defer target.Close()
+	// Redirect to the same server under another host name
+	otherHost := strings.Replace(target.URL, "127.0.0.1", "localhost", 1)
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, otherHost+"/doc.pdf", http.StatusFound)
+	}))
+	defer origin.Close()
+
+	config := &Config{
+		MaxFileSize: 1 << 20,
+		RemoteFetchHeaders: map[string]map[string]string{
+			"127.0.0.1": {"Authorization": "Bearer token", "User-Agent": "archiver/1.0"},
+		},
+	}
+	if err := fetchRemotePDF(context.Background(), config, origin.URL+"/doc.pdf", filepath.Join(t.TempDir(), "remote.pdf")); err != nil {
+		t.Fatal(err)
+	}
+	if got.Get("Authorization") != "" {
+		t.Error("credentials were forwarded to another host")
+	}
+	if got.Get("User-Agent") != "archiver/1.0" {
+		t.Errorf("User-Agent = %q after the redirect, want the configured one", got.Get("User-Agent"))
+	}
+}
+
+func TestParseRemoteFetchHeaders(t *testing.T) {
+	headers, err := ParseRemoteFetchHeaders(`{"Docs.Example.com": {"Authorization": "Bearer x"}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if headers["docs.example.com"]["Authorization"] != "Bearer x" {
+		t.Errorf("headers = %v, want the host lowercased", headers)
+	}
+	for _, value := range []string{`{"a.com": {"Host": "b.com"}}`, `{"a.com": {" ": "x"}}`, `not json`} {
+		if _, err := ParseRemoteFetchHeaders(value); err == nil {
+			t.Errorf("%s: accepted", value)
+		}
+	}
+}
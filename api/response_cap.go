@@ -0,0 +1,87 @@
+package api
+
+import (
+	"encoding/json"
+	"sort"
+
+	pdfPkg "pdf_editor/pdf"
+
+	"github.com/gin-gonic/gin"
+)
+
+// analysisCandidateKeys are the candidate arrays of an analysis response, in the order
+// they are trimmed when confidences tie
+var analysisCandidateKeys = []string{"blank_page_candidates", "text_candidates", "image_candidates"}
+
+// capAnalysisResponse keeps the serialized analysis response within maxBytes. Debug logs
+// are dropped first; if that is not enough, the lowest-confidence candidates are removed
+// and "truncated" is set. Candidate sizes are measured once, so pathological documents
+// with many candidates are not re-marshaled per removed candidate.
+func capAnalysisResponse(response gin.H, maxBytes int64) {
+	size := marshaledSize(response)
+	if size <= maxBytes {
+		return
+	}
+
+	if _, ok := response["debug_logs"]; ok {
+		delete(response, "debug_logs")
+		response["debug_logs_dropped"] = true
+		if size = marshaledSize(response); size <= maxBytes {
+			return
+		}
+	}
+
+	type sizedCandidate struct {
+		key        string
+		index      int
+		confidence float64
+		size       int64
+	}
+	all := []sizedCandidate{}
+	for _, key := range analysisCandidateKeys {
+		candidates, _ := response[key].([]pdfPkg.UnwantedElementCandidate)
+		for i, candidate := range candidates {
+			// Each array element also costs a separating comma
+			all = append(all, sizedCandidate{key: key, index: i, confidence: candidate.Confidence, size: marshaledSize(candidate) + 1})
+		}
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].confidence < all[j].confidence })
+
+	// Account for the fields added below before choosing what to drop
+	size += int64(len(`,"truncated":true,"truncated_candidates":`)) + 10
+	dropped := make(map[string]map[int]bool)
+	count := 0
+	for _, candidate := range all {
+		if size <= maxBytes {
+			break
+		}
+		if dropped[candidate.key] == nil {
+			dropped[candidate.key] = make(map[int]bool)
+		}
+		dropped[candidate.key][candidate.index] = true
+		size -= candidate.size
+		count++
+	}
+
+	for key, indexes := range dropped {
+		candidates := response[key].([]pdfPkg.UnwantedElementCandidate)
+		kept := []pdfPkg.UnwantedElementCandidate{}
+		for i, candidate := range candidates {
+			if !indexes[i] {
+				kept = append(kept, candidate)
+			}
+		}
+		response[key] = kept
+	}
+	response["truncated"] = true
+	response["truncated_candidates"] = count
+}
+
+// marshaledSize returns the length of v encoded as JSON
+func marshaledSize(v any) int64 {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return 0
+	}
+	return int64(len(data))
+}
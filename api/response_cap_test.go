@@ -0,0 +1,83 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	pdfPkg "pdf_editor/pdf"
+
+	"github.com/gin-gonic/gin"
+)
+
+// cappedResponse returns an analysis response with 20 image candidates of confidence
+// 0.50 to 0.97 and debug logs of logBytes bytes
+func cappedResponse(logBytes int) gin.H {
+	images := []pdfPkg.UnwantedElementCandidate{}
+	for i := 0; i < 20; i++ {
+		images = append(images, pdfPkg.UnwantedElementCandidate{
+			Type:        "image",
+			ID:          fmt.Sprintf("candidate_%02d", i),
+			Description: strings.Repeat("x", 100),
+			Confidence:  0.50 + float64(i)*0.025,
+		})
+	}
+	return gin.H{
+		"status":                "candidates_found",
+		"image_candidates":      images,
+		"text_candidates":       []pdfPkg.UnwantedElementCandidate{{Type: "text", ID: "text_low", Confidence: 0.4}},
+		"blank_page_candidates": []pdfPkg.UnwantedElementCandidate{},
+		"debug_logs":            []string{strings.Repeat("d", logBytes)},
+	}
+}
+
+func TestCapAnalysisResponse(t *testing.T) {
+	full := marshaledSize(cappedResponse(0))
+
+	t.Run("within the cap", func(t *testing.T) {
+		response := cappedResponse(1000)
+		capAnalysisResponse(response, full+2000)
+		if _, ok := response["debug_logs"]; !ok || response["truncated"] != nil {
+			t.Errorf("response changed although within the cap: %v", response["truncated"])
+		}
+	})
+
+	t.Run("debug logs dropped first", func(t *testing.T) {
+		response := cappedResponse(50000)
+		capAnalysisResponse(response, full+100)
+		if _, ok := response["debug_logs"]; ok || response["debug_logs_dropped"] != true {
+			t.Error("debug logs kept over the cap")
+		}
+		if response["truncated"] != nil || len(response["image_candidates"].([]pdfPkg.UnwantedElementCandidate)) != 20 {
+			t.Error("candidates truncated although dropping the debug logs was enough")
+		}
+	})
+
+	t.Run("lowest confidence truncated", func(t *testing.T) {
+		const maxBytes = 2000
+		response := cappedResponse(50000)
+		capAnalysisResponse(response, maxBytes)
+		if size := marshaledSize(response); size > maxBytes {
+			t.Errorf("response is %d bytes, over the cap of %d", size, maxBytes)
+		}
+		if response["truncated"] != true {
+			t.Error("truncated not set")
+		}
+
+		kept := response["image_candidates"].([]pdfPkg.UnwantedElementCandidate)
+		dropped := 21 - len(kept) - len(response["text_candidates"].([]pdfPkg.UnwantedElementCandidate))
+		if len(kept) == 0 || len(kept) == 20 || response["truncated_candidates"] != dropped {
+			t.Fatalf("%d image candidates kept, truncated_candidates %v, want %d", len(kept), response["truncated_candidates"], dropped)
+		}
+		// The text candidate has the lowest confidence of all and goes first
+		if texts := response["text_candidates"].([]pdfPkg.UnwantedElementCandidate); len(texts) != 0 {
+			t.Errorf("lowest-confidence text candidate kept: %+v", texts)
+		}
+		// The kept image candidates are the most confident, in their original order
+		for i, candidate := range kept {
+			if want := fmt.Sprintf("candidate_%02d", 20-len(kept)+i); candidate.ID != want {
+				t.Errorf("kept candidate %d is %s, want %s", i, candidate.ID, want)
+			}
+		}
+	})
+}
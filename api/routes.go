@@ -1,26 +1,105 @@
 package api
 
 import (
+	"net/http"
+
 	"github.com/gin-gonic/gin"
 )
 
 // Config holds application configuration
 type Config struct {
-	Port        string
-	MaxFileSize int64
-	TempDir     string
+	Port            string
+	MaxFileSize     int64
+	TempDir         string
+	Debug           bool   // Enables troubleshooting endpoints such as /api/pdf/config
+	OCREnabled      bool   // Set when the OCR engine was found at startup
+	PdfcpuConfigDir string // Writable pdfcpu config/cache directory ("" uses pdfcpu's default)
+
+	// ProtectedObjects are image object numbers that removal never touches
+	ProtectedObjects []string
+
+	// AuditDir receives the original bytes of images removed with audit=true, one
+	// subdirectory per request (empty disables auditing). It lives outside TempDir, so
+	// temp file cleanup never touches it.
+	AuditDir string
+
+	// RemoteFetch lets endpoints that take a "pdf" upload fetch it from a "url" field instead
+	RemoteFetch bool
+
+	// RemoteFetchHeaders are the headers sent when fetching from each host (lowercase
+	// host -> header -> value), e.g. credentials for an authenticated document store
+	RemoteFetchHeaders map[string]map[string]string
+
+	// UploadDedup makes /upload return the file_id of an identical upload stored within
+	// UploadDedupWindow instead of storing another copy
+	UploadDedup bool
+
+	// PreviewMemoryCache keeps extracted previews up to MaxMemoryPreviewSize in memory
+	// instead of on disk
+	PreviewMemoryCache bool
+
+	// MinConfidenceFloor is the lowest min_confidence auto-clean accepts; lower requested
+	// values are raised to it (0 disables the floor)
+	MinConfidenceFloor float64
+
+	// MaxAnalysisResponseSize caps the JSON analysis response in bytes; larger responses
+	// lose their debug logs, then their lowest-confidence candidates
+	MaxAnalysisResponseSize int64
+
+	// MaxImageOccurrences caps the image occurrences removal by ID matches against
+	// (0 uses pdf.DefaultMaxImageOccurrences)
+	MaxImageOccurrences int
 }
 
 func SetupRoutes(r *gin.Engine, config *Config) {
 	apiGroup := r.Group("/api/pdf")
+	apiGroup.Use(cleanupOnPanic(), recordTimings())
 	{
 		apiGroup.POST("/upload", func(c *gin.Context) { HandleUpload(c, config) })
+		apiGroup.GET("/files/:id", func(c *gin.Context) { HandleStoredFile(c, config) })
+		apiGroup.HEAD("/files/:id", func(c *gin.Context) { HandleStoredFile(c, config) })
+		apiGroup.GET("/page", func(c *gin.Context) { HandlePage(c, config) })
+		apiGroup.GET("/word-boxes", func(c *gin.Context) { HandleWordBoxes(c, config) })
 		apiGroup.POST("/resave", func(c *gin.Context) { HandleResave(c, config) })
+		apiGroup.POST("/repair", func(c *gin.Context) { HandleRepair(c, config) })
+		apiGroup.POST("/banner", func(c *gin.Context) { HandleBanner(c, config) })
+		apiGroup.POST("/watermark", func(c *gin.Context) { HandleWatermark(c, config) })
+		apiGroup.POST("/watermark/image", func(c *gin.Context) { HandleImageWatermark(c, config) })
+		apiGroup.POST("/encrypt", func(c *gin.Context) { HandleEncrypt(c, config) })
+		apiGroup.POST("/decrypt", func(c *gin.Context) { HandleDecrypt(c, config) })
+		apiGroup.POST("/rotate", func(c *gin.Context) { HandleRotate(c, config) })
+		apiGroup.POST("/orientation", func(c *gin.Context) { HandleOrientation(c, config) })
+		apiGroup.POST("/auto-orient", func(c *gin.Context) { HandleAutoOrient(c, config) })
+		apiGroup.POST("/ocr", func(c *gin.Context) { HandleOCR(c, config) })
+		apiGroup.POST("/merge", func(c *gin.Context) { HandleMerge(c, config) })
+		apiGroup.POST("/split", func(c *gin.Context) { HandleSplit(c, config) })
+		apiGroup.POST("/split-by-bookmarks", func(c *gin.Context) { HandleSplitByBookmarks(c, config) })
+		apiGroup.POST("/nup", func(c *gin.Context) { HandleNUp(c, config) })
+		apiGroup.POST("/booklet", func(c *gin.Context) { HandleBooklet(c, config) })
 		apiGroup.POST("/remove-pages", func(c *gin.Context) { HandleRemovePages(c, config) })
 		apiGroup.POST("/remove-elements", func(c *gin.Context) { HandleRemoveElements(c, config) })
 		apiGroup.POST("/analyze-unwanted-elements", func(c *gin.Context) { HandleAnalyzeUnwantedElements(c, config) })
 		apiGroup.GET("/preview-image", func(c *gin.Context) { HandlePreviewImage(c, config) })
+		apiGroup.GET("/preview-diff", func(c *gin.Context) { HandlePreviewDiff(c, config) })
+		apiGroup.DELETE("/sessions/:id", HandleDeleteSession)
+		apiGroup.POST("/jobs", func(c *gin.Context) { HandleStartJob(c, config) })
+		apiGroup.GET("/jobs/:id", HandleJobStatus)
+		apiGroup.DELETE("/jobs/:id", HandleCancelJob)
+		apiGroup.POST("/distinct-images", func(c *gin.Context) { HandleDistinctImages(c, config) })
+		apiGroup.GET("/distinct-image-preview", func(c *gin.Context) { HandleDistinctImagePreview(c, config) })
+		apiGroup.POST("/estimate", func(c *gin.Context) { HandleEstimate(c, config) })
+		apiGroup.POST("/validate", func(c *gin.Context) { HandleValidate(c, config) })
+		apiGroup.POST("/fingerprint", func(c *gin.Context) { HandleFingerprint(c, config) })
+		apiGroup.POST("/info", func(c *gin.Context) { HandleInfo(c, config) })
+		apiGroup.POST("/fonts", func(c *gin.Context) { HandleFonts(c, config) })
+		apiGroup.POST("/color-profiles", func(c *gin.Context) { HandleColorProfiles(c, config) })
+		apiGroup.GET("/image-object", func(c *gin.Context) { HandleImageObject(c, config) })
+		apiGroup.POST("/auto-clean", func(c *gin.Context) { HandleAutoClean(c, config) })
+		apiGroup.POST("/clean", func(c *gin.Context) { HandleClean(c, config) })
 		apiGroup.POST("/remove-selected-elements", func(c *gin.Context) { HandleRemoveSelectedElements(c, config) })
+		apiGroup.GET("/config", debugOnly(config), func(c *gin.Context) { HandleConfig(c, config) })
+		apiGroup.GET("/debug-logs", debugOnly(config), HandleDebugLogs)
+		apiGroup.POST("/debug/command", debugOnly(config), HandlePdfcpuCommand)
 	}
 
 	// Unwanted elements management page
@@ -30,3 +109,14 @@ func SetupRoutes(r *gin.Engine, config *Config) {
 		})
 	})
 }
+
+// debugOnly hides troubleshooting endpoints unless debug mode is enabled
+func debugOnly(config *Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !config.Debug {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Not found"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,211 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	pdfPkg "pdf_editor/pdf"
+
+	"github.com/gin-gonic/gin"
+)
+
+// analysisSession pins the input of one analysis, so previews and removal can use it
+// without a re-upload and without racing the cleanup of the analysis request
+type analysisSession struct {
+	path     string // analysis_<session_id>.pdf in the temp directory
+	analysis *pdfPkg.UnwantedElementsAnalysis
+	timer    *time.Timer
+	size     int64
+	created  time.Time
+}
+
+// errSessionStoreFull is returned by sessionStore.Add when no session can be evicted to
+// make room for a new one
+var errSessionStoreFull = errors.New("too many analysis sessions, try again later")
+
+// sessionStore holds the analysis sessions, keyed by session ID (the analysis pdf_file_id).
+// A session ends, and its file is removed, after AnalysisSessionTTL or when it is deleted.
+// Once maxSessions or maxBytes would be exceeded, the oldest sessions are ended early,
+// except those younger than MinAnalysisSessionAge.
+type sessionStore struct {
+	mu          sync.Mutex
+	maxSessions int
+	maxBytes    int64
+	totalBytes  int64
+	sessions    map[string]*analysisSession
+}
+
+var sessions = newSessionStore(MaxAnalysisSessions, MaxAnalysisSessionBytes)
+
+func newSessionStore(maxSessions int, maxBytes int64) *sessionStore {
+	return &sessionStore{
+		maxSessions: maxSessions,
+		maxBytes:    maxBytes,
+		sessions:    make(map[string]*analysisSession),
+	}
+}
+
+// Add starts a session for the analyzed file at path, evicting the oldest sessions if the
+// store is full. It returns errSessionStoreFull, and starts no session, if that is not enough.
+func (s *sessionStore) Add(sessionID, path string, analysis *pdfPkg.UnwantedElementsAnalysis) error {
+	session := &analysisSession{path: path, analysis: analysis, size: diskUsage(path), created: time.Now()}
+
+	s.mu.Lock()
+	// Pick the sessions to end before changing anything, so a full store is left as it was
+	oldestFirst := make([]string, 0, len(s.sessions))
+	for id := range s.sessions {
+		oldestFirst = append(oldestFirst, id)
+	}
+	sort.Slice(oldestFirst, func(i, j int) bool {
+		return s.sessions[oldestFirst[i]].created.Before(s.sessions[oldestFirst[j]].created)
+	})
+	count, bytes := len(s.sessions), s.totalBytes
+	var evicted []string
+	for count >= s.maxSessions || bytes+session.size > s.maxBytes {
+		if len(evicted) == len(oldestFirst) {
+			s.mu.Unlock()
+			return errSessionStoreFull
+		}
+		oldest := s.sessions[oldestFirst[len(evicted)]]
+		if time.Since(oldest.created) < MinAnalysisSessionAge {
+			s.mu.Unlock()
+			return errSessionStoreFull
+		}
+		evicted = append(evicted, oldestFirst[len(evicted)])
+		count--
+		bytes -= oldest.size
+	}
+
+	removed := make([]*analysisSession, len(evicted))
+	for i, id := range evicted {
+		removed[i] = s.sessions[id]
+		delete(s.sessions, id)
+	}
+	session.timer = time.AfterFunc(AnalysisSessionTTL, func() { s.Delete(sessionID) })
+	s.sessions[sessionID] = session
+	s.totalBytes = bytes + session.size
+	s.mu.Unlock()
+
+	for i, oldest := range removed {
+		log.Printf("Analysis session %s evicted to make room for %s", evicted[i], sessionID)
+		oldest.timer.Stop()
+		os.Remove(oldest.path)
+	}
+	return nil
+}
+
+// Get returns the session with sessionID, if it has not ended
+func (s *sessionStore) Get(sessionID string) (*analysisSession, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[sessionID]
+	return session, ok
+}
+
+// Delete ends a session and removes its file. It reports whether the session existed.
+func (s *sessionStore) Delete(sessionID string) bool {
+	s.mu.Lock()
+	session, ok := s.sessions[sessionID]
+	if ok {
+		delete(s.sessions, sessionID)
+		s.totalBytes -= session.size
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		return false
+	}
+	session.timer.Stop()
+	os.Remove(session.path)
+	return true
+}
+
+// findSession looks up the session named by the "session_id" query parameter or, for
+// clients written before sessions, "pdf_file_id" or "file_id"; it answers the request
+// itself on failure
+func findSession(c *gin.Context) (string, *analysisSession, bool) {
+	sessionID := c.Query("session_id")
+	if sessionID == "" {
+		sessionID = c.Query("pdf_file_id")
+	}
+	if sessionID == "" {
+		sessionID = c.Query("file_id")
+	}
+	if !fileIDPattern.MatchString(sessionID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session_id"})
+		return "", nil, false
+	}
+	session, ok := sessions.Get(sessionID)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Analysis session not found or expired"})
+		return "", nil, false
+	}
+	return sessionID, session, true
+}
+
+// saveSessionPDF is saveUploadedPDF for the input of an analysis session given by the
+// "session_id" form field. The session file is copied, so the operation cannot alter or
+// remove it and the session stays usable for further requests.
+func saveSessionPDF(c *gin.Context, config *Config, prefix, sessionID string) (path string, uniqueID string, ok bool) {
+	if !fileIDPattern.MatchString(sessionID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session_id"})
+		return "", "", false
+	}
+	session, found := sessions.Get(sessionID)
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Analysis session not found or expired"})
+		return "", "", false
+	}
+
+	uniqueID = generateUniqueID()
+	path = filepath.Join(config.TempDir, prefix+uniqueID+".pdf")
+	trackTempFile(c, path)
+	if err := copyFile(session.path, path); err != nil {
+		os.Remove(path)
+		log.Printf("Session copy error: %v", err)
+		c.JSON(http.StatusNotFound, gin.H{"error": "Analysis session not found or expired"})
+		return "", "", false
+	}
+	return path, uniqueID, true
+}
+
+// copyFile copies src to dst, replacing dst
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to copy %s: %w", src, err)
+	}
+	return out.Close()
+}
+
+// HandleDeleteSession ends an analysis session before AnalysisSessionTTL, removing its file
+func HandleDeleteSession(c *gin.Context) {
+	sessionID := c.Param("id")
+	if !fileIDPattern.MatchString(sessionID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session_id"})
+		return
+	}
+	if !sessions.Delete(sessionID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Analysis session not found or expired"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
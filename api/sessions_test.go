@@ -0,0 +1,173 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	pdfPkg "pdf_editor/pdf"
+
+	"github.com/gin-gonic/gin"
+)
+
+// addAged adds a session for a new file of size bytes and backdates it by age
+func addAged(t *testing.T, s *sessionStore, id string, size int, age time.Duration) (string, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "analysis_"+id+".pdf")
+	if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Add(id, path, nil); err != nil {
+		return path, err
+	}
+	s.mu.Lock()
+	s.sessions[id].created = time.Now().Add(-age)
+	s.mu.Unlock()
+	t.Cleanup(func() { s.Delete(id) })
+	return path, nil
+}
+
+func TestSessionStoreEvictsOldestFirst(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxSessions int
+		maxBytes    int64
+		sizes       []int // of the existing sessions, oldest first
+		newSize     int
+		evicted     int // number of oldest sessions ended
+	}{
+		{name: "within limits", maxSessions: 3, maxBytes: 1000, sizes: []int{100, 100}, newSize: 100},
+		{name: "session count", maxSessions: 2, maxBytes: 1000, sizes: []int{100, 100}, newSize: 100, evicted: 1},
+		{name: "total bytes", maxSessions: 10, maxBytes: 1000, sizes: []int{400, 400, 100}, newSize: 500, evicted: 1},
+		{name: "several for a large file", maxSessions: 10, maxBytes: 1000, sizes: []int{300, 300, 300}, newSize: 900, evicted: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newSessionStore(tt.maxSessions, tt.maxBytes)
+			var paths []string
+			for i, size := range tt.sizes {
+				path, err := addAged(t, s, string(rune('a'+i)), size, time.Duration(len(tt.sizes)-i)*time.Hour)
+				if err != nil {
+					t.Fatalf("Add %d: %v", i, err)
+				}
+				paths = append(paths, path)
+			}
+
+			if _, err := addAged(t, s, "new", tt.newSize, 0); err != nil {
+				t.Fatalf("Add new: %v", err)
+			}
+			for i, path := range paths {
+				_, inStore := s.Get(string(rune('a' + i)))
+				_, statErr := os.Stat(path)
+				if wantEvicted := i < tt.evicted; wantEvicted == inStore || wantEvicted == (statErr == nil) {
+					t.Errorf("session %d: in store %v, file exists %v, want evicted %v", i, inStore, statErr == nil, wantEvicted)
+				}
+			}
+
+			total := int64(tt.newSize)
+			for _, size := range tt.sizes[tt.evicted:] {
+				total += int64(size)
+			}
+			if s.totalBytes != total {
+				t.Errorf("totalBytes = %d, want %d", s.totalBytes, total)
+			}
+		})
+	}
+}
+
+func TestSessionStoreFull(t *testing.T) {
+	s := newSessionStore(2, 1000)
+	oldPath, _ := addAged(t, s, "old", 100, time.Hour)
+	if _, err := addAged(t, s, "recent", 100, time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only "old" may be evicted, which does not free enough bytes
+	path, err := addAged(t, s, "large", 950, 0)
+	if !errors.Is(err, errSessionStoreFull) {
+		t.Fatalf("err = %v, want errSessionStoreFull", err)
+	}
+	if _, ok := s.Get("large"); ok {
+		t.Error("refused session was added")
+	}
+	if _, ok := s.Get("old"); !ok {
+		t.Error("a session was evicted although the new one was refused")
+	}
+	if _, err := os.Stat(oldPath); err != nil {
+		t.Errorf("file of the kept session was removed: %v", err)
+	}
+	if s.totalBytes != 200 {
+		t.Errorf("totalBytes = %d, want 200", s.totalBytes)
+	}
+	os.Remove(path)
+
+	// Evicting "old" makes room for a small session, then only the recent ones are left
+	if _, err := addAged(t, s, "small", 100, 0); err != nil {
+		t.Fatalf("Add small: %v", err)
+	}
+	if _, err := addAged(t, s, "refused", 100, 0); !errors.Is(err, errSessionStoreFull) {
+		t.Fatalf("err = %v, want errSessionStoreFull", err)
+	}
+}
+
+func TestSessionStoreDelete(t *testing.T) {
+	s := newSessionStore(10, 1000)
+	path, err := addAged(t, s, "a", 300, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.Delete("a") {
+		t.Fatal("Delete reported a missing session")
+	}
+	if s.Delete("a") {
+		t.Error("second Delete reported an existing session")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("session file still exists: %v", err)
+	}
+	if s.totalBytes != 0 {
+		t.Errorf("totalBytes = %d after Delete, want 0", s.totalBytes)
+	}
+}
+
+func TestRemovalUsesSessionAnalysis(t *testing.T) {
+	sessionID := generateUniqueID()
+	sessionPDF := filepath.Join(t.TempDir(), "analysis.pdf")
+	os.WriteFile(sessionPDF, []byte("%PDF-1.7\n%%EOF\n"), 0644)
+	analysis := &pdfPkg.UnwantedElementsAnalysis{ImageCandidates: []pdfPkg.UnwantedElementCandidate{{Type: "image", ID: "identical_image_843f6c0b7eb5"}}}
+	if err := sessions.Add(sessionID, sessionPDF, analysis); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sessions.Delete(sessionID) })
+	config := &Config{TempDir: t.TempDir()}
+
+	// IDs selected from the session are matched against its analysis
+	opts, err := parseRemovalOptions(formContext("session_id="+sessionID+"&elements=identical_image_843f6c0b7eb5"), config)
+	if err != nil || opts.Analysis != analysis {
+		t.Errorf("with session_id: analysis %p, err %v, want the session's %p", opts.Analysis, err, analysis)
+	}
+	opts, _ = parseRemovalOptions(formContext("session_id="+generateUniqueID()), config)
+	if opts.Analysis != nil {
+		t.Error("unknown session gave an analysis")
+	}
+
+	// An uploaded PDF is analyzed again, even with a session_id
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, _ := mw.CreateFormFile("pdf", "other.pdf")
+	part.Write([]byte("%PDF-1.7\n%%EOF\n"))
+	mw.WriteField("session_id", sessionID)
+	mw.Close()
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/", &body)
+	c.Request.Header.Set("Content-Type", mw.FormDataContentType())
+	if opts, _ := parseRemovalOptions(c, config); opts.Analysis != nil {
+		t.Error("uploaded PDF matched against the session's analysis")
+	}
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	pdfPkg "pdf_editor/pdf"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HandleSplit splits the uploaded PDF into the page ranges of the "ranges" field and
+// returns the parts as a ZIP archive of part_1.pdf, part_2.pdf, ...
+func HandleSplit(c *gin.Context, config *Config) {
+	ranges, err := pdfPkg.ParsePageRanges(c.PostForm("ranges"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	method, err := parseZIPCompression(c.PostForm("zip_compression"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	inFile, uniqueID, ok := saveUploadedPDF(c, config, "split_")
+	if !ok {
+		return
+	}
+	defer os.Remove(inFile)
+
+	outDir := filepath.Join(config.TempDir, "split_"+uniqueID)
+	trackTempFile(c, outDir)
+	defer os.RemoveAll(outDir)
+
+	files, err := pdfPkg.SplitPDF(inFile, outDir, ranges)
+	if err != nil {
+		log.Printf("Split error: %v", err)
+		c.JSON(errorStatus(err), errorResponse(err, err.Error()))
+		return
+	}
+
+	entries := make([]zipEntry, len(files))
+	for i, file := range files {
+		entries[i] = zipEntry{Name: filepath.Base(file), Path: file}
+	}
+
+	filename := "document_parts.zip"
+	if _, header, err := c.Request.FormFile("pdf"); err == nil {
+		filename = strings.TrimSuffix(header.Filename, filepath.Ext(header.Filename)) + "_parts.zip"
+	}
+	streamZIP(c, filename, entries, method)
+}
+
+// HandleSplitByBookmarks splits the uploaded PDF at its top-level bookmarks and returns
+// the parts as a ZIP archive, one PDF per chapter
+func HandleSplitByBookmarks(c *gin.Context, config *Config) {
+	method, err := parseZIPCompression(c.PostForm("zip_compression"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	inFile, uniqueID, ok := saveUploadedPDF(c, config, "split_")
+	if !ok {
+		return
+	}
+	defer os.Remove(inFile)
+
+	outDir := filepath.Join(config.TempDir, "split_"+uniqueID)
+	trackTempFile(c, outDir)
+	defer os.RemoveAll(outDir)
+
+	files, err := pdfPkg.SplitByBookmarks(inFile, outDir)
+	if err != nil {
+		if errors.Is(err, pdfPkg.ErrNoOutline) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "PDF has no bookmarks to split at"})
+			return
+		}
+		log.Printf("Split by bookmarks error: %v", err)
+		c.JSON(errorStatus(err), errorResponse(err, "Failed to split PDF by bookmarks"))
+		return
+	}
+
+	entries := make([]zipEntry, len(files))
+	for i, file := range files {
+		entries[i] = zipEntry{Name: filepath.Base(file), Path: file}
+	}
+
+	filename := "document_chapters.zip"
+	if _, header, err := c.Request.FormFile("pdf"); err == nil {
+		filename = strings.TrimSuffix(header.Filename, filepath.Ext(header.Filename)) + "_chapters.zip"
+	}
+	streamZIP(c, filename, entries, method)
+}
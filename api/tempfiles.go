@@ -0,0 +1,68 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// tempFilesKey is the gin context key of the request's tempFileTracker
+const tempFilesKey = "tempFiles"
+
+// tempFileTracker records the temp files created while handling one request, so they can
+// be removed immediately if the handler panics. Normal cleanup (deferred removal or the
+// delayed cleanup goroutines) is unaffected.
+type tempFileTracker struct {
+	mu    sync.Mutex
+	paths []string
+}
+
+// trackTempFile registers path, a file or a directory, for removal should the current
+// request panic. It is a no-op outside the cleanupOnPanic middleware.
+func trackTempFile(c *gin.Context, paths ...string) {
+	value, ok := c.Get(tempFilesKey)
+	if !ok {
+		return
+	}
+	tracker := value.(*tempFileTracker)
+	tracker.mu.Lock()
+	tracker.paths = append(tracker.paths, paths...)
+	tracker.mu.Unlock()
+}
+
+// removeAll deletes every tracked file and directory; files already cleaned up are ignored
+func (t *tempFileTracker) removeAll() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, path := range t.paths {
+		os.RemoveAll(path)
+	}
+	t.paths = nil
+}
+
+// cleanupOnPanic recovers from handler panics, removes the temp files the request
+// registered with trackTempFile and answers with a plain 500, instead of leaving the
+// files for the delayed cleanup goroutines that never got started.
+func cleanupOnPanic() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tracker := &tempFileTracker{}
+		c.Set(tempFilesKey, tracker)
+
+		defer func() {
+			if r := recover(); r != nil {
+				tracker.removeAll()
+				log.Printf("Panic handling %s %s: %v\n%s", c.Request.Method, c.Request.URL.Path, r, debug.Stack())
+				if !c.Writer.Written() {
+					c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+				} else {
+					c.Abort()
+				}
+			}
+		}()
+		c.Next()
+	}
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCleanupOnPanic(t *testing.T) {
+	dir := t.TempDir()
+	upload := filepath.Join(dir, "upload_1.pdf")
+	outDir := filepath.Join(dir, "split_1")
+	untracked := filepath.Join(dir, "other.pdf")
+
+	r := gin.New()
+	r.Use(cleanupOnPanic())
+	r.POST("/panic", func(c *gin.Context) {
+		os.WriteFile(upload, []byte("%PDF-1.7"), 0644)
+		trackTempFile(c, upload)
+		os.MkdirAll(outDir, 0755)
+		os.WriteFile(filepath.Join(outDir, "part_1.pdf"), []byte("%PDF-1.7"), 0644)
+		trackTempFile(c, outDir)
+		os.WriteFile(untracked, []byte("%PDF-1.7"), 0644)
+
+		var counts map[string]int
+		counts["pages"]++ // nil map write, as a bug mid-analysis would
+	})
+	r.POST("/written", func(c *gin.Context) {
+		os.WriteFile(upload, []byte("%PDF-1.7"), 0644)
+		trackTempFile(c, upload)
+		c.String(http.StatusOK, "partial")
+		panic("failed while streaming")
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/panic", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("status %d, want 500", w.Code)
+	}
+	if body := w.Body.String(); !strings.Contains(body, `"error":"Internal server error"`) {
+		t.Errorf("body %q, want a clean JSON error", body)
+	}
+	for _, path := range []string{upload, outDir} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s left behind after the panic: %v", path, err)
+		}
+	}
+	if _, err := os.Stat(untracked); err != nil {
+		t.Errorf("untracked file removed: %v", err)
+	}
+
+	// Once the response has started, the status cannot change, but files are still removed
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/written", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "partial" {
+		t.Errorf("response = %d %q, want the partial response untouched", w.Code, w.Body.String())
+	}
+	if _, err := os.Stat(upload); !os.IsNotExist(err) {
+		t.Errorf("%s left behind after a mid-stream panic: %v", upload, err)
+	}
+}
+
+func TestTrackTempFileWithoutMiddleware(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	path := filepath.Join(t.TempDir(), "kept.pdf")
+	os.WriteFile(path, nil, 0644)
+
+	trackTempFile(c, path)
+	if _, ok := c.Get(tempFilesKey); ok {
+		t.Error("tracker created outside cleanupOnPanic")
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("file removed: %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"sync"
+	"time"
+)
+
+// uploadIndex remembers the content hash of recent uploads, so that with UPLOAD_DEDUP an
+// identical upload within UploadDedupWindow gets the stored file_id back instead of a copy
+type uploadIndex struct {
+	mu      sync.Mutex
+	uploads map[string]indexedUpload // SHA-256 hex -> upload
+}
+
+// indexedUpload is a stored upload known by its content hash
+type indexedUpload struct {
+	fileID  string
+	expires time.Time
+}
+
+var recentUploads = &uploadIndex{uploads: make(map[string]indexedUpload)}
+
+// Find returns the file ID and stored path of an upload with the given hash, if one was
+// added within UploadDedupWindow and is still stored
+func (ui *uploadIndex) Find(config *Config, hash string) (string, string, bool) {
+	ui.mu.Lock()
+	upload, ok := ui.uploads[hash]
+	ui.mu.Unlock()
+
+	if !ok || time.Now().After(upload.expires) {
+		return "", "", false
+	}
+	path, err := findUploadedFile(config, upload.fileID)
+	if err != nil {
+		return "", "", false
+	}
+	return upload.fileID, path, true
+}
+
+// Add records a stored upload under its hash and forgets uploads whose window has passed
+func (ui *uploadIndex) Add(hash, fileID string) {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+
+	now := time.Now()
+	for key, upload := range ui.uploads {
+		if now.After(upload.expires) {
+			delete(ui.uploads, key)
+		}
+	}
+	ui.uploads[hash] = indexedUpload{fileID: fileID, expires: now.Add(UploadDedupWindow)}
+}
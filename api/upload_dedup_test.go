@@ -0,0 +1,126 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// uploadPDF posts data to /api/pdf/upload and returns the decoded response
+func uploadPDF(t *testing.T, r *gin.Engine, data []byte) (fileID string, deduplicated bool) {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, _ := mw.CreateFormFile("pdf", "report.pdf")
+	part.Write(data)
+	mw.Close()
+	req := httptest.NewRequest(http.MethodPost, "/api/pdf/upload", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("upload: status %d: %s", w.Code, w.Body)
+	}
+	var response struct {
+		FileID       string `json:"file_id"`
+		Deduplicated bool   `json:"deduplicated"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	return response.FileID, response.Deduplicated
+}
+
+// storedUploads returns the number of files in dir
+func storedUploads(t *testing.T, dir string) int {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(entries)
+}
+
+func TestUploadDedup(t *testing.T) {
+	config := &Config{MaxFileSize: 1 << 20, TempDir: t.TempDir(), UploadDedup: true}
+	r := gin.New()
+	SetupRoutes(r, config)
+	content := []byte("%PDF-1.7\nupload dedup " + generateUniqueID() + "\n%%EOF\n")
+
+	first, deduplicated := uploadPDF(t, r, content)
+	if deduplicated {
+		t.Error("first upload reported as a duplicate")
+	}
+	second, deduplicated := uploadPDF(t, r, content)
+	if second != first || !deduplicated {
+		t.Errorf("second upload got file_id %s (deduplicated %v), want %s", second, deduplicated, first)
+	}
+	if n := storedUploads(t, config.TempDir); n != 1 {
+		t.Errorf("%d files stored for identical uploads, want 1", n)
+	}
+
+	// Different content is stored on its own
+	other, _ := uploadPDF(t, r, append(content, '\n'))
+	if other == first || storedUploads(t, config.TempDir) != 2 {
+		t.Errorf("different content got file_id %s, %d files stored", other, storedUploads(t, config.TempDir))
+	}
+
+	// Once the stored file is gone, the same content is stored again
+	path, err := findUploadedFile(config, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Remove(path)
+	if again, deduplicated := uploadPDF(t, r, content); again == first || deduplicated {
+		t.Errorf("upload after removal got file_id %s (deduplicated %v), want a new file", again, deduplicated)
+	}
+}
+
+func TestUploadWithoutDedup(t *testing.T) {
+	config := &Config{MaxFileSize: 1 << 20, TempDir: t.TempDir()}
+	r := gin.New()
+	SetupRoutes(r, config)
+	content := []byte("%PDF-1.7\nno dedup " + generateUniqueID() + "\n%%EOF\n")
+
+	first, _ := uploadPDF(t, r, content)
+	second, deduplicated := uploadPDF(t, r, content)
+	if second == first || deduplicated {
+		t.Errorf("identical uploads share file_id %s without UPLOAD_DEDUP", first)
+	}
+	if n := storedUploads(t, config.TempDir); n != 2 {
+		t.Errorf("%d files stored, want 2", n)
+	}
+}
+
+func TestUploadIndexFind(t *testing.T) {
+	config := &Config{TempDir: t.TempDir()}
+	index := &uploadIndex{uploads: make(map[string]indexedUpload)}
+	fileID := generateUniqueID()
+	stored := filepath.Join(config.TempDir, fileID+"_report.pdf")
+	if err := os.WriteFile(stored, []byte("%PDF-1.7\n%%EOF\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	index.Add("hash", fileID)
+
+	// The stored path comes with the file_id, so a duplicate never reports an empty path
+	if id, path, ok := index.Find(config, "hash"); !ok || id != fileID || path != stored {
+		t.Errorf("Find = %q, %q, %v, want %s at %s", id, path, ok, fileID, stored)
+	}
+	if _, _, ok := index.Find(config, "other"); ok {
+		t.Error("unknown hash found")
+	}
+
+	// A stored file that cannot be found any more is no duplicate: the new upload is kept
+	os.Remove(stored)
+	if id, path, ok := index.Find(config, "hash"); ok {
+		t.Errorf("Find = %q, %q after the stored file was removed, want not found", id, path)
+	}
+}
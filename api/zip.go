@@ -0,0 +1,93 @@
+package api
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+)
+
+// zipEntry is a file on disk to be packed into a ZIP response
+type zipEntry struct {
+	Name string // name inside the archive
+	Path string // file on disk
+}
+
+// zipCompressionMethods maps the zip_compression option to archive/zip methods. PDFs are
+// already compressed, so storing is the default: deflating again costs CPU for little gain.
+var zipCompressionMethods = map[string]uint16{
+	"store":   zip.Store,
+	"deflate": zip.Deflate,
+}
+
+// parseZIPCompression reads the zip_compression option ("" selects "store")
+func parseZIPCompression(value string) (uint16, error) {
+	if value == "" {
+		value = "store"
+	}
+	method, ok := zipCompressionMethods[value]
+	if !ok {
+		return 0, fmt.Errorf("invalid zip_compression: %s (supported: store, deflate)", value)
+	}
+	return method, nil
+}
+
+// streamZIP writes entries as a ZIP archive directly to the response without buffering the
+// archive in memory. Sizes are checked against MaxZIPSize before anything is written, so
+// those failures still get a JSON error. Once streaming has started the status can no longer
+// change: a mid-stream failure is logged and the archive is left without its central
+// directory, which ZIP readers report as a corrupt download. Every entry is written with
+// method (zip.Store or zip.Deflate, see parseZIPCompression).
+func streamZIP(c *gin.Context, filename string, entries []zipEntry, method uint16) {
+	var total int64
+	for _, entry := range entries {
+		info, err := os.Stat(entry.Path)
+		if err != nil {
+			log.Printf("ZIP entry %s missing: %v", entry.Name, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to prepare ZIP archive"})
+			return
+		}
+		total += info.Size()
+	}
+	if total > MaxZIPSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Result too large to download as ZIP (%d bytes, limit %d)", total, MaxZIPSize)})
+		return
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", sanitizeFilename(filename)))
+	c.Status(http.StatusOK)
+
+	zw := zip.NewWriter(c.Writer)
+	for _, entry := range entries {
+		if err := writeZIPEntry(zw, entry, method); err != nil {
+			log.Printf("ZIP streaming failed at %s: %v", entry.Name, err)
+			c.Abort()
+			return
+		}
+	}
+	if err := zw.Close(); err != nil {
+		log.Printf("ZIP streaming failed to finish archive: %v", err)
+		c.Abort()
+	}
+}
+
+// writeZIPEntry copies one file into the archive
+func writeZIPEntry(zw *zip.Writer, entry zipEntry, method uint16) error {
+	file, err := os.Open(entry.Path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: entry.Name, Method: method})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, file)
+	return err
+}
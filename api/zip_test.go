@@ -0,0 +1,153 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// zipFixture writes one file per entry name into a temp dir, with the name as its content
+func zipFixture(t *testing.T, names ...string) []zipEntry {
+	t.Helper()
+	dir := t.TempDir()
+	var entries []zipEntry
+	for i, name := range names {
+		path := filepath.Join(dir, "part_"+string(rune('a'+i))+".pdf")
+		if err := os.WriteFile(path, []byte("%PDF-1.7 "+strings.Repeat(name, 100)), 0644); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, zipEntry{Name: name, Path: path})
+	}
+	return entries
+}
+
+// readZIP opens a ZIP response body and returns its entries' contents by name, in order
+func readZIP(t *testing.T, body []byte) ([]string, map[string][]byte) {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		t.Fatalf("response is not a readable ZIP: %v", err)
+	}
+	var names []string
+	contents := make(map[string][]byte)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("entry %s: %v", f.Name, err)
+		}
+		names = append(names, f.Name)
+		contents[f.Name] = data
+	}
+	return names, contents
+}
+
+func TestStreamZIP(t *testing.T) {
+	entries := zipFixture(t, "pages_1-3.pdf", "pages_4-6.pdf", "pages_7-9.pdf")
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	streamZIP(c, "split.zip", entries, zip.Store)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d, want 200", w.Code)
+	}
+	if got := w.Header().Get("Content-Type"); got != "application/zip" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	if got := w.Header().Get("Content-Disposition"); got != `attachment; filename="split.zip"` {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+	names, contents := readZIP(t, w.Body.Bytes())
+	if len(names) != len(entries) {
+		t.Fatalf("archive holds %v, want %d entries", names, len(entries))
+	}
+	for i, entry := range entries {
+		want, _ := os.ReadFile(entry.Path)
+		if names[i] != entry.Name || !bytes.Equal(contents[entry.Name], want) {
+			t.Errorf("entry %d = %s with %d bytes, want %s with %d bytes", i, names[i], len(contents[names[i]]), entry.Name, len(want))
+		}
+	}
+}
+
+func TestStreamZIPMissingEntry(t *testing.T) {
+	entries := zipFixture(t, "a.pdf")
+	entries = append(entries, zipEntry{Name: "b.pdf", Path: filepath.Join(t.TempDir(), "gone.pdf")})
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	streamZIP(c, "split.zip", entries, zip.Store)
+
+	// Nothing was streamed yet, so the failure is still a JSON error
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("status %d, want 500", w.Code)
+	}
+	if got := w.Header().Get("Content-Type"); !strings.HasPrefix(got, "application/json") {
+		t.Errorf("Content-Type = %q, want a JSON error", got)
+	}
+}
+
+func TestStreamZIPCompression(t *testing.T) {
+	for _, option := range []string{"", "store", "deflate"} {
+		t.Run("zip_compression="+option, func(t *testing.T) {
+			method, err := parseZIPCompression(option)
+			if err != nil {
+				t.Fatalf("parseZIPCompression(%q): %v", option, err)
+			}
+			want := uint16(zip.Store)
+			if option == "deflate" {
+				want = zip.Deflate
+			}
+			if method != want {
+				t.Fatalf("parseZIPCompression(%q) = %d, want %d", option, method, want)
+			}
+
+			entries := zipFixture(t, "a.pdf", "b.pdf")
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			streamZIP(c, "split.zip", entries, method)
+
+			// The archive is valid and its entries read back unchanged
+			names, contents := readZIP(t, w.Body.Bytes())
+			for i, entry := range entries {
+				data, _ := os.ReadFile(entry.Path)
+				if names[i] != entry.Name || !bytes.Equal(contents[entry.Name], data) {
+					t.Errorf("entry %d = %s, want %s unchanged", i, names[i], entry.Name)
+				}
+			}
+
+			zr, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, f := range zr.File {
+				if f.Method != want {
+					t.Errorf("%s stored with method %d, want %d", f.Name, f.Method, want)
+				}
+				// The fixtures repeat their name, so deflate must shrink them
+				compressed := f.CompressedSize64 < f.UncompressedSize64
+				if compressed != (want == zip.Deflate) {
+					t.Errorf("%s: %d bytes compressed to %d with method %d", f.Name, f.UncompressedSize64, f.CompressedSize64, f.Method)
+				}
+			}
+		})
+	}
+
+	for _, option := range []string{"zstd", "STORE", "none"} {
+		if _, err := parseZIPCompression(option); err == nil {
+			t.Errorf("parseZIPCompression(%q) accepted an unknown method", option)
+		}
+	}
+}
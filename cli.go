@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	pdfPkg "pdf_editor/pdf"
+	"sort"
+	"strconv"
+)
+
+// cliCommand is a PDF operation runnable from the command line
+type cliCommand struct {
+	usage string // arguments after the input and output paths
+	extra int    // number of arguments after the input and output paths
+	run   func(inFile, outFile string, args []string) error
+}
+
+// cliCommands are the subcommands available as "pdf_editor <command> <in> <out> [args]"
+var cliCommands = map[string]cliCommand{
+	"resave": {
+		run: func(inFile, outFile string, _ []string) error { return pdfPkg.ResavePDF(inFile, outFile) },
+	},
+	"repair": {
+		run: func(inFile, outFile string, _ []string) error { return pdfPkg.RepairPDF(inFile, outFile) },
+	},
+	"remove-pages": {
+		usage: "<pages>",
+		extra: 1,
+		run: func(inFile, outFile string, args []string) error {
+			return pdfPkg.RemovePagesFromPDF(inFile, outFile, args[0])
+		},
+	},
+	"rotate": {
+		usage: "<pages> <degrees>",
+		extra: 2,
+		run: func(inFile, outFile string, args []string) error {
+			degrees, err := strconv.Atoi(args[1])
+			if err != nil {
+				return fmt.Errorf("invalid degrees: %s", args[1])
+			}
+			return pdfPkg.RotatePages(inFile, outFile, args[0], degrees)
+		},
+	},
+}
+
+// isCLICommand reports whether name is a CLI subcommand (or a request for CLI help)
+func isCLICommand(name string) bool {
+	_, ok := cliCommands[name]
+	return ok || name == "selftest" || name == "help" || name == "-h" || name == "--help"
+}
+
+// runCLI runs one subcommand and returns the process exit code
+// "-" as input or output path means stdin or stdout; pdfcpu needs real files, so stdin is
+// buffered to a temp file and the result is streamed from a temp file to stdout
+func runCLI(args []string, tempDir string) int {
+	if args[0] == "selftest" && len(args) == 1 {
+		return runSelfTestCLI(tempDir)
+	}
+
+	cmd, ok := cliCommands[args[0]]
+	if !ok || len(args) != 3+cmd.extra {
+		printCLIUsage()
+		if ok || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+			return 0
+		}
+		return 2
+	}
+
+	if err := os.MkdirAll(tempDir, 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to create temp directory: %v\n", err)
+		return 1
+	}
+	workDir, err := os.MkdirTemp(tempDir, "cli_")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to create work directory: %v\n", err)
+		return 1
+	}
+	defer os.RemoveAll(workDir)
+
+	inFile, outFile := args[1], args[2]
+	if inFile == "-" {
+		inFile = filepath.Join(workDir, "stdin.pdf")
+		if err := copyToFile(inFile, os.Stdin); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to read stdin: %v\n", err)
+			return 1
+		}
+	}
+	toStdout := outFile == "-"
+	if toStdout {
+		outFile = filepath.Join(workDir, "stdout.pdf")
+	}
+
+	if err := cmd.run(inFile, outFile, args[3:]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	if toStdout {
+		result, err := os.Open(outFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to open result: %v\n", err)
+			return 1
+		}
+		defer result.Close()
+		if _, err := io.Copy(os.Stdout, result); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to write stdout: %v\n", err)
+			return 1
+		}
+	}
+	return 0
+}
+
+// copyToFile writes everything from r to a new file at path
+func copyToFile(path string, r io.Reader) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, r); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// printCLIUsage lists the available subcommands on stderr
+func printCLIUsage() {
+	names := make([]string, 0, len(cliCommands))
+	for name := range cliCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(os.Stderr, "Usage: pdf_editor <command> <in.pdf|-> <out.pdf|-> [args]")
+	fmt.Fprintln(os.Stderr, "       pdf_editor selftest")
+	fmt.Fprintln(os.Stderr, "Run without arguments to start the web server. Use - for stdin/stdout.")
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %s <in> <out> %s\n", name, cliCommands[name].usage)
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// fakePdfcpuScript stands in for pdfcpu on PATH: optimize writes a marker line followed
+// by its input, as a rewritten copy of the document
+const fakePdfcpuScript = `#!/bin/sh
+if [ "$1" = "optimize" ]; then
+	{ echo "resaved by fake pdfcpu"; cat "$2"; } > "$3"
+	exit 0
+fi
+echo "unexpected command: $*" >&2
+exit 1
+`
+
+// installFakePdfcpu puts a fake pdfcpu first on PATH for the test
+func installFakePdfcpu(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake pdfcpu is a shell script")
+	}
+	binDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(binDir, "pdfcpu"), []byte(fakePdfcpuScript), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+// redirectStdio points os.Stdin at a file holding input and os.Stdout at a new file,
+// returning the path stdout is written to
+func redirectStdio(t *testing.T, input []byte) string {
+	t.Helper()
+	dir := t.TempDir()
+	inPath, outPath := filepath.Join(dir, "stdin"), filepath.Join(dir, "stdout")
+	if err := os.WriteFile(inPath, input, 0644); err != nil {
+		t.Fatal(err)
+	}
+	stdin, err := os.Open(inPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, err := os.Create(outPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	previousIn, previousOut := os.Stdin, os.Stdout
+	os.Stdin, os.Stdout = stdin, stdout
+	t.Cleanup(func() {
+		os.Stdin, os.Stdout = previousIn, previousOut
+		stdin.Close()
+		stdout.Close()
+	})
+	return outPath
+}
+
+func TestCLIResavePipesStdinToStdout(t *testing.T) {
+	installFakePdfcpu(t)
+	const input = "%PDF-1.7\nsample document\n%%EOF\n"
+	tempDir := t.TempDir()
+	outPath := redirectStdio(t, []byte(input))
+
+	if code := runCLI([]string{"resave", "-", "-"}, tempDir); code != 0 {
+		t.Fatalf("exit code %d, want 0", code)
+	}
+	got, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "resaved by fake pdfcpu\n" + input; string(got) != want {
+		t.Errorf("stdout = %q, want %q", got, want)
+	}
+	if entries, _ := os.ReadDir(tempDir); len(entries) != 0 {
+		t.Errorf("work files left in the temp directory: %v", entries)
+	}
+}
+
+func TestCLIResaveFileToStdout(t *testing.T) {
+	installFakePdfcpu(t)
+	inFile := filepath.Join(t.TempDir(), "in.pdf")
+	if err := os.WriteFile(inFile, []byte("%PDF-1.7\n%%EOF\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	outPath := redirectStdio(t, nil)
+
+	if code := runCLI([]string{"resave", inFile, "-"}, t.TempDir()); code != 0 {
+		t.Fatalf("exit code %d, want 0", code)
+	}
+	if got, _ := os.ReadFile(outPath); string(got) != "resaved by fake pdfcpu\n%PDF-1.7\n%%EOF\n" {
+		t.Errorf("stdout = %q", got)
+	}
+}
+
+func TestCLIFailureWritesNothingToStdout(t *testing.T) {
+	installFakePdfcpu(t)
+	outPath := redirectStdio(t, []byte("%PDF-1.7\n"))
+
+	// The fake pdfcpu fails every command but optimize
+	if code := runCLI([]string{"remove-pages", "-", "-", "1"}, t.TempDir()); code != 1 {
+		t.Errorf("exit code %d, want 1", code)
+	}
+	if got, _ := os.ReadFile(outPath); len(got) != 0 {
+		t.Errorf("stdout = %q after a failure, want nothing", got)
+	}
+}
@@ -9,7 +9,9 @@ import (
 	"os/exec"
 	"os/signal"
 	"pdf_editor/api"
+	pdfPkg "pdf_editor/pdf"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -42,9 +44,43 @@ const (
 func main() {
 	// Load configuration
 	config := &api.Config{
-		Port:        getEnv("PORT", DefaultPort),
-		MaxFileSize: getEnvInt64("MAX_FILE_SIZE", DefaultMaxFileSize),
-		TempDir:     getEnv("TEMP_DIR", DefaultTempDir),
+		Port:                getEnv("PORT", DefaultPort),
+		MaxFileSize:         getEnvInt64("MAX_FILE_SIZE", DefaultMaxFileSize),
+		TempDir:             getEnv("TEMP_DIR", DefaultTempDir),
+		Debug:               getEnv("DEBUG", "") == "true",
+		PdfcpuConfigDir:     getEnv("PDFCPU_CONFIG_DIR", ""),
+		MaxImageOccurrences: int(getEnvInt64("MAX_IMAGE_OCCURRENCES", pdfPkg.DefaultMaxImageOccurrences)),
+	}
+	config.MaxAnalysisResponseSize = getEnvInt64("MAX_ANALYSIS_RESPONSE_SIZE", api.DefaultMaxAnalysisResponseSize)
+	if floor := getEnv("MIN_CONFIDENCE_FLOOR", ""); floor != "" {
+		value, err := strconv.ParseFloat(floor, 64)
+		if err != nil {
+			log.Fatalf("Invalid MIN_CONFIDENCE_FLOOR: %v", err)
+		}
+		config.MinConfidenceFloor = value
+	}
+	if protected := getEnv("PROTECTED_OBJECTS", ""); protected != "" {
+		config.ProtectedObjects = strings.Split(protected, ",")
+	}
+	config.AuditDir = getEnv("AUDIT_DIR", "")
+	config.RemoteFetch = getEnv("REMOTE_FETCH", "") == "true"
+	config.PreviewMemoryCache = getEnv("PREVIEW_MEMORY_CACHE", "") == "true"
+	config.UploadDedup = getEnv("UPLOAD_DEDUP", "") == "true"
+	remoteHeaders, err := api.ParseRemoteFetchHeaders(getEnv("REMOTE_FETCH_HEADERS", ""))
+	if err != nil {
+		log.Fatalf("Invalid REMOTE_FETCH_HEADERS: %v", err)
+	}
+	config.RemoteFetchHeaders = remoteHeaders
+	if err := validateConfig(config); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	if err := pdfPkg.SetPdfcpuConfigDir(config.PdfcpuConfigDir); err != nil {
+		log.Fatalf("Invalid PDFCPU_CONFIG_DIR: %v", err)
+	}
+
+	if err := pdfPkg.SetPdfcpuGlobalFlags(strings.Fields(getEnv("PDFCPU_GLOBAL_FLAGS", ""))); err != nil {
+		log.Fatalf("Invalid PDFCPU_GLOBAL_FLAGS: %v", err)
 	}
 
 	// Check pdfcpu availability on startup
@@ -53,6 +89,24 @@ func main() {
 	}
 	log.Println("pdfcpu CLI is available")
 
+	// CLI mode: run a single operation instead of starting the server
+	if len(os.Args) > 1 && isCLICommand(os.Args[1]) {
+		os.Exit(runCLI(os.Args[1:], config.TempDir))
+	}
+
+	pdfPkg.SetMaxConcurrentCLI(int(getEnvInt64("CLI_MAX_CONCURRENCY", 0)))
+
+	// OCR is optional: the server runs without it and the OCR endpoint reports unavailability
+	pdfPkg.SetOCRBinary(getEnv("OCR_ENGINE_PATH", ""))
+	if err := pdfPkg.CheckOCRAvailable(); err != nil {
+		log.Printf("OCR engine not available, /api/pdf/ocr disabled: %v", err)
+	} else {
+		config.OCREnabled = true
+		log.Println("OCR engine is available")
+	}
+
+	logStartupConfig(config)
+
 	r := gin.Default()
 
 	// Static files for web UI
@@ -89,8 +143,6 @@ func main() {
 	// Start server in a goroutine
 	go func() {
 		log.Printf("Server starting on %s", srv.Addr)
-	log.Printf("Max file size: %d bytes", config.MaxFileSize)
-	log.Printf("Temp directory: %s", config.TempDir)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Failed to start server: %v", err)
 		}
@@ -0,0 +1,47 @@
+package pdf
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// csvMetadataColumns are the candidate metadata keys exported as CSV columns
+var csvMetadataColumns = []string{
+	MetaType, MetaImageID, MetaObject, MetaPageCount, MetaTotalPages, MetaCoverage,
+	MetaWidth, MetaHeight, MetaFileSizeKB, "text",
+}
+
+// WriteCSV writes every candidate (image, text and blank page) as one CSV row with a header
+// row, for reviewing the analysis in a spreadsheet. Missing metadata values are left empty.
+func (wa UnwantedElementsAnalysis) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+
+	header := append([]string{"candidate_type", "id", "page", "description", "confidence"}, csvMetadataColumns...)
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+
+	groups := [][]UnwantedElementCandidate{wa.ImageCandidates, wa.TextCandidates, wa.BlankPageCandidates}
+	for _, candidates := range groups {
+		for _, candidate := range candidates {
+			row := []string{
+				candidate.Type,
+				candidate.ID,
+				strconv.Itoa(candidate.Page),
+				candidate.Description,
+				strconv.FormatFloat(candidate.Confidence, 'f', 2, 64),
+			}
+			for _, key := range csvMetadataColumns {
+				row = append(row, candidate.Metadata[key])
+			}
+			if err := writer.Write(row); err != nil {
+				return fmt.Errorf("failed to write CSV row: %w", err)
+			}
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
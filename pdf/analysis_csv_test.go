@@ -0,0 +1,59 @@
+package pdf
+
+import (
+	"bytes"
+	"encoding/csv"
+	"slices"
+	"testing"
+)
+
+func TestWriteCSV(t *testing.T) {
+	analysis := UnwantedElementsAnalysis{
+		ImageCandidates: []UnwantedElementCandidate{{
+			Type: "image", ID: "fullpage_watermark_Im0", Description: "Watermark, \"CONFIDENTIAL\", on every page", Confidence: 0.95,
+			Metadata: map[string]string{MetaType: "fullpage_watermark", MetaImageID: "Im0", MetaObject: "10", MetaPageCount: "3"},
+		}},
+		TextCandidates: []UnwantedElementCandidate{{
+			Type: "text", ID: "text_watermark_1", Description: "Text watermark\nspanning two lines", Confidence: 0.8,
+			Metadata: map[string]string{"text": "DRAFT, do not copy"},
+		}},
+		BlankPageCandidates: []UnwantedElementCandidate{{
+			Type: "blank_page", ID: "blank_page_2", Page: 2, Description: "Page 2 appears to be blank", Confidence: 0.95,
+		}},
+	}
+
+	var buf bytes.Buffer
+	if err := analysis.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("output is not well-formed CSV: %v\n%s", err, buf.String())
+	}
+
+	header := records[0]
+	if len(records) != 4 {
+		t.Fatalf("%d records, want a header and 3 candidates", len(records))
+	}
+	column := func(record []string, name string) string {
+		return record[slices.Index(header, name)]
+	}
+	want := []struct {
+		candidateType, id, page, description, confidence, extra, extraValue string
+	}{
+		{"image", "fullpage_watermark_Im0", "0", "Watermark, \"CONFIDENTIAL\", on every page", "0.95", MetaObject, "10"},
+		{"text", "text_watermark_1", "0", "Text watermark\nspanning two lines", "0.80", "text", "DRAFT, do not copy"},
+		{"blank_page", "blank_page_2", "2", "Page 2 appears to be blank", "0.95", MetaImageID, ""},
+	}
+	for i, w := range want {
+		record := records[i+1]
+		if len(record) != len(header) {
+			t.Errorf("record %d has %d fields, want %d", i, len(record), len(header))
+			continue
+		}
+		got := []string{column(record, "candidate_type"), column(record, "id"), column(record, "page"), column(record, "description"), column(record, "confidence"), column(record, w.extra)}
+		if !slices.Equal(got, []string{w.candidateType, w.id, w.page, w.description, w.confidence, w.extraValue}) {
+			t.Errorf("record %d = %q", i, record)
+		}
+	}
+}
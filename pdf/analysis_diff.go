@@ -0,0 +1,87 @@
+package pdf
+
+// AnalysisDiff compares the candidates of an analysis before and after cleaning
+type AnalysisDiff struct {
+	BeforeCount int `json:"before_count"`
+	AfterCount  int `json:"after_count"`
+
+	// Eliminated are the candidates found before cleaning and no longer found after
+	Eliminated []UnwantedElementCandidate `json:"eliminated"`
+
+	// Remaining are the candidates found before cleaning that are still found, as
+	// reported by the analysis after cleaning
+	Remaining []UnwantedElementCandidate `json:"remaining"`
+
+	// Introduced are the candidates found only after cleaning, e.g. images that were
+	// hidden behind a removed full-page watermark now standing out on their own
+	Introduced []UnwantedElementCandidate `json:"introduced"`
+}
+
+// DiffAnalyses matches the image, text and blank page candidates of two analyses of the
+// same document. Candidate IDs are not stable across a rewrite, so candidates are matched
+// by what they are: image candidates by signature (by ID prefix if they have none), text
+// candidates by their text and blank pages by ID.
+func DiffAnalyses(before, after *UnwantedElementsAnalysis) AnalysisDiff {
+	beforeCandidates := allCandidates(before)
+	afterCandidates := allCandidates(after)
+	diff := AnalysisDiff{
+		BeforeCount: len(beforeCandidates),
+		AfterCount:  len(afterCandidates),
+		Eliminated:  []UnwantedElementCandidate{},
+		Remaining:   []UnwantedElementCandidate{},
+		Introduced:  []UnwantedElementCandidate{},
+	}
+
+	afterByKey := make(map[string][]UnwantedElementCandidate)
+	for _, candidate := range afterCandidates {
+		key := candidateMatchKey(candidate)
+		afterByKey[key] = append(afterByKey[key], candidate)
+	}
+	matchedKeys := make(map[string]bool)
+	for _, candidate := range beforeCandidates {
+		key := candidateMatchKey(candidate)
+		if _, ok := afterByKey[key]; !ok {
+			diff.Eliminated = append(diff.Eliminated, candidate)
+			continue
+		}
+		if !matchedKeys[key] {
+			matchedKeys[key] = true
+			diff.Remaining = append(diff.Remaining, afterByKey[key]...)
+		}
+	}
+	for _, candidate := range afterCandidates {
+		if !matchedKeys[candidateMatchKey(candidate)] {
+			diff.Introduced = append(diff.Introduced, candidate)
+		}
+	}
+
+	return diff
+}
+
+// allCandidates returns the image, text and blank page candidates of an analysis
+func allCandidates(analysis *UnwantedElementsAnalysis) []UnwantedElementCandidate {
+	if analysis == nil {
+		return nil
+	}
+	candidates := append([]UnwantedElementCandidate{}, analysis.ImageCandidates...)
+	candidates = append(candidates, analysis.TextCandidates...)
+	return append(candidates, analysis.BlankPageCandidates...)
+}
+
+// candidateMatchKey identifies a candidate across analyses of the same document
+func candidateMatchKey(candidate UnwantedElementCandidate) string {
+	switch candidate.Type {
+	case "image":
+		if signature := candidate.Metadata[MetaSignature]; signature != "" {
+			return "image|signature|" + signature
+		}
+		if prefix := candidate.Metadata[MetaPrefix]; prefix != "" && prefix != "unknown" {
+			return "image|prefix|" + prefix
+		}
+	case "text":
+		if text := candidate.Metadata["text"]; text != "" {
+			return "text|" + text
+		}
+	}
+	return candidate.Type + "|id|" + candidate.ID
+}
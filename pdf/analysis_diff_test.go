@@ -0,0 +1,72 @@
+package pdf
+
+import (
+	"slices"
+	"testing"
+)
+
+func candidateIDs(candidates []UnwantedElementCandidate) []string {
+	ids := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		ids[i] = candidate.ID
+	}
+	return ids
+}
+
+func TestDiffAnalyses(t *testing.T) {
+	image := func(id, signature, prefix string) UnwantedElementCandidate {
+		return UnwantedElementCandidate{Type: "image", ID: id, Metadata: map[string]string{MetaSignature: signature, MetaPrefix: prefix}}
+	}
+	text := func(id, content string) UnwantedElementCandidate {
+		return UnwantedElementCandidate{Type: "text", ID: id, Metadata: map[string]string{"text": content}}
+	}
+	blank := UnwantedElementCandidate{Type: "blank_page", ID: "blank_page_7"}
+
+	before := &UnwantedElementsAnalysis{
+		ImageCandidates: []UnwantedElementCandidate{
+			image("fullpage_watermark_Im0_39KB", "600x400_DeviceRGB_40000 B_prefix:Im0", "Im0"),
+			image("repeating_unwanted_element_50x50_De", "50x50_DeviceRGB_3000 B_prefix:Logo", "Logo"),
+			image("deep_match_X", "", "X"),
+		},
+		TextCandidates:      []UnwantedElementCandidate{text("text_watermark_1", "CONFIDENTIAL")},
+		BlankPageCandidates: []UnwantedElementCandidate{blank},
+	}
+	after := &UnwantedElementsAnalysis{
+		ImageCandidates: []UnwantedElementCandidate{
+			// Same signature under a new ID: still there
+			image("repeating_unwanted_element_50x50_De_2", "50x50_DeviceRGB_3000 B_prefix:Logo", "Logo"),
+			// Matched by prefix when there is no signature
+			image("deep_match_X_renamed", "", "X"),
+			// Was hidden behind the watermark
+			image("repeating_unwanted_element_80x80_De", "80x80_DeviceRGB_5000 B_prefix:Seal", "Seal"),
+		},
+		TextCandidates:      []UnwantedElementCandidate{text("text_watermark_2", "DRAFT")},
+		BlankPageCandidates: []UnwantedElementCandidate{blank},
+	}
+
+	diff := DiffAnalyses(before, after)
+	if diff.BeforeCount != 5 || diff.AfterCount != 5 {
+		t.Errorf("counts %d -> %d, want 5 -> 5", diff.BeforeCount, diff.AfterCount)
+	}
+	if got, want := candidateIDs(diff.Eliminated), []string{"fullpage_watermark_Im0_39KB", "text_watermark_1"}; !slices.Equal(got, want) {
+		t.Errorf("eliminated %v, want %v", got, want)
+	}
+	// Remaining candidates are reported as found after cleaning
+	if got, want := candidateIDs(diff.Remaining), []string{"repeating_unwanted_element_50x50_De_2", "deep_match_X_renamed", "blank_page_7"}; !slices.Equal(got, want) {
+		t.Errorf("remaining %v, want %v", got, want)
+	}
+	if got, want := candidateIDs(diff.Introduced), []string{"repeating_unwanted_element_80x80_De", "text_watermark_2"}; !slices.Equal(got, want) {
+		t.Errorf("introduced %v, want %v", got, want)
+	}
+}
+
+func TestDiffAnalysesEmpty(t *testing.T) {
+	before := &UnwantedElementsAnalysis{ImageCandidates: []UnwantedElementCandidate{{Type: "image", ID: "a"}}}
+	diff := DiffAnalyses(before, &UnwantedElementsAnalysis{})
+	if diff.AfterCount != 0 || len(diff.Eliminated) != 1 || diff.Remaining == nil || diff.Introduced == nil {
+		t.Errorf("diff %+v, want one eliminated and empty, non-nil lists", diff)
+	}
+	if diff := DiffAnalyses(nil, nil); diff.BeforeCount != 0 || len(diff.Eliminated)+len(diff.Remaining)+len(diff.Introduced) != 0 {
+		t.Errorf("diff of nil analyses %+v", diff)
+	}
+}
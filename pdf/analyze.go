@@ -1,12 +1,20 @@
 package pdf
 
 import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"math"
 	"regexp"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 // imageInfo represents processed image information for analysis
@@ -21,6 +29,25 @@ type imageInfo struct {
 	colorSpace string
 }
 
+// imageAnalysisResult is the outcome of analyzeImages
+type imageAnalysisResult struct {
+	candidates       []UnwantedElementCandidate
+	images           []rawImageData // every image occurrence parsed from pdfcpu images list
+	deepMatchSkipped bool // deep matching was requested but the distinct-image guard tripped
+	earlyExit        bool // analysis stopped at a definitive candidate (AnalysisOptions.EarlyExit)
+	groupsCapped     bool // MaxTrackedGroups was reached, so some images were not grouped
+}
+
+// pageSources loads the page content streams and geometry of the analyzed PDF. Both
+// are loaded at most once per analysis, however many signals use them.
+type pageSources struct {
+	contents func() (map[int]string, error)
+	geometry func() (map[int]PageGeometry, error)
+}
+
+// errAnalysisStoppedEarly makes the content-based enrichments skip after an early exit
+var errAnalysisStoppedEarly = errors.New("analysis stopped early at a definitive candidate")
+
 // imageWithPage represents an image with its page number for unwanted element detection
 type imageWithPage struct {
 	img  imageInfo
@@ -44,6 +71,76 @@ type rawImageData struct {
 	size       string
 }
 
+// AnalysisSchemaVersion is the version of the analysis JSON contract. It is bumped whenever
+// the meaning of a field or documented metadata key changes.
+const AnalysisSchemaVersion = "1"
+
+// Documented candidate metadata keys (schema version 1). Image candidates always carry:
+//
+//	type         detection path (fullpage_watermark, repeating_watermark, repeating_unwanted_element, ...)
+//	image_id     pdfcpu image ID (resource name) of a representative occurrence
+//	object       pdfcpu object number of a representative occurrence
+//	prefix       image ID prefix used for grouping (see extractIdPrefix)
+//	signature    "<w>x<h>_<colorspace>_<size>_prefix:<prefix>" grouping signature
+//	page_count   number of pages the element appears on
+//	total_pages  number of pages in the document
+//	coverage     page_count / total_pages as a percentage (e.g. "85%")
+//	file_size_kb file size of the representative image in KB
+//	width/height pixel dimensions of the representative image
+//	soft_mask    whether the image has a soft mask (transparency)
+//	image_mask   whether the image is a stencil mask
+//	representative_page  first page the element actually appears on (use for previews)
+//
+// Unknown values are empty strings rather than missing keys. "max_pages" is a deprecated
+// alias of "total_pages" kept for schema version 1 clients.
+const (
+	MetaType       = "type"
+	MetaImageID    = "image_id"
+	MetaObject     = "object"
+	MetaPrefix     = "prefix"
+	MetaSignature  = "signature"
+	MetaPageCount  = "page_count"
+	MetaTotalPages = "total_pages"
+	MetaCoverage   = "coverage"
+	MetaFileSizeKB = "file_size_kb"
+	MetaWidth      = "width"
+	MetaHeight     = "height"
+	MetaSoftMask   = "soft_mask"
+	MetaImageMask  = "image_mask"
+
+	MetaRepresentativePage = "representative_page"
+)
+
+// imageCandidateMetadata builds the metadata shared by every image candidate so that all
+// detection paths expose the same documented key set
+func imageCandidateMetadata(candidateType string, img imageInfo, signature, prefix string, pageCount, totalPages int) map[string]string {
+	coverage := ""
+	if totalPages > 0 {
+		coverage = fmt.Sprintf("%.0f%%", float64(pageCount)/float64(totalPages)*100)
+	}
+	return map[string]string{
+		MetaType:       candidateType,
+		MetaImageID:    img.id,  // Store image ID for removal
+		MetaObject:     img.obj, // Store object number for removal
+		MetaPrefix:     prefix,
+		MetaSignature:  signature,
+		MetaPageCount:  strconv.Itoa(pageCount),
+		MetaTotalPages: strconv.Itoa(totalPages),
+		"max_pages":    strconv.Itoa(totalPages), // Deprecated alias of total_pages
+		MetaCoverage:   coverage,
+		MetaFileSizeKB: fmt.Sprintf("%.1f", parseFileSizeKB(img.size)),
+		MetaWidth:      strconv.Itoa(img.width),
+		MetaHeight:     strconv.Itoa(img.height),
+		MetaSoftMask:   strconv.FormatBool(img.softMask),
+		MetaImageMask:  strconv.FormatBool(img.imgMask),
+	}
+}
+
+// imageSignature builds the grouping signature for an image
+func imageSignature(img imageInfo) string {
+	return fmt.Sprintf("%dx%d_%s_%s_prefix:%s", img.width, img.height, img.colorSpace, img.size, extractIdPrefix(img.id))
+}
+
 // UnwantedElementCandidate represents a potential unwanted element found in the PDF
 type UnwantedElementCandidate struct {
 	Type        string            `json:"type"`        // "image" or "text"
@@ -52,125 +149,811 @@ type UnwantedElementCandidate struct {
 	Description string            `json:"description"` // human-readable description
 	Confidence  float64           `json:"confidence"`  // 0-1 confidence score
 	Metadata    map[string]string `json:"metadata"`    // additional info
+	Pages       []int             `json:"pages"`       // sorted pages the candidate occurs on; Page is 0 for multi-page candidates
+}
+
+// sortedPageSet returns the pages of a page set in ascending order
+func sortedPageSet(pageSet map[int]bool) []int {
+	pages := make([]int, 0, len(pageSet))
+	for page := range pageSet {
+		pages = append(pages, page)
+	}
+	sort.Ints(pages)
+	return pages
+}
+
+// pageSetOf returns the distinct pages of a page list
+func pageSetOf(pages []int) map[int]bool {
+	pageSet := make(map[int]bool, len(pages))
+	for _, page := range pages {
+		pageSet[page] = true
+	}
+	return pageSet
+}
+
+// perPageCandidateCounts counts, for every page, how many candidates occur on it
+func perPageCandidateCounts(groups ...[]UnwantedElementCandidate) map[int]int {
+	counts := make(map[int]int)
+	for _, candidates := range groups {
+		for _, candidate := range candidates {
+			for _, page := range candidate.Pages {
+				counts[page]++
+			}
+		}
+	}
+	return counts
+}
+
+// FilterByPages returns a copy of the analysis holding only the candidates that occur on a
+// page in ranges ([first, last] pairs as returned by ParsePageRanges): single-page
+// candidates by their page, multi-page candidates if any occurrence is in range. The
+// remaining fields, such as TotalPages and the recommendations, still describe the whole document.
+func (wa *UnwantedElementsAnalysis) FilterByPages(ranges [][2]int) *UnwantedElementsAnalysis {
+	inRange := func(page int) bool {
+		for _, r := range ranges {
+			if page >= r[0] && page <= r[1] {
+				return true
+			}
+		}
+		return false
+	}
+	filter := func(candidates []UnwantedElementCandidate) []UnwantedElementCandidate {
+		filtered := []UnwantedElementCandidate{}
+		for _, candidate := range candidates {
+			pages := candidate.Pages
+			if candidate.Page > 0 {
+				pages = []int{candidate.Page}
+			}
+			// Candidates without page information are kept rather than silently dropped
+			if len(pages) == 0 || slices.ContainsFunc(pages, inRange) {
+				filtered = append(filtered, candidate)
+			}
+		}
+		return filtered
+	}
+
+	filtered := *wa
+	filtered.ImageCandidates = filter(wa.ImageCandidates)
+	filtered.TextCandidates = filter(wa.TextCandidates)
+	filtered.BlankPageCandidates = filter(wa.BlankPageCandidates)
+	return &filtered
+}
+
+// setRepresentativePages records the lowest page of each candidate's occurrences, so
+// previews of multi-page candidates (Page 0) target a page that contains the element
+func setRepresentativePages(candidates []UnwantedElementCandidate) {
+	for _, candidate := range candidates {
+		if len(candidate.Pages) > 0 && candidate.Metadata != nil {
+			candidate.Metadata[MetaRepresentativePage] = strconv.Itoa(candidate.Pages[0])
+		}
+	}
 }
 
 // UnwantedElementsAnalysis represents the complete analysis result
 type UnwantedElementsAnalysis struct {
-	TotalPages        int                        `json:"total_pages"`
-	ImageCandidates   []UnwantedElementCandidate `json:"image_candidates"`
-	TextCandidates    []UnwantedElementCandidate `json:"text_candidates"`
-	OverallConfidence float64                    `json:"overall_confidence"`
-	Recommendations   []string                   `json:"recommendations"`
-	DebugLogs         []string                   `json:"debug_logs"` // Debug information for troubleshooting
+	SchemaVersion          string                     `json:"schema_version"`
+	Status                 string                     `json:"status"` // AnalysisStatusCandidatesFound, AnalysisStatusNoneFound or AnalysisStatusPartial
+	TotalPages             int                        `json:"total_pages"`
+	ImageCandidates        []UnwantedElementCandidate `json:"image_candidates"`
+	TextCandidates         []UnwantedElementCandidate `json:"text_candidates"`
+	BlankPageCandidates    []UnwantedElementCandidate `json:"blank_page_candidates"`
+	DocumentType           string                     `json:"document_type"` // "scanned", "digital", "mixed" or "" if unknown
+	OverallConfidence      float64                    `json:"overall_confidence"`
+	Recommendations        []string                   `json:"recommendations"` // Plain text, kept for backward compatibility
+	RecommendationDetails  []Recommendation           `json:"recommendation_details"`
+	PerPageCandidateCounts map[int]int                `json:"per_page_candidate_counts,omitempty"` // page -> candidate count, only with IncludeHeatmap
+	DebugLogs              []string                   `json:"debug_logs"` // Debug information for troubleshooting
+}
+
+// Analysis statuses, so clients can branch without inspecting the candidate arrays
+const (
+	AnalysisStatusCandidatesFound = "candidates_found"
+	AnalysisStatusNoneFound       = "none_found"
+	// AnalysisStatusPartial means not every image was examined (early exit, group limits
+	// or skipped deep matching); candidates may have been found or not
+	AnalysisStatusPartial = "partial"
+)
+
+// Recommendation severities, from informational notes to items the user should act on
+const (
+	SeverityInfo    = "info"
+	SeverityWarning = "warning"
+	SeverityAction  = "action"
+)
+
+// Recommendation is a structured recommendation for UIs that prioritize actionable items
+type Recommendation struct {
+	ID          string `json:"id"`                     // message ID (e.g. "image_candidates_found")
+	Message     string `json:"message"`                // same text as in Recommendations
+	Severity    string `json:"severity"`               // "info", "warning" or "action"
+	CandidateID string `json:"candidate_id,omitempty"` // candidate the recommendation refers to, if any
+}
+
+// addRecommendation appends a recommendation to both the plain and structured lists
+func (a *UnwantedElementsAnalysis) addRecommendation(opts AnalysisOptions, id, severity, candidateID string) {
+	message := opts.message(id)
+	a.Recommendations = append(a.Recommendations, message)
+	a.RecommendationDetails = append(a.RecommendationDetails, Recommendation{
+		ID:          id,
+		Message:     message,
+		Severity:    severity,
+		CandidateID: candidateID,
+	})
+}
+
+// topCandidateID returns the ID of the highest-confidence candidate (first wins ties)
+func topCandidateID(candidates []UnwantedElementCandidate) string {
+	best := -1
+	for i, candidate := range candidates {
+		if best < 0 || candidate.Confidence > candidates[best].Confidence {
+			best = i
+		}
+	}
+	if best < 0 {
+		return ""
+	}
+	return candidates[best].ID
+}
+
+// Recommendation message IDs used as keys in AnalysisOptions.Messages
+const (
+	MsgImageCandidatesFound = "image_candidates_found"
+	MsgTextCandidatesFound  = "text_candidates_found"
+	MsgNoCandidatesFound    = "no_candidates_found"
+	MsgDeepMatchSkipped     = "deep_match_skipped"
+	MsgBlankPagesFound      = "blank_pages_found"
+	MsgScannedDocument      = "scanned_document"
+	MsgMixedDocument        = "mixed_document"
+	MsgDigitalDocument      = "digital_document"
+)
+
+// DefaultMessages is the English message catalog used for recommendations
+var DefaultMessages = map[string]string{
+	MsgImageCandidatesFound: "Images detected that may be unwanted elements - review and select for removal",
+	MsgTextCandidatesFound:  "Text elements detected that may be unwanted elements - review and select for removal",
+	MsgNoCandidatesFound:    "No obvious unwanted element candidates found - the PDF may not contain unwanted elements",
+	MsgDeepMatchSkipped:     "Too many distinct images for deep matching - only coverage and prefix based detection was used",
+	MsgBlankPagesFound:      "Blank pages detected - review and select them to drop the pages",
+	MsgScannedDocument:      "This is a scanned document - removing images may remove the page content itself",
+	MsgMixedDocument:        "Some pages are scanned - removing images on those pages may remove their content",
+	MsgDigitalDocument:      "This is a born-digital document - watermarks can usually be removed without affecting page content",
+}
+
+// AnalysisOptions tunes the unwanted elements analysis
+type AnalysisOptions struct {
+	// Messages overrides recommendation strings by message ID (e.g. for translations)
+	// Missing IDs fall back to DefaultMessages
+	Messages map[string]string
+
+	// MinDPI drops images rendered below this effective resolution (0 disables)
+	// Hairlines and decorative rules are tiny images stretched over large areas
+	MinDPI float64
+
+	// DeepMatch extracts and hashes every image to find byte-identical repeats
+	DeepMatch bool
+
+	// MaxDeepMatchImages caps the distinct-image count for deep matching
+	// (0 uses DefaultMaxDeepMatchImages)
+	MaxDeepMatchImages int
+
+	// DetectBlankPages reports pages without text or significant image content
+	DetectBlankPages bool
+
+	// BlankPageMaxInk is the largest inked fraction of a page that still counts as blank
+	// (0 uses DefaultBlankPageMaxInk)
+	BlankPageMaxInk float64
+
+	// IncludeHeatmap adds per-page candidate occurrence counts to the result
+	IncludeHeatmap bool
+
+	// EarlyExit stops the analysis as soon as a candidate reaches EarlyExitConfidence,
+	// skipping the remaining image and content detection (for "is there an obvious watermark")
+	EarlyExit bool
+
+	// EarlyExitConfidence is the confidence that ends the analysis with EarlyExit
+	// (0 uses DefaultEarlyExitConfidence)
+	EarlyExitConfidence float64
+
+	// MaxTrackedGroups caps the distinct image signatures and ID prefixes tracked while
+	// grouping images (0 uses DefaultMaxTrackedGroups)
+	MaxTrackedGroups int
+
+	// ClassifyPlacement classifies image candidates by where they are drawn (corner, header,
+	// footer, full_page, diagonal, body) and adjusts their confidence per class
+	ClassifyPlacement bool
+
+	// PlacementConfidence overrides DefaultPlacementConfidence adjustments by class
+	PlacementConfidence map[string]float64
+
+	// PrefixFilter restricts image grouping and candidates to images whose ID prefix (as
+	// reported in the "prefix" metadata) is one of these; empty examines every image
+	PrefixFilter []string
+}
+
+// stopsEarlyAt reports whether EarlyExit is set and one of candidates is definitive
+func (o AnalysisOptions) stopsEarlyAt(candidates []UnwantedElementCandidate) bool {
+	if !o.EarlyExit {
+		return false
+	}
+	threshold := o.EarlyExitConfidence
+	if threshold <= 0 {
+		threshold = DefaultEarlyExitConfidence
+	}
+	for _, candidate := range candidates {
+		if candidate.Confidence >= threshold {
+			return true
+		}
+	}
+	return false
+}
+
+// message returns the recommendation text for id, preferring the caller's catalog
+func (o AnalysisOptions) message(id string) string {
+	if msg, ok := o.Messages[id]; ok && msg != "" {
+		return msg
+	}
+	return DefaultMessages[id]
 }
 
 // AnalyzeUnwantedElements analyzes a PDF file and returns potential unwanted element candidates
 func AnalyzeUnwantedElements(filename string) (*UnwantedElementsAnalysis, error) {
+	return AnalyzeUnwantedElementsWithOptions(filename, AnalysisOptions{})
+}
+
+// AnalyzeUnwantedElementsWithOptions is like AnalyzeUnwantedElements but accepts tuning options
+func AnalyzeUnwantedElementsWithOptions(filename string, opts AnalysisOptions) (*UnwantedElementsAnalysis, error) {
+	return AnalyzeUnwantedElementsContext(context.Background(), filename, opts)
+}
+
+// AnalyzeUnwantedElementsContext is like AnalyzeUnwantedElementsWithOptions but stops when
+// ctx is cancelled, killing the running pdfcpu process; the error then wraps
+// ErrCommandCancelled (and context.Canceled)
+func AnalyzeUnwantedElementsContext(ctx context.Context, filename string, opts AnalysisOptions) (*UnwantedElementsAnalysis, error) {
+	analysis, _, err := analyzeUnwantedElements(ctx, filename, opts)
+	return analysis, err
+}
+
+// debugCollector returns a debug log function appending to logs and to the console
+// Guarded by a mutex: enrichments and per-page workers may log concurrently
+func debugCollector(logs *[]string) func(format string, args ...interface{}) {
+	var mu sync.Mutex
+	return func(format string, args ...interface{}) {
+		msg := fmt.Sprintf(format, args...)
+		mu.Lock()
+		*logs = append(*logs, msg)
+		mu.Unlock()
+		log.Print(msg) // Also log to console
+	}
+}
+
+// analyzeUnwantedElements runs the analysis and also returns the image occurrences parsed
+// from pdfcpu images list, so callers such as removal don't need to list images again
+func analyzeUnwantedElements(ctx context.Context, filename string, opts AnalysisOptions) (*UnwantedElementsAnalysis, []rawImageData, error) {
 	analysis := &UnwantedElementsAnalysis{
-		ImageCandidates: []UnwantedElementCandidate{},
-		TextCandidates:  []UnwantedElementCandidate{},
-		Recommendations: []string{},
-		DebugLogs:       []string{},
+		SchemaVersion:         AnalysisSchemaVersion,
+		ImageCandidates:       []UnwantedElementCandidate{},
+		TextCandidates:        []UnwantedElementCandidate{},
+		BlankPageCandidates:   []UnwantedElementCandidate{},
+		Recommendations:       []string{},
+		RecommendationDetails: []Recommendation{},
+		DebugLogs:             []string{},
 	}
 	
 	// Create a debug log collector
-	debugLog := func(format string, args ...interface{}) {
-		msg := fmt.Sprintf(format, args...)
-		analysis.DebugLogs = append(analysis.DebugLogs, msg)
-		log.Printf(msg) // Also log to console
-	}
+	debugLog := debugCollector(&analysis.DebugLogs)
 
 	// Get total pages using pdfcpu info
-	pages, err := getPageCount(filename)
+	info, err := getDocumentInfo(ctx, filename)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get page count: %v", err)
+		return nil, nil, fmt.Errorf("failed to get page count: %w", err)
 	}
+	pages := info.Pages
 	analysis.TotalPages = pages
 
+	// Page content streams and geometry are shared by the placement-based image filters and
+	// the content-based signals below, and loaded at most once
+	sources := pageSources{
+		contents: sync.OnceValues(func() (map[int]string, error) {
+			return extractPageContents(ctx, filename, pages)
+		}),
+		geometry: sync.OnceValues(func() (map[int]PageGeometry, error) {
+			return getPageGeometry(ctx, filename)
+		}),
+	}
+
 	// Analyze images using pdfcpu images list
-	imageCandidates, err := analyzeImages(filename, pages, debugLog)
+	imageResult, err := analyzeImages(ctx, filename, pages, opts, sources, debugLog)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to analyze images: %w", err)
+	}
+	analysis.ImageCandidates = imageResult.candidates
+	setRepresentativePages(analysis.ImageCandidates)
+
+	// Analyze content for potential unwanted text elements
+	// After an early exit the content-based enrichments are skipped
+	loadContents := func() (map[int]string, error) {
+		if imageResult.earlyExit {
+			return nil, errAnalysisStoppedEarly
+		}
+		return sources.contents()
+	}
+	loadGeometry := sources.geometry
+
+	runEnrichment("text_content", debugLog, func() error {
+		contents, err := loadContents()
+		if err != nil {
+			return err
+		}
+		textCandidates, err := analyzeContent(contents, pages, debugLog)
+		if err != nil {
+			return err
+		}
+		analysis.TextCandidates = textCandidates
+		return nil
+	})
+
+	// Detect blank pages, ignoring content that belongs to watermark candidates
+	if opts.DetectBlankPages {
+		maxInk := opts.BlankPageMaxInk
+		if maxInk <= 0 {
+			maxInk = DefaultBlankPageMaxInk
+		}
+		watermarks := append(append([]UnwantedElementCandidate{}, analysis.ImageCandidates...), analysis.TextCandidates...)
+		runEnrichment("blank_pages", debugLog, func() error {
+			contents, err := loadContents()
+			if err != nil {
+				return err
+			}
+			geometry, err := loadGeometry()
+			if err != nil {
+				return err
+			}
+			blankPages, err := analyzeBlankPages(ctx, filename, contents, geometry, pages, imageResult.images, watermarks, maxInk, debugLog)
+			if err != nil {
+				return err
+			}
+			analysis.BlankPageCandidates = blankPages
+			return nil
+		})
+	}
+
+	// Classify the document as scanned, born-digital or mixed
+	runEnrichment("document_type", debugLog, func() error {
+		contents, err := loadContents()
+		if err != nil {
+			return err
+		}
+		geometry, err := loadGeometry()
+		if err != nil {
+			return err
+		}
+		scannedPages := countScannedPages(contents, geometry, imageResult.images)
+		analysis.DocumentType = classifyDocument(scannedPages, pages)
+		if debugLog != nil {
+			debugLog("[DEBUG] Document type: %s (%d of %d pages scanned)", analysis.DocumentType, scannedPages, pages)
+		}
+		return nil
+	})
+
+	// Enrichments log their failures and carry on, so a cancellation that made them fail is
+	// only noticed here
+	if ctx.Err() != nil {
+		return nil, nil, ErrCommandCancelled
+	}
+
+	switch {
+	case imageResult.earlyExit || imageResult.groupsCapped || imageResult.deepMatchSkipped:
+		analysis.Status = AnalysisStatusPartial
+	case len(analysis.ImageCandidates)+len(analysis.TextCandidates)+len(analysis.BlankPageCandidates) > 0:
+		analysis.Status = AnalysisStatusCandidatesFound
+	default:
+		analysis.Status = AnalysisStatusNoneFound
+	}
+
+	if opts.IncludeHeatmap {
+		analysis.PerPageCandidateCounts = perPageCandidateCounts(analysis.ImageCandidates, analysis.TextCandidates)
+	}
+
+	// Calculate overall confidence
+	totalCandidates := len(analysis.ImageCandidates) + len(analysis.TextCandidates)
+	if totalCandidates > 0 {
+		analysis.OverallConfidence = 0.5 // Base confidence if candidates found
+		if totalCandidates > analysis.TotalPages {
+			analysis.OverallConfidence = 0.8 // High if many candidates relative to pages
+		}
+	} else {
+		analysis.OverallConfidence = 0.0 // No candidates found
+	}
+
+	// Add recommendations
+	if len(analysis.ImageCandidates) > 0 {
+		analysis.addRecommendation(opts, MsgImageCandidatesFound, SeverityAction, topCandidateID(analysis.ImageCandidates))
+	}
+	if len(analysis.TextCandidates) > 0 {
+		analysis.addRecommendation(opts, MsgTextCandidatesFound, SeverityAction, topCandidateID(analysis.TextCandidates))
+	}
+	if len(analysis.ImageCandidates) == 0 && len(analysis.TextCandidates) == 0 {
+		analysis.addRecommendation(opts, MsgNoCandidatesFound, SeverityInfo, "")
+	}
+	if len(analysis.BlankPageCandidates) > 0 {
+		analysis.addRecommendation(opts, MsgBlankPagesFound, SeverityAction, analysis.BlankPageCandidates[0].ID)
+	}
+	if imageResult.deepMatchSkipped {
+		analysis.addRecommendation(opts, MsgDeepMatchSkipped, SeverityWarning, "")
+	}
+	switch analysis.DocumentType {
+	case DocumentTypeScanned:
+		analysis.addRecommendation(opts, MsgScannedDocument, SeverityWarning, "")
+	case DocumentTypeMixed:
+		analysis.addRecommendation(opts, MsgMixedDocument, SeverityInfo, "")
+	case DocumentTypeDigital:
+		analysis.addRecommendation(opts, MsgDigitalDocument, SeverityInfo, "")
+	}
+
+	return analysis, imageResult.images, nil
+}
+
+// PageCount returns the number of pages of a PDF using pdfcpu CLI
+func PageCount(filename string) (int, error) {
+	return getPageCount(filename)
+}
+
+// getPageCount extracts the total number of pages from PDF
+func getPageCount(filename string) (int, error) {
+	info, err := GetDocumentInfo(filename)
 	if err != nil {
-		return nil, fmt.Errorf("failed to analyze images: %v", err)
+		return 0, err
+	}
+	return info.Pages, nil
+}
+
+// analyzeImages uses pdfcpu to find images that might be unwanted elements
+// debugLog is a function to collect debug messages (can be nil)
+func analyzeImages(ctx context.Context, filename string, totalPages int, opts AnalysisOptions, sources pageSources, debugLog func(string, ...interface{})) (*imageAnalysisResult, error) {
+	result := &imageAnalysisResult{}
+
+	if debugLog != nil {
+		debugLog("[DEBUG] Starting unwanted elements analysis for file: %s (total pages: %d)", filename, totalPages)
+	}
+	
+	allImages, err := listImages(ctx, filename, debugLog)
+	if err != nil {
+		return nil, err
+	}
+
+	// First pass: collect all images by page
+	// With a prefix filter, other images are skipped here and never grouped
+	var prefixFilter map[string]bool
+	if len(opts.PrefixFilter) > 0 {
+		prefixFilter = make(map[string]bool)
+		for _, prefix := range opts.PrefixFilter {
+			prefixFilter[prefix] = true
+		}
+	}
+	imagesByPage := make(map[int][]imageInfo)
+	skipped := 0
+	for _, img := range allImages {
+		if prefixFilter != nil && !prefixFilter[extractIdPrefix(img.id)] {
+			skipped++
+			continue
+		}
+		imagesByPage[img.page] = append(imagesByPage[img.page], imageInfo{
+			id:         img.id,
+			obj:        img.obj,
+			width:      img.width,
+			height:     img.height,
+			size:       img.size,
+			softMask:   img.softMask == "*",
+			imgMask:    img.imgMask == "*",
+			colorSpace: img.colorSpace,
+		})
+	}
+
+	if prefixFilter != nil && debugLog != nil {
+		debugLog("[DEBUG] Prefix filter %v: skipped %d of %d image occurrences", opts.PrefixFilter, skipped, len(allImages))
+	}
+
+	// Drop low-resolution images (hairlines, rules) when a DPI threshold is set
+	if opts.MinDPI > 0 {
+		runEnrichment("dpi_filter", debugLog, func() error {
+			filtered, err := filterImagesByDPI(imagesByPage, opts.MinDPI, sources, debugLog)
+			if err != nil {
+				return err
+			}
+			imagesByPage = filtered
+			return nil
+		})
+	}
+
+	// Second pass: identify repeating unwanted element patterns
+	candidates := []UnwantedElementCandidate{}
+
+	// Check for images that appear on many pages (80%+ for broader detection)
+	maxPages := totalPages
+	minPages := int(float64(totalPages) * MinPageCoverageThreshold)
+	if debugLog != nil {
+		debugLog("[DEBUG] Minimum pages for watermark detection: %d (%.0f%% of %d total pages)", minPages, MinPageCoverageThreshold*100, totalPages)
+	}
+
+	// Group images by similar characteristics (size, position indicators, and naming patterns)
+	imageSignatures := make(map[string][]int) // signature -> list of pages
+	// Also group by prefix for enhanced detection
+	imagesByPrefix := make(map[string][]imageWithPage) // prefix -> list of images with page numbers
+
+	// Cap the number of distinct signatures and prefixes tracked, so PDFs where every image
+	// is unique cannot grow the maps without bound. Groups already tracked keep growing;
+	// pages are visited in order so the same groups are kept on every run.
+	maxGroups := opts.MaxTrackedGroups
+	if maxGroups <= 0 {
+		maxGroups = DefaultMaxTrackedGroups
+	}
+	signatureCapHit, prefixCapHit := false, false
+	sortedPages := make([]int, 0, len(imagesByPage))
+	for page := range imagesByPage {
+		sortedPages = append(sortedPages, page)
+	}
+	sort.Ints(sortedPages)
+
+	for _, page := range sortedPages {
+		for _, img := range imagesByPage[page] {
+			// Create enhanced signature including naming patterns for watermark detection
+			// Include image ID prefix for publisher unwanted element patterns (e.g., "Image-")
+			prefix := extractIdPrefix(img.id)
+			signature := imageSignature(img)
+			if _, tracked := imageSignatures[signature]; tracked || len(imageSignatures) < maxGroups {
+				imageSignatures[signature] = append(imageSignatures[signature], page)
+			} else if !signatureCapHit {
+				signatureCapHit = true
+				result.groupsCapped = true
+				if debugLog != nil {
+					debugLog("[DEBUG] Signature limit of %d reached at page %d; new signatures are no longer tracked", maxGroups, page)
+				}
+			}
+
+			// Group by prefix for enhanced detection
+			if _, tracked := imagesByPrefix[prefix]; prefix != "unknown" && !tracked && len(imagesByPrefix) >= maxGroups {
+				if !prefixCapHit {
+					prefixCapHit = true
+					result.groupsCapped = true
+					if debugLog != nil {
+						debugLog("[DEBUG] Prefix limit of %d reached at page %d; new prefixes are no longer tracked", maxGroups, page)
+					}
+				}
+			} else if prefix != "unknown" {
+				imagesByPrefix[prefix] = append(imagesByPrefix[prefix], imageWithPage{img: img, page: page})
+				if debugLog != nil {
+					debugLog("[DEBUG] Grouped image by prefix '%s': Page %d, ID: %s, Size: %s", prefix, page, img.id, img.size)
+				}
+			}
+		}
+	}
+	
+	if debugLog != nil {
+		debugLog("[DEBUG] Prefix groups found: %d", len(imagesByPrefix))
+		for prefix, imgs := range imagesByPrefix {
+			debugLog("[DEBUG]   Prefix '%s': %d images", prefix, len(imgs))
+		}
+		debugLog("[DEBUG] Image signatures found: %d", len(imageSignatures))
+	}
+
+	// PRIORITY DETECTION: Images appearing on ALL pages with same prefix and size >= 30KB
+	if debugLog != nil {
+		debugLog("[DEBUG] Starting full-page unwanted element detection...")
+	}
+	fullPageCandidates := detectFullPageUnwantedElements(imagesByPrefix, totalPages, imageSignatures, debugLog)
+	if debugLog != nil {
+		debugLog("[DEBUG] Full-page unwanted element candidates found: %d", len(fullPageCandidates))
+		for i, candidate := range fullPageCandidates {
+			debugLog("[DEBUG]   Candidate %d: %s (confidence: %.1f%%)", i+1, candidate.Description, candidate.Confidence*100)
+		}
+	}
+	candidates = append(candidates, fullPageCandidates...)
+	if opts.stopsEarlyAt(fullPageCandidates) {
+		if debugLog != nil {
+			debugLog("[DEBUG] Early exit: definitive full-page candidate found, skipping remaining detection")
+		}
+		result.candidates = candidates
+		result.images = allImages
+		result.earlyExit = true
+		return result, nil
+	}
+	
+	// Track which signatures we've already handled to avoid duplicates
+	handledSignatures := make(map[string]bool)
+		for _, candidate := range fullPageCandidates {
+			if sig, ok := candidate.Metadata["signature"]; ok {
+				handledSignatures[sig] = true
+			}
+			if members := candidate.Metadata["member_signatures"]; members != "" {
+				for _, sig := range strings.Split(members, "|") {
+					handledSignatures[sig] = true
+				}
+			}
+		}
+
+		// Find signatures that appear on many pages (but not all - those were handled above)
+	for signature, pages := range imageSignatures {
+			if handledSignatures[signature] {
+				continue // Skip if already detected as full-page unwanted element
+			}
+			
+		// Recto/verso watermarks cover only the odd or only the even pages, so they never
+		// reach whole-document coverage; check each parity on its own as well
+		widespread := len(pages) >= minPages || hasContinuousRange(pages, minPages)
+		parity := ""
+		if !widespread {
+			parity = parityOnlyCoverage(pages, totalPages)
+		}
+		if widespread || parity != "" {
+				// This is likely a repeating unwanted element image
+				// Either widespread (>=80% of pages) OR continuous range (>=80% consecutive pages)
+				// OR >=80% of the odd or even pages
+			// Use the first occurrence as representative
+			firstPage := pages[0]
+			firstImg := imageInfo{}
+
+			// Find the image data for this page
+			for _, img := range imagesByPage[firstPage] {
+				testSig := imageSignature(img)
+				if testSig == signature {
+					firstImg = img
+					break
+				}
+			}
+
+				sigPreview := signature
+				if len(sigPreview) > 20 {
+					sigPreview = sigPreview[:20] + "..."
+				}
+				if debugLog != nil {
+					debugLog("[DEBUG] Repeating unwanted element detected - Signature: %s, Pages: %d/%d, ID: %s, Size: %s",
+						sigPreview, len(pages), maxPages, firstImg.id, firstImg.size)
+				}
+
+				// Calculate enhanced confidence for repeating unwanted elements
+				// Parity candidates are scored against the pages of their parity
+				confidence := calculateRepeatingUnwantedElementConfidence(firstImg, len(pages), maxPages)
+				switch parity {
+				case "odd":
+					confidence = calculateRepeatingUnwantedElementConfidence(firstImg, min(len(pages), (totalPages+1)/2), (totalPages+1)/2)
+				case "even":
+					confidence = calculateRepeatingUnwantedElementConfidence(firstImg, min(len(pages), totalPages/2), totalPages/2)
+				}
+
+				// Extract prefix from signature for better description
+				prefix := extractIdPrefix(firstImg.id)
+				description := fmt.Sprintf("Repeating unwanted element image: size %dx%d (%s), appears on %d/%d pages (%s)",
+					firstImg.width, firstImg.height, firstImg.colorSpace, len(pages), maxPages, firstImg.size)
+				if prefix != "unknown" && prefix != "" {
+					description = fmt.Sprintf("Repeating unwanted element image (prefix '%s'): size %dx%d (%s), file size %s, appears on %d/%d pages",
+						prefix, firstImg.width, firstImg.height, firstImg.colorSpace, firstImg.size, len(pages), maxPages)
+				}
+
+				if parity != "" {
+					description += fmt.Sprintf(" (%s pages only)", parity)
+				}
+
+				candidate := UnwantedElementCandidate{
+				Type: "image",
+					ID:   fmt.Sprintf("repeating_unwanted_element_%s", signature[:8]), // Use signature hash for unique ID
+					Page: 0,                                                          // Appears on multiple pages
+					Description: description,
+				Confidence: confidence,
+				Metadata: imageCandidateMetadata("repeating_unwanted_element", firstImg, signature, prefix, len(pages), maxPages),
+				Pages:    sortedPageSet(pageSetOf(pages)),
+			}
+			if parity != "" {
+				candidate.Metadata["parity"] = parity
+			}
+
+				if debugLog != nil {
+					debugLog("[DEBUG]   Created repeating unwanted element candidate: %s (confidence: %.1f%%)", candidate.Description, candidate.Confidence*100)
+				}
+			candidates = append(candidates, candidate)
+		}
 	}
-	analysis.ImageCandidates = imageCandidates
 
-	// Analyze content for potential unwanted text elements
-	textCandidates, err := analyzeContent(filename, pages)
-	if err != nil {
-		return nil, fmt.Errorf("failed to analyze content: %v", err)
+		if debugLog != nil {
+			debugLog("[DEBUG] Repeating unwanted element candidates found: %d", len(candidates)-len(fullPageCandidates))
+		}
+
+		// Skip individual suspicious images - only show images that appear on 80%+ pages
+		// Individual images below the threshold are not shown as they're less likely to be unwanted elements
+		if debugLog != nil {
+			debugLog("[DEBUG] Skipping individual images below 80%% threshold (only showing repeating unwanted elements)")
+		}
+
+	if opts.stopsEarlyAt(candidates) {
+		if debugLog != nil {
+			debugLog("[DEBUG] Early exit: definitive repeating candidate found, skipping remaining detection")
+		}
+		result.candidates = candidates
+		result.images = allImages
+		result.earlyExit = true
+		return result, nil
 	}
-	analysis.TextCandidates = textCandidates
 
-	// Calculate overall confidence
-	totalCandidates := len(analysis.ImageCandidates) + len(analysis.TextCandidates)
-	if totalCandidates > 0 {
-		analysis.OverallConfidence = 0.5 // Base confidence if candidates found
-		if totalCandidates > analysis.TotalPages {
-			analysis.OverallConfidence = 0.8 // High if many candidates relative to pages
+	// Deep matching finds byte-identical repeats, but extracts and hashes every image,
+	// so it only runs when the number of distinct images is bounded
+	if opts.DeepMatch {
+		maxDeepImages := opts.MaxDeepMatchImages
+		if maxDeepImages <= 0 {
+			maxDeepImages = DefaultMaxDeepMatchImages
+		}
+		if distinct := countDistinctImages(allImages); distinct > maxDeepImages {
+			result.deepMatchSkipped = true
+			if debugLog != nil {
+				debugLog("[DEBUG] Skipping deep image matching: %d distinct images exceeds limit of %d", distinct, maxDeepImages)
+			}
+		} else {
+			runEnrichment("deep_match", debugLog, func() error {
+				identical, err := detectIdenticalImages(ctx, filename, totalPages, imagesByPage, candidates, debugLog)
+				if err != nil {
+					return err
+				}
+				candidates = append(candidates, identical...)
+				return nil
+			})
 		}
-	} else {
-		analysis.OverallConfidence = 0.0 // No candidates found
 	}
 
-	// Add recommendations
-	if len(analysis.ImageCandidates) > 0 {
-		analysis.Recommendations = append(analysis.Recommendations,
-			"Images detected that may be unwanted elements - review and select for removal")
+	// Count different types of candidates
+	repeatingCount := 0
+	individualCount := 0
+	for _, c := range candidates {
+		if c.Metadata["type"] == "repeating_unwanted_element" {
+			repeatingCount++
+		} else if c.Page > 0 {
+			individualCount++
+		}
 	}
-	if len(analysis.TextCandidates) > 0 {
-		analysis.Recommendations = append(analysis.Recommendations,
-			"Text elements detected that may be unwanted elements - review and select for removal")
+		if debugLog != nil {
+			debugLog("[DEBUG] Total unwanted element candidates found: %d (full-page: %d, repeating: %d, individual: %d)",
+				len(candidates), len(fullPageCandidates), repeatingCount, individualCount)
 	}
-	if len(analysis.ImageCandidates) == 0 && len(analysis.TextCandidates) == 0 {
-		analysis.Recommendations = append(analysis.Recommendations,
-			"No obvious unwanted element candidates found - the PDF may not contain unwanted elements")
+
+	if opts.ClassifyPlacement {
+		runEnrichment("placement", debugLog, func() error {
+			return classifyCandidatePlacements(candidates, imagesByPage, opts, sources, debugLog)
+		})
 	}
 
-	return analysis, nil
+	result.candidates = candidates
+	result.images = allImages
+	return result, nil
 }
 
-// getPageCount extracts the total number of pages from PDF
-func getPageCount(filename string) (int, error) {
-	output, err := execCommandWithTimeout(DefaultCLITimeout, "pdfcpu", "info", filename)
-	if err != nil {
-		return 0, fmt.Errorf("pdfcpu info failed: %v", err)
+// listImages lists every image occurrence of a PDF with pdfcpu images list. The JSON
+// output of newer pdfcpu versions is preferred; the table output is parsed only if JSON
+// is unavailable or yields no images.
+func listImages(ctx context.Context, filename string, debugLog func(string, ...interface{})) ([]rawImageData, error) {
+	images, err := listImagesJSON(ctx, filename)
+	if err == nil && len(images) > 0 {
+		if debugLog != nil {
+			debugLog("[DEBUG] Parsed %d images from pdfcpu images list JSON output", len(images))
+		}
+		return images, nil
 	}
-
-	outputStr := string(output)
-
-	// Look for page count in various formats
-	patterns := []string{
-		"Page count:\\s+(\\d+)",     // "Page count: 426" (pdfcpu v0.11.1 format)
-		"Pages:\\s+(\\d+)",          // "Pages: 10"
-		"pages\\s*=\\s*(\\d+)",      // "pages = 10"
-		"No\\. of pages:\\s+(\\d+)", // "No. of pages: 10"
-		"Pages: (\\d+)",             // "Pages: 10" (exact format)
+	// A slow document would only time out a second time
+	if errors.Is(err, ErrCommandTimeout) || errors.Is(err, ErrCommandCancelled) {
+		return nil, fmt.Errorf("pdfcpu images list failed: %w", err)
 	}
-
-	for _, pattern := range patterns {
-		re := regexp.MustCompile(pattern)
-		matches := re.FindStringSubmatch(outputStr)
-		if len(matches) > 1 {
-			if pageCount, err := strconv.Atoi(matches[1]); err == nil {
-				return pageCount, nil
-			}
+	if debugLog != nil {
+		if err != nil {
+			debugLog("[DEBUG] pdfcpu images list JSON unavailable, parsing table output: %v", err)
+		} else {
+			debugLog("[DEBUG] pdfcpu images list JSON listed no images, parsing table output")
 		}
 	}
-
-	// Debug: include actual output in error
-	return 0, fmt.Errorf("could not determine page count from output: %s", outputStr)
+	return listImagesText(ctx, filename, debugLog)
 }
 
-// analyzeImages uses pdfcpu to find images that might be unwanted elements
-// debugLog is a function to collect debug messages (can be nil)
-func analyzeImages(filename string, totalPages int, debugLog func(string, ...interface{})) ([]UnwantedElementCandidate, error) {
-	if debugLog != nil {
-		debugLog("[DEBUG] Starting unwanted elements analysis for file: %s (total pages: %d)", filename, totalPages)
-	}
-	
-	output, err := execCommandWithTimeout(AnalysisTimeout, "pdfcpu", "images", "list", filename)
+// listImagesText runs pdfcpu images list and parses every image occurrence from its table output
+func listImagesText(ctx context.Context, filename string, debugLog func(string, ...interface{})) ([]rawImageData, error) {
+	name, args := imagesListCommand(filename)
+	output, err := execCommandContext(ctx, AnalysisTimeout, name, args...)
 	if err != nil {
-		return nil, fmt.Errorf("pdfcpu images list failed: %v", err)
+		return nil, fmt.Errorf("pdfcpu images list failed: %w", err)
 	}
 
 	if debugLog != nil {
@@ -183,12 +966,11 @@ func analyzeImages(filename string, totalPages int, debugLog func(string, ...int
 		debugLog("[DEBUG] pdfcpu output sample (first 500 chars):\n%s", outputSample)
 	}
 
-	// First pass: collect all images by page
-	imagesByPage := make(map[int][]imageInfo)
 	var allImages []rawImageData
+	pagesWithImages := make(map[int]bool)
 
 	// Parse the table output to extract image information
-	lines := strings.Split(string(output), "\n")
+	lines := outputLines(string(output))
 	inTable := false
 	headerLine := ""
 	headerFound := false
@@ -383,21 +1165,12 @@ func analyzeImages(filename string, totalPages int, debugLog func(string, ...int
 		}
 
 			allImages = append(allImages, rawImg)
-			imagesByPage[page] = append(imagesByPage[page], imageInfo{
-				id:         idStr,
-				obj:        objStr,
-				width:      width,
-				height:     height,
-				size:       sizeStr,
-				softMask:   softMask == "*",
-				imgMask:    imgMask == "*",
-				colorSpace: colorSpace,
-			})
+			pagesWithImages[page] = true
 		}
 	
 	if debugLog != nil {
 		debugLog("[DEBUG] Total lines processed: %d, Lines skipped: %d", linesProcessed, linesSkipped)
-		debugLog("[DEBUG] Total images parsed: %d, Images by page count: %d", len(allImages), len(imagesByPage))
+		debugLog("[DEBUG] Total images parsed: %d, Images by page count: %d", len(allImages), len(pagesWithImages))
 		if len(allImages) == 0 && linesProcessed > 0 {
 			debugLog("[DEBUG] WARNING: Processed %d lines but parsed 0 images. Format might be unexpected.", linesProcessed)
 			// Show sample of what was processed
@@ -419,177 +1192,178 @@ func analyzeImages(filename string, totalPages int, debugLog func(string, ...int
 		}
 	}
 
-	// Second pass: identify repeating unwanted element patterns
-	candidates := []UnwantedElementCandidate{}
+	return allImages, nil
+}
 
-	// Check for images that appear on many pages (80%+ for broader detection)
-	maxPages := totalPages
-	minPages := int(float64(totalPages) * MinPageCoverageThreshold)
-	if debugLog != nil {
-		debugLog("[DEBUG] Minimum pages for watermark detection: %d (%.0f%% of %d total pages)", minPages, MinPageCoverageThreshold*100, totalPages)
+// runEnrichment runs an optional analysis signal that depends on content stream or geometry data
+// If it fails the signal is skipped and noted in the debug logs, so the coverage and
+// size-based candidates are still returned. Returns true if the enrichment succeeded.
+func runEnrichment(name string, debugLog func(string, ...interface{}), enrich func() error) bool {
+	if err := enrich(); err != nil {
+		if debugLog != nil {
+			debugLog("[DEBUG] Optional enrichment '%s' unavailable, skipping: %v", name, err)
+		} else {
+			log.Printf("Optional enrichment '%s' unavailable, skipping: %v", name, err)
+		}
+		return false
 	}
+	return true
+}
 
-	// Group images by similar characteristics (size, position indicators, and naming patterns)
-	imageSignatures := make(map[string][]int) // signature -> list of pages
-	// Also group by prefix for enhanced detection
-	imagesByPrefix := make(map[string][]imageWithPage) // prefix -> list of images with page numbers
+// filterImagesByDPI removes images whose effective DPI on the page is below minDPI
+// Images without placement data on a page are kept
+func filterImagesByDPI(imagesByPage map[int][]imageInfo, minDPI float64, sources pageSources, debugLog func(string, ...interface{})) (map[int][]imageInfo, error) {
+	geometry, err := sources.geometry()
+	if err != nil {
+		return nil, err
+	}
+	contents, err := sources.contents()
+	if err != nil {
+		return nil, err
+	}
+	placements := imagePlacementsByPage(contents)
 
+	filtered := make(map[int][]imageInfo)
+	dropped := 0
 	for page, imgs := range imagesByPage {
 		for _, img := range imgs {
-			// Create enhanced signature including naming patterns for watermark detection
-			// Include image ID prefix for publisher unwanted element patterns (e.g., "Image-")
-			prefix := extractIdPrefix(img.id)
-			signature := fmt.Sprintf("%dx%d_%s_%s_prefix:%s", img.width, img.height, img.colorSpace, img.size, prefix)
-			imageSignatures[signature] = append(imageSignatures[signature], page)
-			
-			// Group by prefix for enhanced detection
-			if prefix != "unknown" {
-				imagesByPrefix[prefix] = append(imagesByPrefix[prefix], imageWithPage{img: img, page: page})
+			dpi := 0.0
+			for _, placement := range placements[page] {
+				if placement.name != img.id {
+					continue
+				}
+				bbox := placement.bbox
+				if geo, ok := geometry[page]; ok {
+					bbox = clipToPage(bbox, geo)
+				}
+				// An image drawn several times counts at its sharpest placement
+				dpi = max(dpi, effectiveDPI(img.width, img.height, bbox))
+			}
+
+			if dpi > 0 && dpi < minDPI {
+				dropped++
 				if debugLog != nil {
-					debugLog("[DEBUG] Grouped image by prefix '%s': Page %d, ID: %s, Size: %s", prefix, page, img.id, img.size)
+					debugLog("[DEBUG] Dropping image %s on page %d: effective DPI %.1f < %.1f", img.id, page, dpi, minDPI)
 				}
+				continue
 			}
+			filtered[page] = append(filtered[page], img)
 		}
 	}
-	
-	if debugLog != nil {
-		debugLog("[DEBUG] Prefix groups found: %d", len(imagesByPrefix))
-		for prefix, imgs := range imagesByPrefix {
-			debugLog("[DEBUG]   Prefix '%s': %d images", prefix, len(imgs))
-		}
-		debugLog("[DEBUG] Image signatures found: %d", len(imageSignatures))
-	}
 
-	// PRIORITY DETECTION: Images appearing on ALL pages with same prefix and size >= 30KB
-	if debugLog != nil {
-		debugLog("[DEBUG] Starting full-page unwanted element detection...")
-	}
-	fullPageCandidates := detectFullPageUnwantedElements(imagesByPrefix, totalPages, imageSignatures, debugLog)
 	if debugLog != nil {
-		debugLog("[DEBUG] Full-page unwanted element candidates found: %d", len(fullPageCandidates))
-		for i, candidate := range fullPageCandidates {
-			debugLog("[DEBUG]   Candidate %d: %s (confidence: %.1f%%)", i+1, candidate.Description, candidate.Confidence*100)
-		}
+		debugLog("[DEBUG] DPI threshold %.1f dropped %d image occurrences", minDPI, dropped)
 	}
-	candidates = append(candidates, fullPageCandidates...)
-	
-	// Track which signatures we've already handled to avoid duplicates
-	handledSignatures := make(map[string]bool)
-		for _, candidate := range fullPageCandidates {
-			if sig, ok := candidate.Metadata["signature"]; ok {
-				handledSignatures[sig] = true
-			}
-		}
+	return filtered, nil
+}
 
-		// Find signatures that appear on many pages (but not all - those were handled above)
-	for signature, pages := range imageSignatures {
-			if handledSignatures[signature] {
-				continue // Skip if already detected as full-page unwanted element
-			}
-			
-		if len(pages) >= minPages || hasContinuousRange(pages, minPages) {
-				// This is likely a repeating unwanted element image
-				// Either widespread (>=80% of pages) OR continuous range (>=80% consecutive pages)
-			// Use the first occurrence as representative
-			firstPage := pages[0]
-			firstImg := imageInfo{}
+// analyzeContent looks for text that might be unwanted elements
+// Text drawn rotated or at a very large size (e.g. a diagonal "DRAFT") that repeats on
+// most pages is reported as a text watermark candidate; other text repeated at the same
+// height on most pages (running headers and footers) as a lower-confidence repeated text
+// candidate
+func analyzeContent(contents map[int]string, totalPages int, debugLog func(string, ...interface{})) ([]UnwantedElementCandidate, error) {
+	candidates := []UnwantedElementCandidate{}
 
-			// Find the image data for this page
-			for _, img := range imagesByPage[firstPage] {
-				testSig := fmt.Sprintf("%dx%d_%s_%s", img.width, img.height, img.colorSpace, img.size)
-				if testSig == signature {
-					firstImg = img
-					break
+	// Group watermark-like text blocks by text, rotation and size across pages, and
+	// other text blocks by text and height
+	type textGroup struct {
+		block     textBlock
+		pages     map[int]bool
+		watermark bool
+	}
+	groups := make(map[string]*textGroup)
+	for page, content := range contents {
+		for _, block := range findTextBlocks(content) {
+			watermark := math.Abs(block.rotation) >= TextWatermarkMinRotation || block.fontSize >= TextWatermarkMinFontSize
+			var key string
+			if watermark {
+				key = fmt.Sprintf("%s|%.0f|%.0f", block.text, block.rotation, block.fontSize)
+			} else {
+				if len([]rune(block.text)) < RepeatedTextMinLength {
+					continue
 				}
+				key = fmt.Sprintf("repeated|%s|%.0f", block.text, math.Round(block.y/RepeatedTextPositionTolerance))
 			}
+			group, ok := groups[key]
+			if !ok {
+				group = &textGroup{block: block, pages: make(map[int]bool), watermark: watermark}
+				groups[key] = group
+			}
+			group.pages[page] = true
+		}
+	}
 
-				sigPreview := signature
-				if len(sigPreview) > 20 {
-					sigPreview = sigPreview[:20] + "..."
-				}
-				if debugLog != nil {
-					debugLog("[DEBUG] Repeating unwanted element detected - Signature: %s, Pages: %d/%d, ID: %s, Size: %s",
-						sigPreview, len(pages), maxPages, firstImg.id, firstImg.size)
-				}
-
-				// Calculate enhanced confidence for repeating unwanted elements
-				confidence := calculateRepeatingUnwantedElementConfidence(firstImg, len(pages), maxPages)
-
-				// Extract prefix from signature for better description
-				prefix := extractIdPrefix(firstImg.id)
-				description := fmt.Sprintf("Repeating unwanted element image: size %dx%d (%s), appears on %d/%d pages (%s)",
-					firstImg.width, firstImg.height, firstImg.colorSpace, len(pages), maxPages, firstImg.size)
-				if prefix != "unknown" && prefix != "" {
-					description = fmt.Sprintf("Repeating unwanted element image (prefix '%s'): size %dx%d (%s), file size %s, appears on %d/%d pages",
-						prefix, firstImg.width, firstImg.height, firstImg.colorSpace, firstImg.size, len(pages), maxPages)
-				}
+	minPages := int(float64(totalPages) * MinPageCoverageThreshold)
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
 
-				candidate := UnwantedElementCandidate{
-				Type: "image",
-					ID:   fmt.Sprintf("repeating_unwanted_element_%s", signature[:8]), // Use signature hash for unique ID
-					Page: 0,                                                          // Appears on multiple pages
-					Description: description,
-				Confidence: confidence,
+	for _, key := range keys {
+		group := groups[key]
+		if len(group.pages) == 0 || len(group.pages) < minPages {
+			continue
+		}
+		coverage := float64(len(group.pages)) / float64(totalPages)
+		sum := sha1.Sum([]byte(key))
+		if !group.watermark {
+			candidate := UnwantedElementCandidate{
+				Type: "text",
+				ID:   fmt.Sprintf("repeated_text_%s", hex.EncodeToString(sum[:4])),
+				Page: 0, // Appears on multiple pages
+				Description: fmt.Sprintf("Repeated text \"%s\" at y=%.0f: appears on %d/%d pages",
+					group.block.text, group.block.y, len(group.pages), totalPages),
+				// Running headers and footers are often wanted, so they stay below auto-clean's default
+				Confidence: 0.4 + coverage*0.25,
 				Metadata: map[string]string{
-					"signature":  signature,
-					"page_count": strconv.Itoa(len(pages)),
-					"max_pages":  strconv.Itoa(maxPages),
-						"prefix":     prefix,
-					"soft_mask":  strconv.FormatBool(firstImg.softMask),
-					"image_mask": strconv.FormatBool(firstImg.imgMask),
-						"type":       "repeating_unwanted_element",
-						"object":     firstImg.obj,     // Store object number for removal
-						"image_id":   firstImg.id,      // Store image ID for removal
+					"text":        group.block.text,
+					"font_size":   fmt.Sprintf("%.1f", group.block.fontSize),
+					"x":           fmt.Sprintf("%.1f", group.block.x),
+					"y":           fmt.Sprintf("%.1f", group.block.y),
+					"page_count":  strconv.Itoa(len(group.pages)),
+					"total_pages": strconv.Itoa(totalPages),
+					"coverage":    fmt.Sprintf("%.0f%%", coverage*100),
+					"type":        "repeated_text",
 				},
+				Pages: sortedPageSet(group.pages),
+			}
+			if debugLog != nil {
+				debugLog("[DEBUG] Repeated text candidate: %s (confidence: %.1f%%)", candidate.Description, candidate.Confidence*100)
 			}
-
-				if debugLog != nil {
-					debugLog("[DEBUG]   Created repeating unwanted element candidate: %s (confidence: %.1f%%)", candidate.Description, candidate.Confidence*100)
-				}
 			candidates = append(candidates, candidate)
+			continue
 		}
-	}
-
-		if debugLog != nil {
-			debugLog("[DEBUG] Repeating unwanted element candidates found: %d", len(candidates)-len(fullPageCandidates))
+		candidate := UnwantedElementCandidate{
+			Type: "text",
+			ID:   fmt.Sprintf("text_watermark_%s", hex.EncodeToString(sum[:4])),
+			Page: 0, // Appears on multiple pages
+			Description: fmt.Sprintf("Text watermark \"%s\": rotated %.0f°, font size %.0f, appears on %d/%d pages",
+				group.block.text, group.block.rotation, group.block.fontSize, len(group.pages), totalPages),
+			Confidence: 0.6 + coverage*0.35,
+			Metadata: map[string]string{
+				"text":        group.block.text,
+				"rotation":    fmt.Sprintf("%.1f", group.block.rotation),
+				"font_size":   fmt.Sprintf("%.1f", group.block.fontSize),
+				"x":           fmt.Sprintf("%.1f", group.block.x),
+				"y":           fmt.Sprintf("%.1f", group.block.y),
+				"page_count":  strconv.Itoa(len(group.pages)),
+				"total_pages": strconv.Itoa(totalPages),
+				"coverage":    fmt.Sprintf("%.0f%%", coverage*100),
+				"type":        "text_watermark",
+			},
+			Pages: sortedPageSet(group.pages),
 		}
-
-		// Skip individual suspicious images - only show images that appear on 80%+ pages
-		// Individual images below the threshold are not shown as they're less likely to be unwanted elements
 		if debugLog != nil {
-			debugLog("[DEBUG] Skipping individual images below 80%% threshold (only showing repeating unwanted elements)")
-		}
-
-	// Count different types of candidates
-	repeatingCount := 0
-	individualCount := 0
-	for _, c := range candidates {
-		if c.Metadata["type"] == "repeating_unwanted_element" {
-			repeatingCount++
-		} else if c.Page > 0 {
-			individualCount++
+			debugLog("[DEBUG] Text watermark candidate: %s (confidence: %.1f%%)", candidate.Description, candidate.Confidence*100)
 		}
-	}
-		if debugLog != nil {
-			debugLog("[DEBUG] Total unwanted element candidates found: %d (full-page: %d, repeating: %d, individual: %d)",
-				len(candidates), len(fullPageCandidates), repeatingCount, individualCount)
+		candidates = append(candidates, candidate)
 	}
 
 	return candidates, nil
 }
 
-// analyzeContent looks for text that might be unwanted elements
-func analyzeContent(filename string, totalPages int) ([]UnwantedElementCandidate, error) {
-	candidates := []UnwantedElementCandidate{}
-
-	// For now, return empty list - content analysis is complex
-	// Could be enhanced to extract text and detect repeating patterns
-	// Using pdfcpu extract -mode content filename outputdir/
-	// Then analyze the raw content for watermark-like text
-
-	return candidates, nil
-}
-
 // detectFullPageUnwantedElements detects images that appear on ALL pages with same prefix and size >= 30KB
 func detectFullPageUnwantedElements(imagesByPrefix map[string][]imageWithPage, totalPages int, imageSignatures map[string][]int, debugLog func(string, ...interface{})) []UnwantedElementCandidate {
 	candidates := []UnwantedElementCandidate{}
@@ -646,6 +1420,7 @@ func detectFullPageUnwantedElements(imagesByPrefix map[string][]imageWithPage, t
 		}
 		
 		// Check each size group to see if it covers all pages
+		claimedSizes := make(map[string]bool) // size groups that produced a candidate
 		for sizeKey, sizeGroup := range imagesBySize {
 			if len(sizeGroup) < totalPages {
 				continue
@@ -676,9 +1451,7 @@ func detectFullPageUnwantedElements(imagesByPrefix map[string][]imageWithPage, t
 				if debugLog != nil {
 					debugLog("[DEBUG]       ✓ Meets %.0f%% threshold! Creating watermark candidate...", MinPageCoverageThreshold*100)
 				}
-				signature := fmt.Sprintf("%dx%d_%s_%s_prefix:%s", 
-					representativeImg.width, representativeImg.height, 
-					representativeImg.colorSpace, representativeImg.size, prefix)
+				signature := imageSignature(representativeImg)
 				
 				// Confidence based on coverage: 100% = 95%, 80%+ = 85-95%
 				var confidence float64
@@ -706,31 +1479,25 @@ func detectFullPageUnwantedElements(imagesByPrefix map[string][]imageWithPage, t
 					Page: 0, // Appears on multiple pages
 					Description: description,
 					Confidence: confidence,
-					Metadata: map[string]string{
-						"signature":    signature,
-						"prefix":       prefix,
-						"file_size_kb": fmt.Sprintf("%.1f", parseFileSizeKB(representativeImg.size)),
-						"page_count":   strconv.Itoa(coverageCount),
-						"total_pages":  strconv.Itoa(totalPages),
-						"coverage":     coverageStr,
-						"type":         candidateType,
-						"soft_mask":    strconv.FormatBool(representativeImg.softMask),
-						"image_mask":   strconv.FormatBool(representativeImg.imgMask),
-						"object":       representativeImg.obj,    // Store object number for removal
-						"image_id":     representativeImg.id,     // Store image ID for removal
-					},
+					Metadata:    imageCandidateMetadata(candidateType, representativeImg, signature, prefix, coverageCount, totalPages),
+					Pages:       sortedPageSet(pagesCovered),
 				}
 				
 				if debugLog != nil {
 					debugLog("[DEBUG]       Created candidate: %s (confidence: %.1f%%)", candidate.Description, candidate.Confidence*100)
 				}
 				candidates = append(candidates, candidate)
+				claimedSizes[sizeKey] = true
 			} else {
 				if debugLog != nil {
 					debugLog("[DEBUG]       ✗ Does not meet %.0f%% threshold (%.1f%% coverage)", MinPageCoverageThreshold*100, coveragePercent*100)
 				}
 			}
 		}
+
+		// A watermark re-encoded at alternating sizes (e.g. 110KB on odd pages, 118KB on
+		// even pages) splits into size groups that each miss the threshold
+		candidates = append(candidates, combineSizeGroups(prefix, imagesBySize, claimedSizes, totalPages, debugLog)...)
 	}
 	
 	if debugLog != nil {
@@ -739,6 +1506,89 @@ func detectFullPageUnwantedElements(imagesByPrefix map[string][]imageWithPage, t
 	return candidates
 }
 
+// combineSizeGroups creates candidates from size groups of one prefix that did not make a
+// candidate on their own: groups whose images share dimensions and color space are joined,
+// and the union of their pages is checked against the coverage threshold. Member
+// signatures are listed in the "member_signatures" metadata, separated by "|".
+func combineSizeGroups(prefix string, imagesBySize map[string][]imageWithPage, claimedSizes map[string]bool, totalPages int, debugLog func(string, ...interface{})) []UnwantedElementCandidate {
+	// Join unclaimed size groups by dimensions and color space, in size order so IDs are stable
+	sizeKeys := make([]string, 0, len(imagesBySize))
+	for sizeKey := range imagesBySize {
+		if !claimedSizes[sizeKey] {
+			sizeKeys = append(sizeKeys, sizeKey)
+		}
+	}
+	sort.Slice(sizeKeys, func(i, j int) bool {
+		return parseFileSizeKB(sizeKeys[i]) < parseFileSizeKB(sizeKeys[j])
+	})
+	sizesByShape := make(map[string][]string) // "<w>x<h>_<colorspace>" -> size keys
+	shapes := []string{}
+	for _, sizeKey := range sizeKeys {
+		img := imagesBySize[sizeKey][0].img
+		shape := fmt.Sprintf("%dx%d_%s", img.width, img.height, img.colorSpace)
+		if _, ok := sizesByShape[shape]; !ok {
+			shapes = append(shapes, shape)
+		}
+		sizesByShape[shape] = append(sizesByShape[shape], sizeKey)
+	}
+
+	candidates := []UnwantedElementCandidate{}
+	for _, shape := range shapes {
+		sizes := sizesByShape[shape]
+		if len(sizes) < 2 {
+			continue
+		}
+
+		pagesCovered := make(map[int]bool)
+		signatures := []string{}
+		for _, sizeKey := range sizes {
+			for _, imgPage := range imagesBySize[sizeKey] {
+				pagesCovered[imgPage.page] = true
+			}
+			signatures = append(signatures, imageSignature(imagesBySize[sizeKey][0].img))
+		}
+		coverageCount := len(pagesCovered)
+		coveragePercent := float64(coverageCount) / float64(totalPages)
+		if debugLog != nil {
+			debugLog("[DEBUG]   Combined size groups %v for prefix '%s' (%s): %d unique pages (%.1f%% of %d total)",
+				sizes, prefix, shape, coverageCount, coveragePercent*100, totalPages)
+		}
+		if coveragePercent < MinPageCoverageThreshold {
+			continue
+		}
+
+		// Same confidence scale as a single size group
+		representativeImg := imagesBySize[sizes[0]][0].img
+		candidateType := "repeating_watermark"
+		confidence := 0.80 + (coveragePercent * 0.15)
+		if coverageCount == totalPages {
+			candidateType = "fullpage_watermark"
+			confidence = 0.95
+		}
+		sizeList := strings.Join(sizes, "+")
+
+		metadata := imageCandidateMetadata(candidateType, representativeImg, signatures[0], prefix, coverageCount, totalPages)
+		metadata["sizes"] = sizeList
+		metadata["member_signatures"] = strings.Join(signatures, "|")
+		candidate := UnwantedElementCandidate{
+			Type: "image",
+			ID:   fmt.Sprintf("%s_%s_%s", candidateType, prefix, sizeList),
+			Page: 0, // Appears on multiple pages
+			Description: fmt.Sprintf("Unwanted element (prefix '%s'): size %dx%d (%s), alternating file sizes %s, appears on %d/%d pages (%.0f%%)",
+				prefix, representativeImg.width, representativeImg.height, representativeImg.colorSpace,
+				strings.Join(sizes, ", "), coverageCount, totalPages, coveragePercent*100),
+			Confidence: confidence,
+			Metadata:   metadata,
+			Pages:      sortedPageSet(pagesCovered),
+		}
+		if debugLog != nil {
+			debugLog("[DEBUG]       Created combined candidate: %s (confidence: %.1f%%)", candidate.Description, candidate.Confidence*100)
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates
+}
+
 // parseFileSizeKB parses file size string (e.g., "30KB", "35.2kb", "1024B") and returns size in KB
 func parseFileSizeKB(sizeStr string) float64 {
 	sizeStr = strings.TrimSpace(strings.ToUpper(sizeStr))
@@ -866,6 +1716,28 @@ func extractIdPrefix(id string) string {
 	return "unknown"
 }
 
+// parityOnlyCoverage returns "odd" or "even" if pages cover at least MinPageCoverageThreshold
+// of that page parity, or "" otherwise. Each parity needs at least two pages to count, so
+// a two- or three-page document does not flag every single-page image.
+func parityOnlyCoverage(pages []int, totalPages int) string {
+	oddTotal, evenTotal := (totalPages+1)/2, totalPages/2
+	odd, even := 0, 0
+	for page := range pageSetOf(pages) {
+		if page%2 == 1 {
+			odd++
+		} else {
+			even++
+		}
+	}
+	switch {
+	case oddTotal >= 2 && float64(odd) >= float64(oddTotal)*MinPageCoverageThreshold:
+		return "odd"
+	case evenTotal >= 2 && float64(even) >= float64(evenTotal)*MinPageCoverageThreshold:
+		return "even"
+	}
+	return ""
+}
+
 // hasContinuousRange checks if an array of pages contains a continuous range of sufficient length
 func hasContinuousRange(pages []int, minLength int) bool {
 	if len(pages) < minLength {
@@ -0,0 +1,768 @@
+package pdf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// watermarkedPDF is a fake document of n born-digital pages with a text line on each,
+// all carrying the same centered watermark image Im0 (object 10)
+func watermarkedPDF(pages int) *fakePdfcpu {
+	f := &fakePdfcpu{pages: pages, contents: make(map[int]string)}
+	for page := 1; page <= pages; page++ {
+		f.images = append(f.images, fakeImage{Page: page, Obj: 10, ID: "Im0", Width: 600, Height: 400, CS: "DeviceRGB", Size: 40000})
+		f.contents[page] = "q 300 0 0 200 156 296 cm /Im0 Do Q BT /F1 12 Tf 72 720 Td (Body) Tj ET"
+	}
+	return f
+}
+
+func TestAnalysisLoadsPageSourcesOnce(t *testing.T) {
+	fake := installFakeCLI(t, watermarkedPDF(4))
+	inFile := writeFakePDF(t, t.TempDir(), "in.pdf")
+
+	// The DPI filter, placement classification, text, blank page and document type
+	// signals all read page contents or geometry
+	analysis, err := AnalyzeUnwantedElementsWithOptions(inFile, AnalysisOptions{
+		MinDPI:            72,
+		ClassifyPlacement: true,
+		DetectBlankPages:  true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(analysis.ImageCandidates) == 0 {
+		t.Fatal("expected an image candidate for an image on every page")
+	}
+	if class := analysis.ImageCandidates[0].Metadata[MetaPlacementClass]; class == "" {
+		t.Error("candidate was not classified by placement")
+	}
+	if calls := fake.callCount("info", "-pages"); calls != 1 {
+		t.Errorf("page geometry was read %d times, want 1", calls)
+	}
+	if calls := fake.callCount("extract", "-mode", "content"); calls != 1 {
+		t.Errorf("page contents were extracted %d times, want 1", calls)
+	}
+}
+
+func TestRecommendationCatalog(t *testing.T) {
+	installFakeCLI(t, watermarkedPDF(4))
+	inFile := writeFakePDF(t, t.TempDir(), "in.pdf")
+
+	french := map[string]string{
+		MsgImageCandidatesFound: "Images détectées qui peuvent être indésirables - vérifiez-les avant suppression",
+		MsgDigitalDocument:      "Document numérique - les filigranes peuvent généralement être supprimés",
+	}
+	analysis, err := AnalyzeUnwantedElementsWithOptions(inFile, AnalysisOptions{Messages: french})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	byID := make(map[string]string)
+	for _, rec := range analysis.RecommendationDetails {
+		byID[rec.ID] = rec.Message
+		if !slices.Contains(analysis.Recommendations, rec.Message) {
+			t.Errorf("recommendation %s is missing from the plain list", rec.ID)
+		}
+	}
+	for id, want := range french {
+		if byID[id] != want {
+			t.Errorf("%s = %q, want the catalog text %q", id, byID[id], want)
+		}
+	}
+	for id, got := range byID {
+		if _, translated := french[id]; !translated && got != DefaultMessages[id] {
+			t.Errorf("%s = %q, want the default %q for a message missing from the catalog", id, got, DefaultMessages[id])
+		}
+	}
+}
+
+func TestAnalysisWithoutGeometry(t *testing.T) {
+	fake := installFakeCLI(t, watermarkedPDF(4))
+	fake.respond = func(args []string) (string, bool, error) {
+		if args[0] == "info" && slices.Contains(args, "-pages") {
+			return "", true, errors.New("page boxes unavailable")
+		}
+		return "", false, nil
+	}
+	inFile := writeFakePDF(t, t.TempDir(), "in.pdf")
+
+	base, err := AnalyzeUnwantedElements(inFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	analysis, err := AnalyzeUnwantedElementsWithOptions(inFile, AnalysisOptions{
+		MinDPI:            72,
+		ClassifyPlacement: true,
+		DetectBlankPages:  true,
+	})
+	if err != nil {
+		t.Fatalf("analysis failed without geometry: %v", err)
+	}
+	if len(analysis.ImageCandidates) == 0 || len(analysis.ImageCandidates) != len(base.ImageCandidates) {
+		t.Fatalf("%d image candidates, want the %d of the base analysis", len(analysis.ImageCandidates), len(base.ImageCandidates))
+	}
+	for i, candidate := range analysis.ImageCandidates {
+		if candidate.ID != base.ImageCandidates[i].ID || candidate.Confidence != base.ImageCandidates[i].Confidence {
+			t.Errorf("candidate %d = %s (%.2f), want %s (%.2f)", i, candidate.ID, candidate.Confidence, base.ImageCandidates[i].ID, base.ImageCandidates[i].Confidence)
+		}
+		if class := candidate.Metadata[MetaPlacementClass]; class != "" {
+			t.Errorf("candidate %s classified as %q without geometry", candidate.ID, class)
+		}
+	}
+}
+
+func TestDeepMatchSkippedAboveImageLimit(t *testing.T) {
+	// Every page carries the watermark and a scan image of its own: 7 distinct images
+	f := watermarkedPDF(6)
+	for page := 1; page <= 6; page++ {
+		f.images = append(f.images, fakeImage{Page: page, Obj: 100 + page, ID: "Im1", Width: 2480, Height: 3508, CS: "DeviceGray", Size: 500000})
+	}
+	fake := installFakeCLI(t, f)
+	inFile := writeFakePDF(t, t.TempDir(), "in.pdf")
+
+	tests := []struct {
+		limit   int
+		skipped bool
+	}{
+		{limit: 6, skipped: true},
+		{limit: 7, skipped: false},
+	}
+	for _, tt := range tests {
+		extracts := fake.callCount("extract", "-mode", "image")
+		analysis, err := AnalyzeUnwantedElementsWithOptions(inFile, AnalysisOptions{DeepMatch: true, MaxDeepMatchImages: tt.limit})
+		if err != nil {
+			t.Fatal(err)
+		}
+		hasWarning := slices.ContainsFunc(analysis.RecommendationDetails, func(rec Recommendation) bool {
+			return rec.ID == MsgDeepMatchSkipped
+		})
+		if hasWarning != tt.skipped {
+			t.Errorf("limit %d: deep match skipped warning %v, want %v", tt.limit, hasWarning, tt.skipped)
+		}
+		if partial := analysis.Status == AnalysisStatusPartial; partial != tt.skipped {
+			t.Errorf("limit %d: status %q, want partial %v", tt.limit, analysis.Status, tt.skipped)
+		}
+		if extracted := fake.callCount("extract", "-mode", "image") > extracts; extracted == tt.skipped {
+			t.Errorf("limit %d: images extracted %v, want %v", tt.limit, extracted, !tt.skipped)
+		}
+	}
+}
+
+// documentedImageKeys are the metadata keys every image candidate carries in schema version 1
+var documentedImageKeys = []string{
+	MetaType, MetaImageID, MetaObject, MetaPrefix, MetaSignature, MetaPageCount, MetaTotalPages,
+	MetaCoverage, MetaFileSizeKB, MetaWidth, MetaHeight, MetaSoftMask, MetaImageMask, MetaRepresentativePage,
+}
+
+// mixedImagesPDF is a fake document of 10 pages with a full-page watermark, a small logo
+// that every page names differently, and a banner on the even pages only
+func mixedImagesPDF() *fakePdfcpu {
+	f := watermarkedPDF(10)
+	for page := 1; page <= 10; page++ {
+		f.images = append(f.images, fakeImage{Page: page, Obj: 20, ID: fmt.Sprintf("Logo-%d", page), Width: 50, Height: 50, CS: "DeviceRGB", Size: 3000})
+		if page%2 == 0 {
+			f.images = append(f.images, fakeImage{Page: page, Obj: 30, ID: "X5", Width: 800, Height: 100, CS: "DeviceGray", Size: 20000})
+		}
+	}
+	return f
+}
+
+func TestImageCandidatesCarryDocumentedKeys(t *testing.T) {
+	installFakeCLI(t, mixedImagesPDF())
+	inFile := writeFakePDF(t, t.TempDir(), "in.pdf")
+
+	analysis, err := AnalyzeUnwantedElements(inFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if analysis.SchemaVersion != AnalysisSchemaVersion {
+		t.Errorf("schema_version = %q, want %q", analysis.SchemaVersion, AnalysisSchemaVersion)
+	}
+	if len(analysis.ImageCandidates) < 2 {
+		t.Fatalf("%d image candidates, want the watermark and the repeating elements", len(analysis.ImageCandidates))
+	}
+	for _, candidate := range analysis.ImageCandidates {
+		for _, key := range documentedImageKeys {
+			if _, ok := candidate.Metadata[key]; !ok {
+				t.Errorf("candidate %s has no %q metadata", candidate.ID, key)
+			}
+		}
+		if candidate.Metadata["max_pages"] != candidate.Metadata[MetaTotalPages] {
+			t.Errorf("candidate %s: max_pages %q differs from total_pages %q", candidate.ID, candidate.Metadata["max_pages"], candidate.Metadata[MetaTotalPages])
+		}
+	}
+}
+
+func TestCandidateTypesShareMetadataKeys(t *testing.T) {
+	installFakeCLI(t, mixedImagesPDF())
+	inFile := writeFakePDF(t, t.TempDir(), "in.pdf")
+
+	analysis, err := AnalyzeUnwantedElementsWithOptions(inFile, AnalysisOptions{DeepMatch: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Keys that only describe one detection path
+	typeSpecific := map[string]bool{"parity": true, "content_hash": true}
+	keysByType := make(map[string][]string)
+	for _, candidate := range analysis.ImageCandidates {
+		var keys []string
+		for key := range candidate.Metadata {
+			if !typeSpecific[key] {
+				keys = append(keys, key)
+			}
+		}
+		slices.Sort(keys)
+		candidateType := candidate.Metadata[MetaType]
+		if previous, ok := keysByType[candidateType]; ok && !slices.Equal(previous, keys) {
+			t.Errorf("%s candidates have different keys: %v and %v", candidateType, previous, keys)
+		}
+		keysByType[candidateType] = keys
+
+		for _, key := range []string{MetaObject, MetaWidth, MetaHeight} {
+			if value := candidate.Metadata[key]; value == "" || value == "0" {
+				t.Errorf("%s candidate %s: %s = %q for a listed image", candidateType, candidate.ID, key, value)
+			}
+		}
+	}
+
+	for _, candidateType := range []string{"fullpage_watermark", "repeating_unwanted_element", "identical_image"} {
+		if _, ok := keysByType[candidateType]; !ok {
+			t.Fatalf("no %s candidate among %v", candidateType, keysByType)
+		}
+	}
+	want := keysByType["fullpage_watermark"]
+	for candidateType, keys := range keysByType {
+		if !slices.Equal(keys, want) {
+			t.Errorf("%s keys %v, want the fullpage_watermark keys %v", candidateType, keys, want)
+		}
+	}
+}
+
+func TestRecommendationSeverities(t *testing.T) {
+	fixtures := []struct {
+		name string
+		fake *fakePdfcpu
+		opts AnalysisOptions
+	}{
+		{"watermarked", watermarkedPDF(4), AnalysisOptions{}},
+		{"mixed with skipped deep match", mixedImagesPDF(), AnalysisOptions{DeepMatch: true, MaxDeepMatchImages: 1, DetectBlankPages: true}},
+		{"no images", &fakePdfcpu{pages: 2, contents: map[int]string{1: "BT (a) Tj ET", 2: ""}}, AnalysisOptions{DetectBlankPages: true}},
+	}
+	valid := []string{SeverityInfo, SeverityWarning, SeverityAction}
+	for _, fx := range fixtures {
+		t.Run(fx.name, func(t *testing.T) {
+			installFakeCLI(t, fx.fake)
+			analysis, err := AnalyzeUnwantedElementsWithOptions(writeFakePDF(t, t.TempDir(), "in.pdf"), fx.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(analysis.RecommendationDetails) == 0 || len(analysis.RecommendationDetails) != len(analysis.Recommendations) {
+				t.Fatalf("%d structured recommendations for %d plain ones", len(analysis.RecommendationDetails), len(analysis.Recommendations))
+			}
+
+			candidateIDs := make(map[string]bool)
+			for _, list := range [][]UnwantedElementCandidate{analysis.ImageCandidates, analysis.TextCandidates, analysis.BlankPageCandidates} {
+				for _, candidate := range list {
+					candidateIDs[candidate.ID] = true
+				}
+			}
+			for i, rec := range analysis.RecommendationDetails {
+				if !slices.Contains(valid, rec.Severity) {
+					t.Errorf("%s has severity %q", rec.ID, rec.Severity)
+				}
+				if rec.Message != analysis.Recommendations[i] {
+					t.Errorf("%s message %q differs from the plain list entry %q", rec.ID, rec.Message, analysis.Recommendations[i])
+				}
+				if rec.CandidateID != "" && !candidateIDs[rec.CandidateID] {
+					t.Errorf("%s links to unknown candidate %s", rec.ID, rec.CandidateID)
+				}
+				if rec.Severity == SeverityAction && rec.CandidateID == "" {
+					t.Errorf("actionable %s links to no candidate", rec.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestPerPageCandidateCounts(t *testing.T) {
+	installFakeCLI(t, mixedImagesPDF())
+	inFile := writeFakePDF(t, t.TempDir(), "in.pdf")
+
+	analysis, err := AnalyzeUnwantedElements(inFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if analysis.PerPageCandidateCounts != nil {
+		t.Errorf("counts included without IncludeHeatmap: %v", analysis.PerPageCandidateCounts)
+	}
+
+	analysis, err = AnalyzeUnwantedElementsWithOptions(inFile, AnalysisOptions{IncludeHeatmap: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every page has the watermark, the logo and the repeated "Body" line; even pages
+	// also have the banner
+	if len(analysis.ImageCandidates) != 3 || len(analysis.TextCandidates) != 1 {
+		t.Fatalf("%d image and %d text candidates, want 3 and 1", len(analysis.ImageCandidates), len(analysis.TextCandidates))
+	}
+	for page := 1; page <= 10; page++ {
+		want := 3
+		if page%2 == 0 {
+			want = 4
+		}
+		if got := analysis.PerPageCandidateCounts[page]; got != want {
+			t.Errorf("page %d count = %d, want %d", page, got, want)
+		}
+	}
+	if len(analysis.PerPageCandidateCounts) != 10 {
+		t.Errorf("counts for %d pages, want 10", len(analysis.PerPageCandidateCounts))
+	}
+}
+
+func TestTrackedGroupsCapped(t *testing.T) {
+	// A watermark on every page plus images that each have their own prefix and size
+	f := watermarkedPDF(20)
+	for page := 1; page <= 20; page++ {
+		for i := 0; i < 5; i++ {
+			n := page*10 + i
+			f.images = append(f.images, fakeImage{Page: page, Obj: 100 + n, ID: fmt.Sprintf("U%d-x", n), Width: 100 + n, Height: 50, CS: "DeviceRGB", Size: int64(1000 + n)})
+		}
+	}
+	installFakeCLI(t, f)
+	inFile := writeFakePDF(t, t.TempDir(), "in.pdf")
+
+	const maxGroups = 10
+	analysis, err := AnalyzeUnwantedElementsWithOptions(inFile, AnalysisOptions{MaxTrackedGroups: maxGroups})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"Signature limit of 10 reached", "Prefix limit of 10 reached"} {
+		if !slices.ContainsFunc(analysis.DebugLogs, func(line string) bool { return strings.Contains(line, want) }) {
+			t.Errorf("no %q note in the debug logs", want)
+		}
+	}
+	prefixes := make(map[string]bool)
+	for _, line := range analysis.DebugLogs {
+		if _, rest, ok := strings.Cut(line, "Grouped image by prefix '"); ok {
+			prefix, _, _ := strings.Cut(rest, "'")
+			prefixes[prefix] = true
+		}
+	}
+	if len(prefixes) > maxGroups {
+		t.Errorf("%d prefixes tracked, want at most %d", len(prefixes), maxGroups)
+	}
+
+	// Groups seen before the cap keep growing, so the watermark is still found
+	found := false
+	for _, candidate := range analysis.ImageCandidates {
+		if slices.Contains(candidate.Pages, 20) && len(candidate.Pages) == 20 {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("watermark on every page not found with the cap engaged: %+v", analysis.ImageCandidates)
+	}
+}
+
+func TestEarlyExitSkipsLaterWork(t *testing.T) {
+	opts := AnalysisOptions{DeepMatch: true, DetectBlankPages: true}
+
+	// Full analysis: repeating elements are found, deep matching extracts images and the
+	// content-based signals read the content streams
+	full := installFakeCLI(t, mixedImagesPDF())
+	inFile := writeFakePDF(t, t.TempDir(), "in.pdf")
+	analysis, err := AnalyzeUnwantedElementsWithOptions(inFile, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(analysis.ImageCandidates) < 3 || full.callCount("extract", "-mode", "content") == 0 || full.callCount("extract", "-mode", "image") == 0 {
+		t.Fatalf("full analysis: %d candidates, calls %v", len(analysis.ImageCandidates), full.calls)
+	}
+
+	tests := []struct {
+		name           string
+		confidence     float64
+		wantCandidates int
+		wantLog        string
+	}{
+		// The full-page watermark (0.95) is definitive at the default threshold
+		{name: "at the full-page stage", wantCandidates: 1, wantLog: "definitive full-page candidate"},
+		// Only the repeating elements (1.0) reach a higher threshold
+		{name: "at the repeating stage", confidence: 0.99, wantCandidates: 3, wantLog: "definitive repeating candidate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := installFakeCLI(t, mixedImagesPDF())
+			opts := opts
+			opts.EarlyExit = true
+			opts.EarlyExitConfidence = tt.confidence
+
+			analysis, err := AnalyzeUnwantedElementsWithOptions(inFile, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(analysis.ImageCandidates) != tt.wantCandidates {
+				t.Errorf("%d image candidates, want %d", len(analysis.ImageCandidates), tt.wantCandidates)
+			}
+			if got := f.callCount("extract", "-mode", "content"); got != 0 {
+				t.Errorf("content streams extracted %d times after an early exit", got)
+			}
+			if got := f.callCount("extract", "-mode", "image"); got != 0 {
+				t.Errorf("images extracted for deep matching %d times after an early exit", got)
+			}
+			if !slices.ContainsFunc(analysis.DebugLogs, func(line string) bool { return strings.Contains(line, tt.wantLog) }) {
+				t.Errorf("no %q note in the debug logs", tt.wantLog)
+			}
+		})
+	}
+
+	// Nothing reaches a threshold above every candidate, so the analysis runs in full
+	f := installFakeCLI(t, mixedImagesPDF())
+	opts.EarlyExit, opts.EarlyExitConfidence = true, 1.01
+	if _, err := AnalyzeUnwantedElementsWithOptions(inFile, opts); err != nil {
+		t.Fatal(err)
+	}
+	if f.callCount("extract", "-mode", "content") == 0 {
+		t.Error("analysis stopped early without a definitive candidate")
+	}
+}
+
+func TestDebugCollectorConcurrent(t *testing.T) {
+	// Run with -race: per-page workers log through one collector at the same time
+	previous := cliSlots
+	SetMaxConcurrentCLI(8)
+	t.Cleanup(func() { cliSlots = previous })
+
+	var logs []string
+	debugLog := debugCollector(&logs)
+	const pages, perPage = 50, 4
+	_, err := runPerPage(pageRange(pages), func(page int) (struct{}, error) {
+		for i := 0; i < perPage; i++ {
+			debugLog("[DEBUG] page %d message %d", page, i)
+		}
+		return struct{}{}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(logs) != pages*perPage {
+		t.Fatalf("%d messages captured, want %d", len(logs), pages*perPage)
+	}
+	for page := 1; page <= pages; page++ {
+		for i := 0; i < perPage; i++ {
+			if want := fmt.Sprintf("[DEBUG] page %d message %d", page, i); !slices.Contains(logs, want) {
+				t.Errorf("message %q lost", want)
+			}
+		}
+	}
+}
+
+func TestParityOnlyCoverage(t *testing.T) {
+	tests := []struct {
+		name       string
+		pages      []int
+		totalPages int
+		want       string
+	}{
+		{name: "every even page", pages: []int{2, 4, 6, 8, 10}, totalPages: 10, want: "even"},
+		{name: "every odd page", pages: []int{1, 3, 5, 7, 9}, totalPages: 10, want: "odd"},
+		{name: "odd pages of an odd count", pages: []int{1, 3, 5, 7, 9, 11}, totalPages: 11, want: "odd"},
+		{name: "4 of 5 even pages", pages: []int{2, 4, 6, 10}, totalPages: 10, want: "even"},
+		{name: "3 of 5 even pages", pages: []int{2, 4, 6}, totalPages: 10, want: ""},
+		{name: "scattered", pages: []int{1, 2, 5, 8}, totalPages: 10, want: ""},
+		{name: "repeated occurrences count once", pages: []int{2, 2, 2, 4}, totalPages: 10, want: ""},
+		{name: "two-page document", pages: []int{2}, totalPages: 2, want: ""},
+		{name: "three-page document", pages: []int{2}, totalPages: 3, want: ""},
+		{name: "four-page document", pages: []int{2, 4}, totalPages: 4, want: "even"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parityOnlyCoverage(tt.pages, tt.totalPages); got != tt.want {
+				t.Errorf("parityOnlyCoverage(%v, %d) = %q, want %q", tt.pages, tt.totalPages, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVersoOnlyWatermark(t *testing.T) {
+	// A watermark on the even (verso) pages only covers half the document, below the
+	// whole-document threshold, and a different one on the odd (recto) pages
+	f := &fakePdfcpu{pages: 10}
+	for page := 1; page <= 10; page++ {
+		if page%2 == 0 {
+			f.images = append(f.images, fakeImage{Page: page, Obj: 40, ID: "Verso", Width: 400, Height: 300, CS: "DeviceRGB", Size: 12000})
+		} else {
+			f.images = append(f.images, fakeImage{Page: page, Obj: 41, ID: "Recto", Width: 300, Height: 400, CS: "DeviceGray", Size: 9000})
+		}
+	}
+	installFakeCLI(t, f)
+	inFile := writeFakePDF(t, t.TempDir(), "in.pdf")
+
+	analysis, err := AnalyzeUnwantedElements(inFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parities := make(map[string][]int)
+	for _, candidate := range analysis.ImageCandidates {
+		parities[candidate.Metadata["parity"]] = candidate.Pages
+	}
+	if want := []int{2, 4, 6, 8, 10}; !slices.Equal(parities["even"], want) {
+		t.Errorf("even-page candidate on pages %v, want %v (candidates %+v)", parities["even"], want, analysis.ImageCandidates)
+	}
+	if want := []int{1, 3, 5, 7, 9}; !slices.Equal(parities["odd"], want) {
+		t.Errorf("odd-page candidate on pages %v, want %v", parities["odd"], want)
+	}
+	for _, candidate := range analysis.ImageCandidates {
+		if candidate.Confidence < 0.7 {
+			t.Errorf("%s: confidence %.2f, want parity coverage to score like full coverage", candidate.ID, candidate.Confidence)
+		}
+	}
+}
+
+func TestAlternatingSizes(t *testing.T) {
+	tests := []struct {
+		name       string
+		evenWidth  int
+		wantID     string // of the combined candidate, empty for none
+		wantSizes  string
+		wantOthers int // candidates besides the combined one
+	}{
+		// Each size covers half the pages, together all of them
+		{name: "same dimensions", evenWidth: 600, wantID: "fullpage_watermark_Im0_110KB+118KB", wantSizes: "110KB+118KB"},
+		// Different images that happen to share a name are not joined
+		{name: "different dimensions", evenWidth: 500},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A watermark re-encoded at 110KB on the odd pages and 118KB on the even pages
+			f := &fakePdfcpu{pages: 10}
+			for page := 1; page <= 10; page++ {
+				obj, width, size := 10, 600, int64(110*1024)
+				if page%2 == 0 {
+					obj, width, size = 11, tt.evenWidth, 118*1024
+				}
+				f.images = append(f.images, fakeImage{Page: page, Obj: obj, ID: "Im0", Width: width, Height: 400, CS: "DeviceRGB", Size: size})
+			}
+			installFakeCLI(t, f)
+			inFile := writeFakePDF(t, t.TempDir(), "in.pdf")
+
+			analysis, err := AnalyzeUnwantedElements(inFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var combined []UnwantedElementCandidate
+			for _, candidate := range analysis.ImageCandidates {
+				if candidate.Metadata["sizes"] != "" {
+					combined = append(combined, candidate)
+				}
+			}
+			if tt.wantID == "" {
+				if len(combined) != 0 {
+					t.Errorf("size groups of different images were combined: %+v", combined)
+				}
+				return
+			}
+
+			if len(combined) != 1 {
+				t.Fatalf("%d combined candidates, want 1 (candidates %+v)", len(combined), analysis.ImageCandidates)
+			}
+			candidate := combined[0]
+			if candidate.ID != tt.wantID || candidate.Metadata["sizes"] != tt.wantSizes {
+				t.Errorf("combined candidate %s with sizes %q, want %s with %q", candidate.ID, candidate.Metadata["sizes"], tt.wantID, tt.wantSizes)
+			}
+			if want := pageRange(10); !slices.Equal(candidate.Pages, want) {
+				t.Errorf("combined candidate on pages %v, want %v", candidate.Pages, want)
+			}
+			if members := strings.Split(candidate.Metadata["member_signatures"], "|"); len(members) != 2 {
+				t.Errorf("member_signatures %q, want both sizes", candidate.Metadata["member_signatures"])
+			}
+			// The member signatures are handled, so the sizes are not reported again by parity
+			if len(analysis.ImageCandidates) != 1 {
+				t.Errorf("%d image candidates, want only the combined one: %+v", len(analysis.ImageCandidates), analysis.ImageCandidates)
+			}
+		})
+	}
+}
+
+func TestAnalysisStatus(t *testing.T) {
+	// Body text that differs on every page and one image on a single page
+	plain := func() *fakePdfcpu {
+		f := &fakePdfcpu{pages: 5, contents: make(map[int]string)}
+		for page := 1; page <= 5; page++ {
+			f.contents[page] = fmt.Sprintf("BT /F1 12 Tf 72 720 Td (Chapter %d text) Tj ET", page)
+		}
+		f.images = []fakeImage{{Page: 3, Obj: 10, ID: "Fig1", Width: 300, Height: 200, CS: "DeviceRGB", Size: 15000}}
+		return f
+	}
+	twoFigures := plain()
+	twoFigures.images = append(twoFigures.images, fakeImage{Page: 4, Obj: 11, ID: "Chart", Width: 400, Height: 300, CS: "DeviceGray", Size: 9000})
+	tests := []struct {
+		name string
+		pdf  *fakePdfcpu
+		opts AnalysisOptions
+		want string
+	}{
+		{name: "nothing found", pdf: plain(), want: AnalysisStatusNoneFound},
+		{name: "watermark found", pdf: watermarkedPDF(4), want: AnalysisStatusCandidatesFound},
+		{name: "early exit", pdf: mixedImagesPDF(), opts: AnalysisOptions{EarlyExit: true}, want: AnalysisStatusPartial},
+		// Partial even though nothing was found among the images that were grouped
+		{name: "group limit", pdf: twoFigures, opts: AnalysisOptions{MaxTrackedGroups: 1}, want: AnalysisStatusPartial},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			installFakeCLI(t, tt.pdf)
+			analysis, err := AnalyzeUnwantedElementsWithOptions(writeFakePDF(t, t.TempDir(), "in.pdf"), tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if analysis.Status != tt.want {
+				t.Errorf("status %q, want %q (%d image, %d text candidates)", analysis.Status, tt.want,
+					len(analysis.ImageCandidates), len(analysis.TextCandidates))
+			}
+		})
+	}
+}
+
+func TestPrefixFilter(t *testing.T) {
+	tests := []struct {
+		name       string
+		filter     []string
+		want       []string // prefixes of the image candidates
+		wantImages int      // image occurrences examined of the 25
+	}{
+		{name: "no filter", want: []string{"Im0", "Logo", "unknown"}, wantImages: 25},
+		{name: "one prefix", filter: []string{"Logo"}, want: []string{"Logo"}, wantImages: 10},
+		{name: "two prefixes", filter: []string{"Im0", "Logo"}, want: []string{"Im0", "Logo"}, wantImages: 20},
+		{name: "no match", filter: []string{"Watermark"}, want: []string{}, wantImages: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			installFakeCLI(t, mixedImagesPDF())
+			analysis, err := AnalyzeUnwantedElementsWithOptions(writeFakePDF(t, t.TempDir(), "in.pdf"), AnalysisOptions{PrefixFilter: tt.filter})
+			if err != nil {
+				t.Fatal(err)
+			}
+			prefixes := []string{}
+			for _, candidate := range analysis.ImageCandidates {
+				prefixes = append(prefixes, candidate.Metadata[MetaPrefix])
+			}
+			slices.Sort(prefixes)
+			if !slices.Equal(prefixes, tt.want) {
+				t.Errorf("candidate prefixes %v, want %v", prefixes, tt.want)
+			}
+			if tt.filter != nil {
+				note := fmt.Sprintf("skipped %d of 25 image occurrences", 25-tt.wantImages)
+				if !slices.ContainsFunc(analysis.DebugLogs, func(line string) bool { return strings.Contains(line, note) }) {
+					t.Errorf("no %q note in the debug logs", note)
+				}
+			}
+		})
+	}
+}
+
+func TestFilterByPages(t *testing.T) {
+	analysis := &UnwantedElementsAnalysis{
+		TotalPages: 20,
+		ImageCandidates: []UnwantedElementCandidate{
+			{ID: "watermark", Pages: pageRange(20)},
+			{ID: "even_logo", Pages: []int{2, 4, 6, 8, 10, 12, 14, 16, 18, 20}},
+			{ID: "figure_7", Page: 7, Pages: []int{7}},
+			{ID: "unpaged"},
+		},
+		TextCandidates:      []UnwantedElementCandidate{{ID: "draft_stamp", Pages: []int{1, 15}}},
+		BlankPageCandidates: []UnwantedElementCandidate{{ID: "blank_page_13", Page: 13}},
+	}
+	tests := []struct {
+		name   string
+		ranges [][2]int
+		want   []string // IDs of image, text and blank page candidates
+	}{
+		// A repeating candidate is kept when any of its pages is in range
+		{name: "one odd page", ranges: [][2]int{{3, 3}}, want: []string{"watermark", "unpaged"}},
+		{name: "range", ranges: [][2]int{{5, 8}}, want: []string{"watermark", "even_logo", "figure_7", "unpaged"}},
+		{name: "several ranges", ranges: [][2]int{{1, 1}, {13, 13}}, want: []string{"watermark", "unpaged", "draft_stamp", "blank_page_13"}},
+		// A single-page candidate filters by its own page only
+		{name: "beside a single-page candidate", ranges: [][2]int{{9, 9}}, want: []string{"watermark", "unpaged"}},
+		{name: "past the last page", ranges: [][2]int{{21, 30}}, want: []string{"unpaged"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filtered := analysis.FilterByPages(tt.ranges)
+			got := candidateIDs(filtered.ImageCandidates)
+			got = append(got, candidateIDs(filtered.TextCandidates)...)
+			got = append(got, candidateIDs(filtered.BlankPageCandidates)...)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("FilterByPages(%v) = %v, want %v", tt.ranges, got, tt.want)
+			}
+			if filtered.TotalPages != 20 {
+				t.Errorf("TotalPages = %d, want the other fields kept", filtered.TotalPages)
+			}
+		})
+	}
+
+	// The analysis itself keeps every candidate
+	if len(analysis.ImageCandidates) != 4 || len(analysis.TextCandidates) != 1 || len(analysis.BlankPageCandidates) != 1 {
+		t.Errorf("FilterByPages changed the analysis: %+v", analysis)
+	}
+}
+
+func TestAnalysisCancelStopsCommand(t *testing.T) {
+	fake := installFakeCLI(t, watermarkedPDF(3))
+	fakeRunner := runCommand
+	started := make(chan struct{})
+	stopped := make(chan struct{})
+	runCommand = func(ctx context.Context, name string, args, env []string, stdout, stderr io.Writer) error {
+		if len(args) > 1 && args[0] == "images" && args[1] == "list" {
+			// A long-running images list: only the cancelled context ends it
+			close(started)
+			<-ctx.Done()
+			close(stopped)
+			return ctx.Err()
+		}
+		return fakeRunner(ctx, name, args, env, stdout, stderr)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := AnalyzeUnwantedElementsContext(ctx, writeFakePDF(t, t.TempDir(), "in.pdf"), AnalysisOptions{})
+		done <- err
+	}()
+	<-started
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) || !errors.Is(err, ErrCommandCancelled) {
+			t.Errorf("err = %v, want ErrCommandCancelled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("analysis kept running after its context was cancelled")
+	}
+	select {
+	case <-stopped:
+	default:
+		t.Error("the running command was not stopped")
+	}
+	if n := fake.callCount("extract"); n != 0 {
+		t.Errorf("%d extract calls after cancelling, want none", n)
+	}
+
+	// A context cancelled before the analysis starts runs no command at all
+	calls := len(fake.calls)
+	if _, err := AnalyzeUnwantedElementsContext(ctx, writeFakePDF(t, t.TempDir(), "in.pdf"), AnalysisOptions{}); !errors.Is(err, ErrCommandCancelled) {
+		t.Errorf("err = %v with a cancelled context, want ErrCommandCancelled", err)
+	}
+	if len(fake.calls) != calls {
+		t.Errorf("commands run with a cancelled context: %v", fake.calls[calls:])
+	}
+}
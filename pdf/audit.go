@@ -0,0 +1,86 @@
+package pdf
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// auditImage is an image occurrence whose original bytes are kept for auditing
+type auditImage struct {
+	page int
+	obj  string
+	id   string
+}
+
+// saveAuditImages saves the original bytes of images about to be replaced into auditDir,
+// once per image object (per page and ID when the object number is unknown), and returns
+// the saved paths. The files are written as pdfcpu extracts them, so JPEG and JPEG 2000
+// streams are kept byte for byte. An image that cannot be extracted fails the whole call,
+// so a removal never goes ahead without its audit copy.
+func saveAuditImages(inFile, auditDir string, images []auditImage) ([]string, error) {
+	if err := os.MkdirAll(auditDir, 0750); err != nil {
+		return nil, fmt.Errorf("failed to create audit directory: %w", err)
+	}
+
+	pageSet := make(map[int]bool)
+	for _, img := range images {
+		pageSet[img.page] = true
+	}
+	extractDir, err := os.MkdirTemp(filepath.Dir(inFile), "audit_")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create extract directory: %w", err)
+	}
+	defer os.RemoveAll(extractDir)
+
+	name, args := extractImagesCommand(inFile, extractDir, sortedPageSet(pageSet))
+	output, err := execCommandWithTimeout(DefaultCLITimeout, name, args...)
+	if err != nil {
+		return nil, fmt.Errorf("pdfcpu extract failed: %w\nOutput: %s", err, string(output))
+	}
+
+	// Index the extracted files by page and image ID
+	files, err := os.ReadDir(extractDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read extract directory: %w", err)
+	}
+	base := strings.TrimSuffix(filepath.Base(inFile), filepath.Ext(inFile))
+	extracted := make(map[string]string) // "page|id" -> file name
+	for _, file := range files {
+		matches := extractedImagePattern.FindStringSubmatch(strings.TrimPrefix(file.Name(), base+"_"))
+		if file.IsDir() || len(matches) < 3 {
+			continue
+		}
+		extracted[matches[1]+"|"+matches[2]] = file.Name()
+	}
+
+	saved := []string{}
+	seen := make(map[string]bool)
+	for _, img := range images {
+		key := img.obj
+		if key == "" {
+			key = fmt.Sprintf("%d|%s", img.page, img.id)
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		name, ok := extracted[fmt.Sprintf("%d|%s", img.page, img.id)]
+		if !ok {
+			return nil, fmt.Errorf("failed to preserve image (obj:%s, page:%d, id:%s) for audit: not extracted", img.obj, img.page, img.id)
+		}
+		data, err := os.ReadFile(filepath.Join(extractDir, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read extracted image: %w", err)
+		}
+		path := filepath.Join(auditDir, fmt.Sprintf("obj%s_page%d_%s%s", img.obj, img.page, sanitizeID(img.id), filepath.Ext(name)))
+		if err := os.WriteFile(path, data, 0640); err != nil {
+			return nil, fmt.Errorf("failed to write audit file: %w", err)
+		}
+		saved = append(saved, path)
+	}
+
+	return saved, nil
+}
@@ -0,0 +1,92 @@
+package pdf
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestRemovalKeepsAuditCopies(t *testing.T) {
+	f := installFakeCLI(t, watermarkedPDF(3))
+	dir := t.TempDir()
+	inFile := writeFakePDF(t, dir, "in.pdf")
+	analysis, err := AnalyzeUnwantedElements(inFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	auditDir := filepath.Join(t.TempDir(), "audit", "1_ab")
+	var report RemovalReport
+	err = RemoveElementsByIDsWithOptions(inFile, filepath.Join(dir, "out.pdf"), "image",
+		[]string{analysis.ImageCandidates[0].ID}, RemovalOptions{AuditDir: auditDir, Report: &report})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Object 10 is drawn on every page and saved once, with the bytes pdfcpu extracted
+	want := []string{filepath.Join(auditDir, "obj10_page1_Im0.png")}
+	if !slices.Equal(report.AuditFiles, want) {
+		t.Fatalf("AuditFiles = %v, want %v", report.AuditFiles, want)
+	}
+	if data, err := os.ReadFile(want[0]); err != nil || string(data) != "obj 10" {
+		t.Errorf("audit copy %q, %v, want the original image bytes", data, err)
+	}
+	if len(f.callsOf("images", "update")) == 0 {
+		t.Error("the image was not replaced")
+	}
+	if matches, _ := filepath.Glob(filepath.Join(dir, "audit_*")); len(matches) != 0 {
+		t.Errorf("extract directories left behind: %v", matches)
+	}
+}
+
+func TestRemovalWithoutAuditCopyFails(t *testing.T) {
+	f := watermarkedPDF(3)
+	installFakeCLI(t, f)
+	dir := t.TempDir()
+	inFile := writeFakePDF(t, dir, "in.pdf")
+	analysis, err := AnalyzeUnwantedElements(inFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The image cannot be extracted, so it must not be removed either
+	f.respond = func(args []string) (string, bool, error) {
+		if args[0] == "extract" && slices.Contains(args, "image") {
+			return "", true, errors.New("extract: corrupt image stream")
+		}
+		return "", false, nil
+	}
+	var report RemovalReport
+	err = RemoveElementsByIDsWithOptions(inFile, filepath.Join(dir, "out.pdf"), "image",
+		[]string{analysis.ImageCandidates[0].ID}, RemovalOptions{AuditDir: filepath.Join(t.TempDir(), "audit"), Report: &report})
+	if err == nil {
+		t.Fatal("removal succeeded without an audit copy")
+	}
+	if calls := f.callCount("images", "update"); calls != 0 {
+		t.Errorf("%d images replaced after the audit copy failed", calls)
+	}
+	if len(report.AuditFiles) != 0 {
+		t.Errorf("AuditFiles = %v after a failure", report.AuditFiles)
+	}
+}
+
+func TestRemovalWithoutAuditDir(t *testing.T) {
+	f := installFakeCLI(t, watermarkedPDF(3))
+	dir := t.TempDir()
+	inFile := writeFakePDF(t, dir, "in.pdf")
+	analysis, err := AnalyzeUnwantedElements(inFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report RemovalReport
+	err = RemoveElementsByIDsWithOptions(inFile, filepath.Join(dir, "out.pdf"), "image",
+		[]string{analysis.ImageCandidates[0].ID}, RemovalOptions{Report: &report})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.AuditFiles != nil || f.callCount("extract", "-mode", "image") != 0 {
+		t.Errorf("audit copies %v made without an audit directory", report.AuditFiles)
+	}
+}
@@ -0,0 +1,135 @@
+package pdf
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// AutoCleanOptions tunes which candidates AutoClean removes and how
+type AutoCleanOptions struct {
+	Analysis AnalysisOptions
+	Removal  RemovalOptions
+
+	// MinConfidence is the confidence a candidate needs to be removed
+	MinConfidence float64
+
+	// TopN removes only the N most confident qualifying candidates (0 removes all)
+	TopN int
+
+	// Verify analyzes the cleaned document again and reports the difference in Diff
+	Verify bool
+}
+
+// AutoCleanResult describes what an automatic clean found and removed
+type AutoCleanResult struct {
+	Analysis   *UnwantedElementsAnalysis `json:"analysis"`
+	RemovedIDs []string                  `json:"removed_ids"` // candidate IDs selected for removal
+	Report     RemovalReport             `json:"report"`
+	Diff       *AnalysisDiff             `json:"diff,omitempty"`  // only with AutoCleanOptions.Verify
+	Notes      []string                  `json:"notes,omitempty"` // adjustments made to the request, e.g. a clamped confidence
+}
+
+// AutoClean analyzes inFile and removes, in one pass, every image candidate with at least
+// opts.MinConfidence, plus such blank page candidates when opts.Analysis.DetectBlankPages
+// is set. With opts.TopN only the most confident of those are removed, so a cautious
+// caller can strip one watermark at a time. If nothing qualifies, outFile is a copy of
+// inFile. The analysis is returned with the result so callers do not need to analyze
+// again to learn what was removed; with opts.Verify the cleaned document is analyzed
+// again and compared against it.
+func AutoClean(inFile, outFile string, opts AutoCleanOptions) (*AutoCleanResult, error) {
+	result, err := autoClean(inFile, outFile, opts)
+	if err != nil || !opts.Verify {
+		return result, err
+	}
+
+	after, err := AnalyzeUnwantedElementsWithOptions(outFile, opts.Analysis)
+	if err != nil {
+		return nil, fmt.Errorf("verification analysis failed: %w", err)
+	}
+	diff := DiffAnalyses(result.Analysis, after)
+	result.Diff = &diff
+	return result, nil
+}
+
+// autoClean is AutoClean without verification
+func autoClean(inFile, outFile string, opts AutoCleanOptions) (*AutoCleanResult, error) {
+	if err := checkDistinctFiles(inFile, outFile); err != nil {
+		return nil, err
+	}
+
+	analysis, err := AnalyzeUnwantedElementsWithOptions(inFile, opts.Analysis)
+	if err != nil {
+		return nil, fmt.Errorf("analysis failed: %w", err)
+	}
+
+	result := &AutoCleanResult{Analysis: analysis, RemovedIDs: []string{}}
+	qualifying := []UnwantedElementCandidate{}
+	blankCount := 0
+	for _, candidate := range analysis.ImageCandidates {
+		if candidate.Confidence >= opts.MinConfidence {
+			qualifying = append(qualifying, candidate)
+		}
+	}
+	for _, candidate := range analysis.BlankPageCandidates {
+		if candidate.Confidence >= opts.MinConfidence {
+			qualifying = append(qualifying, candidate)
+			blankCount++
+		}
+	}
+	// Never drop every page, even if all of them look blank
+	if blankCount >= analysis.TotalPages {
+		qualifying = qualifying[:len(qualifying)-blankCount]
+	}
+
+	// Most confident first; ties broken by ID so the same candidates win on every run
+	sort.SliceStable(qualifying, func(i, j int) bool {
+		if qualifying[i].Confidence != qualifying[j].Confidence {
+			return qualifying[i].Confidence > qualifying[j].Confidence
+		}
+		return qualifying[i].ID < qualifying[j].ID
+	})
+	if opts.TopN > 0 && len(qualifying) > opts.TopN {
+		qualifying = qualifying[:opts.TopN]
+	}
+	for _, candidate := range qualifying {
+		result.RemovedIDs = append(result.RemovedIDs, candidate.ID)
+	}
+	blankPages, imageIDs := SplitBlankPageIDs(result.RemovedIDs)
+
+	if len(imageIDs) == 0 && len(blankPages) == 0 {
+		data, err := os.ReadFile(inFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read input: %w", err)
+		}
+		if err := os.WriteFile(outFile, data, 0644); err != nil {
+			return nil, fmt.Errorf("failed to write output: %w", err)
+		}
+		return result, nil
+	}
+	if len(imageIDs) == 0 {
+		return result, RemoveBlankPages(inFile, outFile, blankPages)
+	}
+
+	// The IDs are matched against this analysis, whose options may find candidates a
+	// default re-analysis would not
+	removalOpts := opts.Removal
+	removalOpts.Analysis = analysis
+	removalOpts.Report = &result.Report
+	elementsOut := outFile
+	if len(blankPages) > 0 {
+		elementsOut = strings.TrimSuffix(outFile, ".pdf") + "_elements.pdf"
+		defer os.Remove(elementsOut)
+	}
+	if err := RemoveElementsByIDsWithOptions(inFile, elementsOut, "image", imageIDs, removalOpts); err != nil {
+		return nil, err
+	}
+	if len(blankPages) > 0 {
+		if err := RemoveBlankPages(elementsOut, outFile, blankPages); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
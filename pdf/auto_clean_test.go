@@ -0,0 +1,110 @@
+package pdf
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestAutoCleanTopN(t *testing.T) {
+	// mixedImagesPDF has a watermark (confidence 0.95) and two repeating elements (1.0),
+	// which tie on confidence and are ranked by ID
+	const (
+		logo      = "repeating_unwanted_element_50x50_De"
+		banner    = "repeating_unwanted_element_800x100_"
+		watermark = "fullpage_watermark_Im0_39KB"
+	)
+	tests := []struct {
+		name          string
+		topN          int
+		minConfidence float64
+		want          []string
+		wantObjects   []string // objects replaced with images update
+	}{
+		{name: "top 1", topN: 1, want: []string{logo}, wantObjects: []string{"20"}},
+		{name: "top 2", topN: 2, want: []string{logo, banner}, wantObjects: []string{"20", "30"}},
+		{name: "unlimited", topN: 0, want: []string{logo, banner, watermark}, wantObjects: []string{"10", "20", "30"}},
+		{name: "more than qualify", topN: 5, want: []string{logo, banner, watermark}, wantObjects: []string{"10", "20", "30"}},
+		{name: "after the confidence filter", topN: 1, minConfidence: 0.99, want: []string{logo}, wantObjects: []string{"20"}},
+		{name: "confidence filter alone", minConfidence: 0.99, want: []string{logo, banner}, wantObjects: []string{"20", "30"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := installFakeCLI(t, mixedImagesPDF())
+			dir := t.TempDir()
+			inFile := writeFakePDF(t, dir, "in.pdf")
+
+			result, err := AutoClean(inFile, filepath.Join(dir, "out.pdf"), AutoCleanOptions{TopN: tt.topN, MinConfidence: tt.minConfidence})
+			if err != nil {
+				t.Fatalf("AutoClean: %v", err)
+			}
+			if !slices.Equal(result.RemovedIDs, tt.want) {
+				t.Errorf("RemovedIDs = %v, want %v", result.RemovedIDs, tt.want)
+			}
+
+			var objects []string
+			for _, call := range f.callsOf("images", "update") {
+				objects = append(objects, call[len(call)-1])
+			}
+			slices.Sort(objects)
+			objects = slices.Compact(objects)
+			if !slices.Equal(objects, tt.wantObjects) {
+				t.Errorf("replaced objects %v, want %v", objects, tt.wantObjects)
+			}
+		})
+	}
+}
+
+func TestAutoCleanVerify(t *testing.T) {
+	// The cleaned file no longer has the logo, which the top candidate removes
+	f := mixedImagesPDF()
+	f.respond = func(args []string) (string, bool, error) {
+		if len(args) < 2 || args[0] != "images" || args[1] != "list" || !slices.Contains(args, "-json") ||
+			filepath.Base(args[len(args)-1]) != "out.pdf" {
+			return "", false, nil
+		}
+		var kept []fakeImage
+		for _, img := range f.images {
+			if img.Obj != 20 {
+				kept = append(kept, img)
+			}
+		}
+		data, _ := json.Marshal(kept)
+		return string(data), true, nil
+	}
+	installFakeCLI(t, f)
+	dir := t.TempDir()
+	inFile := writeFakePDF(t, dir, "in.pdf")
+
+	result, err := AutoClean(inFile, filepath.Join(dir, "out.pdf"), AutoCleanOptions{TopN: 1, Verify: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Diff == nil {
+		t.Fatal("no diff with Verify")
+	}
+	if got, want := candidateIDs(result.Diff.Eliminated), []string{"repeating_unwanted_element_50x50_De"}; !slices.Equal(got, want) {
+		t.Errorf("eliminated %v, want %v", got, want)
+	}
+	remaining := candidateIDs(result.Diff.Remaining)
+	if !slices.Contains(remaining, "fullpage_watermark_Im0_39KB") || !slices.Contains(remaining, "repeating_unwanted_element_800x100_") ||
+		len(result.Diff.Introduced) != 0 {
+		t.Errorf("remaining %v, introduced %v, want the banner and watermark remaining", remaining, candidateIDs(result.Diff.Introduced))
+	}
+
+	// Without Verify the cleaned file is not analyzed again
+	f = installFakeCLI(t, mixedImagesPDF())
+	result, err = AutoClean(inFile, filepath.Join(dir, "out.pdf"), AutoCleanOptions{TopN: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Diff != nil {
+		t.Errorf("diff %+v without Verify", result.Diff)
+	}
+	for _, call := range f.callsOf("images", "list") {
+		if filepath.Base(call[len(call)-1]) == "out.pdf" {
+			t.Errorf("cleaned file analyzed without Verify: %v", call)
+		}
+	}
+}
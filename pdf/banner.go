@@ -0,0 +1,58 @@
+package pdf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Banner positions supported by AddBanner
+const (
+	BannerPositionTop    = "top"
+	BannerPositionBottom = "bottom"
+)
+
+// bannerAnchors maps banner positions to pdfcpu stamp anchors (top/bottom center)
+var bannerAnchors = map[string]string{
+	BannerPositionTop:    "tc",
+	BannerPositionBottom: "bc",
+}
+
+// ValidateBannerPosition checks that position is a supported banner edge
+func ValidateBannerPosition(position string) error {
+	if _, ok := bannerAnchors[position]; !ok {
+		return fmt.Errorf("invalid banner position: %s (supported: top, bottom)", position)
+	}
+	return nil
+}
+
+// AddBanner stamps a text banner (e.g. "CONFIDENTIAL") on a background bar at the top
+// or bottom edge of every page using pdfcpu CLI
+func AddBanner(inFile, outFile, text, position string) error {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return fmt.Errorf("banner text must not be empty")
+	}
+	if err := ValidateBannerPosition(position); err != nil {
+		return err
+	}
+
+	// White text on a red bar spanning most of the page width, unrotated, slightly
+	// inset from the page edge
+	offsetY := -10
+	if position == BannerPositionBottom {
+		offsetY = 10
+	}
+	description := fmt.Sprintf("pos:%s, off:0 %d, rot:0, sc:0.9 rel, fillc:#FFFFFF, bgcol:#CC0000, ma:4, op:1",
+		bannerAnchors[position], offsetY)
+
+	name, args := stampCommand(inFile, outFile, "text", text, []string{description})
+	output, err := execCommandWithTimeout(DefaultCLITimeout, name, args...)
+	if err != nil {
+		if outputStr := string(output); outputStr != "" {
+			return fmt.Errorf("pdfcpu stamp add failed: %w\nOutput: %s", err, outputStr)
+		}
+		return fmt.Errorf("pdfcpu stamp add failed: %w", err)
+	}
+
+	return nil
+}
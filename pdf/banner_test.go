@@ -0,0 +1,48 @@
+package pdf
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestAddBannerArguments(t *testing.T) {
+	tests := []struct {
+		position    string
+		description string
+	}{
+		{BannerPositionTop, "pos:tc, off:0 -10, rot:0, sc:0.9 rel, fillc:#FFFFFF, bgcol:#CC0000, ma:4, op:1"},
+		{BannerPositionBottom, "pos:bc, off:0 10, rot:0, sc:0.9 rel, fillc:#FFFFFF, bgcol:#CC0000, ma:4, op:1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.position, func(t *testing.T) {
+			fake := installFakeCLI(t, &fakePdfcpu{pages: 1})
+			dir := t.TempDir()
+			inFile, outFile := writeFakePDF(t, dir, "in.pdf"), filepath.Join(dir, "out.pdf")
+
+			// Leading dashes must stay text, which the -- before it guarantees
+			if err := AddBanner(inFile, outFile, "  -CONFIDENTIAL- ", tt.position); err != nil {
+				t.Fatal(err)
+			}
+			want := []string{"stamp", "add", "-mode", "text", "--", "-CONFIDENTIAL-", tt.description, inFile, outFile}
+			if calls := fake.callsOf("stamp"); len(calls) != 1 || !slices.Equal(calls[0], want) {
+				t.Errorf("pdfcpu calls %q, want %q", calls, want)
+			}
+		})
+	}
+}
+
+func TestAddBannerRejects(t *testing.T) {
+	fake := installFakeCLI(t, &fakePdfcpu{pages: 1})
+	dir := t.TempDir()
+	inFile, outFile := writeFakePDF(t, dir, "in.pdf"), filepath.Join(dir, "out.pdf")
+	if err := AddBanner(inFile, outFile, "   ", BannerPositionTop); err == nil {
+		t.Error("empty text was accepted")
+	}
+	if err := AddBanner(inFile, outFile, "DRAFT", "left"); err == nil {
+		t.Error("unknown position was accepted")
+	}
+	if len(fake.calls) != 0 {
+		t.Errorf("pdfcpu was run: %v", fake.calls)
+	}
+}
@@ -0,0 +1,223 @@
+package pdf
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg" // register JPEG decoding for extracted DCT images
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// BlankPageIDPrefix prefixes the IDs of blank page candidates ("blank_page_<page>")
+const BlankPageIDPrefix = "blank_page_"
+
+// analyzeBlankPages flags pages without text and without significant image content.
+// Content that belongs to a watermark candidate (text candidates by text, image candidates
+// by signature) is ignored, so a page carrying only a faint watermark is still blank.
+// Image content is measured as ink: the page area covered by each placed image, weighted
+// by the fraction of dark pixels in it, so a scanned blank back counts as blank.
+// maxInk is the largest ink fraction of the page area that still counts as blank.
+func analyzeBlankPages(ctx context.Context, filename string, contents map[int]string, geometry map[int]PageGeometry, totalPages int, images []rawImageData, watermarks []UnwantedElementCandidate, maxInk float64, debugLog func(string, ...interface{})) ([]UnwantedElementCandidate, error) {
+	candidates := []UnwantedElementCandidate{}
+
+	inkByImage, err := extractImageInk(ctx, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	watermarkTexts := make(map[string]bool)
+	watermarkSignatures := make(map[string]bool)
+	for _, candidate := range watermarks {
+		if text := candidate.Metadata["text"]; text != "" {
+			watermarkTexts[text] = true
+		}
+		if signature := candidate.Metadata[MetaSignature]; signature != "" {
+			watermarkSignatures[signature] = true
+		}
+	}
+
+	imagesByPage := make(map[int]map[string]rawImageData)
+	for _, img := range images {
+		if imagesByPage[img.page] == nil {
+			imagesByPage[img.page] = make(map[string]rawImageData)
+		}
+		imagesByPage[img.page][img.id] = img
+	}
+
+	for page := 1; page <= totalPages; page++ {
+		content := contents[page]
+
+		hasText := false
+		for _, block := range findTextBlocks(content) {
+			if strings.TrimSpace(block.text) != "" && !watermarkTexts[block.text] {
+				hasText = true
+				break
+			}
+		}
+		if hasText {
+			continue
+		}
+
+		geo, ok := geometry[page]
+		if !ok || geo.Width <= 0 || geo.Height <= 0 {
+			continue
+		}
+		pageArea := geo.Width * geo.Height
+
+		ink := 0.0
+		placements := findImagePlacements(content)
+		for _, placement := range placements {
+			img, ok := imagesByPage[page][placement.name]
+			if !ok {
+				continue // form XObject or unknown resource
+			}
+			info := imageInfo{id: img.id, width: img.width, height: img.height, size: img.size, colorSpace: img.colorSpace}
+			if watermarkSignatures[imageSignature(info)] {
+				continue
+			}
+			bbox := clipToPage(placement.bbox, geo)
+			ratio, decoded := inkByImage[fmt.Sprintf("%d|%s", page, img.id)]
+			if !decoded {
+				ratio = 1 // undecodable images are assumed to be fully inked
+			}
+			ink += bbox.Width * bbox.Height / pageArea * ratio
+		}
+
+		if ink > maxInk {
+			continue
+		}
+
+		confidence := 0.7
+		if len(placements) == 0 && strings.TrimSpace(content) == "" {
+			confidence = 0.95 // nothing drawn at all
+		}
+		if debugLog != nil {
+			debugLog("[DEBUG] Blank page detected: page %d (ink %.4f, threshold %.4f)", page, ink, maxInk)
+		}
+		candidates = append(candidates, UnwantedElementCandidate{
+			Type:        "blank_page",
+			ID:          fmt.Sprintf("%s%d", BlankPageIDPrefix, page),
+			Page:        page,
+			Description: fmt.Sprintf("Page %d appears to be blank", page),
+			Confidence:  confidence,
+			Metadata: map[string]string{
+				MetaType: "blank_page",
+				"ink":    fmt.Sprintf("%.4f", ink),
+			},
+			Pages: []int{page},
+		})
+	}
+
+	return candidates, nil
+}
+
+// extractImageInk extracts every image with pdfcpu CLI and measures its fraction of dark
+// pixels. Returns a map of "<page>|<image id>" -> ink ratio; images in formats Go cannot
+// decode are left out.
+func extractImageInk(ctx context.Context, filename string) (map[string]float64, error) {
+	extractDir, err := os.MkdirTemp(filepath.Dir(filename), "blank_")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create image extract directory: %w", err)
+	}
+	defer os.RemoveAll(extractDir)
+
+	name, args := extractImagesCommand(filename, extractDir, nil)
+	output, err := execCommandContext(ctx, AnalysisTimeout, name, args...)
+	if err != nil {
+		return nil, fmt.Errorf("pdfcpu extract images failed: %w\nOutput: %s", err, string(output))
+	}
+
+	files, err := os.ReadDir(extractDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image extract directory: %w", err)
+	}
+
+	base := strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	inkByImage := make(map[string]float64)
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		matches := extractedImagePattern.FindStringSubmatch(strings.TrimPrefix(file.Name(), base+"_"))
+		if len(matches) < 3 {
+			continue
+		}
+		page, err := strconv.Atoi(matches[1])
+		if err != nil || page < 1 {
+			continue
+		}
+		if ratio, ok := imageInkRatio(filepath.Join(extractDir, file.Name())); ok {
+			inkByImage[fmt.Sprintf("%d|%s", page, matches[2])] = ratio
+		}
+	}
+
+	return inkByImage, nil
+}
+
+// blankInkLuminance is the 16-bit luminance below which a pixel counts as ink
+const blankInkLuminance = 0xC000
+
+// imageInkRatio returns the fraction of visible dark pixels in an image file
+// Large images are sampled on a grid to bound the cost
+func imageInkRatio(path string) (float64, bool) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, false
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return 0, false
+	}
+
+	bounds := img.Bounds()
+	step := max(1, max(bounds.Dx(), bounds.Dy())/500)
+	dark, total := 0, 0
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += step {
+		for x := bounds.Min.X; x < bounds.Max.X; x += step {
+			total++
+			if _, _, _, alpha := img.At(x, y).RGBA(); alpha == 0 {
+				continue
+			}
+			if color.Gray16Model.Convert(img.At(x, y)).(color.Gray16).Y < blankInkLuminance {
+				dark++
+			}
+		}
+	}
+	if total == 0 {
+		return 0, true
+	}
+	return float64(dark) / float64(total), true
+}
+
+// SplitBlankPageIDs separates blank page candidate IDs from other element IDs
+// Returns the page numbers of the blank page IDs and the remaining IDs
+func SplitBlankPageIDs(elementIDs []string) ([]int, []string) {
+	pages := []int{}
+	rest := []string{}
+	for _, id := range elementIDs {
+		if page, err := strconv.Atoi(strings.TrimPrefix(id, BlankPageIDPrefix)); strings.HasPrefix(id, BlankPageIDPrefix) && err == nil {
+			pages = append(pages, page)
+			continue
+		}
+		rest = append(rest, id)
+	}
+	return pages, rest
+}
+
+// RemoveBlankPages drops the given pages (e.g. selected blank page candidates) from a PDF
+func RemoveBlankPages(inFile, outFile string, pages []int) error {
+	if len(pages) == 0 {
+		return fmt.Errorf("no blank pages selected for removal")
+	}
+	pageStrs := make([]string, len(pages))
+	for i, p := range pages {
+		pageStrs[i] = strconv.Itoa(p)
+	}
+	return RemovePagesFromPDF(inFile, outFile, strings.Join(pageStrs, ","))
+}
@@ -0,0 +1,100 @@
+package pdf
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// writeGrayPNG writes a 20x20 PNG of one gray level
+func writeGrayPNG(path string, level uint8) error {
+	img := image.NewGray(image.Rect(0, 0, 20, 20))
+	for i := range img.Pix {
+		img.Pix[i] = level
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return png.Encode(file, img)
+}
+
+func TestBlankPagesIgnoreFaintAndWatermarkContent(t *testing.T) {
+	const watermark = "q 300 0 0 200 156 296 cm /Im0 Do Q "
+	f := &fakePdfcpu{pages: 5, contents: map[int]string{
+		1: watermark + "BT /F1 12 Tf 72 720 Td (Body) Tj ET",
+		2: "",
+		3: watermark,
+		4: watermark + "q 612 0 0 792 0 0 cm /Faint Do Q",
+		5: watermark + "q 306 0 0 396 0 0 cm /Dark Do Q",
+	}}
+	for page := 1; page <= 5; page++ {
+		f.images = append(f.images, fakeImage{Page: page, Obj: 10, ID: "Im0", Width: 600, Height: 400, CS: "DeviceGray", Size: 40000})
+	}
+	f.images = append(f.images,
+		fakeImage{Page: 4, Obj: 20, ID: "Faint", Width: 20, Height: 20, CS: "DeviceGray", Size: 500},
+		fakeImage{Page: 5, Obj: 30, ID: "Dark", Width: 20, Height: 20, CS: "DeviceGray", Size: 500})
+	// The watermark and Dark are black, Faint is a light gray that is not ink
+	levels := map[string]uint8{"Im0": 0, "Faint": 0xF0, "Dark": 0}
+	f.respond = func(args []string) (string, bool, error) {
+		if args[0] != "extract" || !slices.Contains(args, "image") {
+			return "", false, nil
+		}
+		inFile, outDir := args[len(args)-2], args[len(args)-1]
+		base := strings.TrimSuffix(filepath.Base(inFile), ".pdf")
+		for _, img := range f.images {
+			name := filepath.Join(outDir, fmt.Sprintf("%s_%d_%s.png", base, img.Page, img.ID))
+			if err := writeGrayPNG(name, levels[img.ID]); err != nil {
+				return "", true, err
+			}
+		}
+		return "", true, nil
+	}
+	installFakeCLI(t, f)
+	inFile := writeFakePDF(t, t.TempDir(), "in.pdf")
+
+	blankPages := func(opts AnalysisOptions) map[int]float64 {
+		t.Helper()
+		opts.DetectBlankPages = true
+		analysis, err := AnalyzeUnwantedElementsWithOptions(inFile, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages := make(map[int]float64)
+		for _, candidate := range analysis.BlankPageCandidates {
+			if candidate.Type != "blank_page" || candidate.ID != fmt.Sprintf("%s%d", BlankPageIDPrefix, candidate.Page) {
+				t.Errorf("malformed blank page candidate %+v", candidate)
+			}
+			pages[candidate.Page] = candidate.Confidence
+		}
+		return pages
+	}
+
+	// Page 1 has text and page 5 a dark quarter-page image; page 3 carries only the
+	// watermark and page 4 the watermark and a faint image
+	got := blankPages(AnalysisOptions{})
+	if len(got) != 3 || got[2] == 0 || got[3] == 0 || got[4] == 0 {
+		t.Fatalf("blank pages = %v, want 2, 3 and 4", got)
+	}
+	if got[2] <= got[3] {
+		t.Errorf("empty page confidence %.2f, want above the %.2f of a page with ignored content", got[2], got[3])
+	}
+
+	// Raising the threshold above a quarter page also counts page 5
+	if got := blankPages(AnalysisOptions{BlankPageMaxInk: 0.3}); len(got) != 4 || got[5] == 0 {
+		t.Errorf("blank pages with max ink 0.3 = %v, want 2 to 5", got)
+	}
+}
+
+func TestSplitBlankPageIDs(t *testing.T) {
+	pages, rest := SplitBlankPageIDs([]string{"blank_page_3", "img_1", "blank_page_x", "blank_page_10"})
+	if !slices.Equal(pages, []int{3, 10}) || !slices.Equal(rest, []string{"img_1", "blank_page_x"}) {
+		t.Errorf("SplitBlankPageIDs = %v, %q", pages, rest)
+	}
+}
@@ -0,0 +1,155 @@
+package pdf
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ErrNoOutline is returned when a PDF has no bookmarks to split at
+var ErrNoOutline = errors.New("PDF has no bookmarks")
+
+// Bookmark is an outline entry of a PDF
+type Bookmark struct {
+	Title string     `json:"title"`
+	Page  int        `json:"page"` // page the bookmark points to
+	Kids  []Bookmark `json:"kids,omitempty"`
+}
+
+// bookmarksExport is the JSON written by "pdfcpu bookmarks export"
+type bookmarksExport struct {
+	Bookmarks []Bookmark `json:"bookmarks"`
+}
+
+// noOutlineMarkers are pdfcpu messages for documents without an outline
+var noOutlineMarkers = []string{"no outlines", "no bookmarks", "no outline"}
+
+// ListBookmarks returns the outline of a PDF using pdfcpu CLI. Returns ErrNoOutline if the
+// document has no bookmarks.
+func ListBookmarks(inFile string) ([]Bookmark, error) {
+	exportDir, err := os.MkdirTemp(filepath.Dir(inFile), "bookmarks_")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bookmarks directory: %w", err)
+	}
+	defer os.RemoveAll(exportDir)
+
+	exportFile := filepath.Join(exportDir, "bookmarks.json")
+	name, args := bookmarksExportCommand(inFile, exportFile)
+	output, err := execCommandWithTimeout(DefaultCLITimeout, name, args...)
+	if err != nil {
+		lower := strings.ToLower(string(output))
+		for _, marker := range noOutlineMarkers {
+			if strings.Contains(lower, marker) {
+				return nil, ErrNoOutline
+			}
+		}
+		return nil, fmt.Errorf("pdfcpu bookmarks export failed: %w\nOutput: %s", err, string(output))
+	}
+
+	data, err := os.ReadFile(exportFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bookmarks export: %w", err)
+	}
+	var export bookmarksExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("invalid bookmarks export: %w", err)
+	}
+	if len(export.Bookmarks) == 0 {
+		return nil, ErrNoOutline
+	}
+	return export.Bookmarks, nil
+}
+
+// bookmarkRange is a part of the document between two top-level bookmarks
+type bookmarkRange struct {
+	title    string
+	from, to int
+}
+
+// bookmarkRanges maps top-level bookmarks to consecutive page ranges: each bookmark runs
+// up to the page before the next one and the last runs to the end of the document. Pages
+// before the first bookmark form a "Front matter" part. Bookmarks pointing at the same
+// page as the next one, or outside the document, have no pages of their own and are skipped.
+func bookmarkRanges(bookmarks []Bookmark, totalPages int) []bookmarkRange {
+	sorted := []Bookmark{}
+	for _, bookmark := range bookmarks {
+		if bookmark.Page >= 1 && bookmark.Page <= totalPages {
+			sorted = append(sorted, bookmark)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Page < sorted[j].Page })
+
+	ranges := []bookmarkRange{}
+	if len(sorted) > 0 && sorted[0].Page > 1 {
+		ranges = append(ranges, bookmarkRange{title: "Front matter", from: 1, to: sorted[0].Page - 1})
+	}
+	for i, bookmark := range sorted {
+		to := totalPages
+		if i+1 < len(sorted) {
+			to = sorted[i+1].Page - 1
+		}
+		if to < bookmark.Page {
+			continue
+		}
+		ranges = append(ranges, bookmarkRange{title: bookmark.Title, from: bookmark.Page, to: to})
+	}
+	return ranges
+}
+
+// bookmarkFilename turns a bookmark title into a safe file name stem
+func bookmarkFilename(title string) string {
+	var b strings.Builder
+	for _, r := range strings.TrimSpace(title) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_':
+			b.WriteRune(r)
+		case unicode.IsSpace(r):
+			b.WriteRune('_')
+		}
+	}
+	name := strings.Trim(b.String(), "_")
+	if runes := []rune(name); len(runes) > 60 {
+		name = string(runes[:60])
+	}
+	if name == "" {
+		name = "part"
+	}
+	return name
+}
+
+// SplitByBookmarks writes one PDF per top-level bookmark to outDir, named
+// "<NN>_<sanitized title>.pdf" in document order, and returns their paths. Returns
+// ErrNoOutline for documents without bookmarks.
+func SplitByBookmarks(inFile, outDir string) ([]string, error) {
+	bookmarks, err := ListBookmarks(inFile)
+	if err != nil {
+		return nil, err
+	}
+	totalPages, err := getPageCount(inFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get page count: %w", err)
+	}
+	ranges := bookmarkRanges(bookmarks, totalPages)
+	if len(ranges) == 0 {
+		return nil, fmt.Errorf("%w: no bookmark points to a page of the document", ErrNoOutline)
+	}
+
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	files := make([]string, 0, len(ranges))
+	for i, part := range ranges {
+		outFile := filepath.Join(outDir, fmt.Sprintf("%02d_%s.pdf", i+1, bookmarkFilename(part.title)))
+		if err := ExtractPages(inFile, outFile, fmt.Sprintf("%d-%d", part.from, part.to)); err != nil {
+			return nil, fmt.Errorf("failed to extract %q (pages %d-%d): %w", part.title, part.from, part.to, err)
+		}
+		files = append(files, outFile)
+	}
+	return files, nil
+}
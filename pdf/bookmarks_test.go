@@ -0,0 +1,119 @@
+package pdf
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// sampleOutline is a bookmarks export with a title page before the first chapter, a
+// nested section and two bookmarks on the same page
+const sampleOutline = `{
+	"header": {"source": "book.pdf", "version": "pdfcpu v0.11.1"},
+	"bookmarks": [
+		{"title": "Chapter 1: Getting Started", "page": 3, "kids": [
+			{"title": "1.1 Installation", "page": 4}
+		]},
+		{"title": "Chapter 2 / Usage", "page": 6},
+		{"title": "Appendix", "page": 9},
+		{"title": "Index", "page": 9},
+		{"title": "Dangling", "page": 40}
+	]
+}`
+
+// installOutlineCLI fakes a pdfcpu whose bookmarks export writes outline, or fails with
+// exportErr
+func installOutlineCLI(t *testing.T, pages int, outline string, exportErr error) *fakePdfcpu {
+	t.Helper()
+	return installFakeCLI(t, &fakePdfcpu{pages: pages, respond: func(args []string) (string, bool, error) {
+		if len(args) < 4 || args[0] != "bookmarks" || args[1] != "export" {
+			return "", false, nil
+		}
+		if exportErr != nil {
+			return "", true, exportErr
+		}
+		return "", true, os.WriteFile(args[3], []byte(outline), 0644)
+	}})
+}
+
+func TestSplitByBookmarks(t *testing.T) {
+	f := installOutlineCLI(t, 10, sampleOutline, nil)
+	dir := t.TempDir()
+	inFile := writeFakePDF(t, dir, "book.pdf")
+	outDir := filepath.Join(dir, "chapters")
+
+	files, err := SplitByBookmarks(inFile, outDir)
+	if err != nil {
+		t.Fatalf("SplitByBookmarks: %v", err)
+	}
+	wantNames := []string{"01_Front_matter.pdf", "02_Chapter_1_Getting_Started.pdf", "03_Chapter_2__Usage.pdf", "04_Index.pdf"}
+	var names []string
+	for _, file := range files {
+		if filepath.Dir(file) != outDir {
+			t.Errorf("%s written outside %s", file, outDir)
+		}
+		if _, err := os.Stat(file); err != nil {
+			t.Errorf("part not written: %v", err)
+		}
+		names = append(names, filepath.Base(file))
+	}
+	if !slices.Equal(names, wantNames) {
+		t.Errorf("parts = %v, want %v", names, wantNames)
+	}
+
+	// Top-level bookmarks only: the nested section stays in its chapter
+	var pages []string
+	for _, call := range f.callsOf("trim") {
+		pages = append(pages, call[2])
+	}
+	if want := []string{"1,2", "3,4,5", "6,7,8", "9,10"}; !slices.Equal(pages, want) {
+		t.Errorf("extracted pages %v, want %v", pages, want)
+	}
+}
+
+func TestSplitByBookmarksWithoutOutline(t *testing.T) {
+	tests := []struct {
+		name      string
+		outline   string
+		exportErr error
+	}{
+		{name: "pdfcpu reports no outline", exportErr: fmt.Errorf("pdfcpu: no outlines available")},
+		{name: "empty export", outline: `{"bookmarks": []}`},
+		{name: "no bookmark inside the document", outline: `{"bookmarks": [{"title": "Gone", "page": 99}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := installOutlineCLI(t, 5, tt.outline, tt.exportErr)
+			dir := t.TempDir()
+			inFile := writeFakePDF(t, dir, "in.pdf")
+
+			files, err := SplitByBookmarks(inFile, filepath.Join(dir, "out"))
+			if !errors.Is(err, ErrNoOutline) {
+				t.Errorf("err = %v, want ErrNoOutline", err)
+			}
+			if len(files) != 0 || f.callCount("trim") != 0 {
+				t.Errorf("parts %v written for a document without an outline", files)
+			}
+		})
+	}
+}
+
+func TestBookmarkFilename(t *testing.T) {
+	tests := []struct{ title, want string }{
+		{"Chapter 1", "Chapter_1"},
+		{"  Einführung  ", "Einführung"},
+		{"../../etc/passwd", "etcpasswd"},
+		{"Q&A: What? Why!", "QA_What_Why"},
+		{"***", "part"},
+		{"", "part"},
+		{"A very long chapter title that keeps going well past the sixty character limit", "A_very_long_chapter_title_that_keeps_going_well_past_the_six"},
+	}
+	for _, tt := range tests {
+		if got := bookmarkFilename(tt.title); got != tt.want {
+			t.Errorf("bookmarkFilename(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package pdf
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// CleanReport describes what Clean removed
+type CleanReport struct {
+	RemovedPages []int         `json:"removed_pages"`
+	Removal      RemovalReport `json:"removal"`
+}
+
+// Clean removes, in one operation, the pages of a page specification ("" for none) and
+// the image candidates elementIDs, including blank_page_<n> candidates. Page numbers and
+// IDs both refer to inFile as given: elements are removed first and all pages are dropped
+// afterwards in a single step, because removing pages first would renumber the pages that
+// blank page IDs and later page numbers point at. Everything is validated before any
+// pdfcpu command runs; opts.Report is replaced by the report's Removal field.
+func Clean(inFile, outFile, pages string, elementIDs []string, opts RemovalOptions) (*CleanReport, error) {
+	if err := checkDistinctFiles(inFile, outFile); err != nil {
+		return nil, err
+	}
+	if pages == "" && len(elementIDs) == 0 {
+		return nil, fmt.Errorf("nothing selected for removal")
+	}
+	if err := ValidateMatchMode(opts.MatchMode); err != nil {
+		return nil, err
+	}
+
+	totalPages, err := getPageCount(inFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get page count: %w", err)
+	}
+
+	blankPages, imageIDs := SplitBlankPageIDs(elementIDs)
+	pageSet := pageSetOf(blankPages)
+	if pages != "" {
+		pageNumbers, err := ParsePageSpecifierWithTotal(pages, totalPages)
+		if err != nil {
+			return nil, err
+		}
+		for _, page := range pageNumbers {
+			pageSet[page] = true
+		}
+	}
+	removedPages := sortedPageSet(pageSet)
+	if err := ValidatePageNumbers(removedPages, totalPages); err != nil {
+		return nil, err
+	}
+	if len(removedPages) >= totalPages {
+		return nil, fmt.Errorf("%w: all %d pages selected", ErrWouldEmptyDocument, totalPages)
+	}
+
+	report := &CleanReport{RemovedPages: removedPages}
+	opts.Report = &report.Removal
+
+	if len(imageIDs) == 0 {
+		return report, RemovePagesFromPDF(inFile, outFile, joinPages(removedPages))
+	}
+
+	elementsOut := outFile
+	if len(removedPages) > 0 {
+		elementsOut = strings.TrimSuffix(outFile, ".pdf") + "_elements.pdf"
+		defer os.Remove(elementsOut)
+	}
+	if err := RemoveElementsByIDsWithOptions(inFile, elementsOut, "image", imageIDs, opts); err != nil {
+		return nil, err
+	}
+	if len(removedPages) > 0 {
+		if err := RemovePagesFromPDF(elementsOut, outFile, joinPages(removedPages)); err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
+
+// joinPages formats page numbers as a comma-separated page specification
+func joinPages(pages []int) string {
+	pageStrs := make([]string, len(pages))
+	for i, p := range pages {
+		pageStrs[i] = strconv.Itoa(p)
+	}
+	return strings.Join(pageStrs, ",")
+}
@@ -0,0 +1,97 @@
+package pdf
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCleanPagesAndElements(t *testing.T) {
+	f := installFakeCLI(t, watermarkedPDF(5))
+	dir := t.TempDir()
+	inFile := writeFakePDF(t, dir, "in.pdf")
+	outFile := filepath.Join(dir, "out.pdf")
+
+	analysis, err := AnalyzeUnwantedElements(inFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(analysis.ImageCandidates) == 0 {
+		t.Fatal("expected a candidate for the watermark")
+	}
+	watermark := analysis.ImageCandidates[0].ID
+
+	// Drop the cover page and page 3 (as a blank page candidate), and strip the watermark
+	report, err := Clean(inFile, outFile, "1", []string{watermark, "blank_page_3"}, RemovalOptions{})
+	if err != nil {
+		t.Fatalf("Clean: %v", err)
+	}
+	if !slices.Equal(report.RemovedPages, []int{1, 3}) {
+		t.Errorf("removed pages %v, want [1 3]", report.RemovedPages)
+	}
+	if report.Removal.Removed != 5 {
+		t.Errorf("removed %d image occurrences, want the watermark on all 5 pages", report.Removal.Removed)
+	}
+
+	// Elements are removed from the original pages, then the pages are dropped in one step
+	var order []string
+	for _, call := range f.calls {
+		switch {
+		case slices.Equal(call[:2], []string{"images", "update"}):
+			if len(order) == 0 || order[len(order)-1] != "images update" {
+				order = append(order, "images update")
+			}
+		case slices.Equal(call[:2], []string{"pages", "remove"}):
+			order = append(order, "pages remove "+call[3])
+			if call[len(call)-1] != outFile || call[len(call)-2] == inFile {
+				t.Errorf("pages removed by %v, want from the intermediate into the output", call)
+			}
+		}
+	}
+	if want := []string{"images update", "pages remove 1,3"}; !slices.Equal(order, want) {
+		t.Errorf("operations %v, want %v", order, want)
+	}
+
+	if _, err := os.Stat(outFile); err != nil {
+		t.Errorf("output not written: %v", err)
+	}
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		if strings.Contains(entry.Name(), "_elements") {
+			t.Errorf("intermediate %s left behind", entry.Name())
+		}
+	}
+}
+
+func TestCleanValidatesUpFront(t *testing.T) {
+	tests := []struct {
+		name       string
+		pages      string
+		elementIDs []string
+		wantErr    error
+	}{
+		{name: "nothing selected"},
+		{name: "page past the end", pages: "7", wantErr: ErrPageOutOfRange},
+		{name: "blank page past the end", elementIDs: []string{"blank_page_9"}, wantErr: ErrPageOutOfRange},
+		{name: "every page", pages: "1-3", elementIDs: []string{"blank_page_4", "blank_page_5"}, wantErr: ErrWouldEmptyDocument},
+		{name: "malformed pages", pages: "1-x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := installFakeCLI(t, watermarkedPDF(5))
+			dir := t.TempDir()
+			inFile := writeFakePDF(t, dir, "in.pdf")
+
+			_, err := Clean(inFile, filepath.Join(dir, "out.pdf"), tt.pages, tt.elementIDs, RemovalOptions{})
+			if err == nil || (tt.wantErr != nil && !errors.Is(err, tt.wantErr)) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if n := f.callCount("images", "update") + f.callCount("pages", "remove"); n != 0 {
+				t.Errorf("%d modifying commands ran before validation failed", n)
+			}
+		})
+	}
+}
@@ -1,9 +1,16 @@
 package pdf
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
 	"time"
 )
 
@@ -11,24 +18,269 @@ import (
 const (
 	DefaultCLITimeout = 30 * time.Second
 	AnalysisTimeout   = 60 * time.Second // Longer timeout for analysis operations
+
+	// PerPageCLITimeout is added to DefaultCLITimeout for every page of commands that
+	// rewrite the whole document page by page, such as merging or stamping OCR text layers
+	PerPageCLITimeout = 500 * time.Millisecond
 )
 
-// execCommandWithTimeout executes a command with a timeout
+// documentTimeout returns the timeout of a command processing every page of a document
+func documentTimeout(totalPages int) time.Duration {
+	return DefaultCLITimeout + time.Duration(totalPages)*PerPageCLITimeout
+}
+
+// ErrCommandTimeout is returned when a CLI command runs past its timeout
+var ErrCommandTimeout = errors.New("command timed out")
+
+// ErrCommandCancelled is returned when the context of a CLI command is cancelled, which
+// kills the command's process. It wraps context.Canceled.
+var ErrCommandCancelled = fmt.Errorf("command cancelled: %w", context.Canceled)
+
+// execCommandWithTimeout executes a command with a timeout and returns its stdout. A zero
+// exit status is success whatever the command wrote to stderr, so warnings pdfcpu prints
+// there are dropped and never mixed into output that callers parse. On failure the stderr
+// text is returned instead (stdout if stderr is empty), since that is where the cause is.
+// Waiting for a free CLI slot (see SetMaxConcurrentCLI) does not count against the timeout
 func execCommandWithTimeout(timeout time.Duration, name string, args ...string) ([]byte, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	return execCommandContext(context.Background(), timeout, name, args...)
+}
+
+// execCommandContext is execCommandWithTimeout for a command that is also stopped, killing
+// its process, when ctx is cancelled; the error then wraps ErrCommandCancelled
+func execCommandContext(ctx context.Context, timeout time.Duration, name string, args ...string) ([]byte, error) {
+	stdout, stderr, err := execCommandStreamsContext(ctx, timeout, name, args...)
+	if err != nil && len(bytes.TrimSpace(stderr)) > 0 {
+		return stderr, err
+	}
+	return stdout, err
+}
+
+// outputLines splits CLI output into lines. pdfcpu on Windows, and some terminals, end
+// lines with CRLF; a stray "\r" would otherwise stay on the last field of every line.
+func outputLines(output string) []string {
+	return strings.Split(normalizeNewlines(output), "\n")
+}
+
+// normalizeNewlines converts CRLF and lone CR line endings to LF
+func normalizeNewlines(output string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(output, "\r\n", "\n"), "\r", "\n")
+}
+
+// execCommandStreams is execCommandWithTimeout for callers that need stdout and stderr
+// separately. Only the output of a failed pdfcpu command is scanned for known causes
+// (see classifyPdfcpuFailure), stderr first.
+func execCommandStreams(timeout time.Duration, name string, args ...string) ([]byte, []byte, error) {
+	return execCommandStreamsContext(context.Background(), timeout, name, args...)
+}
+
+// execCommandStreamsContext is execCommandStreams for a command that is also stopped when
+// ctx is cancelled. A command cancelled while it waits for a CLI slot is never started.
+func execCommandStreamsContext(parent context.Context, timeout time.Duration, name string, args ...string) ([]byte, []byte, error) {
+	release, err := acquireCLISlotContext(parent)
+	if err != nil {
+		return nil, nil, ErrCommandCancelled
+	}
+	defer release()
+
+	if name == "pdfcpu" {
+		args = withGlobalFlags(args)
+	}
+
+	ctx, cancel := context.WithTimeout(parent, timeout)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, name, args...)
-	output, err := cmd.CombinedOutput()
+	var env []string
+	if name == "pdfcpu" && pdfcpuConfigDir != "" {
+		env = append(os.Environ(), "XDG_CONFIG_HOME="+pdfcpuConfigDir)
+	}
+	var stdout, stderr bytes.Buffer
+	err = runCommand(ctx, name, args, env, &stdout, &stderr)
 
+	if parent.Err() != nil {
+		return nil, nil, ErrCommandCancelled
+	}
 	if ctx.Err() == context.DeadlineExceeded {
-		return nil, fmt.Errorf("command timed out after %v", timeout)
+		return nil, nil, fmt.Errorf("%w after %v", ErrCommandTimeout, timeout)
 	}
 
 	if err != nil {
-		return output, fmt.Errorf("command failed: %v", err)
+		if name == "pdfcpu" {
+			failure := stderr.Bytes()
+			if len(bytes.TrimSpace(failure)) == 0 {
+				failure = stdout.Bytes()
+			}
+			if cause := classifyPdfcpuFailure(failure); cause != nil {
+				return stdout.Bytes(), stderr.Bytes(), fmt.Errorf("command failed: %w", cause)
+			}
+		}
+		return stdout.Bytes(), stderr.Bytes(), fmt.Errorf("command failed: %w", err)
+	}
+
+	return stdout.Bytes(), stderr.Bytes(), nil
+}
+
+// commandRunner runs one external command to completion, writing its output to stdout and
+// stderr. env is the complete environment, or nil to inherit the server's.
+type commandRunner func(ctx context.Context, name string, args, env []string, stdout, stderr io.Writer) error
+
+// runCommand is the commandRunner behind every CLI call; tests replace it to fake pdfcpu
+// and the OCR engine without the binaries installed
+var runCommand commandRunner = execRunner
+
+// execRunner is the commandRunner that runs the real binary
+func execRunner(ctx context.Context, name string, args, env []string, stdout, stderr io.Writer) error {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Env = env
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	return cmd.Run()
+}
+
+// pdfcpuConfigDir overrides the directory pdfcpu keeps its config and cache in ("" keeps the default)
+var pdfcpuConfigDir string
+
+// SetPdfcpuConfigDir points pdfcpu's config/cache directory at dir, for deployments where the
+// user's default config directory is read-only or shared. pdfcpu resolves it through
+// XDG_CONFIG_HOME, which is set on every pdfcpu command. Must be called at startup.
+func SetPdfcpuConfigDir(dir string) error {
+	if dir == "" {
+		pdfcpuConfigDir = ""
+		return nil
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("invalid pdfcpu config directory: %w", err)
+	}
+	if err := os.MkdirAll(absDir, 0755); err != nil {
+		return fmt.Errorf("failed to create pdfcpu config directory: %w", err)
+	}
+	pdfcpuConfigDir = absDir
+	return nil
+}
+
+// pdfcpuGlobalFlagArity lists the pdfcpu flags accepted as global flags and whether each takes a value
+var pdfcpuGlobalFlagArity = map[string]bool{
+	"-c":       true, // config dir, or "disable"
+	"-conf":    true,
+	"-opw":     true, // owner password
+	"-upw":     true, // user password
+	"-u":       true, // display unit
+	"-unit":    true,
+	"-o":       false, // offline
+	"-offline": false,
+	"-q":       false,
+	"-quiet":   false,
+	"-v":       false,
+	"-verbose": false,
+	"-vv":      false,
+}
+
+// pdfcpuGroupCommands are pdfcpu commands that take a second subcommand word (e.g. "images list")
+var pdfcpuGroupCommands = map[string]bool{
+	"annotations": true, "attachments": true, "bookmarks": true, "boxes": true,
+	"config": true, "fonts": true, "form": true, "images": true, "keywords": true,
+	"pagelayout": true, "pagemode": true, "pages": true, "permissions": true,
+	"portfolio": true, "properties": true, "stamp": true, "viewerpref": true,
+	"watermark": true,
+}
+
+// pdfcpuGlobalFlags are added to every pdfcpu invocation (see SetPdfcpuGlobalFlags)
+var pdfcpuGlobalFlags [][]string
+
+// SetPdfcpuGlobalFlags configures flags added to every pdfcpu command, e.g. "-c disable"
+// Only pdfcpu's global flags are accepted; a flag also passed by a specific call site is
+// left to that call site. Must be called at startup before any PDF operation runs.
+func SetPdfcpuGlobalFlags(flags []string) error {
+	parsed := [][]string{}
+	for i := 0; i < len(flags); i++ {
+		takesValue, ok := pdfcpuGlobalFlagArity[flags[i]]
+		if !ok {
+			return fmt.Errorf("unsupported pdfcpu global flag: %s", flags[i])
+		}
+		if !takesValue {
+			parsed = append(parsed, []string{flags[i]})
+			continue
+		}
+		if i+1 >= len(flags) || strings.HasPrefix(flags[i+1], "-") {
+			return fmt.Errorf("pdfcpu global flag %s requires a value", flags[i])
+		}
+		parsed = append(parsed, []string{flags[i], flags[i+1]})
+		i++
+	}
+	pdfcpuGlobalFlags = parsed
+	return nil
+}
+
+// withGlobalFlags inserts the configured global flags right after the pdfcpu command words,
+// where pdfcpu parses flags. Flags already present in args take precedence.
+func withGlobalFlags(args []string) []string {
+	if len(pdfcpuGlobalFlags) == 0 || len(args) == 0 {
+		return args
+	}
+
+	commandWords := pdfcpuCommandWords(args)
+	present := make(map[string]bool)
+	for _, arg := range args[commandWords:] {
+		if arg == "--" {
+			break
+		}
+		present[arg] = true
+	}
+
+	result := append([]string{}, args[:commandWords]...)
+	for _, flag := range pdfcpuGlobalFlags {
+		if !present[flag[0]] {
+			result = append(result, flag...)
+		}
 	}
+	return append(result, args[commandWords:]...)
+}
+
+// pdfcpuCommandWords returns how many leading args name the pdfcpu command (e.g. 2 for "images list")
+func pdfcpuCommandWords(args []string) int {
+	if len(args) > 1 && pdfcpuGroupCommands[args[0]] && !strings.HasPrefix(args[1], "-") {
+		return 2
+	}
+	return 1
+}
 
+// runPdfcpuJSON runs a pdfcpu command with -json added after the command words and returns
+// its output, which is checked to be JSON. pdfcpu versions without JSON output for the
+// command fail, so callers can fall back to parsing the text output.
+func runPdfcpuJSON(ctx context.Context, timeout time.Duration, args ...string) ([]byte, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("no pdfcpu command given")
+	}
+	commandWords := pdfcpuCommandWords(args)
+	jsonArgs := append(append(append([]string{}, args[:commandWords]...), "-json"), args[commandWords:]...)
+
+	output, err := execCommandContext(ctx, timeout, "pdfcpu", jsonArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("pdfcpu %s -json failed: %w", strings.Join(args[:commandWords], " "), err)
+	}
+	output = bytes.TrimSpace(output)
+	if !json.Valid(output) {
+		return nil, fmt.Errorf("pdfcpu %s -json returned no JSON", strings.Join(args[:commandWords], " "))
+	}
 	return output, nil
 }
 
+// ErrSameInputOutput is returned when an operation is asked to write over its own input
+// pdfcpu may truncate the output file before it has finished reading the input
+var ErrSameInputOutput = errors.New("output file must differ from input file")
+
+// checkDistinctFiles returns ErrSameInputOutput if inFile and outFile name the same path
+func checkDistinctFiles(inFile, outFile string) error {
+	inAbs, err := filepath.Abs(inFile)
+	if err != nil {
+		return fmt.Errorf("invalid input path: %w", err)
+	}
+	outAbs, err := filepath.Abs(outFile)
+	if err != nil {
+		return fmt.Errorf("invalid output path: %w", err)
+	}
+	if inAbs == outAbs {
+		return fmt.Errorf("%w: %s", ErrSameInputOutput, inFile)
+	}
+	return nil
+}
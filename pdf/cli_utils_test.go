@@ -0,0 +1,330 @@
+package pdf
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSameInputOutputRejected(t *testing.T) {
+	dir := t.TempDir()
+	inFile := writeFakePDF(t, dir, "in.pdf")
+	sameFile := filepath.Join(dir, "sub", "..", "in.pdf")
+
+	operations := []struct {
+		name string
+		run  func(inFile, outFile string) error
+	}{
+		{"remove pages", func(in, out string) error { return RemovePagesFromPDF(in, out, "1") }},
+		{"remove elements", func(in, out string) error {
+			return RemoveElementsByIDsWithOptions(in, out, "image", []string{"img"}, RemovalOptions{})
+		}},
+		{"extract pages", func(in, out string) error { return ExtractPages(in, out, "1") }},
+		{"rotate", func(in, out string) error { return RotatePages(in, out, "1", 90) }},
+		{"resave", ResavePDF},
+	}
+	for _, op := range operations {
+		t.Run(op.name, func(t *testing.T) {
+			fake := installFakeCLI(t, &fakePdfcpu{pages: 3})
+			for _, outFile := range []string{inFile, sameFile} {
+				if err := op.run(inFile, outFile); !errors.Is(err, ErrSameInputOutput) {
+					t.Errorf("output %s: err = %v, want ErrSameInputOutput", outFile, err)
+				}
+			}
+			// Reading the page count first is harmless; nothing may be written
+			if writes := len(fake.calls) - fake.callCount("info"); writes != 0 {
+				t.Errorf("pdfcpu was run: %v", fake.calls)
+			}
+		})
+	}
+}
+
+// Cancelling a job relies on the runner killing the process when its context ends
+func TestExecRunnerStopsOnCancel(t *testing.T) {
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("sleep is not available")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- execRunner(ctx, "sleep", []string{"30"}, nil, io.Discard, io.Discard) }()
+
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("cancelled command reported success")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("command kept running after its context was cancelled")
+	}
+}
+
+func TestGlobalFlagsPrepended(t *testing.T) {
+	if err := SetPdfcpuGlobalFlags([]string{"-c", "disable", "-opw", "owner", "-q"}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { SetPdfcpuGlobalFlags(nil) })
+	// Only the arguments matter, so no command touches the files it names
+	fake := installFakeCLI(t, &fakePdfcpu{respond: func([]string) (string, bool, error) { return "", true, nil }})
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"single command word", []string{"optimize", "in.pdf", "out.pdf"},
+			[]string{"optimize", "-c", "disable", "-opw", "owner", "-q", "in.pdf", "out.pdf"}},
+		{"group command", []string{"images", "list", "in.pdf"},
+			[]string{"images", "list", "-c", "disable", "-opw", "owner", "-q", "in.pdf"}},
+		{"call site flag wins", []string{"decrypt", "-upw", "pw", "-opw", "pw", "in.pdf", "out.pdf"},
+			[]string{"decrypt", "-c", "disable", "-q", "-upw", "pw", "-opw", "pw", "in.pdf", "out.pdf"}},
+		{"file named like a flag", []string{"pages", "remove", "-p", "1", "--", "-q", "out.pdf"},
+			[]string{"pages", "remove", "-c", "disable", "-opw", "owner", "-q", "-p", "1", "--", "-q", "out.pdf"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := execCommandWithTimeout(time.Second, "pdfcpu", tt.args...); err != nil {
+				t.Fatal(err)
+			}
+			calls := fake.callsOf(tt.args[0])
+			if got := calls[len(calls)-1]; !slices.Equal(got, tt.want) {
+				t.Errorf("pdfcpu ran with %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// The OCR engine does not get pdfcpu flags
+	if _, err := execCommandWithTimeout(time.Second, ocrBinary, "--version"); err != nil {
+		t.Fatal(err)
+	}
+	if got := fake.ocrCalls[len(fake.ocrCalls)-1]; !slices.Equal(got, []string{"--version"}) {
+		t.Errorf("OCR engine ran with %q", got)
+	}
+}
+
+func TestSetPdfcpuGlobalFlagsRejects(t *testing.T) {
+	t.Cleanup(func() { SetPdfcpuGlobalFlags(nil) })
+	for _, flags := range [][]string{{"-p", "1"}, {"-c"}, {"-opw", "-q"}, {"disable"}} {
+		if err := SetPdfcpuGlobalFlags(flags); err == nil {
+			t.Errorf("SetPdfcpuGlobalFlags(%q) accepted", flags)
+		}
+	}
+}
+
+func TestPdfcpuConfigDirEnvironment(t *testing.T) {
+	envs := make(map[string][]string)
+	previous := runCommand
+	runCommand = func(ctx context.Context, name string, args, env []string, stdout, stderr io.Writer) error {
+		envs[name] = env
+		return nil
+	}
+	t.Cleanup(func() { runCommand = previous })
+	t.Cleanup(func() { SetPdfcpuConfigDir("") })
+
+	configDir := filepath.Join(t.TempDir(), "pdfcpu-config")
+	if err := SetPdfcpuConfigDir(configDir); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(configDir); err != nil || !info.IsDir() {
+		t.Fatalf("config directory was not created: %v", err)
+	}
+
+	execCommandWithTimeout(time.Second, "pdfcpu", "version")
+	execCommandWithTimeout(time.Second, ocrBinary, "--version")
+	env := envs["pdfcpu"]
+	if !slices.Contains(env, "XDG_CONFIG_HOME="+configDir) {
+		t.Errorf("pdfcpu environment has no XDG_CONFIG_HOME=%s", configDir)
+	}
+	if path := os.Getenv("PATH"); !slices.Contains(env, "PATH="+path) {
+		t.Error("pdfcpu environment does not inherit the server environment")
+	}
+	if envs[ocrBinary] != nil {
+		t.Errorf("OCR engine environment = %q, want the inherited one", envs[ocrBinary])
+	}
+
+	// Without a config directory pdfcpu inherits the environment unchanged
+	SetPdfcpuConfigDir("")
+	execCommandWithTimeout(time.Second, "pdfcpu", "version")
+	if envs["pdfcpu"] != nil {
+		t.Errorf("pdfcpu environment = %q without a config directory", envs["pdfcpu"])
+	}
+}
+
+func TestCommandTimeout(t *testing.T) {
+	previous := runCommand
+	// A command that only ends when it is killed
+	runCommand = func(ctx context.Context, name string, args, env []string, stdout, stderr io.Writer) error {
+		<-ctx.Done()
+		return errors.New("signal: killed")
+	}
+	t.Cleanup(func() { runCommand = previous })
+
+	start := time.Now()
+	_, err := execCommandWithTimeout(50*time.Millisecond, "pdfcpu", "optimize", "in.pdf", "out.pdf")
+	if !errors.Is(err, ErrCommandTimeout) {
+		t.Fatalf("err = %v, want ErrCommandTimeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("timeout took %v", elapsed)
+	}
+}
+
+// installStreamsCLI runs every pdfcpu call through fn, which writes to both streams, and
+// returns the recorded calls
+func installStreamsCLI(t *testing.T, fn func(args []string) (stdout, stderr string, err error)) *[][]string {
+	t.Helper()
+	calls := &[][]string{}
+	previous := runCommand
+	runCommand = func(ctx context.Context, name string, args, env []string, stdout, stderr io.Writer) error {
+		*calls = append(*calls, args)
+		out, errOut, err := fn(args)
+		io.WriteString(stdout, out)
+		io.WriteString(stderr, errOut)
+		return err
+	}
+	t.Cleanup(func() { runCommand = previous })
+	return calls
+}
+
+func TestSuccessWithWarning(t *testing.T) {
+	// pdfcpu repaired the file and said so on stderr, then succeeded
+	const warning = "pdfcpu: warning: no watermarks found in broken xref section, repaired\n"
+	calls := installStreamsCLI(t, func(args []string) (string, string, error) {
+		if args[0] == "info" {
+			return "PDF version: 1.7\nPage count: 3\n", warning, nil
+		}
+		return "", warning, nil
+	})
+
+	output, err := execCommandWithTimeout(time.Second, "pdfcpu", "info", "in.pdf")
+	if err != nil {
+		t.Fatalf("a zero exit status with stderr output failed: %v", err)
+	}
+	if strings.Contains(string(output), "warning") {
+		t.Errorf("stderr mixed into the output: %q", output)
+	}
+	if pages, err := getPageCount("in.pdf"); err != nil || pages != 3 {
+		t.Errorf("getPageCount = %d, %v, want 3", pages, err)
+	}
+
+	// The warning mentions "no watermarks found", but the removal succeeded
+	dir := t.TempDir()
+	if err := RemoveElementsByIDs(writeFakePDF(t, dir, "in.pdf"), filepath.Join(dir, "out.pdf"), "watermark", nil); err != nil {
+		t.Fatalf("watermark removal: %v", err)
+	}
+	for _, call := range *calls {
+		if call[0] == "stamp" {
+			t.Errorf("fell back to %v after a successful watermark removal", call)
+		}
+	}
+}
+
+func TestFailureOutput(t *testing.T) {
+	tests := []struct {
+		name           string
+		stdout, stderr string
+		want           string
+	}{
+		{name: "stderr carries the cause", stdout: "processing in.pdf\n", stderr: "pdfcpu: no watermarks found\n", want: "pdfcpu: no watermarks found\n"},
+		{name: "only stdout", stdout: "pdfcpu: no watermarks found\n", stderr: "  \n", want: "pdfcpu: no watermarks found\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := installStreamsCLI(t, func(args []string) (string, string, error) {
+				if args[0] == "watermark" {
+					return tt.stdout, tt.stderr, errors.New("exit status 1")
+				}
+				return "", "", nil
+			})
+			output, err := execCommandWithTimeout(time.Second, "pdfcpu", "watermark", "remove", "--", "in.pdf", "out.pdf")
+			if err == nil || string(output) != tt.want {
+				t.Errorf("output %q, err %v, want %q and an error", output, err, tt.want)
+			}
+
+			// The known message is still recognized, so removal falls back to stamps
+			dir := t.TempDir()
+			if err := RemoveElementsByIDs(writeFakePDF(t, dir, "in.pdf"), filepath.Join(dir, "out.pdf"), "watermark", nil); err != nil {
+				t.Fatalf("watermark removal: %v", err)
+			}
+			if last := (*calls)[len(*calls)-1]; last[0] != "stamp" {
+				t.Errorf("last command %v, want the stamp remove fallback", last)
+			}
+		})
+	}
+}
+
+// crlfSample is pdfcpu output for a two-page document with one image per page, as printed
+// on Linux; tests convert it to CRLF
+var crlfSample = map[string]string{
+	"info":        "PDF version: 1.7\nPage count: 2\nEncrypted: No\n",
+	"info -pages": "Page 1:\n  MediaBox (0.00, 0.00, 612.00, 792.00)\nPage 2:\n  MediaBox (0.00, 0.00, 595.28, 841.89)\n",
+	"images list": "in.pdf:\n" +
+		"Page │ Obj# │ Id │ Type │ SoftMask │ ImgMask │ Width │ Height │ ColorSpace │ Comp │ bpc │ Interp │ Size\n" +
+		"─────┼──────┼────┼──────┼──────────┼─────────┼───────┼────────┼────────────┼──────┼─────┼────────┼──────\n" +
+		"   1 │   10 │ Im0 │ image │ no │ no │ 600 │ 400 │ DeviceRGB │ 3 │ 8 │ no │ 39 KB\n" +
+		"   2 │   10 │ Im0 │ image │ no │ no │ 600 │ 400 │ DeviceRGB │ 3 │ 8 │ no │ 39 KB\n" +
+		"2 images available\n",
+}
+
+func TestCRLFOutput(t *testing.T) {
+	parse := func(lineEnding string) ([]rawImageData, int, map[int]PageGeometry) {
+		t.Helper()
+		installStreamsCLI(t, func(args []string) (string, string, error) {
+			key := args[0]
+			switch {
+			case args[0] == "info" && slices.Contains(args, "-pages"):
+				key = "info -pages"
+			case args[0] == "images" && slices.Contains(args, "-json"):
+				return "", "", errors.New("flag provided but not defined: -json")
+			case args[0] == "images":
+				key = "images list"
+			}
+			return strings.ReplaceAll(crlfSample[key], "\n", lineEnding), "", nil
+		})
+		images, err := listImages(context.Background(), "in.pdf", nil)
+		if err != nil {
+			t.Fatalf("listImages: %v", err)
+		}
+		pages, err := getPageCount("in.pdf")
+		if err != nil {
+			t.Fatalf("getPageCount: %v", err)
+		}
+		geometry, err := GetPageGeometry("in.pdf")
+		if err != nil {
+			t.Fatalf("GetPageGeometry: %v", err)
+		}
+		return images, pages, geometry
+	}
+
+	lfImages, lfPages, lfGeometry := parse("\n")
+	if len(lfImages) != 2 || lfImages[0].width != 600 || lfImages[0].size != "39 KB" || lfPages != 2 || len(lfGeometry) != 2 {
+		t.Fatalf("LF output parsed as %+v, %d pages, geometry %+v", lfImages, lfPages, lfGeometry)
+	}
+	for _, lineEnding := range []string{"\r\n", "\r"} {
+		images, pages, geometry := parse(lineEnding)
+		if !reflect.DeepEqual(images, lfImages) {
+			t.Errorf("%q: images %+v, want %+v", lineEnding, images, lfImages)
+		}
+		if pages != lfPages || !reflect.DeepEqual(geometry, lfGeometry) {
+			t.Errorf("%q: %d pages, geometry %+v, want %d and %+v", lineEnding, pages, geometry, lfPages, lfGeometry)
+		}
+	}
+}
+
+func TestOutputLines(t *testing.T) {
+	want := []string{"a", "b", "", "c"}
+	for _, output := range []string{"a\nb\n\nc", "a\r\nb\r\n\r\nc", "a\rb\r\rc", "a\r\nb\n\rc"} {
+		if got := outputLines(output); !slices.Equal(got, want) {
+			t.Errorf("outputLines(%q) = %q, want %q", output, got, want)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package pdf
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ColorProfile is one ICC-based color space of a PDF with the image objects drawn in it
+type ColorProfile struct {
+	ColorSpace string   `json:"color_space"` // as reported by pdfcpu, e.g. "ICCBased"
+	Components int      `json:"components"`  // 1, 3 or 4 (0 if pdfcpu did not report it)
+	Model      string   `json:"model"`       // "Gray", "RGB", "CMYK" or "" for other component counts
+	Objects    []string `json:"objects"`     // image object numbers
+	Pages      []int    `json:"pages"`
+}
+
+// ListColorProfiles returns the embedded ICC color profiles used by the images of a PDF,
+// one entry per color space and component count. It is based on "pdfcpu images list", so
+// profiles used only by vector content or by the document's output intent are not
+// reported. A PDF without ICC-based images yields an empty list.
+func ListColorProfiles(inFile string) ([]ColorProfile, error) {
+	images, err := listImages(context.Background(), inFile, nil)
+	if err != nil {
+		return nil, err
+	}
+	return colorProfilesOf(images), nil
+}
+
+// colorProfilesOf groups the images drawn in an ICC-based color space
+func colorProfilesOf(images []rawImageData) []ColorProfile {
+	profiles := []ColorProfile{}
+	byKey := make(map[string]int) // color space and components -> index in profiles
+	for _, img := range images {
+		if !strings.Contains(strings.ToLower(img.colorSpace), "icc") {
+			continue
+		}
+
+		key := fmt.Sprintf("%s|%d", img.colorSpace, img.components)
+		idx, ok := byKey[key]
+		if !ok {
+			idx = len(profiles)
+			byKey[key] = idx
+			profiles = append(profiles, ColorProfile{
+				ColorSpace: img.colorSpace,
+				Components: img.components,
+				Model:      colorModel(img.components),
+				Objects:    []string{},
+				Pages:      []int{},
+			})
+		}
+		if img.obj != "" && !slices.Contains(profiles[idx].Objects, img.obj) {
+			profiles[idx].Objects = append(profiles[idx].Objects, img.obj)
+		}
+		if img.page > 0 && !slices.Contains(profiles[idx].Pages, img.page) {
+			profiles[idx].Pages = append(profiles[idx].Pages, img.page)
+		}
+	}
+
+	for i := range profiles {
+		sort.Ints(profiles[i].Pages)
+	}
+	return profiles
+}
+
+// colorModel names the color model of an ICC profile with the given number of components
+func colorModel(components int) string {
+	switch components {
+	case 1:
+		return "Gray"
+	case 3:
+		return "RGB"
+	case 4:
+		return "CMYK"
+	}
+	return ""
+}
@@ -0,0 +1,77 @@
+package pdf
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// colorProfilesSample is pdfcpu images list output with images in two ICC profiles and
+// one device color space
+const colorProfilesSample = `brochure.pdf:
+Page │ Obj# │ Id  │ Type  │ SoftMask │ ImgMask │ Width │ Height │ ColorSpace │ Comp │ bpc │ Interp │ Size
+─────┼──────┼─────┼───────┼──────────┼─────────┼───────┼────────┼────────────┼──────┼─────┼────────┼──────
+   1 │   12 │ Im0 │ image │ no │ no │ 1200 │ 800 │ ICCBased │ 3 │ 8 │ no │ 210 KB
+   1 │   14 │ Im1 │ image │ no │ no │ 300 │ 300 │ DeviceGray │ 1 │ 8 │ no │ 12 KB
+   2 │   12 │ Im0 │ image │ no │ no │ 1200 │ 800 │ ICCBased │ 3 │ 8 │ no │ 210 KB
+   3 │   20 │ Im0 │ image │ no │ no │ 2400 │ 1600 │ ICCBased │ 4 │ 8 │ no │ 1.2 MB
+   3 │   21 │ Im1 │ image │ no │ no │ 640 │ 480 │ ICCBased │ 3 │ 8 │ no │ 80 KB
+4 images available
+`
+
+// installImagesTable makes pdfcpu images list print table instead of JSON
+func installImagesTable(t *testing.T, table string) {
+	t.Helper()
+	installFakeCLI(t, &fakePdfcpu{pages: 3, respond: func(args []string) (string, bool, error) {
+		if len(args) < 2 || args[0] != "images" || args[1] != "list" {
+			return "", false, nil
+		}
+		if slices.Contains(args, "-json") {
+			return "", true, errors.New("flag provided but not defined: -json")
+		}
+		return table, true, nil
+	}})
+}
+
+func TestListColorProfiles(t *testing.T) {
+	installImagesTable(t, colorProfilesSample)
+	profiles, err := ListColorProfiles(writeFakePDF(t, t.TempDir(), "brochure.pdf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ColorProfile{
+		{ColorSpace: "ICCBased", Components: 3, Model: "RGB", Objects: []string{"12", "21"}, Pages: []int{1, 2, 3}},
+		{ColorSpace: "ICCBased", Components: 4, Model: "CMYK", Objects: []string{"20"}, Pages: []int{3}},
+	}
+	if !reflect.DeepEqual(profiles, want) {
+		t.Errorf("ListColorProfiles = %+v\nwant %+v", profiles, want)
+	}
+}
+
+func TestListColorProfilesWithoutProfiles(t *testing.T) {
+	for name, table := range map[string]string{
+		"device color spaces": "plain.pdf:\nPage │ Obj# │ Id │ Type │ SoftMask │ ImgMask │ Width │ Height │ ColorSpace │ Comp │ bpc │ Interp │ Size\n" +
+			"   1 │ 5 │ Im0 │ image │ no │ no │ 100 │ 100 │ DeviceRGB │ 3 │ 8 │ no │ 9 KB\n",
+		"no images": "plain.pdf:\nno images available\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			installImagesTable(t, table)
+			profiles, err := ListColorProfiles(writeFakePDF(t, t.TempDir(), "plain.pdf"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if profiles == nil || len(profiles) != 0 {
+				t.Errorf("ListColorProfiles = %#v, want an empty list", profiles)
+			}
+		})
+	}
+}
+
+func TestColorModel(t *testing.T) {
+	for components, want := range map[int]string{0: "", 1: "Gray", 2: "", 3: "RGB", 4: "CMYK"} {
+		if got := colorModel(components); got != want {
+			t.Errorf("colorModel(%d) = %q, want %q", components, got, want)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package pdf
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CommandParams are the inputs of an operation whose pdfcpu command line PdfcpuCommand builds
+type CommandParams struct {
+	InFile  string
+	OutFile string
+
+	// Pages is a page specification for remove-pages, extract-pages and rotate
+	Pages string
+
+	// Degrees is the clockwise rotation for rotate, a multiple of 90
+	Degrees int
+
+	// Text and Watermark are the text and style of a watermark
+	Text      string
+	Watermark WatermarkOptions
+}
+
+// CommandOperations are the operations PdfcpuCommand builds command lines for
+var CommandOperations = []string{"remove-pages", "extract-pages", "rotate", "resave", "repair", "watermark"}
+
+// PdfcpuCommand returns the pdfcpu command line, starting with "pdfcpu" and including the
+// configured global flags, that an operation runs for params. Nothing is executed, so
+// page numbers are not checked against a document. Operations that run several commands
+// (rotate with several specs, or repair falling back to validate) return the first.
+func PdfcpuCommand(operation string, params CommandParams) ([]string, error) {
+	var name string
+	var args []string
+	switch operation {
+	case "remove-pages", "extract-pages", "rotate":
+		pageNumbers, err := ParsePageSpecifier(params.Pages)
+		if err != nil {
+			return nil, err
+		}
+		switch operation {
+		case "remove-pages":
+			name, args = removePagesCommand(params.InFile, params.OutFile, pageNumbers)
+		case "extract-pages":
+			name, args = extractPagesCommand(params.InFile, params.OutFile, pageNumbers)
+		default:
+			if params.Degrees%90 != 0 {
+				return nil, fmt.Errorf("degrees must be a multiple of 90, got %d", params.Degrees)
+			}
+			name, args = rotateCommand(params.InFile, params.OutFile, pageNumbers, params.Degrees)
+		}
+	case "resave", "repair":
+		name, args = optimizeCommand(params.InFile, params.OutFile)
+	case "watermark":
+		text := strings.TrimSpace(params.Text)
+		if text == "" {
+			return nil, fmt.Errorf("watermark text must not be empty")
+		}
+		if err := ValidateWatermarkOptions(params.Watermark); err != nil {
+			return nil, err
+		}
+		name, args = stampCommand(params.InFile, params.OutFile, "text", text, textWatermarkDescription(params.Watermark))
+	default:
+		return nil, fmt.Errorf("unknown operation: %q (expected %s)", operation, strings.Join(CommandOperations, ", "))
+	}
+
+	return append([]string{name}, withGlobalFlags(args)...), nil
+}
+
+// pagesArg joins page numbers for a pdfcpu -p flag
+func pagesArg(pageNumbers []int) string {
+	pageStrs := make([]string, len(pageNumbers))
+	for i, p := range pageNumbers {
+		pageStrs[i] = strconv.Itoa(p)
+	}
+	return strings.Join(pageStrs, ",")
+}
+
+// The command builders below return the program and arguments of one pdfcpu call, for
+// execCommandWithTimeout(timeout, name, args...). Global flags are added when it runs.
+
+// removePagesCommand builds pdfcpu pages remove -p pages -- inFile outFile
+func removePagesCommand(inFile, outFile string, pageNumbers []int) (string, []string) {
+	return "pdfcpu", []string{"pages", "remove", "-p", pagesArg(pageNumbers), "--", inFile, outFile}
+}
+
+// extractPagesCommand builds pdfcpu trim -p pages -- inFile outFile, which keeps only the
+// selected pages
+func extractPagesCommand(inFile, outFile string, pageNumbers []int) (string, []string) {
+	return "pdfcpu", []string{"trim", "-p", pagesArg(pageNumbers), "--", inFile, outFile}
+}
+
+// rotateCommand builds pdfcpu rotate -p pages -- inFile rotation outFile
+func rotateCommand(inFile, outFile string, pageNumbers []int, degrees int) (string, []string) {
+	return "pdfcpu", []string{"rotate", "-p", pagesArg(pageNumbers), "--", inFile, strconv.Itoa(degrees), outFile}
+}
+
+// optimizeCommand builds pdfcpu optimize inFile outFile
+func optimizeCommand(inFile, outFile string) (string, []string) {
+	return "pdfcpu", []string{"optimize", inFile, outFile}
+}
+
+// stampCommand builds pdfcpu stamp add -mode mode -- content description inFile outFile
+func stampCommand(inFile, outFile, mode, content string, description []string) (string, []string) {
+	return "pdfcpu", []string{"stamp", "add", "-mode", mode, "--", content, strings.Join(description, ", "), inFile, outFile}
+}
+
+// stampPagesCommand builds pdfcpu stamp add -mode pdf -- stampFile:1:1 description inFile
+// outFile. The 1:1 suffix makes pdfcpu multistamp: page i of stampFile is stamped onto
+// page i of inFile, starting at the first page of both.
+func stampPagesCommand(inFile, stampFile, outFile string, description []string) (string, []string) {
+	return stampCommand(inFile, outFile, "pdf", stampFile+":1:1", description)
+}
+
+// watermarkRemoveCommand builds pdfcpu watermark remove -- inFile outFile, which only
+// removes watermarks added by pdfcpu
+func watermarkRemoveCommand(inFile, outFile string) (string, []string) {
+	return "pdfcpu", []string{"watermark", "remove", "--", inFile, outFile}
+}
+
+// stampRemoveCommand builds pdfcpu stamp remove -- inFile outFile, which only removes
+// stamps added by pdfcpu
+func stampRemoveCommand(inFile, outFile string) (string, []string) {
+	return "pdfcpu", []string{"stamp", "remove", "--", inFile, outFile}
+}
+
+// imagesListCommand builds pdfcpu images list inFile
+func imagesListCommand(inFile string) (string, []string) {
+	return "pdfcpu", []string{"images", "list", inFile}
+}
+
+// imagesUpdateCommand builds pdfcpu images update inFile imageFile outFile image, which
+// replaces the image given by its object number or by "pageNr id" with imageFile
+func imagesUpdateCommand(inFile, imageFile, outFile, image string) (string, []string) {
+	return "pdfcpu", []string{"images", "update", inFile, imageFile, outFile, image}
+}
+
+// extractContentCommand builds pdfcpu extract -mode content -pages first-last inFile outDir
+func extractContentCommand(inFile, outDir string, first, last int) (string, []string) {
+	pages := strconv.Itoa(first)
+	if last > first {
+		pages += "-" + strconv.Itoa(last)
+	}
+	return "pdfcpu", []string{"extract", "-mode", "content", "-pages", pages, inFile, outDir}
+}
+
+// extractImagesCommand builds pdfcpu extract -mode image [-pages pages] inFile outDir,
+// extracting the images of every page when pageNumbers is empty
+func extractImagesCommand(inFile, outDir string, pageNumbers []int) (string, []string) {
+	args := []string{"extract", "-mode", "image"}
+	if len(pageNumbers) > 0 {
+		args = append(args, "-pages", pagesArg(pageNumbers))
+	}
+	return "pdfcpu", append(args, inFile, outDir)
+}
+
+// infoCommand builds pdfcpu info inFile
+func infoCommand(inFile string) (string, []string) {
+	return "pdfcpu", []string{"info", inFile}
+}
+
+// pageInfoCommand builds pdfcpu info -pages 1-totalPages inFile, which reports the page
+// boxes of every page
+func pageInfoCommand(inFile string, totalPages int) (string, []string) {
+	return "pdfcpu", []string{"info", "-pages", fmt.Sprintf("1-%d", totalPages), inFile}
+}
+
+// fontsInfoCommand builds pdfcpu info -fonts inFile
+func fontsInfoCommand(inFile string) (string, []string) {
+	return "pdfcpu", []string{"info", "-fonts", inFile}
+}
+
+// validateCommand builds pdfcpu validate -mode mode inFile, where mode is strict or relaxed
+func validateCommand(inFile, mode string) (string, []string) {
+	return "pdfcpu", []string{"validate", "-mode", mode, inFile}
+}
+
+// bookmarksExportCommand builds pdfcpu bookmarks export inFile exportFile
+func bookmarksExportCommand(inFile, exportFile string) (string, []string) {
+	return "pdfcpu", []string{"bookmarks", "export", inFile, exportFile}
+}
+
+// encryptCommand builds pdfcpu encrypt -mode aes [-perm perms] [-upw userPw] -opw ownerPw
+// inFile outFile, leaving out the optional flags that are empty
+func encryptCommand(inFile, outFile, perms, userPw, ownerPw string) (string, []string) {
+	args := []string{"encrypt", "-mode", "aes"}
+	if perms != "" {
+		args = append(args, "-perm", perms)
+	}
+	if userPw != "" {
+		args = append(args, "-upw", userPw)
+	}
+	return "pdfcpu", append(args, "-opw", ownerPw, inFile, outFile)
+}
+
+// decryptCommand builds pdfcpu decrypt -upw password -opw password inFile outFile, trying
+// password as both the user and the owner password
+func decryptCommand(inFile, outFile, password string) (string, []string) {
+	return "pdfcpu", []string{"decrypt", "-upw", password, "-opw", password, inFile, outFile}
+}
+
+// collectCommand builds pdfcpu collect -p pages -- inFile outFile, which keeps the
+// selected pages in the given order
+func collectCommand(inFile, outFile string, pageNumbers []int) (string, []string) {
+	return "pdfcpu", []string{"collect", "-p", pagesArg(pageNumbers), "--", inFile, outFile}
+}
+
+// mergeCommand builds pdfcpu merge -- outFile inFile...
+func mergeCommand(outFile string, inFiles []string) (string, []string) {
+	return "pdfcpu", append([]string{"merge", "--", outFile}, inFiles...)
+}
+
+// cropCommand builds pdfcpu crop -- "[llx lly urx ury]" inFile outFile, which sets the
+// crop box of every page to box
+func cropCommand(inFile, outFile string, box BBox) (string, []string) {
+	description := fmt.Sprintf("[%.2f %.2f %.2f %.2f]", box.X, box.Y, box.X+box.Width, box.Y+box.Height)
+	return "pdfcpu", []string{"crop", "--", description, inFile, outFile}
+}
+
+// nUpCommand builds pdfcpu nup|booklet -- [description] outFile n inFile; note the output
+// comes before the input
+func nUpCommand(command, inFile, outFile string, n int, description string) (string, []string) {
+	args := []string{command, "--"}
+	if description != "" {
+		args = append(args, description)
+	}
+	return "pdfcpu", append(args, outFile, strconv.Itoa(n), inFile)
+}
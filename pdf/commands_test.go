@@ -0,0 +1,122 @@
+package pdf
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestCommandBuilders(t *testing.T) {
+	build := func(name string, args []string) []string { return append([]string{name}, args...) }
+	tests := []struct {
+		name string
+		got  []string
+		want []string
+	}{
+		{"remove pages", build(removePagesCommand("in.pdf", "out.pdf", []int{1, 3})),
+			[]string{"pdfcpu", "pages", "remove", "-p", "1,3", "--", "in.pdf", "out.pdf"}},
+		{"extract pages", build(extractPagesCommand("in.pdf", "out.pdf", []int{2})),
+			[]string{"pdfcpu", "trim", "-p", "2", "--", "in.pdf", "out.pdf"}},
+		{"rotate", build(rotateCommand("in.pdf", "out.pdf", []int{1, 2}, 270)),
+			[]string{"pdfcpu", "rotate", "-p", "1,2", "--", "in.pdf", "270", "out.pdf"}},
+		{"stamp", build(stampCommand("in.pdf", "out.pdf", "text", "-DRAFT", []string{"rot:45", "op:0.5"})),
+			[]string{"pdfcpu", "stamp", "add", "-mode", "text", "--", "-DRAFT", "rot:45, op:0.5", "in.pdf", "out.pdf"}},
+		{"content of one page", build(extractContentCommand("in.pdf", "dir", 4, 4)),
+			[]string{"pdfcpu", "extract", "-mode", "content", "-pages", "4", "in.pdf", "dir"}},
+		{"content of a range", build(extractContentCommand("in.pdf", "dir", 1, 25)),
+			[]string{"pdfcpu", "extract", "-mode", "content", "-pages", "1-25", "in.pdf", "dir"}},
+		{"images of every page", build(extractImagesCommand("in.pdf", "dir", nil)),
+			[]string{"pdfcpu", "extract", "-mode", "image", "in.pdf", "dir"}},
+		{"info", build(infoCommand("in.pdf")), []string{"pdfcpu", "info", "in.pdf"}},
+		{"page info", build(pageInfoCommand("in.pdf", 12)), []string{"pdfcpu", "info", "-pages", "1-12", "in.pdf"}},
+		{"fonts", build(fontsInfoCommand("in.pdf")), []string{"pdfcpu", "info", "-fonts", "in.pdf"}},
+		{"validate", build(validateCommand("in.pdf", "relaxed")), []string{"pdfcpu", "validate", "-mode", "relaxed", "in.pdf"}},
+		{"bookmarks", build(bookmarksExportCommand("in.pdf", "b.json")), []string{"pdfcpu", "bookmarks", "export", "in.pdf", "b.json"}},
+		{"encrypt", build(encryptCommand("in.pdf", "out.pdf", "print", "user", "owner")),
+			[]string{"pdfcpu", "encrypt", "-mode", "aes", "-perm", "print", "-upw", "user", "-opw", "owner", "in.pdf", "out.pdf"}},
+		{"encrypt with owner password only", build(encryptCommand("in.pdf", "out.pdf", "", "", "owner")),
+			[]string{"pdfcpu", "encrypt", "-mode", "aes", "-opw", "owner", "in.pdf", "out.pdf"}},
+		{"decrypt", build(decryptCommand("in.pdf", "out.pdf", "pw")),
+			[]string{"pdfcpu", "decrypt", "-upw", "pw", "-opw", "pw", "in.pdf", "out.pdf"}},
+		{"collect", build(collectCommand("in.pdf", "out.pdf", []int{3, 1, 3})),
+			[]string{"pdfcpu", "collect", "-p", "3,1,3", "--", "in.pdf", "out.pdf"}},
+		{"merge", build(mergeCommand("out.pdf", []string{"a.pdf", "b.pdf"})),
+			[]string{"pdfcpu", "merge", "--", "out.pdf", "a.pdf", "b.pdf"}},
+		{"nup", build(nUpCommand("nup", "in.pdf", "out.pdf", 4, "")),
+			[]string{"pdfcpu", "nup", "--", "out.pdf", "4", "in.pdf"}},
+		{"booklet with paper size", build(nUpCommand("booklet", "in.pdf", "out.pdf", 2, "formsize:A4")),
+			[]string{"pdfcpu", "booklet", "--", "formsize:A4", "out.pdf", "2", "in.pdf"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !slices.Equal(tt.got, tt.want) {
+				t.Errorf("got %q, want %q", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPdfcpuCommandRemovePages(t *testing.T) {
+	want := []string{"pdfcpu", "pages", "remove", "-p", "1,2,3,5", "--", "in.pdf", "out.pdf"}
+	got, err := PdfcpuCommand("remove-pages", CommandParams{InFile: "in.pdf", OutFile: "out.pdf", Pages: "5,1-3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("PdfcpuCommand = %q, want %q", got, want)
+	}
+
+	// It is the command the operation runs
+	f := installFakeCLI(t, &fakePdfcpu{pages: 6})
+	dir := t.TempDir()
+	inFile := writeFakePDF(t, dir, "in.pdf")
+	outFile := filepath.Join(dir, "out.pdf")
+	if err := RemovePagesFromPDF(inFile, outFile, "5,1-3"); err != nil {
+		t.Fatal(err)
+	}
+	wantRun, _ := PdfcpuCommand("remove-pages", CommandParams{InFile: inFile, OutFile: outFile, Pages: "5,1-3"})
+	if calls := f.callsOf("pages", "remove"); len(calls) != 1 || !slices.Equal(append([]string{"pdfcpu"}, calls[0]...), wantRun) {
+		t.Errorf("ran %q, want %q", calls, wantRun)
+	}
+
+	// Global flags are included where pdfcpu parses them
+	if err := SetPdfcpuGlobalFlags([]string{"-q"}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { SetPdfcpuGlobalFlags(nil) })
+	got, _ = PdfcpuCommand("remove-pages", CommandParams{InFile: "in.pdf", OutFile: "out.pdf", Pages: "2"})
+	if want := []string{"pdfcpu", "pages", "remove", "-q", "-p", "2", "--", "in.pdf", "out.pdf"}; !slices.Equal(got, want) {
+		t.Errorf("with global flags %q, want %q", got, want)
+	}
+}
+
+func TestPdfcpuCommand(t *testing.T) {
+	tests := []struct {
+		operation string
+		params    CommandParams
+		want      []string // nil for an error
+	}{
+		{"extract-pages", CommandParams{InFile: "in.pdf", OutFile: "out.pdf", Pages: "2-3"},
+			[]string{"pdfcpu", "trim", "-p", "2,3", "--", "in.pdf", "out.pdf"}},
+		{"rotate", CommandParams{InFile: "in.pdf", OutFile: "out.pdf", Pages: "1", Degrees: 180},
+			[]string{"pdfcpu", "rotate", "-p", "1", "--", "in.pdf", "180", "out.pdf"}},
+		{"resave", CommandParams{InFile: "in.pdf", OutFile: "out.pdf"}, []string{"pdfcpu", "optimize", "in.pdf", "out.pdf"}},
+		{"repair", CommandParams{InFile: "in.pdf", OutFile: "out.pdf"}, []string{"pdfcpu", "optimize", "in.pdf", "out.pdf"}},
+		{"rotate", CommandParams{InFile: "in.pdf", OutFile: "out.pdf", Pages: "1", Degrees: 45}, nil},
+		{"remove-pages", CommandParams{InFile: "in.pdf", OutFile: "out.pdf", Pages: "x"}, nil},
+		{"watermark", CommandParams{InFile: "in.pdf", OutFile: "out.pdf", Text: "  "}, nil},
+		{"merge", CommandParams{InFile: "in.pdf", OutFile: "out.pdf"}, nil},
+	}
+	for _, tt := range tests {
+		got, err := PdfcpuCommand(tt.operation, tt.params)
+		if tt.want == nil {
+			if err == nil {
+				t.Errorf("%s %+v: got %q, want an error", tt.operation, tt.params, got)
+			}
+			continue
+		}
+		if err != nil || !slices.Equal(got, tt.want) {
+			t.Errorf("%s: got %q, %v, want %q", tt.operation, got, err, tt.want)
+		}
+	}
+}
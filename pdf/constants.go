@@ -18,5 +18,81 @@ const (
 	
 	// FullPageCoverageThreshold is 100% page coverage - image appears on all pages
 	FullPageCoverageThreshold = 1.0
-)
 
+	// DefaultMaxDeepMatchImages is the distinct-image count above which deep image matching
+	// (extracting and hashing every image) is skipped in favor of coverage/prefix detection
+	DefaultMaxDeepMatchImages = 200
+
+	// TextWatermarkMinRotation is the minimum text rotation in degrees for watermark text detection
+	TextWatermarkMinRotation = 10.0
+
+	// TextWatermarkMinFontSize is the minimum effective font size in points for watermark text detection
+	TextWatermarkMinFontSize = 36.0
+
+	// RepeatedTextMinLength is the minimum number of characters of repeated header/footer text;
+	// shorter strings (bullets, single digits) repeat by chance
+	RepeatedTextMinLength = 3
+
+	// RepeatedTextPositionTolerance is the height in points within which repeated text counts
+	// as drawn at the same position
+	RepeatedTextPositionTolerance = 10.0
+
+	// PlacementEdgeBand is the fraction of the page width/height from each edge within which
+	// an image center counts as header, footer or corner placement
+	PlacementEdgeBand = 0.2
+
+	// PlacementFullPageMinArea is the fraction of the page area an image must cover to be
+	// classified as full-page
+	PlacementFullPageMinArea = 0.5
+
+	// PlacementDiagonalMinRotation is the minimum rotation in degrees (away from the axes)
+	// for an image to be classified as diagonal
+	PlacementDiagonalMinRotation = 15.0
+
+	// DefaultBlankPageMaxInk is the largest fraction of a page area covered by dark image
+	// pixels for the page to still count as blank (scanner noise, punch holes)
+	DefaultBlankPageMaxInk = 0.01
+
+	// ScannedPageMinCoverage is the fraction of the page area a single image must cover
+	// for the page to count as a scanned page
+	ScannedPageMinCoverage = 0.9
+
+	// ScannedDocumentMinFraction is the fraction of scanned pages above which a document is
+	// classified as scanned (and below 1 minus it, as digital)
+	ScannedDocumentMinFraction = 0.9
+
+	// MaxBlankImageDimension caps each side of a blank replacement image in pixels, so a
+	// watermark declared as e.g. 10000x10000 does not allocate a huge RGBA buffer
+	MaxBlankImageDimension = 1024
+
+	// DefaultMaxTrackedGroups is the number of distinct image signatures (and, separately, ID
+	// prefixes) above which analysis stops tracking new groups, bounding memory on PDFs
+	// where every image is unique
+	DefaultMaxTrackedGroups = 5000
+
+	// DefaultMaxImageOccurrences is the number of image occurrences above which removal by
+	// candidate ID refuses to run (see ErrTooManyImages)
+	DefaultMaxImageOccurrences = 100000
+
+	// DefaultEarlyExitConfidence is the candidate confidence that ends an analysis run with EarlyExit
+	DefaultEarlyExitConfidence = 0.9
+
+	// DefaultAutoCleanMinConfidence is the confidence a candidate needs to be removed by AutoClean
+	DefaultAutoCleanMinConfidence = 0.7
+
+	// GlyphWidthEm is the advance width, in em, assumed for every glyph when computing word
+	// boxes; font widths are not read, so boxes of proportional fonts are approximate
+	GlyphWidthEm = 0.5
+
+	// MinPagesPerContentBatch is the fewest pages extracted by one pdfcpu content extraction
+	// call. Every call parses the whole document, so small documents are extracted in one call
+	// and large ones in at most one batch per CLI slot
+	MinPagesPerContentBatch = 25
+
+	// GlyphAscentEm and GlyphDescentEm are the extent of a word box above and below the baseline, in em
+	GlyphAscentEm  = 0.8
+	GlyphDescentEm = 0.2
+
+	// DiffRegionMargin is the space, in points, kept around a removed element in a preview diff
+	DiffRegionMargin = 12.0
+)
@@ -0,0 +1,616 @@
+package pdf
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// BBox is an axis-aligned rectangle in PDF user space (points, origin bottom-left)
+type BBox struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// imagePlacement is an image XObject drawn on a page together with its bounding box
+type imagePlacement struct {
+	name     string // resource name (matches the pdfcpu images list ID)
+	bbox     BBox
+	rotation float64 // degrees counterclockwise of the image x axis
+}
+
+// matrix is a PDF transformation matrix [a b c d e f]
+type matrix [6]float64
+
+var identityMatrix = matrix{1, 0, 0, 1, 0, 0}
+
+// multiply returns m x n (apply m first, then n)
+func (m matrix) multiply(n matrix) matrix {
+	return matrix{
+		m[0]*n[0] + m[1]*n[2],
+		m[0]*n[1] + m[1]*n[3],
+		m[2]*n[0] + m[3]*n[2],
+		m[2]*n[1] + m[3]*n[3],
+		m[4]*n[0] + m[5]*n[2] + n[4],
+		m[4]*n[1] + m[5]*n[3] + n[5],
+	}
+}
+
+// transformPoint applies the matrix to a point
+func (m matrix) transformPoint(x, y float64) (float64, float64) {
+	return m[0]*x + m[2]*y + m[4], m[1]*x + m[3]*y + m[5]
+}
+
+// unitSquareBBox returns the bounding box of the unit square mapped through the matrix,
+// which is where an image XObject is painted
+func (m matrix) unitSquareBBox() BBox {
+	xs := make([]float64, 0, 4)
+	ys := make([]float64, 0, 4)
+	for _, p := range [][2]float64{{0, 0}, {1, 0}, {0, 1}, {1, 1}} {
+		x, y := m.transformPoint(p[0], p[1])
+		xs = append(xs, x)
+		ys = append(ys, y)
+	}
+	minX, maxX := xs[0], xs[0]
+	minY, maxY := ys[0], ys[0]
+	for i := 1; i < 4; i++ {
+		if xs[i] < minX {
+			minX = xs[i]
+		}
+		if xs[i] > maxX {
+			maxX = xs[i]
+		}
+		if ys[i] < minY {
+			minY = ys[i]
+		}
+		if ys[i] > maxY {
+			maxY = ys[i]
+		}
+	}
+	return BBox{X: minX, Y: minY, Width: maxX - minX, Height: maxY - minY}
+}
+
+// extractPageContents extracts the decoded content stream of each page using pdfcpu CLI
+// Every pdfcpu call parses the whole document, so pages are extracted in contiguous
+// batches of at least MinPagesPerContentBatch pages, one call per batch, run in parallel
+// Returns a map of page number -> content stream text
+func extractPageContents(ctx context.Context, filename string, totalPages int) (map[int]string, error) {
+	extractDir, err := os.MkdirTemp(filepath.Dir(filename), "content_")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create content extract directory: %w", err)
+	}
+	defer os.RemoveAll(extractDir)
+
+	batches := pageBatches(totalPages, min(cap(cliSlots), (totalPages+MinPagesPerContentBatch-1)/MinPagesPerContentBatch))
+	batchIndexes := make([]int, len(batches))
+	for i := range batches {
+		batchIndexes[i] = i
+	}
+	results, err := runPerPage(batchIndexes, func(i int) (map[int]string, error) {
+		return extractContentRange(ctx, filename, extractDir, batches[i][0], batches[i][1])
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	contents := make(map[int]string)
+	for _, batch := range results {
+		for page, content := range batch {
+			contents[page] = content
+		}
+	}
+	return contents, nil
+}
+
+// pageBatches splits pages 1..totalPages into n contiguous [first, last] ranges of
+// near-equal size (fewer if there are fewer pages)
+func pageBatches(totalPages, n int) [][2]int {
+	n = max(min(n, totalPages), 1)
+	batches := make([][2]int, 0, n)
+	first := 1
+	for i := 0; i < n && first <= totalPages; i++ {
+		size := (totalPages - first + 1) / (n - i)
+		if (totalPages-first+1)%(n-i) != 0 {
+			size++
+		}
+		batches = append(batches, [2]int{first, first + size - 1})
+		first += size
+	}
+	return batches
+}
+
+// extractPageContent extracts the decoded content stream of one page, using a
+// subdirectory of extractDir for pdfcpu's output
+func extractPageContent(ctx context.Context, filename, extractDir string, page int) (string, error) {
+	contents, err := extractContentRange(ctx, filename, extractDir, page, page)
+	if err != nil {
+		return "", err
+	}
+	return contents[page], nil
+}
+
+// extractContentRange extracts the decoded content streams of pages first..last with one
+// pdfcpu call, using a subdirectory of extractDir for pdfcpu's output
+func extractContentRange(ctx context.Context, filename, extractDir string, first, last int) (map[int]string, error) {
+	rangeDir := filepath.Join(extractDir, fmt.Sprintf("%d-%d", first, last))
+	if err := os.Mkdir(rangeDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create content extract directory: %w", err)
+	}
+
+	name, args := extractContentCommand(filename, rangeDir, first, last)
+	output, err := execCommandContext(ctx, AnalysisTimeout, name, args...)
+	if err != nil {
+		return nil, fmt.Errorf("pdfcpu extract content failed on pages %d-%d: %w\nOutput: %s", first, last, err, string(output))
+	}
+
+	return readPageContents(rangeDir)
+}
+
+// contentPagePattern matches the page number in pdfcpu content extraction filenames
+// (e.g. "doc_Content_page_3.txt")
+var contentPagePattern = regexp.MustCompile(`(?i)page_(\d+)`)
+
+// readPageContents reads pdfcpu content extraction output files from dir
+func readPageContents(dir string) (map[int]string, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read content extract directory: %w", err)
+	}
+
+	contents := make(map[int]string)
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		matches := contentPagePattern.FindStringSubmatch(file.Name())
+		if len(matches) < 2 {
+			continue
+		}
+		page, err := strconv.Atoi(matches[1])
+		if err != nil || page < 1 {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read content stream for page %d: %w", page, err)
+		}
+		// A page may have several content streams; they are concatenated in order
+		contents[page] += string(data) + "\n"
+	}
+
+	return contents, nil
+}
+
+// contentToken is a lexical token from a content stream
+type contentToken struct {
+	value    string
+	operator bool
+}
+
+// tokenizeContent splits a content stream into operands and operators
+// Strings, arrays and dictionaries are kept as single operand tokens; inline image
+// data (BI ... ID ... EI) is skipped
+func tokenizeContent(content string) []contentToken {
+	var tokens []contentToken
+	i := 0
+	n := len(content)
+
+	isDelimiter := func(b byte) bool {
+		return strings.IndexByte("()<>[]{}/%", b) >= 0
+	}
+	isSpace := func(b byte) bool {
+		return b == ' ' || b == '\t' || b == '\r' || b == '\n' || b == '\f' || b == 0
+	}
+
+	for i < n {
+		ch := content[i]
+		switch {
+		case isSpace(ch):
+			i++
+		case ch == '%':
+			// Comment until end of line
+			for i < n && content[i] != '\n' && content[i] != '\r' {
+				i++
+			}
+		case ch == '(':
+			start := i
+			i = skipLiteralString(content, i)
+			tokens = append(tokens, contentToken{value: content[start:i]})
+		case ch == '<' && i+1 < n && content[i+1] == '<', ch == '[':
+			// Dictionary or array: keep as one token, tracking nesting
+			start := i
+			openDelim, closeDelim := "[", "]"
+			if ch == '<' {
+				openDelim, closeDelim = "<<", ">>"
+			}
+			depth := 0
+			for i < n {
+				if content[i] == '(' {
+					// Skip nested strings so brackets inside them don't count
+					i = skipLiteralString(content, i)
+					continue
+				}
+				if strings.HasPrefix(content[i:], openDelim) {
+					depth++
+					i += len(openDelim)
+					continue
+				}
+				if strings.HasPrefix(content[i:], closeDelim) {
+					depth--
+					i += len(closeDelim)
+					if depth == 0 {
+						break
+					}
+					continue
+				}
+				i++
+			}
+			tokens = append(tokens, contentToken{value: content[start:i]})
+		case ch == '<':
+			// Hex string
+			start := i
+			for i < n && content[i] != '>' {
+				i++
+			}
+			if i < n {
+				i++
+			}
+			tokens = append(tokens, contentToken{value: content[start:i]})
+		case ch == '/':
+			start := i
+			i++
+			for i < n && !isSpace(content[i]) && !isDelimiter(content[i]) {
+				i++
+			}
+			tokens = append(tokens, contentToken{value: content[start:i]})
+		default:
+			start := i
+			for i < n && !isSpace(content[i]) && !isDelimiter(content[i]) {
+				i++
+			}
+			if start == i {
+				// Stray delimiter such as ')' or '>'; skip it
+				i++
+				continue
+			}
+			word := content[start:i]
+			if _, err := strconv.ParseFloat(word, 64); err == nil || word == "true" || word == "false" || word == "null" {
+				tokens = append(tokens, contentToken{value: word})
+				continue
+			}
+			tokens = append(tokens, contentToken{value: word, operator: true})
+			if word == "ID" {
+				// Skip inline image data up to the EI operator
+				if end := strings.Index(content[i:], "EI"); end >= 0 {
+					i += end + 2
+				} else {
+					i = n
+				}
+			}
+		}
+	}
+
+	return tokens
+}
+
+// skipLiteralString returns the index just past the literal string starting at start,
+// honouring balanced parentheses and backslash escapes
+func skipLiteralString(content string, start int) int {
+	depth := 0
+	i := start
+	for i < len(content) {
+		switch content[i] {
+		case '\\':
+			i += 2
+			continue
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+		i++
+	}
+	return len(content)
+}
+
+// operandFloats parses the last count operands as numbers
+func operandFloats(operands []string, count int) ([]float64, bool) {
+	if len(operands) < count {
+		return nil, false
+	}
+	values := make([]float64, count)
+	for i, op := range operands[len(operands)-count:] {
+		v, err := strconv.ParseFloat(op, 64)
+		if err != nil {
+			return nil, false
+		}
+		values[i] = v
+	}
+	return values, true
+}
+
+// findImagePlacements walks a page content stream and returns the bounding box of every
+// XObject drawn with the Do operator, tracking the current transformation matrix
+// through q/Q/cm. Form XObjects are reported like images; their inner content is not followed.
+func findImagePlacements(content string) []imagePlacement {
+	placements := []imagePlacement{}
+	ctm := identityMatrix
+	stack := []matrix{}
+	operands := []string{}
+
+	for _, tok := range tokenizeContent(content) {
+		if !tok.operator {
+			operands = append(operands, tok.value)
+			continue
+		}
+
+		switch tok.value {
+		case "q":
+			stack = append(stack, ctm)
+		case "Q":
+			if len(stack) > 0 {
+				ctm = stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+			}
+		case "cm":
+			if v, ok := operandFloats(operands, 6); ok {
+				ctm = matrix{v[0], v[1], v[2], v[3], v[4], v[5]}.multiply(ctm)
+			}
+		case "Do":
+			if len(operands) > 0 && strings.HasPrefix(operands[len(operands)-1], "/") {
+				placements = append(placements, imagePlacement{
+					name:     strings.TrimPrefix(operands[len(operands)-1], "/"),
+					bbox:     ctm.unitSquareBBox(),
+					rotation: math.Atan2(ctm[1], ctm[0]) * 180 / math.Pi,
+				})
+			}
+		}
+		operands = operands[:0]
+	}
+
+	return placements
+}
+
+// imagePlacementsByPage returns the image placements of every page content stream
+// Returns a map of page number -> placements on that page
+func imagePlacementsByPage(contents map[int]string) map[int][]imagePlacement {
+	placements := make(map[int][]imagePlacement)
+	for page, content := range contents {
+		placements[page] = findImagePlacements(content)
+	}
+	return placements
+}
+
+// textBlock is the text drawn inside one BT ... ET block
+type textBlock struct {
+	text     string
+	fontSize float64 // effective font size in user space
+	rotation float64 // degrees counter-clockwise from horizontal
+	x, y     float64 // origin of the first glyph in user space
+}
+
+// findTextBlocks walks a page content stream and returns the text of each BT ... ET block
+// with its position, rotation and effective font size, derived from the text matrix
+// (Tm/Td/TD/T*) combined with the current transformation matrix
+func findTextBlocks(content string) []textBlock {
+	blocks := []textBlock{}
+	ctm := identityMatrix
+	stack := []matrix{}
+	operands := []string{}
+
+	inText := false
+	var tm, tlm matrix
+	var fontSize, leading float64
+	var current textBlock
+	var text strings.Builder
+	positioned := false
+
+	show := func(s string) {
+		if !positioned {
+			trm := tm.multiply(ctm)
+			current.x, current.y = trm[4], trm[5]
+			current.rotation = math.Atan2(trm[1], trm[0]) * 180 / math.Pi
+			current.fontSize = fontSize * math.Hypot(trm[2], trm[3])
+			positioned = true
+		}
+		text.WriteString(s)
+	}
+	nextLine := func(tx, ty float64) {
+		tlm = matrix{1, 0, 0, 1, tx, ty}.multiply(tlm)
+		tm = tlm
+	}
+
+	for _, tok := range tokenizeContent(content) {
+		if !tok.operator {
+			operands = append(operands, tok.value)
+			continue
+		}
+
+		switch tok.value {
+		case "q":
+			stack = append(stack, ctm)
+		case "Q":
+			if len(stack) > 0 {
+				ctm = stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+			}
+		case "cm":
+			if v, ok := operandFloats(operands, 6); ok {
+				ctm = matrix{v[0], v[1], v[2], v[3], v[4], v[5]}.multiply(ctm)
+			}
+		case "BT":
+			inText = true
+			tm, tlm = identityMatrix, identityMatrix
+			current = textBlock{}
+			text.Reset()
+			positioned = false
+		case "ET":
+			if inText && strings.TrimSpace(text.String()) != "" {
+				current.text = strings.TrimSpace(text.String())
+				blocks = append(blocks, current)
+			}
+			inText = false
+		case "Tf":
+			if v, ok := operandFloats(operands, 1); ok {
+				fontSize = v[0]
+			}
+		case "TL":
+			if v, ok := operandFloats(operands, 1); ok {
+				leading = v[0]
+			}
+		case "Tm":
+			if v, ok := operandFloats(operands, 6); ok {
+				tlm = matrix{v[0], v[1], v[2], v[3], v[4], v[5]}
+				tm = tlm
+			}
+		case "Td":
+			if v, ok := operandFloats(operands, 2); ok {
+				nextLine(v[0], v[1])
+			}
+		case "TD":
+			if v, ok := operandFloats(operands, 2); ok {
+				leading = -v[1]
+				nextLine(v[0], v[1])
+			}
+		case "T*":
+			nextLine(0, -leading)
+		case "Tj":
+			if inText && len(operands) > 0 {
+				show(decodePDFString(operands[len(operands)-1]))
+			}
+		case "'", "\"":
+			nextLine(0, -leading)
+			if inText && len(operands) > 0 {
+				show(decodePDFString(operands[len(operands)-1]))
+			}
+		case "TJ":
+			if inText && len(operands) > 0 {
+				show(decodeTJArray(operands[len(operands)-1]))
+			}
+		}
+		operands = operands[:0]
+	}
+
+	return blocks
+}
+
+// decodeTJArray concatenates the strings of a TJ array; large negative kerning
+// adjustments are treated as word spaces
+func decodeTJArray(array string) string {
+	array = strings.TrimSpace(array)
+	array = strings.TrimPrefix(array, "[")
+	array = strings.TrimSuffix(array, "]")
+
+	var out strings.Builder
+	for _, tok := range tokenizeContent(array) {
+		if strings.HasPrefix(tok.value, "(") || strings.HasPrefix(tok.value, "<") {
+			out.WriteString(decodePDFString(tok.value))
+			continue
+		}
+		if v, err := strconv.ParseFloat(tok.value, 64); err == nil && v < -200 {
+			out.WriteString(" ")
+		}
+	}
+	return out.String()
+}
+
+// decodePDFString decodes a literal "(...)" or hex "<...>" string operand
+// Only single-byte and UTF-16BE (BOM-prefixed) encodings are decoded; text in
+// other encodings comes back with non-printable runes dropped
+func decodePDFString(s string) string {
+	var raw []byte
+	switch {
+	case strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") && len(s) >= 2:
+		raw = unescapeLiteral(s[1 : len(s)-1])
+	case strings.HasPrefix(s, "<") && strings.HasSuffix(s, ">") && len(s) >= 2:
+		hexStr := strings.Map(func(r rune) rune {
+			if unicode.IsSpace(r) {
+				return -1
+			}
+			return r
+		}, s[1:len(s)-1])
+		if len(hexStr)%2 == 1 {
+			hexStr += "0"
+		}
+		decoded, err := hex.DecodeString(hexStr)
+		if err != nil {
+			return ""
+		}
+		raw = decoded
+	default:
+		return ""
+	}
+
+	var runes []rune
+	if len(raw) >= 2 && raw[0] == 0xFE && raw[1] == 0xFF {
+		for i := 2; i+1 < len(raw); i += 2 {
+			runes = append(runes, rune(raw[i])<<8|rune(raw[i+1]))
+		}
+	} else {
+		for _, b := range raw {
+			runes = append(runes, rune(b))
+		}
+	}
+
+	return strings.Map(func(r rune) rune {
+		if unicode.IsPrint(r) {
+			return r
+		}
+		return -1
+	}, string(runes))
+}
+
+// unescapeLiteral resolves backslash escapes in a literal string body
+func unescapeLiteral(body string) []byte {
+	out := make([]byte, 0, len(body))
+	for i := 0; i < len(body); i++ {
+		ch := body[i]
+		if ch != '\\' || i+1 >= len(body) {
+			out = append(out, ch)
+			continue
+		}
+		i++
+		switch esc := body[i]; esc {
+		case 'n':
+			out = append(out, '\n')
+		case 'r':
+			out = append(out, '\r')
+		case 't':
+			out = append(out, '\t')
+		case 'b':
+			out = append(out, '\b')
+		case 'f':
+			out = append(out, '\f')
+		case '\r', '\n':
+			// Line continuation
+			if esc == '\r' && i+1 < len(body) && body[i+1] == '\n' {
+				i++
+			}
+		default:
+			if esc >= '0' && esc <= '7' {
+				value := 0
+				j := i
+				for ; j < len(body) && j < i+3 && body[j] >= '0' && body[j] <= '7'; j++ {
+					value = value*8 + int(body[j]-'0')
+				}
+				out = append(out, byte(value))
+				i = j - 1
+			} else {
+				out = append(out, esc)
+			}
+		}
+	}
+	return out
+}
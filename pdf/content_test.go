@@ -0,0 +1,202 @@
+package pdf
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPageBatches(t *testing.T) {
+	tests := []struct {
+		totalPages, n int
+		want          [][2]int
+	}{
+		{1, 1, [][2]int{{1, 1}}},
+		{10, 1, [][2]int{{1, 10}}},
+		{10, 3, [][2]int{{1, 4}, {5, 7}, {8, 10}}},
+		{100, 4, [][2]int{{1, 25}, {26, 50}, {51, 75}, {76, 100}}},
+		{2, 5, [][2]int{{1, 1}, {2, 2}}},
+		{5, 0, [][2]int{{1, 5}}},
+	}
+	for _, tt := range tests {
+		if got := pageBatches(tt.totalPages, tt.n); !slices.Equal(got, tt.want) {
+			t.Errorf("pageBatches(%d, %d) = %v, want %v", tt.totalPages, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestExtractPageContentsBatchesCalls(t *testing.T) {
+	for _, totalPages := range []int{1, MinPagesPerContentBatch, 4 * MinPagesPerContentBatch} {
+		t.Run(fmt.Sprintf("%d pages", totalPages), func(t *testing.T) {
+			contents := make(map[int]string)
+			for page := 1; page <= totalPages; page++ {
+				contents[page] = fmt.Sprintf("BT (page %d) Tj ET", page)
+			}
+			fake := installFakeCLI(t, &fakePdfcpu{pages: totalPages, contents: contents})
+			inFile := writeFakePDF(t, t.TempDir(), "doc.pdf")
+
+			got, err := extractPageContents(context.Background(), inFile, totalPages)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for page := 1; page <= totalPages; page++ {
+				if got[page] != contents[page]+"\n" {
+					t.Fatalf("page %d content = %q, want %q", page, got[page], contents[page])
+				}
+			}
+
+			wantCalls := min(MaxConcurrentCLI(), (totalPages+MinPagesPerContentBatch-1)/MinPagesPerContentBatch)
+			if calls := fake.callCount("extract"); calls != wantCalls {
+				t.Errorf("%d pdfcpu extract calls for %d pages, want %d", calls, totalPages, wantCalls)
+			}
+		})
+	}
+}
+
+// BenchmarkExtractPageContents compares extracting the content streams of a 200-page
+// document with one pdfcpu call per page, one call for the whole document, and the
+// batches extractPageContents uses. The fake pdfcpu takes time to parse the document on
+// every call and to write every extracted page, as the real one does, with four CLI slots.
+func BenchmarkExtractPageContents(b *testing.B) {
+	previous := MaxConcurrentCLI()
+	SetMaxConcurrentCLI(4)
+	b.Cleanup(func() { SetMaxConcurrentCLI(previous) })
+
+	const totalPages = 200
+	const parseCost = 20 * time.Microsecond // per document page, on every call
+	const writeCost = 50 * time.Microsecond // per extracted page
+
+	contents := make(map[int]string)
+	for page := 1; page <= totalPages; page++ {
+		contents[page] = "BT (text) Tj ET"
+	}
+	fake := &fakePdfcpu{pages: totalPages, contents: contents}
+	fake.respond = func(args []string) (string, bool, error) {
+		if args[0] == "extract" {
+			pages, _ := ParsePageSpecifierWithTotal(args[4], totalPages)
+			time.Sleep(totalPages*parseCost + time.Duration(len(pages))*writeCost)
+		}
+		return "", false, nil
+	}
+	installFakeCLI(b, fake)
+	inFile := writeFakePDF(b, b.TempDir(), "doc.pdf")
+
+	b.Run("call per page", func(b *testing.B) {
+		for b.Loop() {
+			dir, _ := os.MkdirTemp(b.TempDir(), "content_")
+			if _, err := runPerPage(pageRange(totalPages), func(page int) (string, error) {
+				return extractPageContent(context.Background(), inFile, dir, page)
+			}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("single call", func(b *testing.B) {
+		for b.Loop() {
+			if _, err := extractContentRange(context.Background(), inFile, b.TempDir(), 1, totalPages); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("batched", func(b *testing.B) {
+		for b.Loop() {
+			if _, err := extractPageContents(context.Background(), inFile, totalPages); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// rotatedDraft draws "DRAFT" at 60pt, rotated 45 degrees, from (150, 200)
+const rotatedDraft = "BT /F1 60 Tf 0.7071 0.7071 -0.7071 0.7071 150 200 Tm (DRAFT) Tj ET"
+
+func TestFindTextBlocksRotated(t *testing.T) {
+	blocks := findTextBlocks("q 1 0 0 1 0 0 cm " + rotatedDraft + " Q BT /F1 12 Tf 72 720 Td (Body) Tj ET")
+	if len(blocks) != 2 {
+		t.Fatalf("found %d text blocks, want 2: %+v", len(blocks), blocks)
+	}
+	draft := blocks[0]
+	if draft.text != "DRAFT" || math.Abs(draft.rotation-45) > 0.1 || math.Abs(draft.fontSize-60) > 0.1 ||
+		math.Abs(draft.x-150) > 0.1 || math.Abs(draft.y-200) > 0.1 {
+		t.Errorf("DRAFT block = %+v, want text DRAFT rotated 45 at size 60 from (150, 200)", draft)
+	}
+	if body := blocks[1]; body.text != "Body" || body.rotation != 0 || body.fontSize != 12 {
+		t.Errorf("body block = %+v, want upright text at size 12", body)
+	}
+}
+
+func TestAnalyzeContentRotatedWatermark(t *testing.T) {
+	contents := make(map[int]string)
+	for page := 1; page <= 5; page++ {
+		// The body text differs on every page, so only the watermark repeats
+		contents[page] = fmt.Sprintf("%s BT /F1 12 Tf 72 %d Td (Body %d) Tj ET", rotatedDraft, 700-page*20, page)
+	}
+
+	candidates, err := analyzeContent(contents, 5, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candidates) != 1 {
+		t.Fatalf("%d text candidates, want the DRAFT watermark only: %+v", len(candidates), candidates)
+	}
+	c := candidates[0]
+	if c.Type != "text" || c.Metadata["type"] != "text_watermark" || c.Metadata["text"] != "DRAFT" {
+		t.Errorf("candidate = %s %v, want a text watermark for DRAFT", c.Type, c.Metadata)
+	}
+	if c.Metadata["rotation"] != "45.0" || c.Metadata["x"] != "150.0" || c.Metadata["y"] != "200.0" || c.Metadata["page_count"] != "5" {
+		t.Errorf("candidate metadata = %v, want rotation 45.0 at (150.0, 200.0) on 5 pages", c.Metadata)
+	}
+
+	// The same text drawn upright at body size is not a watermark
+	for page := range contents {
+		contents[page] = fmt.Sprintf("BT /F1 12 Tf 72 %d Td (DRAFT) Tj ET", 700-page*20)
+	}
+	if candidates, _ := analyzeContent(contents, 5, nil); len(candidates) != 0 {
+		t.Errorf("upright body-size text reported as %+v", candidates)
+	}
+}
+
+func TestTextWatermarkSignatureSeparatesBodyText(t *testing.T) {
+	// Removing a text watermark by its string must spare body text with the same string,
+	// so candidates for each carry the font size and rotation that tell them apart
+	contents := make(map[int]string)
+	for page := 1; page <= 5; page++ {
+		contents[page] = rotatedDraft + " BT /F1 12 Tf 72 720 Td (DRAFT) Tj ET"
+	}
+
+	candidates, err := analyzeContent(contents, 5, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byType := make(map[string]UnwantedElementCandidate)
+	for _, c := range candidates {
+		if c.Metadata["text"] != "DRAFT" {
+			t.Errorf("unexpected candidate %v", c.Metadata)
+		}
+		byType[c.Metadata["type"]] = c
+	}
+	watermark, ok := byType["text_watermark"]
+	if !ok {
+		t.Fatalf("no text watermark candidate in %+v", candidates)
+	}
+	body, ok := byType["repeated_text"]
+	if !ok {
+		t.Fatalf("no repeated text candidate for the body text in %+v", candidates)
+	}
+	if watermark.ID == body.ID {
+		t.Errorf("watermark and body text share the ID %s", watermark.ID)
+	}
+	if watermark.Metadata["font_size"] != "60.0" || watermark.Metadata["rotation"] != "45.0" {
+		t.Errorf("watermark signature = %v, want size 60.0 rotated 45.0", watermark.Metadata)
+	}
+	if body.Metadata["font_size"] != "12.0" || body.Metadata["y"] != "720.0" {
+		t.Errorf("body text signature = %v, want size 12.0 at y 720.0", body.Metadata)
+	}
+	if watermark.Confidence <= body.Confidence {
+		t.Errorf("watermark confidence %.2f not above body text %.2f", watermark.Confidence, body.Confidence)
+	}
+}
@@ -0,0 +1,32 @@
+package pdf
+
+import "math"
+
+// pixelRect is a region of a rendered page image in pixels, origin top-left
+type pixelRect struct {
+	X, Y, Width, Height int
+}
+
+// cropRect returns the region of a page rendered at dpi that shows bbox, widened by margin
+// points on every side and clamped to the page. PDF space is bottom-up, so the top of the
+// region is the page height minus the top of the box. Without a bbox, or when it lies
+// outside the page, the whole page is returned so a diff can fall back to the full page.
+func cropRect(bbox *BBox, page PageGeometry, dpi, margin float64) pixelRect {
+	// Multiply before dividing, so whole-pixel sizes are not pushed over by rounding errors
+	pixels := func(points float64) float64 { return points * dpi / 72 }
+	pageWidth := int(math.Ceil(pixels(page.Width)))
+	pageHeight := int(math.Ceil(pixels(page.Height)))
+	full := pixelRect{Width: pageWidth, Height: pageHeight}
+	if bbox == nil || bbox.Width <= 0 || bbox.Height <= 0 {
+		return full
+	}
+
+	left := max(0, int(math.Floor(pixels(bbox.X-margin))))
+	right := min(pageWidth, int(math.Ceil(pixels(bbox.X+bbox.Width+margin))))
+	top := max(0, int(math.Floor(pixels(page.Height-bbox.Y-bbox.Height-margin))))
+	bottom := min(pageHeight, int(math.Ceil(pixels(page.Height-bbox.Y+margin))))
+	if right <= left || bottom <= top {
+		return full
+	}
+	return pixelRect{X: left, Y: top, Width: right - left, Height: bottom - top}
+}
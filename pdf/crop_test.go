@@ -0,0 +1,45 @@
+package pdf
+
+import "testing"
+
+func TestCropRect(t *testing.T) {
+	letter := PageGeometry{Page: 1, Width: 612, Height: 792}
+	tests := []struct {
+		name   string
+		bbox   *BBox
+		page   PageGeometry
+		dpi    float64
+		margin float64
+		want   pixelRect
+	}{
+		{name: "72 dpi is points", bbox: &BBox{X: 100, Y: 600, Width: 200, Height: 100}, page: letter, dpi: 72,
+			want: pixelRect{X: 100, Y: 92, Width: 200, Height: 100}},
+		{name: "scaled to dpi", bbox: &BBox{X: 100, Y: 600, Width: 200, Height: 100}, page: letter, dpi: 144,
+			want: pixelRect{X: 200, Y: 184, Width: 400, Height: 200}},
+		{name: "margin", bbox: &BBox{X: 100, Y: 600, Width: 200, Height: 100}, page: letter, dpi: 72, margin: 10,
+			want: pixelRect{X: 90, Y: 82, Width: 220, Height: 120}},
+		{name: "fractional points round outward", bbox: &BBox{X: 10.4, Y: 20.6, Width: 5, Height: 5}, page: letter, dpi: 72,
+			want: pixelRect{X: 10, Y: 766, Width: 6, Height: 6}},
+		{name: "clamped to the bottom-left corner", bbox: &BBox{X: -50, Y: -50, Width: 100, Height: 100}, page: letter, dpi: 72, margin: 5,
+			want: pixelRect{X: 0, Y: 737, Width: 55, Height: 55}},
+		{name: "clamped to the top-right corner", bbox: &BBox{X: 600, Y: 780, Width: 50, Height: 50}, page: letter, dpi: 72,
+			want: pixelRect{X: 600, Y: 0, Width: 12, Height: 12}},
+		{name: "full-page watermark", bbox: &BBox{X: 0, Y: 0, Width: 612, Height: 792}, page: letter, dpi: 150,
+			want: pixelRect{Width: 1275, Height: 1650}},
+		{name: "A4 page size rounds up", bbox: nil, page: PageGeometry{Width: 595.28, Height: 841.89}, dpi: 72,
+			want: pixelRect{Width: 596, Height: 842}},
+		{name: "no bbox falls back to the page", bbox: nil, page: letter, dpi: 72,
+			want: pixelRect{Width: 612, Height: 792}},
+		{name: "empty bbox falls back to the page", bbox: &BBox{X: 100, Y: 100}, page: letter, dpi: 72,
+			want: pixelRect{Width: 612, Height: 792}},
+		{name: "bbox off the page falls back to the page", bbox: &BBox{X: 700, Y: 100, Width: 50, Height: 50}, page: letter, dpi: 72,
+			want: pixelRect{Width: 612, Height: 792}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cropRect(tt.bbox, tt.page, tt.dpi, tt.margin); got != tt.want {
+				t.Errorf("cropRect = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package pdf
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// extractedImagePattern matches "<page>_<id>.<ext>" after the document basename prefix
+// has been stripped from a pdfcpu image extraction filename
+var extractedImagePattern = regexp.MustCompile(`^(\d+)_(.+)\.[A-Za-z0-9]+$`)
+
+// countDistinctImages counts distinct image objects (by object number, or ID if unknown)
+func countDistinctImages(images []rawImageData) int {
+	distinct := make(map[string]bool)
+	for _, img := range images {
+		key := img.obj
+		if key == "" {
+			key = "id:" + img.id
+		}
+		distinct[key] = true
+	}
+	return len(distinct)
+}
+
+// hashExtractedImages extracts every image with pdfcpu CLI and hashes its bytes
+// Returns a map of content hash -> pages on which an image with that content appears,
+// and hash -> a representative image ID
+func hashExtractedImages(ctx context.Context, filename string) (map[string][]int, map[string]string, error) {
+	extractDir, err := os.MkdirTemp(filepath.Dir(filename), "deepmatch_")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create image extract directory: %w", err)
+	}
+	defer os.RemoveAll(extractDir)
+
+	name, args := extractImagesCommand(filename, extractDir, nil)
+	output, err := execCommandContext(ctx, AnalysisTimeout, name, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("pdfcpu extract images failed: %w\nOutput: %s", err, string(output))
+	}
+
+	files, err := os.ReadDir(extractDir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read image extract directory: %w", err)
+	}
+
+	base := strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	pagesByHash := make(map[string][]int)
+	idByHash := make(map[string]string)
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		matches := extractedImagePattern.FindStringSubmatch(strings.TrimPrefix(file.Name(), base+"_"))
+		if len(matches) < 3 {
+			continue
+		}
+		page, err := strconv.Atoi(matches[1])
+		if err != nil || page < 1 {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(extractDir, file.Name()))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read extracted image %s: %w", file.Name(), err)
+		}
+		sum := sha256.Sum256(data)
+		hash := hex.EncodeToString(sum[:])
+		pagesByHash[hash] = append(pagesByHash[hash], page)
+		if _, ok := idByHash[hash]; !ok {
+			idByHash[hash] = matches[2]
+		}
+	}
+
+	return pagesByHash, idByHash, nil
+}
+
+// detectIdenticalImages finds byte-identical images that repeat on enough pages, even when
+// the PDF gives every occurrence a different ID or size label. Images whose ID is already
+// covered by an existing candidate are skipped.
+func detectIdenticalImages(ctx context.Context, filename string, totalPages int, imagesByPage map[int][]imageInfo, existing []UnwantedElementCandidate, debugLog func(string, ...interface{})) ([]UnwantedElementCandidate, error) {
+	pagesByHash, idByHash, err := hashExtractedImages(ctx, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	knownIDs := make(map[string]bool)
+	for _, candidate := range existing {
+		if id := candidate.Metadata["image_id"]; id != "" {
+			knownIDs[id] = true
+		}
+	}
+
+	minPages := int(float64(totalPages) * MinPageCoverageThreshold)
+	candidates := []UnwantedElementCandidate{}
+	for hash, pages := range pagesByHash {
+		uniquePages := make(map[int]bool)
+		for _, page := range pages {
+			uniquePages[page] = true
+		}
+		if len(uniquePages) < minPages || len(uniquePages) == 0 || knownIDs[idByHash[hash]] {
+			continue
+		}
+
+		sortedPages := make([]int, 0, len(uniquePages))
+		for page := range uniquePages {
+			sortedPages = append(sortedPages, page)
+		}
+		sort.Ints(sortedPages)
+
+		// Look up the listed image data for a representative occurrence; the ID was taken
+		// from whichever extracted file was read first, not necessarily the lowest page
+		imgID := idByHash[hash]
+		representativeImg := imageInfo{id: imgID}
+	lookup:
+		for _, page := range sortedPages {
+			for _, img := range imagesByPage[page] {
+				if img.id == imgID {
+					representativeImg = img
+					break lookup
+				}
+			}
+		}
+
+		coverage := float64(len(sortedPages)) / float64(totalPages)
+		prefix := extractIdPrefix(imgID)
+		metadata := imageCandidateMetadata("identical_image", representativeImg, imageSignature(representativeImg), prefix, len(sortedPages), totalPages)
+		metadata["content_hash"] = hash
+		candidate := UnwantedElementCandidate{
+			Type:        "image",
+			ID:          fmt.Sprintf("identical_image_%s", hash[:12]),
+			Page:        0, // Appears on multiple pages
+			Description: fmt.Sprintf("Byte-identical image (ID '%s') appears on %d/%d pages", imgID, len(sortedPages), totalPages),
+			Confidence:  0.7 + coverage*0.25,
+			Metadata:    metadata,
+			Pages:       sortedPages,
+		}
+		if debugLog != nil {
+			debugLog("[DEBUG] Deep match found identical image %s on %d pages", idByHash[hash], len(sortedPages))
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	return candidates, nil
+}
@@ -0,0 +1,142 @@
+package pdf
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DistinctImage is one image object of a PDF with all the places it is drawn
+type DistinctImage struct {
+	Object      string `json:"object"` // object number ("" if pdfcpu did not report one)
+	ID          string `json:"id"`     // resource ID of the first occurrence
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	Size        string `json:"size"`
+	ColorSpace  string `json:"color_space"`
+	Occurrences int    `json:"occurrences"`
+	Pages       []int  `json:"pages"`
+	PreviewFile string `json:"-"` // extracted preview in the output directory ("" if not extracted)
+}
+
+// distinctImageKey identifies an image object; images without an object number are keyed by page and ID
+func distinctImageKey(img rawImageData) string {
+	if img.obj != "" {
+		return img.obj
+	}
+	return fmt.Sprintf("p%d_%s", img.page, img.id)
+}
+
+// ListDistinctImages returns the distinct image objects of a PDF, each with one preview
+// extracted into outputDir as "obj_<key>.<ext>". Only the pages holding the first
+// occurrence of an object are extracted, one pdfcpu call per page on the worker pool.
+func ListDistinctImages(inFile, outputDir string) ([]DistinctImage, error) {
+	images, err := listImages(context.Background(), inFile, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	distinct := []*DistinctImage{}
+	byKey := make(map[string]*DistinctImage)
+	firstOccurrence := make(map[string]rawImageData)
+	for _, img := range images {
+		key := distinctImageKey(img)
+		entry, ok := byKey[key]
+		if !ok {
+			entry = &DistinctImage{
+				Object:     img.obj,
+				ID:         img.id,
+				Width:      img.width,
+				Height:     img.height,
+				Size:       img.size,
+				ColorSpace: img.colorSpace,
+				Pages:      []int{},
+			}
+			byKey[key] = entry
+			firstOccurrence[key] = img
+			distinct = append(distinct, entry)
+		}
+		entry.Occurrences++
+		if !slices.Contains(entry.Pages, img.page) {
+			entry.Pages = append(entry.Pages, img.page)
+		}
+	}
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+	extractDir, err := os.MkdirTemp(outputDir, "extract_")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create extract directory: %w", err)
+	}
+	defer os.RemoveAll(extractDir)
+
+	previewPages := []int{}
+	for _, first := range firstOccurrence {
+		if !slices.Contains(previewPages, first.page) {
+			previewPages = append(previewPages, first.page)
+		}
+	}
+	sort.Ints(previewPages)
+	extracted, err := runPerPage(previewPages, func(page int) (map[string]string, error) {
+		return extractPageImages(inFile, filepath.Join(extractDir, strconv.Itoa(page)), page)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for key, entry := range byKey {
+		first := firstOccurrence[key]
+		file, ok := extracted[first.page][first.id]
+		if !ok {
+			continue
+		}
+		previewFile := filepath.Join(outputDir, "obj_"+sanitizeID(key)+strings.ToLower(filepath.Ext(file)))
+		if err := os.Rename(file, previewFile); err != nil {
+			return nil, fmt.Errorf("failed to store preview for image %s: %w", key, err)
+		}
+		entry.PreviewFile = previewFile
+	}
+
+	result := make([]DistinctImage, len(distinct))
+	for i, entry := range distinct {
+		sort.Ints(entry.Pages)
+		result[i] = *entry
+	}
+	return result, nil
+}
+
+// extractPageImages extracts the images of one page into dir and returns their files by image ID
+func extractPageImages(inFile, dir string, page int) (map[string]string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create extract directory: %w", err)
+	}
+	name, args := extractImagesCommand(inFile, dir, []int{page})
+	output, err := execCommandWithTimeout(AnalysisTimeout, name, args...)
+	if err != nil {
+		return nil, fmt.Errorf("pdfcpu extract images of page %d failed: %w\nOutput: %s", page, err, string(output))
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read extract directory: %w", err)
+	}
+	extracted := make(map[string]string)
+	base := strings.TrimSuffix(filepath.Base(inFile), filepath.Ext(inFile))
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		matches := extractedImagePattern.FindStringSubmatch(strings.TrimPrefix(file.Name(), base+"_"))
+		if len(matches) < 3 || matches[1] != strconv.Itoa(page) {
+			continue
+		}
+		extracted[matches[2]] = filepath.Join(dir, file.Name())
+	}
+	return extracted, nil
+}
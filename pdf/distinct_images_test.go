@@ -0,0 +1,65 @@
+package pdf
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestListDistinctImages(t *testing.T) {
+	fake := installFakeCLI(t, &fakePdfcpu{pages: 4, images: []fakeImage{
+		{Page: 1, Obj: 10, ID: "Im0", Width: 600, Height: 400, CS: "DeviceRGB", Size: 40000},
+		{Page: 2, Obj: 10, ID: "Im0", Width: 600, Height: 400, CS: "DeviceRGB", Size: 40000},
+		{Page: 2, Obj: 20, ID: "Im1", Width: 50, Height: 50, CS: "DeviceGray", Size: 900},
+		{Page: 3, Obj: 10, ID: "Wm", Width: 600, Height: 400, CS: "DeviceRGB", Size: 40000},
+		{Page: 3, ID: "Inline", Width: 8, Height: 8, CS: "DeviceGray", Size: 64},
+		{Page: 4, Obj: 20, ID: "Im1", Width: 50, Height: 50, CS: "DeviceGray", Size: 900},
+	}})
+	dir := t.TempDir()
+	inFile := writeFakePDF(t, dir, "in.pdf")
+	outDir := filepath.Join(dir, "previews")
+
+	images, err := ListDistinctImages(inFile, outDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		object, id  string
+		occurrences int
+		pages       []int
+		preview     string
+	}{
+		{"10", "Im0", 3, []int{1, 2, 3}, "obj_10.png"},
+		{"20", "Im1", 2, []int{2, 4}, "obj_20.png"},
+		{"", "Inline", 1, []int{3}, "obj_p3_Inline.png"},
+	}
+	if len(images) != len(want) {
+		t.Fatalf("%d distinct images, want %d: %+v", len(images), len(want), images)
+	}
+	for i, w := range want {
+		img := images[i]
+		if img.Object != w.object || img.ID != w.id || img.Occurrences != w.occurrences || !slices.Equal(img.Pages, w.pages) {
+			t.Errorf("image %d = %+v, want object %q (%s) drawn %d times on %v", i, img, w.object, w.id, w.occurrences, w.pages)
+		}
+		if img.PreviewFile != filepath.Join(outDir, w.preview) {
+			t.Errorf("image %d preview = %s, want %s", i, img.PreviewFile, w.preview)
+		} else if _, err := os.Stat(img.PreviewFile); err != nil {
+			t.Errorf("image %d preview missing: %v", i, err)
+		}
+	}
+
+	// Page 4 only repeats objects already extracted from page 2
+	var extracted []string
+	for _, call := range fake.callsOf("extract") {
+		extracted = append(extracted, call[slices.Index(call, "-pages")+1])
+	}
+	slices.Sort(extracted)
+	if !slices.Equal(extracted, []string{"1", "2", "3"}) {
+		t.Errorf("extracted pages %v, want one call each for pages 1, 2 and 3", extracted)
+	}
+	entries, _ := os.ReadDir(outDir)
+	if len(entries) != len(want) {
+		t.Errorf("output directory holds %d entries, want only the %d previews", len(entries), len(want))
+	}
+}
@@ -0,0 +1,57 @@
+package pdf
+
+// Document types reported by the analysis
+const (
+	DocumentTypeScanned = "scanned" // (almost) every page is a full-page image
+	DocumentTypeDigital = "digital" // (almost) no page is a full-page image
+	DocumentTypeMixed   = "mixed"
+)
+
+// countScannedPages counts pages dominated by a single image covering at least
+// ScannedPageMinCoverage of the page. Text is not considered, since OCRed scans carry an
+// invisible text layer on top of the page image.
+func countScannedPages(contents map[int]string, geometry map[int]PageGeometry, images []rawImageData) int {
+	imageNames := make(map[int]map[string]bool)
+	for _, img := range images {
+		if imageNames[img.page] == nil {
+			imageNames[img.page] = make(map[string]bool)
+		}
+		imageNames[img.page][img.id] = true
+	}
+
+	scanned := 0
+	for page, content := range contents {
+		geo, ok := geometry[page]
+		if !ok || geo.Width <= 0 || geo.Height <= 0 {
+			continue
+		}
+		pageArea := geo.Width * geo.Height
+		for _, placement := range findImagePlacements(content) {
+			if !imageNames[page][placement.name] {
+				continue // form XObject
+			}
+			bbox := clipToPage(placement.bbox, geo)
+			if bbox.Width*bbox.Height >= pageArea*ScannedPageMinCoverage {
+				scanned++
+				break
+			}
+		}
+	}
+	return scanned
+}
+
+// classifyDocument maps the number of scanned pages to a document type
+func classifyDocument(scannedPages, totalPages int) string {
+	if totalPages <= 0 {
+		return ""
+	}
+	fraction := float64(scannedPages) / float64(totalPages)
+	switch {
+	case fraction >= ScannedDocumentMinFraction:
+		return DocumentTypeScanned
+	case fraction <= 1-ScannedDocumentMinFraction:
+		return DocumentTypeDigital
+	default:
+		return DocumentTypeMixed
+	}
+}
@@ -0,0 +1,71 @@
+package pdf
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// scannedPDF is a fake document of 10 pages whose first scanned pages are each a
+// full-page image with an invisible OCR text layer; the other pages are born-digital
+func scannedPDF(scanned int) *fakePdfcpu {
+	f := &fakePdfcpu{pages: 10, contents: make(map[int]string)}
+	for page := 1; page <= 10; page++ {
+		if page <= scanned {
+			id := fmt.Sprintf("Scan%d", page)
+			f.images = append(f.images, fakeImage{Page: page, Obj: 100 + page, ID: id, Width: 2550, Height: 3300, CS: "DeviceGray", Size: int64(300000 + page)})
+			f.contents[page] = fmt.Sprintf("q 612 0 0 792 0 0 cm /%s Do Q BT 3 Tr 72 720 Td (page %d) Tj ET", id, page)
+			continue
+		}
+		f.contents[page] = fmt.Sprintf("BT /F1 12 Tf 72 720 Td (page %d) Tj ET", page)
+	}
+	return f
+}
+
+func TestDocumentClassification(t *testing.T) {
+	tests := []struct {
+		name    string
+		fake    *fakePdfcpu
+		want    string
+		message string
+	}{
+		{"scanned", scannedPDF(10), DocumentTypeScanned, MsgScannedDocument},
+		{"digital", scannedPDF(0), DocumentTypeDigital, MsgDigitalDocument},
+		{"digital with a watermark", watermarkedPDF(10), DocumentTypeDigital, MsgDigitalDocument},
+		{"mixed", scannedPDF(5), DocumentTypeMixed, MsgMixedDocument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			installFakeCLI(t, tt.fake)
+			analysis, err := AnalyzeUnwantedElements(writeFakePDF(t, t.TempDir(), "in.pdf"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if analysis.DocumentType != tt.want {
+				t.Errorf("document_type = %q, want %q", analysis.DocumentType, tt.want)
+			}
+			if !slices.ContainsFunc(analysis.RecommendationDetails, func(rec Recommendation) bool { return rec.ID == tt.message }) {
+				t.Errorf("no %s recommendation in %+v", tt.message, analysis.RecommendationDetails)
+			}
+		})
+	}
+}
+
+func TestClassifyDocumentThresholds(t *testing.T) {
+	tests := []struct {
+		scanned, total int
+		want           string
+	}{
+		{9, 10, DocumentTypeScanned},
+		{8, 10, DocumentTypeMixed},
+		{2, 10, DocumentTypeMixed},
+		{1, 10, DocumentTypeDigital},
+		{0, 1, DocumentTypeDigital},
+		{0, 0, ""},
+	}
+	for _, tt := range tests {
+		if got := classifyDocument(tt.scanned, tt.total); got != tt.want {
+			t.Errorf("classifyDocument(%d, %d) = %q, want %q", tt.scanned, tt.total, got, tt.want)
+		}
+	}
+}
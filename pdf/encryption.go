@@ -0,0 +1,155 @@
+package pdf
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnsupportedEncryption is returned when a PDF is encrypted with a cipher or security
+// handler revision pdfcpu cannot read, whatever password is supplied
+var ErrUnsupportedEncryption = errors.New("PDF uses an encryption method that is not supported; " +
+	"remove the encryption with another tool (e.g. qpdf --decrypt) and upload it again")
+
+// ErrPasswordRequired is returned when a PDF is encrypted and no or a wrong password was given
+var ErrPasswordRequired = errors.New("PDF is password protected; the password is missing or wrong")
+
+// ErrIncorrectPassword is returned by DecryptPDF when the given password opens neither
+// as user nor as owner password
+var ErrIncorrectPassword = errors.New("the password is incorrect")
+
+// ErrNotEncrypted is returned by DecryptPDF for a PDF without encryption
+var ErrNotEncrypted = errors.New("PDF is not encrypted; there is no password to remove")
+
+// pdfcpuNotEncryptedMarkers are lowercase fragments of pdfcpu's message for decrypting a
+// PDF that is not encrypted
+var pdfcpuNotEncryptedMarkers = []string{
+	"not encrypted",
+}
+
+// pdfcpuUnsupportedEncryptionMarkers are lowercase fragments of pdfcpu's messages for
+// encryption it does not implement (unknown filters, V/R/Length combinations, crypt filters)
+var pdfcpuUnsupportedEncryptionMarkers = []string{
+	"unsupported encryption",
+	"encryption not supported",
+	"unsupported crypt filter",
+	"unsupported security handler",
+	"unsupported revision",
+	"unsupported encrypt",
+}
+
+// pdfcpuPasswordMarkers are lowercase fragments of pdfcpu's messages for missing or wrong passwords
+var pdfcpuPasswordMarkers = []string{
+	"correct password",
+	"please provide the owner password",
+	"please provide the user password",
+	"wrong password",
+	"invalid password",
+}
+
+// classifyPdfcpuFailure maps pdfcpu's output for a failed command to a sentinel error, or
+// returns nil if the output carries no recognized cause. Unsupported encryption is checked
+// first: pdfcpu may also ask for a password for files it could never decrypt.
+func classifyPdfcpuFailure(output []byte) error {
+	text := strings.ToLower(string(output))
+	for _, marker := range pdfcpuUnsupportedEncryptionMarkers {
+		if strings.Contains(text, marker) {
+			return fmt.Errorf("%w (pdfcpu: %s)", ErrUnsupportedEncryption, strings.TrimSpace(string(output)))
+		}
+	}
+	for _, marker := range pdfcpuPasswordMarkers {
+		if strings.Contains(text, marker) {
+			return ErrPasswordRequired
+		}
+	}
+	return nil
+}
+
+// Permission sets accepted by EncryptPDF, as named by pdfcpu's -perm flag
+const (
+	PermissionsNone  = "none"
+	PermissionsPrint = "print"
+	PermissionsAll   = "all"
+)
+
+// ValidatePermissions checks that perms is a permission set EncryptPDF accepts ("" keeps
+// pdfcpu's default)
+func ValidatePermissions(perms string) error {
+	switch perms {
+	case "", PermissionsNone, PermissionsPrint, PermissionsAll:
+		return nil
+	}
+	return fmt.Errorf("invalid permissions: %s (supported: none, print, all)", perms)
+}
+
+// EncryptPDF encrypts a PDF with AES using pdfcpu CLI. Either password may be empty, but
+// not both; without an owner password the user password is also the owner password,
+// since pdfcpu requires one. The passwords never appear in returned errors.
+func EncryptPDF(inFile, outFile, userPw, ownerPw, perms string) error {
+	if userPw == "" && ownerPw == "" {
+		return fmt.Errorf("a user or owner password is required")
+	}
+	if err := ValidatePermissions(perms); err != nil {
+		return err
+	}
+	if err := checkDistinctFiles(inFile, outFile); err != nil {
+		return err
+	}
+	if ownerPw == "" {
+		ownerPw = userPw
+	}
+
+	name, args := encryptCommand(inFile, outFile, perms, userPw, ownerPw)
+	output, err := execCommandWithTimeout(DefaultCLITimeout, name, args...)
+	if err != nil {
+		return passwordCommandError("encrypt", err, output, userPw, ownerPw)
+	}
+	return nil
+}
+
+// DecryptPDF removes the encryption of a PDF using pdfcpu CLI. password may be the user
+// or the owner password. A wrong password fails with ErrIncorrectPassword and a PDF that
+// is not encrypted with ErrNotEncrypted. The password never appears in returned errors.
+func DecryptPDF(inFile, outFile, password string) error {
+	if password == "" {
+		return fmt.Errorf("a password is required")
+	}
+	if err := checkDistinctFiles(inFile, outFile); err != nil {
+		return err
+	}
+
+	// The password is tried as both user and owner password, so either one removes the encryption
+	name, args := decryptCommand(inFile, outFile, password)
+	output, err := execCommandWithTimeout(DefaultCLITimeout, name, args...)
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, ErrPasswordRequired) {
+		return ErrIncorrectPassword
+	}
+	text := strings.ToLower(string(output))
+	for _, marker := range pdfcpuNotEncryptedMarkers {
+		if strings.Contains(text, marker) {
+			return ErrNotEncrypted
+		}
+	}
+	return passwordCommandError("decrypt", err, output, password)
+}
+
+// passwordCommandError wraps the failure of a pdfcpu command that was given passwords.
+// pdfcpu may echo its arguments, so the passwords are masked in the message; the cause
+// stays matchable with errors.Is unless its own text had to be masked.
+func passwordCommandError(command string, err error, output []byte, passwords ...string) error {
+	redact := func(s string) string {
+		for _, pw := range passwords {
+			if pw != "" {
+				s = strings.ReplaceAll(s, pw, "***")
+			}
+		}
+		return s
+	}
+	if message := redact(err.Error()); message != err.Error() {
+		return fmt.Errorf("pdfcpu %s failed: %s\nOutput: %s", command, message, redact(string(output)))
+	}
+	return fmt.Errorf("pdfcpu %s failed: %w\nOutput: %s", command, err, redact(string(output)))
+}
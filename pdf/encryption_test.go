@@ -0,0 +1,60 @@
+package pdf
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestClassifyPdfcpuFailure(t *testing.T) {
+	tests := []struct {
+		output string
+		want   error
+	}{
+		{"pdfcpu: unsupported encryption: V=5 R=7", ErrUnsupportedEncryption},
+		{"pdfcpu: encryption not supported for this file", ErrUnsupportedEncryption},
+		{"pdfcpu: unsupported crypt filter: /Identity2", ErrUnsupportedEncryption},
+		{"pdfcpu: unsupported security handler: Adobe.PubSec", ErrUnsupportedEncryption},
+		// Checked before passwords: pdfcpu may ask for one it could never use
+		{"pdfcpu: Unsupported Revision 7 - please provide the user password", ErrUnsupportedEncryption},
+		{"pdfcpu: please provide the correct password", ErrPasswordRequired},
+		{"pdfcpu: Please provide the owner password with -opw", ErrPasswordRequired},
+		{"pdfcpu: please provide the user password", ErrPasswordRequired},
+		{"validation error: dangling object reference", nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		got := classifyPdfcpuFailure([]byte(tt.output))
+		if tt.want == nil && got != nil || tt.want != nil && !errors.Is(got, tt.want) {
+			t.Errorf("classifyPdfcpuFailure(%q) = %v, want %v", tt.output, got, tt.want)
+		}
+	}
+}
+
+func TestDecryptPDFErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		stderr string
+		want   error
+	}{
+		{"unsupported cipher", "pdfcpu: unsupported encryption: AESV4", ErrUnsupportedEncryption},
+		{"wrong password", "pdfcpu: please provide the correct password", ErrIncorrectPassword},
+		{"not encrypted", "pdfcpu: this file is not encrypted", ErrNotEncrypted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := installFakeCLI(t, &fakePdfcpu{pages: 1})
+			fake.respond = func(args []string) (string, bool, error) {
+				if args[0] == "decrypt" {
+					return "", true, errors.New(tt.stderr)
+				}
+				return "", false, nil
+			}
+			dir := t.TempDir()
+			err := DecryptPDF(writeFakePDF(t, dir, "in.pdf"), filepath.Join(dir, "out.pdf"), "secret")
+			if !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -1,12 +1,29 @@
 package pdf
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 )
 
+// ErrImageObjectNotFound is returned when no image with the requested object number exists
+var ErrImageObjectNotFound = errors.New("image object not found")
+
+// imageMIMETypes maps pdfcpu image extraction file extensions to MIME types
+var imageMIMETypes = map[string]string{
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".png":  "image/png",
+	".tif":  "image/tiff",
+	".tiff": "image/tiff",
+	".jp2":  "image/jp2",
+	".jpx":  "image/jpx",
+}
+
 // ExtractImagePreview extracts an image from a PDF file for preview
 // Returns the path to the extracted image file
 func ExtractImagePreview(pdfFile, outputDir, elementID string, metadata map[string]string) (string, error) {
@@ -20,18 +37,17 @@ func ExtractImagePreview(pdfFile, outputDir, elementID string, metadata map[stri
 		return "", fmt.Errorf("cannot extract image: missing image_id in metadata")
 	}
 	
-	// For repeating elements, we need to find a page where it appears
-	// We'll analyze the PDF to find which page has this image
-	page := 1 // Default to page 1
-	if pageStr, ok := metadata["page_count"]; ok && pageStr != "" {
-		// page_count tells us how many pages, but we need an actual page number
-		// We'll extract from page 1 as a representative sample
-		page = 1
+	// For repeating elements, extract from the first page the image actually appears on
+	page := 1 // Default to page 1 for metadata from older analyses
+	if pageStr, ok := metadata[MetaRepresentativePage]; ok && pageStr != "" {
+		if representative, err := strconv.Atoi(pageStr); err == nil && representative > 0 {
+			page = representative
+		}
 	}
 	
 	// Create output directory if it doesn't exist
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create output directory: %v", err)
+		return "", fmt.Errorf("failed to create output directory: %w", err)
 	}
 	
 	// Generate output filename
@@ -40,22 +56,22 @@ func ExtractImagePreview(pdfFile, outputDir, elementID string, metadata map[stri
 	// Extract images from the page
 	extractDir := filepath.Join(outputDir, fmt.Sprintf("extract_%s", sanitizeID(elementID)))
 	if err := os.MkdirAll(extractDir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create extract directory: %v", err)
+		return "", fmt.Errorf("failed to create extract directory: %w", err)
 	}
 	defer os.RemoveAll(extractDir) // Clean up extract directory
 	
 	// Use pdfcpu extract to extract images from the page
-	pageSpec := fmt.Sprintf("%d", page)
-	output, err := execCommandWithTimeout(DefaultCLITimeout, "pdfcpu", "extract", "-mode=image", "-pages="+pageSpec, pdfFile, extractDir)
+	name, args := extractImagesCommand(pdfFile, extractDir, []int{page})
+	output, err := execCommandWithTimeout(DefaultCLITimeout, name, args...)
 	if err != nil {
-		return "", fmt.Errorf("pdfcpu extract failed: %v\nOutput: %s", err, string(output))
+		return "", fmt.Errorf("pdfcpu extract failed: %w\nOutput: %s", err, string(output))
 	}
 	
 	// Find the extracted image file
 	// pdfcpu extracts images with names like "page_1_img_0.png" or similar
 	files, err := os.ReadDir(extractDir)
 	if err != nil {
-		return "", fmt.Errorf("failed to read extract directory: %v", err)
+		return "", fmt.Errorf("failed to read extract directory: %w", err)
 	}
 	
 	var imageFile string
@@ -92,22 +108,90 @@ func ExtractImagePreview(pdfFile, outputDir, elementID string, metadata map[stri
 	// Copy the extracted image to the output location
 	inputData, err := os.ReadFile(imageFile)
 	if err != nil {
-		return "", fmt.Errorf("failed to read extracted image: %v", err)
+		return "", fmt.Errorf("failed to read extracted image: %w", err)
 	}
 	
 	if err := os.WriteFile(outputFile, inputData, 0644); err != nil {
-		return "", fmt.Errorf("failed to write output image: %v", err)
+		return "", fmt.Errorf("failed to write output image: %w", err)
 	}
 	
 	return outputFile, nil
 }
 
-// sanitizeID sanitizes an ID string for use in filenames
+// ExtractImageBytes returns the image object objNr exactly as pdfcpu extracts it, with its
+// MIME type. Unlike ExtractImagePreview nothing is re-encoded: DCT (JPEG) and JPX streams
+// come back as their original bytes; other filters are wrapped by pdfcpu (e.g. PNG, TIFF).
+func ExtractImageBytes(inFile string, objNr string) ([]byte, string, error) {
+	if _, err := strconv.Atoi(objNr); err != nil {
+		return nil, "", fmt.Errorf("invalid object number: %q", objNr)
+	}
+
+	// Find the page and resource ID under which the object is drawn
+	images, err := listImages(context.Background(), inFile, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	var target *rawImageData
+	for i := range images {
+		if images[i].obj == objNr {
+			target = &images[i]
+			break
+		}
+	}
+	if target == nil {
+		return nil, "", fmt.Errorf("%w: object %s", ErrImageObjectNotFound, objNr)
+	}
+
+	extractDir, err := os.MkdirTemp(filepath.Dir(inFile), "imgobj_")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create extract directory: %w", err)
+	}
+	defer os.RemoveAll(extractDir)
+
+	name, args := extractImagesCommand(inFile, extractDir, []int{target.page})
+	output, err := execCommandWithTimeout(DefaultCLITimeout, name, args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("pdfcpu extract failed: %w\nOutput: %s", err, string(output))
+	}
+
+	files, err := os.ReadDir(extractDir)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read extract directory: %w", err)
+	}
+
+	base := strings.TrimSuffix(filepath.Base(inFile), filepath.Ext(inFile))
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		matches := extractedImagePattern.FindStringSubmatch(strings.TrimPrefix(file.Name(), base+"_"))
+		if len(matches) < 3 || matches[1] != strconv.Itoa(target.page) || matches[2] != target.id {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(extractDir, file.Name()))
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read extracted image: %w", err)
+		}
+		mimeType, ok := imageMIMETypes[strings.ToLower(filepath.Ext(file.Name()))]
+		if !ok {
+			mimeType = "application/octet-stream"
+		}
+		return data, mimeType, nil
+	}
+
+	return nil, "", fmt.Errorf("%w: object %s was not extracted from page %d", ErrImageObjectNotFound, objNr, target.page)
+}
+
+// sanitizeID sanitizes an ID string for use in filenames: every character other than
+// ASCII letters, digits, "-" and "_" becomes "_", so no ID can name another directory
 func sanitizeID(id string) string {
-	sanitized := strings.ReplaceAll(id, " ", "_")
-	sanitized = strings.ReplaceAll(sanitized, "/", "_")
-	sanitized = strings.ReplaceAll(sanitized, "\\", "_")
-	sanitized = strings.ReplaceAll(sanitized, ":", "_")
+	sanitized := strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' || (r >= '0' && r <= '9') || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') {
+			return r
+		}
+		return '_'
+	}, id)
 	if len(sanitized) > 50 {
 		sanitized = sanitized[:50]
 	}
@@ -0,0 +1,117 @@
+package pdf
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestExtractImageBytes(t *testing.T) {
+	jpeg := []byte("\xff\xd8\xff\xe0 original DCT stream")
+	f := installFakeCLI(t, &fakePdfcpu{pages: 3, images: []fakeImage{
+		{Page: 1, Obj: 10, ID: "Im0", Width: 300, Height: 200, CS: "DeviceRGB", Size: 4000},
+		{Page: 2, Obj: 10, ID: "Im0", Width: 300, Height: 200, CS: "DeviceRGB", Size: 4000},
+		{Page: 2, Obj: 20, ID: "Im1", Width: 640, Height: 480, CS: "DeviceRGB", Size: int64(len(jpeg))},
+	}})
+	// pdfcpu keeps DCT streams as .jpg files; the other images of the page are extracted too
+	f.respond = func(args []string) (string, bool, error) {
+		if args[0] != "extract" {
+			return "", false, nil
+		}
+		outDir := args[len(args)-1]
+		os.WriteFile(filepath.Join(outDir, "in_2_Im0.png"), []byte("png of object 10"), 0644)
+		return "", true, os.WriteFile(filepath.Join(outDir, "in_2_Im1.jpg"), jpeg, 0644)
+	}
+	inFile := writeFakePDF(t, t.TempDir(), "in.pdf")
+
+	data, mimeType, err := ExtractImageBytes(inFile, "20")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, jpeg) || mimeType != "image/jpeg" {
+		t.Errorf("got %q as %s, want the original JPEG bytes", data, mimeType)
+	}
+	calls := f.callsOf("extract")
+	if len(calls) != 1 || !slices.Equal(calls[0][:5], []string{"extract", "-mode", "image", "-pages", "2"}) {
+		t.Errorf("extract calls = %v, want one for page 2", calls)
+	}
+
+	if _, _, err := ExtractImageBytes(inFile, "99"); !errors.Is(err, ErrImageObjectNotFound) {
+		t.Errorf("unknown object: err = %v, want ErrImageObjectNotFound", err)
+	}
+	before := len(f.calls)
+	if _, _, err := ExtractImageBytes(inFile, "10 0 R"); err == nil {
+		t.Error("invalid object number accepted")
+	}
+	if len(f.calls) != before {
+		t.Errorf("pdfcpu ran for an invalid object number: %v", f.calls[before:])
+	}
+}
+
+func TestRepresentativePage(t *testing.T) {
+	// The element starts on page 3 and is on every page after it
+	f := &fakePdfcpu{pages: 10}
+	for page := 10; page >= 3; page-- {
+		f.images = append(f.images, fakeImage{Page: page, Obj: 10, ID: "Im0", Width: 300, Height: 200, CS: "DeviceRGB", Size: 4000})
+	}
+	installFakeCLI(t, f)
+	dir := t.TempDir()
+	inFile := writeFakePDF(t, dir, "in.pdf")
+
+	analysis, err := AnalyzeUnwantedElements(inFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(analysis.ImageCandidates) == 0 {
+		t.Fatal("expected a candidate for the image on pages 3-10")
+	}
+	candidate := analysis.ImageCandidates[0]
+	if candidate.Page != 0 || candidate.Metadata[MetaRepresentativePage] != "3" {
+		t.Fatalf("page %d, representative_page %q, want 0 and 3", candidate.Page, candidate.Metadata[MetaRepresentativePage])
+	}
+
+	// The preview is taken from that page, not page 1 where the image is missing
+	if _, err := ExtractImagePreview(inFile, filepath.Join(dir, "previews"), candidate.ID, candidate.Metadata); err != nil {
+		t.Fatalf("ExtractImagePreview: %v", err)
+	}
+	calls := f.callsOf("extract", "-mode", "image")
+	if len(calls) != 1 || !slices.Equal(calls[0][:5], []string{"extract", "-mode", "image", "-pages", "3"}) {
+		t.Errorf("image extract calls = %v, want one for page 3", calls)
+	}
+
+	// Every candidate's representative page is the lowest page it occurs on
+	installFakeCLI(t, mixedImagesPDF())
+	analysis, err = AnalyzeUnwantedElements(inFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, candidate := range analysis.ImageCandidates {
+		if want := strconv.Itoa(slices.Min(candidate.Pages)); candidate.Metadata[MetaRepresentativePage] != want {
+			t.Errorf("%s: representative_page %q, want %s (pages %v)", candidate.ID, candidate.Metadata[MetaRepresentativePage], want, candidate.Pages)
+		}
+	}
+}
+
+func TestSanitizeID(t *testing.T) {
+	tests := []struct {
+		id   string
+		want string
+	}{
+		{id: "Im0", want: "Im0"},
+		{id: "fullpage_watermark-Im0", want: "fullpage_watermark-Im0"},
+		{id: "../../etc/passwd", want: "______etc_passwd"},
+		{id: `..\secret`, want: "___secret"},
+		{id: "a\x00b", want: "a_b"},
+		{id: strings.Repeat("x", 60), want: strings.Repeat("x", 50)},
+	}
+	for _, tt := range tests {
+		if got := sanitizeID(tt.id); got != tt.want {
+			t.Errorf("sanitizeID(%q) = %q, want %q", tt.id, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package pdf
+
+import (
+	"fmt"
+)
+
+// ExtractPages writes the pages selected by a page specification (e.g. "3" or "1-2,5")
+// to outFile as a new PDF using pdfcpu CLI
+func ExtractPages(inFile, outFile, pages string) error {
+	totalPages, err := getPageCount(inFile)
+	if err != nil {
+		return fmt.Errorf("failed to get page count: %w", err)
+	}
+	pageNumbers, err := ParsePageSpecifierWithTotal(pages, totalPages)
+	if err != nil {
+		return err
+	}
+	if err := ValidatePageNumbers(pageNumbers, totalPages); err != nil {
+		return err
+	}
+	if err := checkDistinctFiles(inFile, outFile); err != nil {
+		return err
+	}
+
+	name, args := extractPagesCommand(inFile, outFile, pageNumbers)
+	output, err := execCommandWithTimeout(DefaultCLITimeout, name, args...)
+	if err != nil {
+		return fmt.Errorf("pdfcpu trim failed: %w\nOutput: %s", err, string(output))
+	}
+
+	return nil
+}
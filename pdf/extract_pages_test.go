@@ -0,0 +1,43 @@
+package pdf
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestExtractSinglePage(t *testing.T) {
+	f := installFakeCLI(t, &fakePdfcpu{pages: 3})
+	dir := t.TempDir()
+	inFile := writeFakePDF(t, dir, "in.pdf")
+	outFile := filepath.Join(dir, "page_2.pdf")
+
+	if err := ExtractPages(inFile, outFile, "2"); err != nil {
+		t.Fatalf("ExtractPages: %v", err)
+	}
+	calls := f.callsOf("trim")
+	if len(calls) != 1 || !slices.Equal(calls[0], []string{"trim", "-p", "2", "--", inFile, outFile}) {
+		t.Errorf("trim calls = %v, want one keeping page 2", calls)
+	}
+	data, err := os.ReadFile(outFile)
+	if err != nil {
+		t.Fatalf("single-page PDF not written: %v", err)
+	}
+	if !slices.Equal(data[:5], []byte("%PDF-")) {
+		t.Errorf("output %q is not a PDF", data)
+	}
+
+	for _, page := range []string{"0", "4"} {
+		err := ExtractPages(inFile, filepath.Join(dir, "page_"+page+".pdf"), page)
+		if page == "4" && !errors.Is(err, ErrPageOutOfRange) {
+			t.Errorf("page %s of 3: err = %v, want ErrPageOutOfRange", page, err)
+		} else if err == nil {
+			t.Errorf("page %s of 3 was extracted", page)
+		}
+	}
+	if got := f.callCount("trim"); got != 1 {
+		t.Errorf("trim ran %d times, want only the valid page extracted", got)
+	}
+}
@@ -0,0 +1,235 @@
+package pdf
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeImage is an image occurrence reported by fakePdfcpu's images list
+type fakeImage struct {
+	Page   int    `json:"pageNr"`
+	Obj    int    `json:"objNr"`
+	ID     string `json:"name"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	CS     string `json:"cs"`
+	Size   int64  `json:"size"`
+}
+
+// fakePdfcpu stands in for the pdfcpu binary: it answers info, images list and extract
+// from its fields, merge concatenates its inputs, and other commands that write a PDF copy
+// their input to their output. It also stands in for the OCR engine.
+type fakePdfcpu struct {
+	pages    int
+	images   []fakeImage
+	contents map[int]string // page -> content stream
+	width    float64        // page size in points, 612x792 if zero
+	height   float64
+
+	// respond, if set, answers a command before the defaults; handled is false to fall through
+	respond func(args []string) (stdout string, handled bool, err error)
+
+	mu       sync.Mutex
+	calls    [][]string
+	ocrCalls [][]string // OCR engine calls, which write "<outBase>.pdf" naming their image
+}
+
+// installFakeCLI makes every CLI call of the test run through f instead of a binary
+func installFakeCLI(t testing.TB, f *fakePdfcpu) *fakePdfcpu {
+	t.Helper()
+	previous := runCommand
+	runCommand = func(ctx context.Context, name string, args, env []string, stdout, stderr io.Writer) error {
+		if name == ocrBinary {
+			f.mu.Lock()
+			f.ocrCalls = append(f.ocrCalls, append([]string{}, args...))
+			f.mu.Unlock()
+			if len(args) < 2 || args[0] == "--version" {
+				return nil
+			}
+			return os.WriteFile(args[1]+".pdf", []byte("%PDF-1.7 text layer of "+filepath.Base(args[0])+"\n"), 0644)
+		}
+		if name != "pdfcpu" {
+			return fmt.Errorf("unexpected command %s", name)
+		}
+		f.mu.Lock()
+		f.calls = append(f.calls, append([]string{}, args...))
+		f.mu.Unlock()
+
+		out, err := f.run(args)
+		if err != nil {
+			io.WriteString(stderr, err.Error())
+			return fmt.Errorf("exit status 1")
+		}
+		io.WriteString(stdout, out)
+		return nil
+	}
+	t.Cleanup(func() { runCommand = previous })
+	return f
+}
+
+// callCount returns how many commands started with the given words, e.g. "images", "list"
+func (f *fakePdfcpu) callCount(words ...string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	count := 0
+	for _, call := range f.calls {
+		if len(call) >= len(words) && slices.Equal(call[:len(words)], words) {
+			count++
+		}
+	}
+	return count
+}
+
+// callsOf returns the commands that started with the given words
+func (f *fakePdfcpu) callsOf(words ...string) [][]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var calls [][]string
+	for _, call := range f.calls {
+		if len(call) >= len(words) && slices.Equal(call[:len(words)], words) {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+func (f *fakePdfcpu) run(args []string) (string, error) {
+	if f.respond != nil {
+		if out, handled, err := f.respond(args); handled {
+			return out, err
+		}
+	}
+	if len(args) == 0 {
+		return "", fmt.Errorf("no command")
+	}
+
+	switch {
+	case args[0] == "version":
+		return "pdfcpu: v0.11.1 dev\n", nil
+	case args[0] == "info" && slices.Contains(args, "-json"):
+		return "", fmt.Errorf("flag provided but not defined: -json")
+	case args[0] == "info" && slices.Contains(args, "-pages"):
+		width, height := f.width, f.height
+		if width == 0 {
+			width, height = 612, 792
+		}
+		var b strings.Builder
+		for page := 1; page <= f.pages; page++ {
+			fmt.Fprintf(&b, "Page %d:\n  MediaBox (0.00, 0.00, %.2f, %.2f)\n", page, width, height)
+		}
+		return b.String(), nil
+	case args[0] == "info":
+		return fmt.Sprintf("PDF version: 1.7\nPage count: %d\nEncrypted: No\n", f.pages), nil
+	case len(args) > 1 && args[0] == "images" && args[1] == "list":
+		if slices.Contains(args, "-json") {
+			data, _ := json.Marshal(f.images)
+			return string(data), nil
+		}
+		return "no images available\n", nil
+	case args[0] == "extract":
+		return "", f.extract(args)
+	case args[0] == "merge" && len(args) > 2:
+		// merge -- outFile inFile...: concatenate the inputs
+		var merged []byte
+		for _, in := range args[3:] {
+			data, err := os.ReadFile(in)
+			if err != nil {
+				return "", err
+			}
+			merged = append(merged, data...)
+		}
+		return "", os.WriteFile(args[2], merged, 0644)
+	}
+
+	// Everything else writes a new PDF: copy the input to the output
+	var pdfs []string
+	for _, arg := range args {
+		if strings.HasSuffix(arg, ".pdf") || strings.HasSuffix(arg, ".optimized") {
+			pdfs = append(pdfs, arg)
+		}
+	}
+	if len(pdfs) < 2 {
+		return "", nil
+	}
+	data, err := os.ReadFile(pdfs[0])
+	if err != nil {
+		return "", err
+	}
+	return "", os.WriteFile(pdfs[len(pdfs)-1], data, 0644)
+}
+
+// extract writes content streams or image files for the selected pages
+func (f *fakePdfcpu) extract(args []string) error {
+	mode, pagesSpec := "", ""
+	var positional []string
+	for i := 1; i < len(args); i++ {
+		switch args[i] {
+		case "-mode":
+			i++
+			mode = args[i]
+		case "-pages", "-p":
+			i++
+			pagesSpec = args[i]
+		default:
+			positional = append(positional, args[i])
+		}
+	}
+	if len(positional) != 2 {
+		return fmt.Errorf("extract: expected inFile and outDir, got %v", positional)
+	}
+	inFile, outDir := positional[0], positional[1]
+	base := strings.TrimSuffix(filepath.Base(inFile), filepath.Ext(inFile))
+
+	selected := pageRange(f.pages)
+	if pagesSpec != "" {
+		parsed, err := ParsePageSpecifierWithTotal(pagesSpec, f.pages)
+		if err != nil {
+			return err
+		}
+		selected = parsed
+	}
+
+	for _, page := range selected {
+		switch mode {
+		case "content":
+			if content, ok := f.contents[page]; ok {
+				name := fmt.Sprintf("%s_Content_page_%d.txt", base, page)
+				if err := os.WriteFile(filepath.Join(outDir, name), []byte(content), 0644); err != nil {
+					return err
+				}
+			}
+		case "image":
+			for _, img := range f.images {
+				if img.Page != page {
+					continue
+				}
+				name := fmt.Sprintf("%s_%d_%s.png", base, page, img.ID)
+				if err := os.WriteFile(filepath.Join(outDir, name), []byte("obj "+strconv.Itoa(img.Obj)), 0644); err != nil {
+					return err
+				}
+			}
+		default:
+			return fmt.Errorf("extract: unsupported mode %q", mode)
+		}
+	}
+	return nil
+}
+
+// writeFakePDF writes a small placeholder PDF file into dir
+func writeFakePDF(t testing.TB, dir, name string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("%PDF-1.7\n%%EOF\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
@@ -0,0 +1,61 @@
+package pdf
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Fingerprint returns a stable fingerprint of a PDF in the form "<file>:<content>", both
+// SHA-256 hex digests. The file part changes with any byte of the file; the content part
+// covers only the page count and each page's decoded content stream, so files that differ
+// cosmetically (metadata, object order, compression) but show the same pages share it.
+func Fingerprint(inFile string) (string, error) {
+	fileHash, err := fileSHA256(inFile)
+	if err != nil {
+		return "", err
+	}
+	contentHash, err := contentFingerprint(inFile)
+	if err != nil {
+		return "", err
+	}
+	return fileHash + ":" + contentHash, nil
+}
+
+// fileSHA256 returns the hex SHA-256 digest of a file's bytes
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("failed to hash file: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// contentFingerprint hashes the page count and the content stream of every page in order
+func contentFingerprint(inFile string) (string, error) {
+	totalPages, err := getPageCount(inFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to get page count: %w", err)
+	}
+	contents, err := extractPageContents(context.Background(), inFile, totalPages)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "pages:%d\n", totalPages)
+	for page := 1; page <= totalPages; page++ {
+		pageHash := sha256.Sum256([]byte(contents[page]))
+		fmt.Fprintf(h, "%d:%x\n", page, pageHash)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
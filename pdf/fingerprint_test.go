@@ -0,0 +1,56 @@
+package pdf
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fingerprintOf returns the fingerprint of a file with the given bytes, as read by f
+func fingerprintOf(t *testing.T, f *fakePdfcpu, data string) (file, content string) {
+	t.Helper()
+	installFakeCLI(t, f)
+	path := filepath.Join(t.TempDir(), "in.pdf")
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fingerprint, err := Fingerprint(path)
+	if err != nil {
+		t.Fatalf("Fingerprint: %v", err)
+	}
+	file, content, ok := strings.Cut(fingerprint, ":")
+	if !ok || len(file) != 64 || len(content) != 64 {
+		t.Fatalf("fingerprint %q, want two SHA-256 hex digests", fingerprint)
+	}
+	return file, content
+}
+
+func TestFingerprint(t *testing.T) {
+	const original = "%PDF-1.7\n1 0 obj\n%%EOF\n"
+	file, content := fingerprintOf(t, watermarkedPDF(3), original)
+
+	// Identical files and identical content yield identical fingerprints
+	if gotFile, gotContent := fingerprintOf(t, watermarkedPDF(3), original); gotFile != file || gotContent != content {
+		t.Errorf("same file fingerprinted as %s:%s, then %s:%s", file, content, gotFile, gotContent)
+	}
+
+	// A cosmetically different file with the same pages only shares the content part
+	gotFile, gotContent := fingerprintOf(t, watermarkedPDF(3), "%PDF-1.7\n%producer changed\n1 0 obj\n%%EOF\n")
+	if gotFile == file {
+		t.Error("different bytes share the file hash")
+	}
+	if gotContent != content {
+		t.Error("same pages have a different content hash")
+	}
+
+	// Different page content or page count changes the content part
+	changed := watermarkedPDF(3)
+	changed.contents[2] = "BT /F1 12 Tf 72 720 Td (Other) Tj ET"
+	if _, gotContent := fingerprintOf(t, changed, original); gotContent == content {
+		t.Error("changed page content kept the content hash")
+	}
+	if _, gotContent := fingerprintOf(t, watermarkedPDF(4), original); gotContent == content {
+		t.Error("an added page kept the content hash")
+	}
+}
@@ -0,0 +1,125 @@
+package pdf
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FontInfo is one font resource of a PDF with the pages it is used on
+type FontInfo struct {
+	Name     string `json:"name"`     // base font name without the subset tag
+	Type     string `json:"type"`     // e.g. "Type1", "TrueType", "Type0"
+	Encoding string `json:"encoding"` // "" if pdfcpu did not report one
+	Embedded bool   `json:"embedded"`
+	Subset   bool   `json:"subset"` // only the used glyphs are embedded ("ABCDEF+Name")
+	Pages    []int  `json:"pages"`
+}
+
+// subsetTagPattern matches the six-letter tag PDF writers prefix to subset font names
+var subsetTagPattern = regexp.MustCompile(`^[A-Z]{6}\+`)
+
+// ListFonts returns the fonts used by a PDF, one entry per font object. It wraps
+// "pdfcpu info -fonts"; "pdfcpu fonts list" only lists the fonts installed for pdfcpu
+// itself. A PDF without fonts (e.g. a pure scan) yields an empty list.
+func ListFonts(inFile string) ([]FontInfo, error) {
+	name, args := fontsInfoCommand(inFile)
+	output, err := execCommandWithTimeout(DefaultCLITimeout, name, args...)
+	if err != nil {
+		return nil, fmt.Errorf("pdfcpu info failed: %w", err)
+	}
+	return parseFontsInfo(string(output)), nil
+}
+
+// parseFontsInfo parses the font table of "pdfcpu info -fonts". Columns are located by
+// their header, so additional or reordered columns in other pdfcpu versions are tolerated.
+func parseFontsInfo(output string) []FontInfo {
+	fonts := []FontInfo{}
+	byKey := make(map[string]int) // font key -> index in fonts
+
+	var columns []string
+	for _, line := range outputLines(output) {
+		lineTrimmed := strings.TrimSpace(line)
+		if lineTrimmed == "" {
+			continue
+		}
+
+		if columns == nil {
+			lower := strings.ToLower(lineTrimmed)
+			if strings.Contains(lower, "name") && strings.Contains(lower, "type") {
+				columns = strings.Fields(lower)
+			}
+			continue
+		}
+
+		// Skip separator lines
+		if matched, _ := regexp.MatchString(`^[\s│|\-=_]+$`, lineTrimmed); matched {
+			continue
+		}
+
+		fields := strings.Fields(lineTrimmed)
+		if len(fields) != len(columns) {
+			// Fall back to fixed-width columns separated by at least two spaces
+			fields = regexp.MustCompile(`\s{2,}`).Split(lineTrimmed, -1)
+			if len(fields) != len(columns) {
+				continue
+			}
+		}
+
+		column := func(prefix string) string { return fontColumn(columns, fields, prefix) }
+		rawName := column("name")
+		if rawName == "" {
+			continue
+		}
+		name := subsetTagPattern.ReplaceAllString(rawName, "")
+		page, _ := strconv.Atoi(column("page"))
+
+		key := column("obj")
+		if key == "" {
+			key = rawName
+		}
+		idx, ok := byKey[key]
+		if !ok {
+			idx = len(fonts)
+			byKey[key] = idx
+			fonts = append(fonts, FontInfo{
+				Name:     name,
+				Type:     column("type"),
+				Encoding: column("enc"),
+				Embedded: parseFontFlag(column("emb")),
+				Subset:   subsetTagPattern.MatchString(rawName) || parseFontFlag(column("sub")),
+				Pages:    []int{},
+			})
+		}
+		if page > 0 && !slices.Contains(fonts[idx].Pages, page) {
+			fonts[idx].Pages = append(fonts[idx].Pages, page)
+		}
+	}
+
+	for i := range fonts {
+		sort.Ints(fonts[i].Pages)
+	}
+	return fonts
+}
+
+// fontColumn returns the field of the first column whose header starts with prefix
+func fontColumn(columns, fields []string, prefix string) string {
+	for i, column := range columns {
+		if strings.HasPrefix(column, prefix) {
+			return fields[i]
+		}
+	}
+	return ""
+}
+
+// parseFontFlag interprets the yes/no columns of the font table
+func parseFontFlag(value string) bool {
+	switch strings.ToLower(value) {
+	case "true", "yes", "y", "x", "*":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+package pdf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFontsInfo(t *testing.T) {
+	output := `in.pdf:
+  3 font(s):
+
+Page Obj# Id Name                Type     Encoding         Embedded
+--------------------------------------------------------------------
+   1   12 F1 ABCDEF+Helvetica    TrueType WinAnsiEncoding  true
+   2   12 F1 ABCDEF+Helvetica    TrueType WinAnsiEncoding  true
+   2   15 F2 Courier             Type1    StandardEncoding false
+   3   20 F3 QWERTY+NotoSans-Bold Type0   Identity-H       true
+`
+	want := []FontInfo{
+		{Name: "Helvetica", Type: "TrueType", Encoding: "WinAnsiEncoding", Embedded: true, Subset: true, Pages: []int{1, 2}},
+		{Name: "Courier", Type: "Type1", Encoding: "StandardEncoding", Embedded: false, Subset: false, Pages: []int{2}},
+		{Name: "NotoSans-Bold", Type: "Type0", Encoding: "Identity-H", Embedded: true, Subset: true, Pages: []int{3}},
+	}
+	if got := parseFontsInfo(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseFontsInfo =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestParseFontsInfoWithoutFonts(t *testing.T) {
+	for _, output := range []string{"", "scan.pdf:\n  0 font(s)\n", "scan.pdf:\r\nno fonts available\r\n"} {
+		if got := parseFontsInfo(output); got == nil || len(got) != 0 {
+			t.Errorf("parseFontsInfo(%q) = %#v, want an empty list", output, got)
+		}
+	}
+}
+
+func TestListFonts(t *testing.T) {
+	fake := installFakeCLI(t, &fakePdfcpu{pages: 1})
+	fake.respond = func(args []string) (string, bool, error) {
+		if args[0] == "info" && len(args) > 1 && args[1] == "-fonts" {
+			return "Page Obj# Id Name Type Encoding Embedded\r\n   1   7 F1 Times-Roman Type1 WinAnsiEncoding false\r\n", true, nil
+		}
+		return "", false, nil
+	}
+	fonts, err := ListFonts(writeFakePDF(t, t.TempDir(), "in.pdf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fonts) != 1 || fonts[0].Name != "Times-Roman" || fonts[0].Embedded {
+		t.Errorf("ListFonts = %+v, want one non-embedded Times-Roman", fonts)
+	}
+}
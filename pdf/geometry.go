@@ -0,0 +1,128 @@
+package pdf
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// PageGeometry describes the visible area of a page in points
+type PageGeometry struct {
+	Page   int     `json:"page"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+var (
+	// pageHeaderPattern matches per-page sections of "pdfcpu info -pages" (e.g. "Page 3:")
+	pageHeaderPattern = regexp.MustCompile(`(?m)^\s*Page (\d+):`)
+	// mediaBoxPattern matches "MediaBox (0.00, 0.00, 595.28, 841.89)" style lines
+	mediaBoxPattern = regexp.MustCompile(`(?i)(?:CropBox|MediaBox)\s*\(?\s*([-\d.]+)[,\s]+([-\d.]+)[,\s]+([-\d.]+)[,\s]+([-\d.]+)`)
+	// pageSizePattern matches the document-wide "Page size: 595.28 x 841.89 points" line
+	pageSizePattern = regexp.MustCompile(`(?i)Page sizes?:\s*([\d.]+)\s*x\s*([\d.]+)`)
+)
+
+// GetPageGeometry returns the size of every page of a PDF using pdfcpu CLI
+// Returns a map of page number -> geometry
+func GetPageGeometry(filename string) (map[int]PageGeometry, error) {
+	return getPageGeometry(context.Background(), filename)
+}
+
+// getPageGeometry is GetPageGeometry with its pdfcpu calls stopped when ctx is cancelled
+func getPageGeometry(ctx context.Context, filename string) (map[int]PageGeometry, error) {
+	info, err := getDocumentInfo(ctx, filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get page count: %w", err)
+	}
+	totalPages := info.Pages
+
+	name, args := pageInfoCommand(filename, totalPages)
+	output, err := execCommandContext(ctx, DefaultCLITimeout, name, args...)
+	if err != nil {
+		return nil, fmt.Errorf("pdfcpu info failed: %w", err)
+	}
+
+	geometry := parsePageGeometry(normalizeNewlines(string(output)), totalPages)
+	if len(geometry) == 0 {
+		return nil, fmt.Errorf("could not determine page geometry from output")
+	}
+	return geometry, nil
+}
+
+// parsePageGeometry parses pdfcpu info output into per-page geometry
+// Per-page box sections are preferred; a single document-wide page size is
+// applied to every page when no per-page data is present
+func parsePageGeometry(output string, totalPages int) map[int]PageGeometry {
+	geometry := make(map[int]PageGeometry)
+
+	headers := pageHeaderPattern.FindAllStringSubmatchIndex(output, -1)
+	for i, header := range headers {
+		page, err := strconv.Atoi(output[header[2]:header[3]])
+		if err != nil {
+			continue
+		}
+		end := len(output)
+		if i+1 < len(headers) {
+			end = headers[i+1][0]
+		}
+		section := output[header[1]:end]
+
+		// CropBox (visible area) wins over MediaBox when both are listed
+		var box []string
+		for _, m := range mediaBoxPattern.FindAllStringSubmatch(section, -1) {
+			if box == nil || strings.HasPrefix(strings.ToLower(m[0]), "cropbox") {
+				box = m
+			}
+		}
+		if box == nil {
+			continue
+		}
+		llx, _ := strconv.ParseFloat(box[1], 64)
+		lly, _ := strconv.ParseFloat(box[2], 64)
+		urx, _ := strconv.ParseFloat(box[3], 64)
+		ury, _ := strconv.ParseFloat(box[4], 64)
+		geometry[page] = PageGeometry{Page: page, Width: urx - llx, Height: ury - lly}
+	}
+
+	if len(geometry) > 0 {
+		return geometry
+	}
+
+	if m := pageSizePattern.FindStringSubmatch(output); m != nil {
+		width, _ := strconv.ParseFloat(m[1], 64)
+		height, _ := strconv.ParseFloat(m[2], 64)
+		for page := 1; page <= totalPages; page++ {
+			geometry[page] = PageGeometry{Page: page, Width: width, Height: height}
+		}
+	}
+
+	return geometry
+}
+
+// clipToPage intersects a bounding box with the page area
+func clipToPage(bbox BBox, page PageGeometry) BBox {
+	minX := max(bbox.X, 0)
+	minY := max(bbox.Y, 0)
+	maxX := min(bbox.X+bbox.Width, page.Width)
+	maxY := min(bbox.Y+bbox.Height, page.Height)
+	if maxX < minX {
+		maxX = minX
+	}
+	if maxY < minY {
+		maxY = minY
+	}
+	return BBox{X: minX, Y: minY, Width: maxX - minX, Height: maxY - minY}
+}
+
+// effectiveDPI computes the rendered resolution of an image drawn into bbox
+// The lower of the horizontal and vertical resolutions is returned; 0 means unknown
+func effectiveDPI(pixelWidth, pixelHeight int, bbox BBox) float64 {
+	if pixelWidth <= 0 || pixelHeight <= 0 || bbox.Width <= 0 || bbox.Height <= 0 {
+		return 0
+	}
+	dpiX := float64(pixelWidth) * 72 / bbox.Width
+	dpiY := float64(pixelHeight) * 72 / bbox.Height
+	return min(dpiX, dpiY)
+}
@@ -0,0 +1,93 @@
+package pdf
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestEffectiveDPI(t *testing.T) {
+	tests := []struct {
+		name          string
+		width, height int
+		bbox          BBox
+		want          float64
+	}{
+		{name: "one inch at 300 dpi", width: 300, height: 300, bbox: BBox{Width: 72, Height: 72}, want: 300},
+		{name: "scan filling a letter page", width: 2550, height: 3300, bbox: BBox{Width: 612, Height: 792}, want: 300},
+		{name: "lower axis wins", width: 600, height: 100, bbox: BBox{Width: 72, Height: 72}, want: 100},
+		{name: "hairline stretched across a page", width: 1, height: 1, bbox: BBox{Width: 540, Height: 0.5}, want: 72.0 / 540},
+		{name: "unknown pixel size", width: 0, height: 100, bbox: BBox{Width: 72, Height: 72}, want: 0},
+		{name: "empty bbox", width: 100, height: 100, bbox: BBox{Width: 72}, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := effectiveDPI(tt.width, tt.height, tt.bbox); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("effectiveDPI = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClipToPage(t *testing.T) {
+	page := PageGeometry{Width: 612, Height: 792}
+	tests := []struct {
+		name string
+		bbox BBox
+		want BBox
+	}{
+		{name: "inside", bbox: BBox{X: 10, Y: 10, Width: 100, Height: 50}, want: BBox{X: 10, Y: 10, Width: 100, Height: 50}},
+		{name: "bleeding off the bottom left", bbox: BBox{X: -10, Y: -20, Width: 100, Height: 100}, want: BBox{Width: 90, Height: 80}},
+		{name: "larger than the page", bbox: BBox{X: -100, Y: -100, Width: 1000, Height: 1000}, want: BBox{Width: 612, Height: 792}},
+		{name: "off the page", bbox: BBox{X: 700, Y: 10, Width: 50, Height: 50}, want: BBox{X: 700, Y: 10, Height: 50}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := clipToPage(tt.bbox, page); got != tt.want {
+				t.Errorf("clipToPage = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterImagesByDPI(t *testing.T) {
+	// Page 1 draws a 300x300 logo one inch wide (300 dpi) and a 1x1 rule across the page;
+	// page 2 draws the logo at 4 inches (75 dpi) and mentions an image it never draws
+	contents := map[int]string{
+		1: "q 72 0 0 72 36 700 cm /Logo Do Q q 540 0 0 0.5 36 400 cm /Rule Do Q",
+		2: "q 288 0 0 288 36 400 cm /Logo Do Q",
+	}
+	sources := pageSources{
+		contents: func() (map[int]string, error) { return contents, nil },
+		geometry: func() (map[int]PageGeometry, error) {
+			return map[int]PageGeometry{1: {Page: 1, Width: 612, Height: 792}, 2: {Page: 2, Width: 612, Height: 792}}, nil
+		},
+	}
+	imagesByPage := map[int][]imageInfo{
+		1: {{id: "Logo", width: 300, height: 300}, {id: "Rule", width: 1, height: 1}},
+		2: {{id: "Logo", width: 300, height: 300}, {id: "Unplaced", width: 10, height: 10}},
+	}
+
+	tests := []struct {
+		minDPI float64
+		want   map[int][]string
+	}{
+		{minDPI: 50, want: map[int][]string{1: {"Logo"}, 2: {"Logo", "Unplaced"}}},
+		{minDPI: 150, want: map[int][]string{1: {"Logo"}, 2: {"Unplaced"}}},
+	}
+	for _, tt := range tests {
+		filtered, err := filterImagesByDPI(imagesByPage, tt.minDPI, sources, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for page, want := range tt.want {
+			var got []string
+			for _, img := range filtered[page] {
+				got = append(got, img.id)
+			}
+			if !slices.Equal(got, want) {
+				t.Errorf("min DPI %v, page %d: kept %v, want %v", tt.minDPI, page, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package pdf
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// imageListEntry is one image of "pdfcpu images list -json". Field names differ between
+// pdfcpu versions (e.g. "pageNr" and "page"), so each field accepts the known spellings,
+// matched case-insensitively.
+type imageListEntry struct {
+	Page        int
+	Obj         int
+	ID          string
+	Type        string
+	SoftMask    bool
+	ImageMask   bool
+	Width       int
+	Height      int
+	ColorSpace  string
+	Components  int
+	BPC         int
+	Interpolate bool
+	Size        int64
+}
+
+// imageListFieldNames are the accepted JSON keys of each imageListEntry field, lowercase
+var imageListFieldNames = map[string][]string{
+	"page":        {"pagenr", "page"},
+	"obj":         {"objnr", "obj", "objectnr"},
+	"id":          {"name", "id"},
+	"type":        {"filetype", "type"},
+	"softmask":    {"hassmask", "smask", "softmask"},
+	"imagemask":   {"isimgmask", "imgmask", "imagemask"},
+	"width":       {"width"},
+	"height":      {"height"},
+	"colorspace":  {"cs", "colorspace"},
+	"components":  {"comp", "components"},
+	"bpc":         {"bpc"},
+	"interpolate": {"interpol", "interpolate"},
+	"size":        {"size"},
+}
+
+// listImagesJSON lists the image occurrences of a PDF from "pdfcpu images list -json"
+func listImagesJSON(ctx context.Context, filename string) ([]rawImageData, error) {
+	_, args := imagesListCommand(filename)
+	output, err := runPdfcpuJSON(ctx, AnalysisTimeout, args...)
+	if err != nil {
+		return nil, err
+	}
+	return parseImagesListJSON(output)
+}
+
+// parseImagesListJSON parses "pdfcpu images list -json" output. The image objects are
+// looked for anywhere in the document (a top-level list, under a key such as "images",
+// or grouped by page), since the layout has changed between pdfcpu versions. Images are
+// returned ordered by page, in output order within a page.
+func parseImagesListJSON(output []byte) ([]rawImageData, error) {
+	var doc any
+	if err := json.Unmarshal(output, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse pdfcpu images list JSON: %w", err)
+	}
+
+	images := []rawImageData{}
+	var walk func(v any)
+	walk = func(v any) {
+		switch value := v.(type) {
+		case []any:
+			for _, item := range value {
+				walk(item)
+			}
+		case map[string]any:
+			if entry, ok := imageListEntryOf(value); ok {
+				images = append(images, entry.rawImageData())
+				return
+			}
+			keys := make([]string, 0, len(value))
+			for key := range value {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				walk(value[key])
+			}
+		}
+	}
+	walk(doc)
+
+	sort.SliceStable(images, func(i, j int) bool { return images[i].page < images[j].page })
+	return images, nil
+}
+
+// imageListEntryOf reads an image from a JSON object; ok is false for objects without
+// the page and resource name every image has
+func imageListEntryOf(object map[string]any) (imageListEntry, bool) {
+	fields := make(map[string]any, len(object))
+	for key, value := range object {
+		fields[strings.ToLower(key)] = value
+	}
+	field := func(name string) (any, bool) {
+		for _, key := range imageListFieldNames[name] {
+			if value, ok := fields[key]; ok {
+				return value, true
+			}
+		}
+		return nil, false
+	}
+	number := func(name string) int64 {
+		value, _ := field(name)
+		switch n := value.(type) {
+		case float64:
+			return int64(n)
+		case string:
+			parsed, _ := strconv.ParseInt(strings.TrimSpace(n), 10, 64)
+			return parsed
+		}
+		return 0
+	}
+	text := func(name string) string {
+		value, _ := field(name)
+		if s, ok := value.(string); ok {
+			return s
+		}
+		return ""
+	}
+	flag := func(name string) bool {
+		value, _ := field(name)
+		switch b := value.(type) {
+		case bool:
+			return b
+		case string:
+			return parseFontFlag(b)
+		}
+		return false
+	}
+
+	if _, ok := field("page"); !ok {
+		return imageListEntry{}, false
+	}
+	entry := imageListEntry{
+		Page:        int(number("page")),
+		Obj:         int(number("obj")),
+		ID:          text("id"),
+		Type:        text("type"),
+		SoftMask:    flag("softmask"),
+		ImageMask:   flag("imagemask"),
+		Width:       int(number("width")),
+		Height:      int(number("height")),
+		ColorSpace:  text("colorspace"),
+		Components:  int(number("components")),
+		BPC:         int(number("bpc")),
+		Interpolate: flag("interpolate"),
+		Size:        number("size"),
+	}
+	if entry.Page <= 0 || entry.ID == "" {
+		return imageListEntry{}, false
+	}
+	return entry, true
+}
+
+// rawImageData converts an entry to the form the text parser produces, so both parsers
+// feed the analysis the same values: "*" marks set mask flags, sizes are in bytes
+func (e imageListEntry) rawImageData() rawImageData {
+	mark := func(set bool) string {
+		if set {
+			return "*"
+		}
+		return ""
+	}
+	obj := ""
+	if e.Obj > 0 {
+		obj = strconv.Itoa(e.Obj)
+	}
+	return rawImageData{
+		page:       e.Page,
+		obj:        obj,
+		id:         e.ID,
+		imgType:    e.Type,
+		softMask:   mark(e.SoftMask),
+		imgMask:    mark(e.ImageMask),
+		width:      e.Width,
+		height:     e.Height,
+		colorSpace: e.ColorSpace,
+		components: e.Components,
+		bpc:        e.BPC,
+		interp:     mark(e.Interpolate),
+		size:       fmt.Sprintf("%d B", e.Size),
+	}
+}
@@ -0,0 +1,110 @@
+package pdf
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DocumentInfo is the basic metadata of a PDF reported by pdfcpu info
+type DocumentInfo struct {
+	Pages     int    `json:"pages"`
+	Title     string `json:"title"`
+	Version   string `json:"pdf_version"` // e.g. "1.7"
+	Encrypted bool   `json:"encrypted"`
+}
+
+// pageCountPatterns match the page count in the text output of the pdfcpu versions seen so far
+var pageCountPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`Page count:\s+(\d+)`),    // "Page count: 426" (pdfcpu v0.11.1 format)
+	regexp.MustCompile(`Pages:\s+(\d+)`),         // "Pages: 10"
+	regexp.MustCompile(`pages\s*=\s*(\d+)`),      // "pages = 10"
+	regexp.MustCompile(`No\. of pages:\s+(\d+)`), // "No. of pages: 10"
+}
+
+var (
+	infoTitlePattern     = regexp.MustCompile(`(?m)^\s*Title:[ \t]*(.*)$`)
+	infoVersionPattern   = regexp.MustCompile(`(?m)^\s*PDF version:\s*([\d.]+)`)
+	infoEncryptedPattern = regexp.MustCompile(`(?m)^\s*Encrypted:\s*(\S+)`)
+)
+
+// GetDocumentInfo returns the page count, title, PDF version and encryption flag of a PDF.
+// The text output of "pdfcpu info" is parsed first; if it has no recognizable page count,
+// the JSON output of newer pdfcpu versions ("pdfcpu info -json") is used instead.
+func GetDocumentInfo(filename string) (*DocumentInfo, error) {
+	return getDocumentInfo(context.Background(), filename)
+}
+
+// getDocumentInfo is GetDocumentInfo with its pdfcpu calls stopped when ctx is cancelled
+func getDocumentInfo(ctx context.Context, filename string) (*DocumentInfo, error) {
+	name, args := infoCommand(filename)
+	output, err := execCommandContext(ctx, DefaultCLITimeout, name, args...)
+	if err != nil {
+		return nil, fmt.Errorf("pdfcpu info failed: %w", err)
+	}
+
+	outputStr := normalizeNewlines(string(output))
+	if info, ok := parseInfoText(outputStr); ok {
+		return info, nil
+	}
+
+	jsonOutput, err := runPdfcpuJSON(ctx, DefaultCLITimeout, args...)
+	if err == nil {
+		if info, err := parseInfoJSON(jsonOutput); err == nil {
+			return info, nil
+		}
+	}
+
+	// Debug: include actual output in error
+	return nil, fmt.Errorf("could not determine page count from output: %s", outputStr)
+}
+
+// parseInfoText parses the text output of "pdfcpu info"; ok is false without a page count
+func parseInfoText(output string) (*DocumentInfo, bool) {
+	info := &DocumentInfo{}
+	for _, pattern := range pageCountPatterns {
+		if matches := pattern.FindStringSubmatch(output); len(matches) > 1 {
+			if pageCount, err := strconv.Atoi(matches[1]); err == nil {
+				info.Pages = pageCount
+				break
+			}
+		}
+	}
+	if info.Pages == 0 {
+		return nil, false
+	}
+
+	if matches := infoTitlePattern.FindStringSubmatch(output); len(matches) > 1 {
+		info.Title = strings.TrimSpace(matches[1])
+	}
+	if matches := infoVersionPattern.FindStringSubmatch(output); len(matches) > 1 {
+		info.Version = matches[1]
+	}
+	if matches := infoEncryptedPattern.FindStringSubmatch(output); len(matches) > 1 {
+		info.Encrypted = parseFontFlag(matches[1])
+	}
+	return info, true
+}
+
+// parseInfoJSON parses the output of "pdfcpu info -json", which holds one entry per input file
+func parseInfoJSON(output []byte) (*DocumentInfo, error) {
+	var parsed struct {
+		Infos []struct {
+			Version   string `json:"version"`
+			PageCount int    `json:"pageCount"`
+			Title     string `json:"title"`
+			Encrypted bool   `json:"encrypted"`
+		} `json:"infos"`
+	}
+	if err := json.Unmarshal(output, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse pdfcpu info JSON: %w", err)
+	}
+	if len(parsed.Infos) == 0 || parsed.Infos[0].PageCount <= 0 {
+		return nil, fmt.Errorf("pdfcpu info JSON has no page count")
+	}
+	entry := parsed.Infos[0]
+	return &DocumentInfo{Pages: entry.PageCount, Title: entry.Title, Version: entry.Version, Encrypted: entry.Encrypted}, nil
+}
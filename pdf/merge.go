@@ -0,0 +1,104 @@
+package pdf
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// MergePDFs merges the input files whole, in order, into outFile
+func MergePDFs(inFiles []string, outFile string) error {
+	return MergeSelected(inFiles, nil, outFile)
+}
+
+// MergeSelection picks pages from one of the merge inputs
+type MergeSelection struct {
+	FileIndex int    `json:"file_index"` // 0-based index into the input files
+	Pages     string `json:"pages"`      // page specification, e.g. "1-3,5"; "" selects all pages
+}
+
+// MergeSelected builds outFile from the selected pages of the input files, in selection
+// order. The same input may be selected several times. Without selections every input is
+// merged whole, in order. Each page specification is validated against its file's page
+// count before anything is written.
+func MergeSelected(inFiles []string, selections []MergeSelection, outFile string) error {
+	if len(inFiles) == 0 {
+		return fmt.Errorf("no input files to merge")
+	}
+	if len(selections) == 0 {
+		for i := range inFiles {
+			selections = append(selections, MergeSelection{FileIndex: i})
+		}
+	}
+	for _, inFile := range inFiles {
+		if err := checkDistinctFiles(inFile, outFile); err != nil {
+			return err
+		}
+	}
+
+	// Resolve every selection to explicit page numbers up front
+	pageCounts := make(map[int]int)
+	selectedPages := make([][]int, len(selections))
+	for i, selection := range selections {
+		if selection.FileIndex < 0 || selection.FileIndex >= len(inFiles) {
+			return fmt.Errorf("selection %d: file_index %d out of range (0-%d)", i+1, selection.FileIndex, len(inFiles)-1)
+		}
+		if selection.Pages == "" {
+			continue
+		}
+		totalPages, ok := pageCounts[selection.FileIndex]
+		if !ok {
+			var err error
+			totalPages, err = getPageCount(inFiles[selection.FileIndex])
+			if err != nil {
+				return fmt.Errorf("failed to get page count of file %d: %w", selection.FileIndex, err)
+			}
+			pageCounts[selection.FileIndex] = totalPages
+		}
+		pages, err := ParsePageSpecifierWithTotal(selection.Pages, totalPages)
+		if err != nil {
+			return fmt.Errorf("selection %d: %w", i+1, err)
+		}
+		if err := ValidatePageNumbers(pages, totalPages); err != nil {
+			return fmt.Errorf("selection %d: %w", i+1, err)
+		}
+		selectedPages[i] = pages
+	}
+
+	workDir, err := os.MkdirTemp(filepath.Dir(outFile), "merge_")
+	if err != nil {
+		return fmt.Errorf("failed to create work directory: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	// Collect each page subset into its own file; whole files are merged as they are
+	parts := make([]string, len(selections))
+	for i, selection := range selections {
+		if selectedPages[i] == nil {
+			parts[i] = inFiles[selection.FileIndex]
+			continue
+		}
+		parts[i] = filepath.Join(workDir, fmt.Sprintf("part_%d.pdf", i))
+		name, args := collectCommand(inFiles[selection.FileIndex], parts[i], selectedPages[i])
+		output, err := execCommandWithTimeout(DefaultCLITimeout, name, args...)
+		if err != nil {
+			return fmt.Errorf("pdfcpu collect failed for selection %d: %w\nOutput: %s", i+1, err, string(output))
+		}
+	}
+
+	if len(parts) == 1 {
+		data, err := os.ReadFile(parts[0])
+		if err != nil {
+			return fmt.Errorf("failed to read merged part: %w", err)
+		}
+		return os.WriteFile(outFile, data, 0644)
+	}
+
+	name, args := mergeCommand(outFile, parts)
+	output, err := execCommandWithTimeout(DefaultCLITimeout, name, args...)
+	if err != nil {
+		return fmt.Errorf("pdfcpu merge failed: %w\nOutput: %s", err, string(output))
+	}
+
+	return nil
+}
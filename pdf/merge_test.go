@@ -0,0 +1,115 @@
+package pdf
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// installMergeCLI fakes pdfcpu for merges: every input is a text file naming itself,
+// info reports the page count of pageCounts, and collect writes "name:pages" lines
+func installMergeCLI(t *testing.T, pageCounts map[string]int) *fakePdfcpu {
+	t.Helper()
+	return installFakeCLI(t, &fakePdfcpu{respond: func(args []string) (string, bool, error) {
+		switch args[0] {
+		case "info":
+			in := filepath.Base(args[len(args)-1])
+			return fmt.Sprintf("Page count: %d\n", pageCounts[in]), true, nil
+		case "collect":
+			// collect -p pages -- inFile outFile
+			in, out := args[4], args[5]
+			line := filepath.Base(in) + ":" + args[2] + "\n"
+			return "", true, os.WriteFile(out, []byte(line), 0644)
+		}
+		return "", false, nil
+	}})
+}
+
+// writeMergeInput writes an input whose content is its whole-file line
+func writeMergeInput(t *testing.T, dir, name string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(name+":all\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestMergeSelectedOrderAndSelection(t *testing.T) {
+	tests := []struct {
+		name       string
+		selections []MergeSelection
+		want       string
+		collects   int
+	}{
+		{name: "whole files without selections", want: "a.pdf:all\nb.pdf:all\nc.pdf:all\n"},
+		{name: "pages 1-3 of A, all of B, page 5 of C",
+			selections: []MergeSelection{{FileIndex: 0, Pages: "1-3"}, {FileIndex: 1}, {FileIndex: 2, Pages: "5"}},
+			want:       "a.pdf:1,2,3\nb.pdf:all\nc.pdf:5\n", collects: 2},
+		{name: "selection order, not file order",
+			selections: []MergeSelection{{FileIndex: 2, Pages: "2"}, {FileIndex: 0, Pages: "4"}},
+			want:       "c.pdf:2\na.pdf:4\n", collects: 2},
+		{name: "same file twice",
+			selections: []MergeSelection{{FileIndex: 1, Pages: "2"}, {FileIndex: 0}, {FileIndex: 1, Pages: "1"}},
+			want:       "b.pdf:2\na.pdf:all\nb.pdf:1\n", collects: 2},
+		{name: "single selection",
+			selections: []MergeSelection{{FileIndex: 0, Pages: "even"}},
+			want:       "a.pdf:2,4\n", collects: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := installMergeCLI(t, map[string]int{"a.pdf": 4, "b.pdf": 2, "c.pdf": 6})
+			dir := t.TempDir()
+			inFiles := []string{writeMergeInput(t, dir, "a.pdf"), writeMergeInput(t, dir, "b.pdf"), writeMergeInput(t, dir, "c.pdf")}
+			out := filepath.Join(dir, "merged.pdf")
+
+			if err := MergeSelected(inFiles, tt.selections, out); err != nil {
+				t.Fatalf("MergeSelected: %v", err)
+			}
+			data, err := os.ReadFile(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("merged output:\n%s\nwant:\n%s", data, tt.want)
+			}
+			if got := f.callCount("collect"); got != tt.collects {
+				t.Errorf("collect ran %d times, want %d", got, tt.collects)
+			}
+		})
+	}
+}
+
+func TestMergeSelectedValidatesEachSelection(t *testing.T) {
+	tests := []struct {
+		name       string
+		selections []MergeSelection
+		wantErr    string
+	}{
+		{name: "page past its own file", selections: []MergeSelection{{FileIndex: 0, Pages: "1"}, {FileIndex: 1, Pages: "3"}}, wantErr: "selection 2"},
+		{name: "file index out of range", selections: []MergeSelection{{FileIndex: 2}}, wantErr: "file_index 2 out of range"},
+		{name: "negative file index", selections: []MergeSelection{{FileIndex: -1}}, wantErr: "out of range"},
+		{name: "malformed page spec", selections: []MergeSelection{{FileIndex: 0, Pages: "1-x"}}, wantErr: "selection 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := installMergeCLI(t, map[string]int{"a.pdf": 4, "b.pdf": 2})
+			dir := t.TempDir()
+			inFiles := []string{writeMergeInput(t, dir, "a.pdf"), writeMergeInput(t, dir, "b.pdf")}
+			out := filepath.Join(dir, "merged.pdf")
+
+			err := MergeSelected(inFiles, tt.selections, out)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want it to mention %q", err, tt.wantErr)
+			}
+			if f.callCount("collect") != 0 || f.callCount("merge") != 0 {
+				t.Errorf("pages were collected before every selection was validated: %v", f.calls)
+			}
+			if _, err := os.Stat(out); !os.IsNotExist(err) {
+				t.Errorf("output written for an invalid selection: %v", err)
+			}
+		})
+	}
+}
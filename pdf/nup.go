@@ -0,0 +1,79 @@
+package pdf
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Paper sizes supported for N-up and booklet output, mapped to pdfcpu form sizes
+var paperSizes = map[string]string{
+	"a4":     "A4",
+	"a3":     "A3",
+	"letter": "Letter",
+	"legal":  "Legal",
+}
+
+// nUpCounts are the page-per-sheet counts pdfcpu supports for N-up output
+var nUpCounts = []int{2, 3, 4, 8, 9, 12, 16}
+
+// bookletCounts are the page-per-sheet counts pdfcpu supports for booklets
+var bookletCounts = []int{2, 4}
+
+// ValidatePaperSize checks that paperSize is a supported preset ("" keeps pdfcpu's default)
+func ValidatePaperSize(paperSize string) error {
+	if paperSize == "" {
+		return nil
+	}
+	if _, ok := paperSizes[strings.ToLower(paperSize)]; !ok {
+		return fmt.Errorf("invalid paper size: %s (supported: A4, A3, Letter, Legal)", paperSize)
+	}
+	return nil
+}
+
+// nUpDescription builds the pdfcpu description string selecting the output paper size
+func nUpDescription(paperSize string) string {
+	if paperSize == "" {
+		return ""
+	}
+	return "formsize:" + paperSizes[strings.ToLower(paperSize)]
+}
+
+// NUpPDF places n input pages on each output sheet, optionally forcing the paper size
+// (A4, A3, Letter, Legal) using pdfcpu CLI
+func NUpPDF(inFile, outFile string, n int, paperSize string) error {
+	if !slices.Contains(nUpCounts, n) {
+		return fmt.Errorf("invalid N-up count: %d (supported: 2, 3, 4, 8, 9, 12, 16)", n)
+	}
+	return runNUpCommand("nup", inFile, outFile, n, paperSize)
+}
+
+// BookletPDF arranges the input pages for printing as a folded booklet with n pages per
+// sheet (2 or 4), optionally forcing the paper size using pdfcpu CLI
+func BookletPDF(inFile, outFile string, n int, paperSize string) error {
+	if !slices.Contains(bookletCounts, n) {
+		return fmt.Errorf("invalid booklet count: %d (supported: 2, 4)", n)
+	}
+	return runNUpCommand("booklet", inFile, outFile, n, paperSize)
+}
+
+// runNUpCommand runs pdfcpu nup or booklet, which share their argument layout
+func runNUpCommand(command, inFile, outFile string, n int, paperSize string) error {
+	if err := ValidatePaperSize(paperSize); err != nil {
+		return err
+	}
+	if err := checkDistinctFiles(inFile, outFile); err != nil {
+		return err
+	}
+
+	name, args := nUpCommand(command, inFile, outFile, n, nUpDescription(paperSize))
+	output, err := execCommandWithTimeout(DefaultCLITimeout, name, args...)
+	if err != nil {
+		if outputStr := string(output); outputStr != "" {
+			return fmt.Errorf("pdfcpu %s failed: %w\nOutput: %s", command, err, outputStr)
+		}
+		return fmt.Errorf("pdfcpu %s failed: %w", command, err)
+	}
+
+	return nil
+}
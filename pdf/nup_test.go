@@ -0,0 +1,78 @@
+package pdf
+
+import "testing"
+
+func TestNUpPaperSizePresets(t *testing.T) {
+	tests := []struct {
+		paperSize string
+		want      string // description argument, "" for none
+	}{
+		{paperSize: "", want: ""},
+		{paperSize: "A4", want: "formsize:A4"},
+		{paperSize: "a3", want: "formsize:A3"},
+		{paperSize: "Letter", want: "formsize:Letter"},
+		{paperSize: "LEGAL", want: "formsize:Legal"},
+	}
+	for _, tt := range tests {
+		t.Run("preset "+tt.paperSize, func(t *testing.T) {
+			f := installFakeCLI(t, &fakePdfcpu{pages: 4, respond: func(args []string) (string, bool, error) {
+				return "", args[0] == "nup" || args[0] == "booklet", nil
+			}})
+			dir := t.TempDir()
+			in := writeFakePDF(t, dir, "in.pdf")
+
+			if err := NUpPDF(in, dir+"/nup.pdf", 4, tt.paperSize); err != nil {
+				t.Fatalf("NUpPDF: %v", err)
+			}
+			if err := BookletPDF(in, dir+"/booklet.pdf", 2, tt.paperSize); err != nil {
+				t.Fatalf("BookletPDF: %v", err)
+			}
+
+			for _, command := range []string{"nup", "booklet"} {
+				calls := f.callsOf(command)
+				if len(calls) != 1 {
+					t.Fatalf("%s ran %d times, want 1", command, len(calls))
+				}
+				// command -- [description] outFile n inFile
+				args := calls[0][2:]
+				if tt.want == "" {
+					if len(args) != 3 {
+						t.Errorf("%s args = %v, want no description", command, calls[0])
+					}
+				} else if len(args) != 4 || args[0] != tt.want {
+					t.Errorf("%s args = %v, want description %q", command, calls[0], tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestNUpRejectsUnknownPaperSize(t *testing.T) {
+	f := installFakeCLI(t, &fakePdfcpu{pages: 4})
+	dir := t.TempDir()
+	in := writeFakePDF(t, dir, "in.pdf")
+
+	for _, paperSize := range []string{"A5", "tabloid", "formsize:A4", " A4"} {
+		if err := ValidatePaperSize(paperSize); err == nil {
+			t.Errorf("ValidatePaperSize(%q) accepted an unknown size", paperSize)
+		}
+		if err := NUpPDF(in, dir+"/out.pdf", 2, paperSize); err == nil {
+			t.Errorf("NUpPDF accepted paper size %q", paperSize)
+		}
+		if err := BookletPDF(in, dir+"/out.pdf", 2, paperSize); err == nil {
+			t.Errorf("BookletPDF accepted paper size %q", paperSize)
+		}
+	}
+	if len(f.calls) != 0 {
+		t.Errorf("pdfcpu ran for rejected paper sizes: %v", f.calls)
+	}
+
+	for _, n := range []int{0, 5, 32} {
+		if err := NUpPDF(in, dir+"/out.pdf", n, "A4"); err == nil {
+			t.Errorf("NUpPDF accepted count %d", n)
+		}
+	}
+	if err := BookletPDF(in, dir+"/out.pdf", 8, "A4"); err == nil {
+		t.Error("BookletPDF accepted count 8")
+	}
+}
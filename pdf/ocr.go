@@ -0,0 +1,187 @@
+package pdf
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ocrBinary is the OCR engine executable (tesseract-compatible CLI)
+var ocrBinary = "tesseract"
+
+// SetOCRBinary configures the path of the OCR engine executable
+func SetOCRBinary(path string) {
+	if path != "" {
+		ocrBinary = path
+	}
+}
+
+// CheckOCRAvailable verifies that the OCR engine is available
+func CheckOCRAvailable() error {
+	if _, err := execCommandWithTimeout(DefaultCLITimeout, ocrBinary, "--version"); err != nil {
+		return fmt.Errorf("%s command not found or not executable: %w", ocrBinary, err)
+	}
+	return nil
+}
+
+// ocrLanguagePattern matches tesseract language codes such as "eng" or "eng+deu"
+var ocrLanguagePattern = regexp.MustCompile(`^[a-z_]{3,}(\+[a-z_]{3,})*$`)
+
+// ValidateOCRLanguage checks that lang is a well-formed tesseract language code list
+func ValidateOCRLanguage(lang string) error {
+	if !ocrLanguagePattern.MatchString(lang) {
+		return fmt.Errorf("invalid OCR language: %q (expected codes like \"eng\" or \"eng+deu\")", lang)
+	}
+	return nil
+}
+
+// ErrNothingToOCR is returned by OCRPDF when no page of the PDF has a scanned image
+var ErrNothingToOCR = errors.New("no page has a scanned image to OCR")
+
+// ocrLayerDescription places an OCR text layer over the whole page. The layer is scaled
+// relative to the page, since its size follows the pixel size and resolution of the scan.
+var ocrLayerDescription = []string{"pos:c", "sc:1 rel", "rot:0", "op:1"}
+
+// OCRPDF makes a scanned PDF searchable. The scanned image of every page is extracted with
+// pdfcpu and run through the OCR engine, which writes only the recognized text, as an
+// invisible text layer. The layers are merged into one document and stamped page by page
+// onto the original, so its content, vector graphics and page sizes are kept.
+// Pages without a scan image get an empty layer and are left as they are.
+func OCRPDF(inFile, outFile, lang string) error {
+	if err := ValidateOCRLanguage(lang); err != nil {
+		return err
+	}
+	if err := checkDistinctFiles(inFile, outFile); err != nil {
+		return err
+	}
+
+	totalPages, err := getPageCount(inFile)
+	if err != nil {
+		return fmt.Errorf("failed to get page count: %w", err)
+	}
+
+	workDir, err := os.MkdirTemp(filepath.Dir(outFile), "ocr_")
+	if err != nil {
+		return fmt.Errorf("failed to create OCR work directory: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	pageImages, err := extractScanImages(inFile, workDir)
+	if err != nil {
+		return err
+	}
+	if len(pageImages) == 0 {
+		return ErrNothingToOCR
+	}
+
+	// Pages without a scan are OCRed from a small white image, which yields an empty layer
+	// page and keeps the layers aligned with the pages
+	blankImage := sync.OnceValues(func() (string, error) {
+		return createBlankImage(workDir, 100, 100, &color.RGBA{R: 255, G: 255, B: 255, A: 255})
+	})
+
+	layers, err := runPerPage(pageRange(totalPages), func(page int) (string, error) {
+		scan, ok := pageImages[page]
+		if !ok {
+			blank, err := blankImage()
+			if err != nil {
+				return "", fmt.Errorf("failed to create blank image: %w", err)
+			}
+			scan = blank
+		}
+		outBase := filepath.Join(workDir, fmt.Sprintf("ocr_page_%d", page))
+		name, args := ocrCommand(scan, outBase, lang)
+		output, err := execCommandWithTimeout(AnalysisTimeout, name, args...)
+		if err != nil {
+			return "", fmt.Errorf("OCR failed on page %d: %w\nOutput: %s", page, err, string(output))
+		}
+		return outBase + ".pdf", nil
+	})
+	if err != nil {
+		return err
+	}
+
+	layerFile := layers[1]
+	if totalPages > 1 {
+		layerFiles := make([]string, 0, totalPages)
+		for page := 1; page <= totalPages; page++ {
+			layerFiles = append(layerFiles, layers[page])
+		}
+		layerFile = filepath.Join(workDir, "text_layer.pdf")
+		name, args := mergeCommand(layerFile, layerFiles)
+		output, err := execCommandWithTimeout(documentTimeout(totalPages), name, args...)
+		if err != nil {
+			return fmt.Errorf("pdfcpu merge failed: %w\nOutput: %s", err, string(output))
+		}
+	}
+
+	name, args := stampPagesCommand(inFile, layerFile, outFile, ocrLayerDescription)
+	output, err := execCommandWithTimeout(documentTimeout(totalPages), name, args...)
+	if err != nil {
+		return fmt.Errorf("pdfcpu stamp add failed: %w\nOutput: %s", err, string(output))
+	}
+
+	return nil
+}
+
+// ocrCommand builds the OCR engine call writing the text of image as an invisible text
+// layer without the image: tesseract image outBase -l lang -c textonly_pdf=1 pdf
+// writes outBase.pdf
+func ocrCommand(image, outBase, lang string) (string, []string) {
+	return ocrBinary, []string{image, outBase, "-l", lang, "-c", "textonly_pdf=1", "pdf"}
+}
+
+// extractScanImages extracts all images from a PDF and returns the largest image file of
+// each page, which for scanned documents is the page scan
+func extractScanImages(inFile, workDir string) (map[int]string, error) {
+	extractDir := filepath.Join(workDir, "images")
+	if err := os.MkdirAll(extractDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create image extract directory: %w", err)
+	}
+
+	name, args := extractImagesCommand(inFile, extractDir, nil)
+	output, err := execCommandWithTimeout(AnalysisTimeout, name, args...)
+	if err != nil {
+		return nil, fmt.Errorf("pdfcpu extract images failed: %w\nOutput: %s", err, string(output))
+	}
+
+	files, err := os.ReadDir(extractDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image extract directory: %w", err)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name() < files[j].Name() })
+
+	base := strings.TrimSuffix(filepath.Base(inFile), filepath.Ext(inFile))
+	pageImages := make(map[int]string)
+	pageImageSizes := make(map[int]int64)
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		matches := extractedImagePattern.FindStringSubmatch(strings.TrimPrefix(file.Name(), base+"_"))
+		if len(matches) < 3 {
+			continue
+		}
+		page, err := strconv.Atoi(matches[1])
+		if err != nil || page < 1 {
+			continue
+		}
+		info, err := file.Info()
+		if err != nil {
+			continue
+		}
+		if info.Size() > pageImageSizes[page] {
+			pageImages[page] = filepath.Join(extractDir, file.Name())
+			pageImageSizes[page] = info.Size()
+		}
+	}
+
+	return pageImages, nil
+}
@@ -0,0 +1,106 @@
+package pdf
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOCRPDFOverlaysTextLayers(t *testing.T) {
+	// Pages 1 and 3 are scans, page 2 is born-digital with only a small logo
+	scan := fakeImage{ID: "Im0", Width: 2550, Height: 3300, CS: "DeviceGray", Size: 900000}
+	scan1, scan3 := scan, scan
+	scan1.Page, scan1.Obj = 1, 10
+	scan3.Page, scan3.Obj = 3, 12
+	fake := installFakeCLI(t, &fakePdfcpu{pages: 3, images: []fakeImage{scan1, scan3}})
+
+	dir := t.TempDir()
+	inFile := writeFakePDF(t, dir, "in.pdf")
+	outFile := filepath.Join(dir, "out.pdf")
+	if err := OCRPDF(inFile, outFile, "eng"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every page gets a text-only layer, from its scan or from a blank image
+	if len(fake.ocrCalls) != 3 {
+		t.Fatalf("OCR engine ran %d times, want 3: %v", len(fake.ocrCalls), fake.ocrCalls)
+	}
+	layerImages := make(map[string]string) // layer base -> image
+	for _, call := range fake.ocrCalls {
+		if !slices.Contains(call, "textonly_pdf=1") {
+			t.Errorf("OCR call %v does not write a text-only layer", call)
+		}
+		layerImages[filepath.Base(call[1])] = filepath.Base(call[0])
+	}
+	if got := layerImages["ocr_page_1"]; got != "in_1_Im0.png" {
+		t.Errorf("page 1 OCRed from %q, want its scan", got)
+	}
+	if got := layerImages["ocr_page_2"]; got == "" || strings.HasPrefix(got, "in_") {
+		t.Errorf("page 2 OCRed from %q, want a blank image", got)
+	}
+
+	// One merge of the layers in page order, one stamp of the layers onto the original
+	merges := fake.callsOf("merge")
+	if len(merges) != 1 {
+		t.Fatalf("%d merges, want 1", len(merges))
+	}
+	var order []string
+	for _, layer := range merges[0][3:] {
+		order = append(order, filepath.Base(layer))
+	}
+	if want := []string{"ocr_page_1.pdf", "ocr_page_2.pdf", "ocr_page_3.pdf"}; !slices.Equal(order, want) {
+		t.Errorf("layers merged as %v, want %v", order, want)
+	}
+	stamps := fake.callsOf("stamp", "add", "-mode", "pdf")
+	if len(stamps) != 1 {
+		t.Fatalf("%d PDF stamps, want 1", len(stamps))
+	}
+	stamp := stamps[0]
+	if stamp[len(stamp)-2] != inFile || stamp[len(stamp)-1] != outFile {
+		t.Errorf("stamp %v does not write the original onto the output", stamp)
+	}
+	if spec := stamp[5]; spec != merges[0][2]+":1:1" {
+		t.Errorf("stamp spec %q, want the merged layers page by page", spec)
+	}
+
+	// The original pages are kept: the fake stamp copies its input
+	want, _ := os.ReadFile(inFile)
+	if got, _ := os.ReadFile(outFile); string(got) != string(want) {
+		t.Errorf("output = %q, want the original document", got)
+	}
+}
+
+func TestOCRPDFWithoutScans(t *testing.T) {
+	fake := installFakeCLI(t, &fakePdfcpu{pages: 2})
+	dir := t.TempDir()
+	err := OCRPDF(writeFakePDF(t, dir, "in.pdf"), filepath.Join(dir, "out.pdf"), "eng")
+	if !errors.Is(err, ErrNothingToOCR) {
+		t.Fatalf("err = %v, want ErrNothingToOCR", err)
+	}
+	if len(fake.ocrCalls) != 0 {
+		t.Errorf("OCR engine ran: %v", fake.ocrCalls)
+	}
+}
+
+func TestDocumentTimeout(t *testing.T) {
+	tests := []struct {
+		pages int
+		want  time.Duration
+	}{
+		{0, DefaultCLITimeout},
+		{1, DefaultCLITimeout + PerPageCLITimeout},
+		{1000, DefaultCLITimeout + 1000*PerPageCLITimeout},
+	}
+	for _, tt := range tests {
+		if got := documentTimeout(tt.pages); got != tt.want {
+			t.Errorf("documentTimeout(%d) = %v, want %v", tt.pages, got, tt.want)
+		}
+	}
+	if documentTimeout(500) <= documentTimeout(5) {
+		t.Error("timeout does not grow with the page count")
+	}
+}
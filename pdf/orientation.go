@@ -0,0 +1,94 @@
+package pdf
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Page orientations accepted by AutoOrient
+const (
+	OrientationPortrait  = "portrait"
+	OrientationLandscape = "landscape"
+)
+
+// OrientationReport lists the pages of a PDF by orientation. Square pages count as both
+// and are in neither list.
+type OrientationReport struct {
+	Portrait  []int `json:"portrait"`
+	Landscape []int `json:"landscape"`
+	Mixed     bool  `json:"mixed"` // both portrait and landscape pages are present
+}
+
+// DetectOrientation reports which pages of a PDF are portrait and which are landscape,
+// e.g. to find scanned pages that were fed sideways. The orientation is taken from the
+// page boxes reported by GetPageGeometry; a /Rotate entry already set on a page is not
+// taken into account.
+func DetectOrientation(inFile string) (*OrientationReport, error) {
+	geometry, err := GetPageGeometry(inFile)
+	if err != nil {
+		return nil, err
+	}
+	return orientationOf(geometry), nil
+}
+
+// orientationOf sorts pages into portrait and landscape by their geometry
+func orientationOf(geometry map[int]PageGeometry) *OrientationReport {
+	pageSet := make(map[int]bool, len(geometry))
+	for page := range geometry {
+		pageSet[page] = true
+	}
+
+	report := &OrientationReport{Portrait: []int{}, Landscape: []int{}}
+	for _, page := range sortedPageSet(pageSet) {
+		geo := geometry[page]
+		switch {
+		case geo.Height > geo.Width:
+			report.Portrait = append(report.Portrait, page)
+		case geo.Width > geo.Height:
+			report.Landscape = append(report.Landscape, page)
+		}
+	}
+	report.Mixed = len(report.Portrait) > 0 && len(report.Landscape) > 0
+	return report
+}
+
+// AutoOrient rotates every page that does not match target (OrientationPortrait or
+// OrientationLandscape) by 90 degrees clockwise using RotatePages. Pages whose content
+// was scanned upside down as well still need a manual 180 degree rotation. If every page
+// already matches, outFile is a copy of inFile.
+func AutoOrient(inFile, outFile, target string) error {
+	if target != OrientationPortrait && target != OrientationLandscape {
+		return fmt.Errorf("invalid target orientation: %q (expected %s or %s)", target, OrientationPortrait, OrientationLandscape)
+	}
+	if err := checkDistinctFiles(inFile, outFile); err != nil {
+		return err
+	}
+
+	report, err := DetectOrientation(inFile)
+	if err != nil {
+		return err
+	}
+	mismatched := report.Landscape
+	if target == OrientationLandscape {
+		mismatched = report.Portrait
+	}
+
+	if len(mismatched) == 0 {
+		data, err := os.ReadFile(inFile)
+		if err != nil {
+			return fmt.Errorf("failed to read input: %w", err)
+		}
+		if err := os.WriteFile(outFile, data, 0644); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
+		return nil
+	}
+
+	pages := make([]string, len(mismatched))
+	for i, page := range mismatched {
+		pages[i] = strconv.Itoa(page)
+	}
+	return RotatePages(inFile, outFile, strings.Join(pages, ","), 90)
+}
@@ -0,0 +1,103 @@
+package pdf
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// mixedOrientationPDF has portrait letter pages, landscape pages 3 and 5 and a square page 6
+func mixedOrientationPDF() *fakePdfcpu {
+	return &fakePdfcpu{pages: 6, respond: func(args []string) (string, bool, error) {
+		if args[0] != "info" || !slices.Contains(args, "-pages") {
+			return "", false, nil
+		}
+		var b strings.Builder
+		for page := 1; page <= 6; page++ {
+			width, height := 612.0, 792.0
+			switch page {
+			case 3, 5:
+				width, height = 792, 612
+			case 6:
+				width, height = 500, 500
+			}
+			fmt.Fprintf(&b, "Page %d:\n  MediaBox (0.00, 0.00, %.2f, %.2f)\n", page, width, height)
+		}
+		return b.String(), true, nil
+	}}
+}
+
+func TestDetectOrientation(t *testing.T) {
+	installFakeCLI(t, mixedOrientationPDF())
+	report, err := DetectOrientation(writeFakePDF(t, t.TempDir(), "in.pdf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(report.Portrait, []int{1, 2, 4}) || !slices.Equal(report.Landscape, []int{3, 5}) || !report.Mixed {
+		t.Errorf("report = %+v, want portrait 1,2,4, landscape 3,5 and mixed", report)
+	}
+
+	installFakeCLI(t, &fakePdfcpu{pages: 3})
+	report, err = DetectOrientation(writeFakePDF(t, t.TempDir(), "in.pdf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Portrait) != 3 || len(report.Landscape) != 0 || report.Mixed {
+		t.Errorf("report = %+v, want three portrait pages", report)
+	}
+}
+
+func TestAutoOrient(t *testing.T) {
+	tests := []struct {
+		target    string
+		wantPages string // rotated by 90 degrees
+	}{
+		{target: OrientationPortrait, wantPages: "3,5"},
+		{target: OrientationLandscape, wantPages: "1,2,4"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			f := installFakeCLI(t, mixedOrientationPDF())
+			dir := t.TempDir()
+			if err := AutoOrient(writeFakePDF(t, dir, "in.pdf"), filepath.Join(dir, "out.pdf"), tt.target); err != nil {
+				t.Fatal(err)
+			}
+			calls := f.callsOf("rotate")
+			// rotate -p pages -- inFile degrees outFile
+			if len(calls) != 1 || calls[0][2] != tt.wantPages || calls[0][5] != "90" {
+				t.Errorf("rotate calls %v, want pages %s by 90", calls, tt.wantPages)
+			}
+		})
+	}
+}
+
+func TestAutoOrientMatchingPages(t *testing.T) {
+	f := installFakeCLI(t, &fakePdfcpu{pages: 3})
+	dir := t.TempDir()
+	outFile := filepath.Join(dir, "out.pdf")
+	if err := AutoOrient(writeFakePDF(t, dir, "in.pdf"), outFile, OrientationPortrait); err != nil {
+		t.Fatal(err)
+	}
+	if calls := f.callCount("rotate"); calls != 0 {
+		t.Errorf("%d rotate calls for pages already in portrait", calls)
+	}
+	if data, err := os.ReadFile(outFile); err != nil || !strings.HasPrefix(string(data), "%PDF-") {
+		t.Errorf("output %q, %v, want a copy of the input", data, err)
+	}
+}
+
+func TestAutoOrientInvalidTarget(t *testing.T) {
+	f := installFakeCLI(t, mixedOrientationPDF())
+	dir := t.TempDir()
+	for _, target := range []string{"", "upright", "Portrait"} {
+		if err := AutoOrient(writeFakePDF(t, dir, "in.pdf"), filepath.Join(dir, "out.pdf"), target); err == nil {
+			t.Errorf("target %q accepted", target)
+		}
+	}
+	if len(f.calls) != 0 {
+		t.Errorf("pdfcpu ran for an invalid target: %v", f.calls)
+	}
+}
@@ -1,6 +1,7 @@
 package pdf
 
 import (
+	"errors"
 	"fmt"
 	"regexp"
 	"sort"
@@ -8,9 +9,24 @@ import (
 	"strings"
 )
 
+// ErrPageOutOfRange is returned when a page number does not exist in the document
+var ErrPageOutOfRange = errors.New("page out of range")
+
+// ErrPageTotalUnknown is returned when a page specification counts from the end of the
+// document (e.g. "7-", "even" or "last") but the total page count is not known
+var ErrPageTotalUnknown = errors.New("page specification needs the total page count")
+
 // ParsePageSpecifier parses a page specification string and returns a list of page numbers.
-// Supports formats: "1", "1,3", "1-5", "1,3-5,7"
+// Supports formats: "1", "1,3", "1-5", "1,3-5,7" and "-5" (pages 1 through 5). Forms
+// counting from the end of the document need ParsePageSpecifierWithTotal.
 func ParsePageSpecifier(pages string) ([]int, error) {
+	return ParsePageSpecifierWithTotal(pages, 0)
+}
+
+// ParsePageSpecifierWithTotal is ParsePageSpecifier for a document of total pages, which
+// also supports "7-" (page 7 to the last page), "even", "odd" and "last" (or "l"), e.g.
+// "-3,even,10-". A total of 0 means unknown: those forms then fail with ErrPageTotalUnknown.
+func ParsePageSpecifierWithTotal(pages string, total int) ([]int, error) {
 	if pages == "" {
 		return nil, fmt.Errorf("empty page specification")
 	}
@@ -18,25 +34,61 @@ func ParsePageSpecifier(pages string) ([]int, error) {
 	// Remove all whitespace
 	pages = regexp.MustCompile(`\s`).ReplaceAllString(pages, "")
 
+	needTotal := func(part string) error {
+		if total < 1 {
+			return fmt.Errorf("%w: %s", ErrPageTotalUnknown, part)
+		}
+		return nil
+	}
+
 	var pageList []int
 	parts := strings.Split(pages, ",")
 
 	for _, part := range parts {
-		if strings.Contains(part, "-") {
-			// Range like "1-5"
-			rangeParts := strings.Split(part, "-")
-			if len(rangeParts) != 2 {
-				return nil, fmt.Errorf("invalid range: %s", part)
-			}
+		if part == "" {
+			return nil, fmt.Errorf("empty page in specification: %s", pages)
+		}
 
-			start, err := strconv.Atoi(rangeParts[0])
-			if err != nil {
-				return nil, fmt.Errorf("invalid start page: %s", rangeParts[0])
+		switch strings.ToLower(part) {
+		case "even", "odd":
+			if err := needTotal(part); err != nil {
+				return nil, err
+			}
+			first := 2
+			if strings.ToLower(part) == "odd" {
+				first = 1
+			}
+			for i := first; i <= total; i += 2 {
+				pageList = append(pageList, i)
+			}
+			continue
+		case "last", "l":
+			if err := needTotal(part); err != nil {
+				return nil, err
 			}
+			pageList = append(pageList, total)
+			continue
+		}
 
-			end, err := strconv.Atoi(rangeParts[1])
-			if err != nil {
-				return nil, fmt.Errorf("invalid end page: %s", rangeParts[1])
+		if startStr, endStr, isRange := strings.Cut(part, "-"); isRange {
+			// Range like "1-5", "-5" (from the first page) or "7-" (to the last page)
+			start, end := 1, 0
+			var err error
+			if startStr != "" {
+				if start, err = parsePageNumber(startStr, "start page"); err != nil {
+					return nil, err
+				}
+			}
+			if endStr == "" {
+				if startStr == "" {
+					return nil, fmt.Errorf("invalid range: %s", part)
+				}
+				if err := needTotal(part); err != nil {
+					return nil, err
+				}
+				end = total
+			} else if end, err = parsePageNumber(endStr, "end page"); err != nil {
+				return nil, err
 			}
 
 			if start > end {
@@ -48,9 +100,9 @@ func ParsePageSpecifier(pages string) ([]int, error) {
 			}
 		} else {
 			// Single page like "3"
-			pageNum, err := strconv.Atoi(part)
+			pageNum, err := parsePageNumber(part, "page number")
 			if err != nil {
-				return nil, fmt.Errorf("invalid page number: %s", part)
+				return nil, err
 			}
 			pageList = append(pageList, pageNum)
 		}
@@ -68,6 +120,55 @@ func ParsePageSpecifier(pages string) ([]int, error) {
 	return deduped, nil
 }
 
+// parsePageNumber parses one page number of a page specification. Signs are rejected, so
+// "1--5" is not read as the range 1 to -5, and so is page 0.
+func parsePageNumber(s, what string) (int, error) {
+	if strings.TrimLeft(s, "0123456789") != "" {
+		return 0, fmt.Errorf("invalid %s: %s", what, s)
+	}
+	page, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %s", what, s)
+	}
+	if page < 1 {
+		return 0, fmt.Errorf("page numbers must be positive, got %d", page)
+	}
+	return page, nil
+}
+
+// ParsePageRanges parses a comma-separated list of page ranges such as "1-3,4-6,7-10"
+// into [first, last] pairs, in the given order. A single page "5" is the range 5-5.
+// Unlike ParsePageSpecifier, ranges are neither sorted nor merged.
+func ParsePageRanges(spec string) ([][2]int, error) {
+	spec = regexp.MustCompile(`\s`).ReplaceAllString(spec, "")
+	if spec == "" {
+		return nil, fmt.Errorf("empty range specification")
+	}
+
+	var ranges [][2]int
+	for _, part := range strings.Split(spec, ",") {
+		bounds := strings.Split(part, "-")
+		if len(bounds) > 2 {
+			return nil, fmt.Errorf("invalid range: %s", part)
+		}
+		first, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid range: %s", part)
+		}
+		last := first
+		if len(bounds) == 2 {
+			if last, err = strconv.Atoi(bounds[1]); err != nil {
+				return nil, fmt.Errorf("invalid range: %s", part)
+			}
+		}
+		if first < 1 || first > last {
+			return nil, fmt.Errorf("invalid range: %s", part)
+		}
+		ranges = append(ranges, [2]int{first, last})
+	}
+	return ranges, nil
+}
+
 // ValidatePageNumbers checks if all page numbers are valid for a given total number of pages
 func ValidatePageNumbers(pages []int, totalPages int) error {
 	for _, page := range pages {
@@ -75,7 +176,7 @@ func ValidatePageNumbers(pages []int, totalPages int) error {
 			return fmt.Errorf("page numbers must be positive, got %d", page)
 		}
 		if page > totalPages {
-			return fmt.Errorf("page %d exceeds total pages (%d)", page, totalPages)
+			return fmt.Errorf("%w: page %d exceeds total pages (%d)", ErrPageOutOfRange, page, totalPages)
 		}
 	}
 	return nil
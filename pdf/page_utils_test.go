@@ -0,0 +1,101 @@
+package pdf
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParsePageSpecifierWithTotal(t *testing.T) {
+	tests := []struct {
+		spec  string
+		total int
+		want  []int
+	}{
+		{spec: "-3,even,10-", total: 12, want: []int{1, 2, 3, 4, 6, 8, 10, 11, 12}},
+		{spec: "odd", total: 5, want: []int{1, 3, 5}},
+		{spec: "even", total: 1, want: []int{}},
+		{spec: "EVEN, last", total: 7, want: []int{2, 4, 6, 7}},
+		{spec: "l", total: 9, want: []int{9}},
+		{spec: "7-", total: 7, want: []int{7}},
+		{spec: "2-4,3-", total: 5, want: []int{2, 3, 4, 5}},
+		{spec: "1-3", total: 0, want: []int{1, 2, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParsePageSpecifierWithTotal(tt.spec, tt.total)
+			if err != nil {
+				t.Fatalf("ParsePageSpecifierWithTotal(%q, %d): %v", tt.spec, tt.total, err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ParsePageSpecifierWithTotal(%q, %d) = %v, want %v", tt.spec, tt.total, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePageSpecifierClosedRanges(t *testing.T) {
+	tests := []struct {
+		spec string
+		want []int
+	}{
+		{spec: "3", want: []int{3}},
+		{spec: "1,3", want: []int{1, 3}},
+		{spec: "1-5", want: []int{1, 2, 3, 4, 5}},
+		{spec: "5,1,3-4,3", want: []int{1, 3, 4, 5}},
+		{spec: " 1 - 2 , 4 ", want: []int{1, 2, 4}},
+		{spec: "-3", want: []int{1, 2, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParsePageSpecifier(tt.spec)
+			if err != nil {
+				t.Fatalf("ParsePageSpecifier(%q): %v", tt.spec, err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ParsePageSpecifier(%q) = %v, want %v", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePageSpecifierNeedsTotal(t *testing.T) {
+	for _, spec := range []string{"7-", "even", "odd", "last", "l", "1,3-"} {
+		t.Run(spec, func(t *testing.T) {
+			if _, err := ParsePageSpecifier(spec); !errors.Is(err, ErrPageTotalUnknown) {
+				t.Errorf("ParsePageSpecifier(%q) error = %v, want ErrPageTotalUnknown", spec, err)
+			}
+		})
+	}
+}
+
+func TestParsePageSpecifierErrors(t *testing.T) {
+	tests := []struct {
+		spec    string
+		wantErr string
+	}{
+		{spec: "", wantErr: "empty page specification"},
+		{spec: "0", wantErr: "page numbers must be positive, got 0"},
+		{spec: "1-0", wantErr: "page numbers must be positive, got 0"},
+		{spec: "0-3", wantErr: "page numbers must be positive, got 0"},
+		{spec: "3-1", wantErr: "start > end (3 > 1)"},
+		{spec: "1--5", wantErr: "invalid end page: -5"},
+		{spec: "1,,3", wantErr: "empty page in specification"},
+		{spec: "1,", wantErr: "empty page in specification"},
+		{spec: "-", wantErr: "invalid range"},
+		{spec: "a", wantErr: "invalid page number: a"},
+		{spec: "+2", wantErr: "invalid page number: +2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			pages, err := ParsePageSpecifierWithTotal(tt.spec, 10)
+			if err == nil {
+				t.Fatalf("ParsePageSpecifierWithTotal(%q) = %v, want an error", tt.spec, pages)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %q, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package pdf
+
+import (
+	"math"
+)
+
+// Placement classes of repeating images, from where they are drawn on the page. A logo in
+// a fixed corner is usually branding the user may want to keep, while a full-page or
+// diagonal image is almost always a watermark.
+const (
+	PlacementCorner   = "corner"
+	PlacementHeader   = "header"
+	PlacementFooter   = "footer"
+	PlacementFullPage = "full_page"
+	PlacementDiagonal = "diagonal"
+	PlacementBody     = "body"
+)
+
+// MetaPlacementClass is the candidate metadata key holding the placement class
+// (set only when AnalysisOptions.ClassifyPlacement is enabled and placement data exists)
+const MetaPlacementClass = "placement_class"
+
+// DefaultPlacementConfidence is the confidence adjustment applied to a candidate of each
+// placement class; AnalysisOptions.PlacementConfidence overrides individual classes
+var DefaultPlacementConfidence = map[string]float64{
+	PlacementCorner:   -0.15,
+	PlacementHeader:   0,
+	PlacementFooter:   0,
+	PlacementFullPage: 0.1,
+	PlacementDiagonal: 0.15,
+	PlacementBody:     0,
+}
+
+// placementClassOrder breaks ties between equally frequent classes deterministically
+var placementClassOrder = []string{PlacementDiagonal, PlacementFullPage, PlacementCorner, PlacementHeader, PlacementFooter, PlacementBody}
+
+// classifyPlacement maps one image placement to a placement class. Returns "" when the
+// page size is unknown.
+func classifyPlacement(placement imagePlacement, page PageGeometry) string {
+	if page.Width <= 0 || page.Height <= 0 {
+		return ""
+	}
+
+	// Rotation modulo 90 degrees, so landscape-rotated images are not diagonal
+	rotation := math.Mod(math.Abs(placement.rotation), 90)
+	if rotation >= PlacementDiagonalMinRotation && rotation <= 90-PlacementDiagonalMinRotation {
+		return PlacementDiagonal
+	}
+
+	bbox := clipToPage(placement.bbox, page)
+	if bbox.Width*bbox.Height >= PlacementFullPageMinArea*page.Width*page.Height {
+		return PlacementFullPage
+	}
+
+	centerX := (bbox.X + bbox.Width/2) / page.Width
+	centerY := (bbox.Y + bbox.Height/2) / page.Height
+	top := centerY >= 1-PlacementEdgeBand
+	bottom := centerY <= PlacementEdgeBand
+	side := centerX <= PlacementEdgeBand || centerX >= 1-PlacementEdgeBand
+	switch {
+	case (top || bottom) && side:
+		return PlacementCorner
+	case top:
+		return PlacementHeader
+	case bottom:
+		return PlacementFooter
+	}
+	return PlacementBody
+}
+
+// classifyCandidatePlacements sets the placement class of every image candidate with a
+// signature to the most frequent class of its occurrences, and adjusts its confidence by
+// the class adjustment. Candidates without placement data are left unchanged.
+func classifyCandidatePlacements(candidates []UnwantedElementCandidate, imagesByPage map[int][]imageInfo, opts AnalysisOptions, sources pageSources, debugLog func(string, ...interface{})) error {
+	geometry, err := sources.geometry()
+	if err != nil {
+		return err
+	}
+	contents, err := sources.contents()
+	if err != nil {
+		return err
+	}
+	placements := imagePlacementsByPage(contents)
+
+	for i := range candidates {
+		candidate := &candidates[i]
+		signature := candidate.Metadata[MetaSignature]
+		if candidate.Type != "image" || signature == "" {
+			continue
+		}
+
+		votes := make(map[string]int)
+		for _, page := range candidate.Pages {
+			geo, ok := geometry[page]
+			if !ok {
+				continue
+			}
+			for _, img := range imagesByPage[page] {
+				if imageSignature(img) != signature {
+					continue
+				}
+				for _, placement := range placements[page] {
+					if placement.name != img.id {
+						continue
+					}
+					if class := classifyPlacement(placement, geo); class != "" {
+						votes[class]++
+					}
+				}
+			}
+		}
+
+		class := ""
+		for _, candidateClass := range placementClassOrder {
+			if votes[candidateClass] > votes[class] {
+				class = candidateClass
+			}
+		}
+		if class == "" {
+			continue
+		}
+
+		adjustment, ok := opts.PlacementConfidence[class]
+		if !ok {
+			adjustment = DefaultPlacementConfidence[class]
+		}
+		candidate.Metadata[MetaPlacementClass] = class
+		candidate.Confidence = min(max(candidate.Confidence+adjustment, 0), 1)
+		if debugLog != nil {
+			debugLog("[DEBUG] Candidate %s classified as %s (%v), confidence adjusted by %+.2f", candidate.ID, class, votes, adjustment)
+		}
+	}
+	return nil
+}
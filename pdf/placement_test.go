@@ -0,0 +1,84 @@
+package pdf
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestClassifyPlacement(t *testing.T) {
+	letter := PageGeometry{Page: 1, Width: 612, Height: 792}
+	tests := []struct {
+		name      string
+		placement imagePlacement
+		page      PageGeometry
+		want      string
+	}{
+		{name: "top-left logo", placement: imagePlacement{bbox: BBox{X: 36, Y: 720, Width: 60, Height: 40}}, page: letter, want: PlacementCorner},
+		{name: "top-right logo", placement: imagePlacement{bbox: BBox{X: 520, Y: 720, Width: 60, Height: 40}}, page: letter, want: PlacementCorner},
+		{name: "bottom-left logo", placement: imagePlacement{bbox: BBox{X: 36, Y: 30, Width: 60, Height: 40}}, page: letter, want: PlacementCorner},
+		{name: "bottom-right logo", placement: imagePlacement{bbox: BBox{X: 520, Y: 30, Width: 60, Height: 40}}, page: letter, want: PlacementCorner},
+		{name: "header banner", placement: imagePlacement{bbox: BBox{X: 72, Y: 730, Width: 468, Height: 40}}, page: letter, want: PlacementHeader},
+		{name: "footer banner", placement: imagePlacement{bbox: BBox{X: 72, Y: 20, Width: 468, Height: 40}}, page: letter, want: PlacementFooter},
+		{name: "full-page background", placement: imagePlacement{bbox: BBox{Width: 612, Height: 792}}, page: letter, want: PlacementFullPage},
+		{name: "bleeding off the page", placement: imagePlacement{bbox: BBox{X: -20, Y: -20, Width: 652, Height: 832}}, page: letter, want: PlacementFullPage},
+		{name: "half the page", placement: imagePlacement{bbox: BBox{X: 0, Y: 0, Width: 612, Height: 400}}, page: letter, want: PlacementFullPage},
+		{name: "diagonal stamp", placement: imagePlacement{bbox: BBox{X: 150, Y: 250, Width: 300, Height: 300}, rotation: 45}, page: letter, want: PlacementDiagonal},
+		{name: "negative diagonal", placement: imagePlacement{bbox: BBox{X: 150, Y: 250, Width: 300, Height: 300}, rotation: -30}, page: letter, want: PlacementDiagonal},
+		{name: "landscape-rotated is not diagonal", placement: imagePlacement{bbox: BBox{X: 200, Y: 300, Width: 100, Height: 150}, rotation: 90}, page: letter, want: PlacementBody},
+		{name: "slightly tilted is not diagonal", placement: imagePlacement{bbox: BBox{X: 200, Y: 300, Width: 100, Height: 150}, rotation: 5}, page: letter, want: PlacementBody},
+		{name: "figure in the body", placement: imagePlacement{bbox: BBox{X: 156, Y: 296, Width: 300, Height: 200}}, page: letter, want: PlacementBody},
+		{name: "side margin mid-page", placement: imagePlacement{bbox: BBox{X: 10, Y: 350, Width: 40, Height: 80}}, page: letter, want: PlacementBody},
+		{name: "unknown page size", placement: imagePlacement{bbox: BBox{X: 36, Y: 720, Width: 60, Height: 40}}, page: PageGeometry{}, want: ""},
+		{name: "A4 corner", placement: imagePlacement{bbox: BBox{X: 500, Y: 770, Width: 60, Height: 40}}, page: PageGeometry{Width: 595.28, Height: 841.89}, want: PlacementCorner},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyPlacement(tt.placement, tt.page); got != tt.want {
+				t.Errorf("classifyPlacement(%+v) = %q, want %q", tt.placement.bbox, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlacementClassInCandidates(t *testing.T) {
+	// A corner logo and a full-page background on every page
+	f := &fakePdfcpu{pages: 5, contents: make(map[int]string)}
+	for page := 1; page <= 5; page++ {
+		f.images = append(f.images,
+			fakeImage{Page: page, Obj: 10, ID: "Im0", Width: 120, Height: 80, CS: "DeviceRGB", Size: 4000},
+			fakeImage{Page: page, Obj: 11, ID: "Im1", Width: 1275, Height: 1650, CS: "DeviceRGB", Size: 90000})
+		f.contents[page] = "q 612 0 0 792 0 0 cm /Im1 Do Q q 60 0 0 40 36 720 cm /Im0 Do Q"
+	}
+	installFakeCLI(t, f)
+	inFile := writeFakePDF(t, t.TempDir(), "in.pdf")
+
+	plain, err := AnalyzeUnwantedElements(inFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	classified, err := AnalyzeUnwantedElementsWithOptions(inFile, AnalysisOptions{ClassifyPlacement: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	confidence := make(map[string]float64)
+	for _, candidate := range plain.ImageCandidates {
+		if _, ok := candidate.Metadata[MetaPlacementClass]; ok {
+			t.Errorf("%s classified without ClassifyPlacement", candidate.ID)
+		}
+		confidence[candidate.ID] = candidate.Confidence
+	}
+
+	classes := make(map[string]string)
+	for _, candidate := range classified.ImageCandidates {
+		class := candidate.Metadata[MetaPlacementClass]
+		classes[candidate.Metadata[MetaImageID]] = class
+		want := min(max(confidence[candidate.ID]+DefaultPlacementConfidence[class], 0), 1)
+		if math.Abs(candidate.Confidence-want) > 1e-9 {
+			t.Errorf("%s (%s): confidence %.2f, want %.2f", candidate.ID, class, candidate.Confidence, want)
+		}
+	}
+	if want := map[string]string{"Im0": PlacementCorner, "Im1": PlacementFullPage}; fmt.Sprint(classes) != fmt.Sprint(want) {
+		t.Errorf("placement classes = %v, want %v", classes, want)
+	}
+}
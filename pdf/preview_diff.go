@@ -0,0 +1,104 @@
+package pdf
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// DiffRegion is the part of a page a preview diff shows, in PDF points (origin bottom-left)
+type DiffRegion struct {
+	Page     int  `json:"page"`
+	BBox     BBox `json:"bbox"`
+	FullPage bool `json:"full_page"` // the image's placement was not found, so the whole page is shown
+}
+
+// PreviewDiff writes a two-page PDF to outFile showing the region of page where the image
+// of candidate is drawn: page 1 from original and page 2 from result, the same document
+// after the candidate was removed. Both pages are cropped to the image's bounding box plus
+// DiffRegionMargin, so nothing has to be rasterized; when the placement cannot be found in
+// the page content the whole page is shown.
+func PreviewDiff(original, result, outFile string, candidate UnwantedElementCandidate, page int) (*DiffRegion, error) {
+	ctx := context.Background()
+	totalPages, err := getPageCount(original)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get page count: %w", err)
+	}
+	if err := ValidatePageNumbers([]int{page}, totalPages); err != nil {
+		return nil, err
+	}
+
+	workDir, err := os.MkdirTemp(filepath.Dir(outFile), "diff_")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create work directory: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	geometry, err := getPageGeometry(ctx, original)
+	if err != nil {
+		return nil, err
+	}
+	contents, err := extractContentRange(ctx, original, workDir, page, page)
+	if err != nil {
+		return nil, err
+	}
+	region := diffRegion(contents[page], candidate.Metadata["image_id"], geometry[page])
+
+	before := filepath.Join(workDir, "before.pdf")
+	after := filepath.Join(workDir, "after.pdf")
+	if err := ExtractPages(original, before, strconv.Itoa(page)); err != nil {
+		return nil, err
+	}
+	if err := ExtractPages(result, after, strconv.Itoa(page)); err != nil {
+		return nil, err
+	}
+
+	merged := filepath.Join(workDir, "merged.pdf")
+	name, args := mergeCommand(merged, []string{before, after})
+	if output, err := execCommandWithTimeout(DefaultCLITimeout, name, args...); err != nil {
+		return nil, fmt.Errorf("pdfcpu merge failed: %w\nOutput: %s", err, string(output))
+	}
+	if region.FullPage {
+		if err := os.Rename(merged, outFile); err != nil {
+			return nil, fmt.Errorf("failed to store preview diff: %w", err)
+		}
+	} else {
+		name, args := cropCommand(merged, outFile, region.BBox)
+		if output, err := execCommandWithTimeout(DefaultCLITimeout, name, args...); err != nil {
+			return nil, fmt.Errorf("pdfcpu crop failed: %w\nOutput: %s", err, string(output))
+		}
+	}
+
+	region.Page = page
+	return &region, nil
+}
+
+// diffRegion returns the region of a page that shows every placement of imageID in its
+// content stream, widened by DiffRegionMargin and clamped to the page. The crop math is
+// cropRect's at 72 dpi, where pixels are points, flipped back to PDF's bottom-up space.
+func diffRegion(content, imageID string, page PageGeometry) DiffRegion {
+	var bbox *BBox
+	for _, placement := range findImagePlacements(content) {
+		if placement.name != imageID {
+			continue
+		}
+		box := placement.bbox
+		if bbox != nil {
+			box = unionBBox(*bbox, box)
+		}
+		bbox = &box
+	}
+
+	rect := cropRect(bbox, page, 72, DiffRegionMargin)
+	return DiffRegion{
+		BBox: BBox{
+			X:      float64(rect.X),
+			Y:      page.Height - float64(rect.Y+rect.Height),
+			Width:  float64(rect.Width),
+			Height: float64(rect.Height),
+		},
+		FullPage: bbox == nil,
+	}
+}
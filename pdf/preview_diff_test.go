@@ -0,0 +1,78 @@
+package pdf
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestPreviewDiff(t *testing.T) {
+	candidate := UnwantedElementCandidate{Type: "image", ID: "wm", Metadata: map[string]string{"image_id": "Im0"}}
+	tests := []struct {
+		name     string
+		content  string
+		want     BBox
+		fullPage bool
+	}{
+		// 300x200 at (156, 296) plus the margin on every side
+		{name: "cropped to the placement", content: "q 300 0 0 200 156 296 cm /Im0 Do Q BT /F1 12 Tf 72 720 Td (Body) Tj ET",
+			want: BBox{X: 144, Y: 284, Width: 324, Height: 224}},
+		{name: "placements are joined", content: "q 100 0 0 100 50 50 cm /Im0 Do Q q 100 0 0 100 400 600 cm /Im0 Do Q",
+			want: BBox{X: 38, Y: 38, Width: 474, Height: 674}},
+		{name: "full page without a placement", content: "q 300 0 0 200 156 296 cm /Logo Do Q",
+			want: BBox{Width: 612, Height: 792}, fullPage: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := installFakeCLI(t, &fakePdfcpu{pages: 3, contents: map[int]string{2: tt.content}})
+			dir := t.TempDir()
+			original := writeFakePDF(t, dir, "original.pdf")
+			result := writeFakePDF(t, dir, "result.pdf")
+			outFile := filepath.Join(dir, "diff.pdf")
+
+			region, err := PreviewDiff(original, result, outFile, candidate, 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if region.Page != 2 || region.BBox != tt.want || region.FullPage != tt.fullPage {
+				t.Errorf("region = %+v, want page 2 %+v (full page %v)", region, tt.want, tt.fullPage)
+			}
+			if _, err := os.Stat(outFile); err != nil {
+				t.Fatalf("preview diff not written: %v", err)
+			}
+
+			// Page 2 of the original, then page 2 of the result
+			trims := fake.callsOf("trim")
+			if len(trims) != 2 || !slices.Contains(trims[0], original) || !slices.Contains(trims[1], result) {
+				t.Errorf("trim calls %v, want page 2 of the original then the result", trims)
+			}
+			crops := fake.callsOf("crop")
+			switch {
+			case tt.fullPage && len(crops) != 0:
+				t.Errorf("full-page diff was cropped: %v", crops)
+			case !tt.fullPage && (len(crops) != 1 || !slices.Contains(crops[0], cropDescription(tt.want))):
+				t.Errorf("crop calls %v, want one to %s", crops, cropDescription(tt.want))
+			}
+			if entries, _ := os.ReadDir(dir); len(entries) != 3 {
+				t.Errorf("work files left behind: %v", entries)
+			}
+		})
+	}
+
+	fake := installFakeCLI(t, &fakePdfcpu{pages: 3})
+	dir := t.TempDir()
+	original := writeFakePDF(t, dir, "original.pdf")
+	if _, err := PreviewDiff(original, original, filepath.Join(dir, "diff.pdf"), candidate, 4); err == nil {
+		t.Error("page beyond the document accepted")
+	}
+	if calls := fake.callCount("trim"); calls != 0 {
+		t.Errorf("%d trim calls for an invalid page", calls)
+	}
+}
+
+// cropDescription is the box argument cropCommand passes for box
+func cropDescription(box BBox) string {
+	_, args := cropCommand("in.pdf", "out.pdf", box)
+	return args[2]
+}
@@ -2,12 +2,18 @@ package pdf
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"image"
+	"image/color"
+	"image/draw"
 	"image/png"
 	"log"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 )
@@ -17,9 +23,159 @@ func RemoveElementFromPDF(inFile, outFile, elementType string) error {
 	return RemoveElementsByIDs(inFile, outFile, elementType, nil)
 }
 
+// RemovalOptions controls how selected images are blanked during removal
+type RemovalOptions struct {
+	// PreserveDimensions replaces each image with a transparent image of the same
+	// width/height (taken from the candidate metadata) instead of a 1x1 image, so
+	// layouts that depend on the image size do not reflow
+	PreserveDimensions bool
+
+	// OptimizeAfter runs pdfcpu optimize on the result to garbage-collect the removed
+	// image objects and deduplicate the blank replacements
+	OptimizeAfter bool
+
+	// ProtectedObjects are image object numbers that are never removed, even if selected
+	ProtectedObjects []string
+
+	// MaxImageOccurrences caps the image occurrences removal by ID will match against
+	// (0 uses DefaultMaxImageOccurrences)
+	MaxImageOccurrences int
+
+	// MatchMode selects how a candidate's image ID is matched to image occurrences (see
+	// MatchByID); "" matches by ID alone
+	MatchMode string
+
+	// RedactColor, if set, replaces images with a solid image of this color (e.g. black to
+	// black out the region) instead of a transparent one. Redaction images always have the
+	// original dimensions, whether or not PreserveDimensions is set.
+	RedactColor *color.RGBA
+
+	// AuditDir, if set, receives the original bytes of every image object before it is
+	// replaced (see RemovalReport.AuditFiles); removal fails if an image cannot be saved
+	AuditDir string
+
+	// Analysis, if set, is the analysis the selected IDs were taken from, e.g. that of an
+	// analysis session. Without it the PDF is re-analyzed with default options, which does
+	// not find candidates of options such as DeepMatch.
+	Analysis *UnwantedElementsAnalysis
+
+	// Report, if set, is filled in with what the removal did
+	Report *RemovalReport
+}
+
+// Image matching modes for RemovalOptions.MatchMode. pdfcpu image IDs are resource names,
+// which are only unique per page: unrelated images on different pages can share an ID like
+// "Im0". MatchByID (the default) removes every image with the candidate's ID, which catches
+// re-encoded copies of a watermark but may remove unrelated images that reuse the ID. The
+// strict modes also require the same object number (misses copies stored as separate
+// objects) or the same pixel dimensions (misses rescaled copies).
+const (
+	MatchByID         = "id"
+	MatchByObject     = "object"
+	MatchByDimensions = "dimensions"
+)
+
+// ValidateMatchMode checks that mode is a supported image matching mode ("" is MatchByID)
+func ValidateMatchMode(mode string) error {
+	switch mode {
+	case "", MatchByID, MatchByObject, MatchByDimensions:
+		return nil
+	}
+	return fmt.Errorf("invalid match mode: %s (supported: id, object, dimensions)", mode)
+}
+
+// DefaultRedactColor is the fill used for redaction when no color is given
+const DefaultRedactColor = "#000000"
+
+var hexColorPattern = regexp.MustCompile(`^#?([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// ParseRedactColor parses a "#RRGGBB" or "#RGB" hex color (the "#" is optional)
+func ParseRedactColor(hex string) (color.RGBA, error) {
+	matches := hexColorPattern.FindStringSubmatch(strings.TrimSpace(hex))
+	if matches == nil {
+		return color.RGBA{}, fmt.Errorf("invalid redact color: %q (expected #RRGGBB or #RGB)", hex)
+	}
+	digits := matches[1]
+	if len(digits) == 3 {
+		digits = string([]byte{digits[0], digits[0], digits[1], digits[1], digits[2], digits[2]})
+	}
+	value, _ := strconv.ParseUint(digits, 16, 32)
+	return color.RGBA{R: uint8(value >> 16), G: uint8(value >> 8), B: uint8(value), A: 0xff}, nil
+}
+
+// ErrTooManyImages is returned when a document has more image occurrences than removal by
+// ID is allowed to match against (see RemovalOptions.MaxImageOccurrences)
+var ErrTooManyImages = errors.New("too many image occurrences for removal by ID; " +
+	"split the document (e.g. with remove-pages or split-by-bookmarks) and clean the parts, " +
+	"or remove the image objects by object number with a PDF editor")
+
+// ErrNoMatchingImages is returned when none of the selected candidates matches an image
+// occurrence of the document
+var ErrNoMatchingImages = errors.New("no matching images found for selected IDs")
+
+// ErrAllImagesProtected is returned when every image matched for removal is one of
+// RemovalOptions.ProtectedObjects
+var ErrAllImagesProtected = errors.New("all selected images are protected objects and were not removed")
+
+// RemovalReport describes the outcome of an image removal
+type RemovalReport struct {
+	Removed           int      `json:"removed"`                      // image occurrences replaced
+	SkippedProtected  []string `json:"skipped_protected"`            // selected object numbers skipped because they are protected
+	SkippedUnresolved []string `json:"skipped_unresolved,omitempty"` // "page id" of selected images kept because their object number is unknown
+	AuditFiles        []string `json:"audit_files,omitempty"`        // original images saved with RemovalOptions.AuditDir
+	SkippedText       []string `json:"skipped_text,omitempty"`       // selected text candidates, which removal by ID cannot strip
+}
+
 // RemoveElementsByIDs removes specific elements by their IDs from a PDF file using pdfcpu CLI
 // elementIDs is a list of candidate IDs to remove (can be nil to remove all of a type)
 func RemoveElementsByIDs(inFile, outFile, elementType string, elementIDs []string) error {
+	return RemoveElementsByIDsWithOptions(inFile, outFile, elementType, elementIDs, RemovalOptions{})
+}
+
+// RemoveElementsByIDsWithOptions is like RemoveElementsByIDs but allows tuning how images are replaced
+func RemoveElementsByIDsWithOptions(inFile, outFile, elementType string, elementIDs []string, opts RemovalOptions) error {
+	if err := checkDistinctFiles(inFile, outFile); err != nil {
+		return err
+	}
+
+	if err := removeElements(inFile, outFile, elementType, elementIDs, opts); err != nil {
+		return err
+	}
+
+	if opts.OptimizeAfter {
+		return optimizeInPlace(outFile)
+	}
+	return nil
+}
+
+// optimizeInPlace rewrites a PDF with pdfcpu optimize, dropping the objects left unused
+// by removal and merging the duplicate blank images that replaced removed ones
+func optimizeInPlace(filename string) error {
+	before, err := os.Stat(filename)
+	if err != nil {
+		return fmt.Errorf("failed to stat output: %w", err)
+	}
+
+	optimized := filename + ".optimized"
+	name, args := optimizeCommand(filename, optimized)
+	output, err := execCommandWithTimeout(DefaultCLITimeout, name, args...)
+	if err != nil {
+		os.Remove(optimized)
+		return fmt.Errorf("pdfcpu optimize after removal failed: %w\nOutput: %s", err, string(output))
+	}
+	if err := os.Rename(optimized, filename); err != nil {
+		os.Remove(optimized)
+		return fmt.Errorf("failed to replace output with optimized file: %w", err)
+	}
+
+	if after, err := os.Stat(filename); err == nil {
+		log.Printf("Optimized removal output: %d -> %d bytes", before.Size(), after.Size())
+	}
+	return nil
+}
+
+// removeElements performs the removal for RemoveElementsByIDsWithOptions
+func removeElements(inFile, outFile, elementType string, elementIDs []string, opts RemovalOptions) error {
 	// Validate element type
 	if elementType != "watermark" && elementType != "image" {
 		return fmt.Errorf("invalid element type: %s (supported: watermark, image)", elementType)
@@ -27,11 +183,9 @@ func RemoveElementsByIDs(inFile, outFile, elementType string, elementIDs []strin
 
 	switch elementType {
 	case "watermark":
-		// pdfcpu watermark remove command
-		// Syntax: pdfcpu watermark remove -- input.pdf output.pdf
-		// Note: The "--" separator is required
 		// Note: This only removes watermarks added by pdfcpu, not regular embedded images
-		output, err := execCommandWithTimeout(DefaultCLITimeout, "pdfcpu", "watermark", "remove", "--", inFile, outFile)
+		name, args := watermarkRemoveCommand(inFile, outFile)
+		output, err := execCommandWithTimeout(DefaultCLITimeout, name, args...)
 		if err != nil {
 			outputStr := string(output)
 
@@ -40,7 +194,8 @@ func RemoveElementsByIDs(inFile, outFile, elementType string, elementIDs []strin
 			if strings.Contains(outputStr, "no watermarks found") || strings.Contains(outputStr, "no stamps found") {
 				// Try stamp remove as an alternative (stamps are similar to watermarks)
 				log.Printf("No pdfcpu watermarks found, trying stamp remove as alternative...")
-				stampOutput, stampErr := execCommandWithTimeout(DefaultCLITimeout, "pdfcpu", "stamp", "remove", "--", inFile, outFile)
+				name, args := stampRemoveCommand(inFile, outFile)
+				stampOutput, stampErr := execCommandWithTimeout(DefaultCLITimeout, name, args...)
 				if stampErr == nil {
 					if stampStr := string(stampOutput); stampStr != "" {
 						log.Printf("pdfcpu stamp remove output: %s", stampStr)
@@ -64,9 +219,9 @@ func RemoveElementsByIDs(inFile, outFile, elementType string, elementIDs []strin
 
 			// Include output in error for debugging
 			if outputStr != "" {
-				return fmt.Errorf("pdfcpu watermark remove failed: %v\nOutput: %s", err, outputStr)
+				return fmt.Errorf("pdfcpu watermark remove failed: %w\nOutput: %s", err, outputStr)
 			}
-			return fmt.Errorf("pdfcpu watermark remove failed: %v", err)
+			return fmt.Errorf("pdfcpu watermark remove failed: %w", err)
 		}
 		// Log output for debugging even on success
 		if outputStr := string(output); outputStr != "" {
@@ -86,18 +241,46 @@ func RemoveElementsByIDs(inFile, outFile, elementType string, elementIDs []strin
 		// 1. Re-analyzing the PDF to get object numbers for the selected IDs
 		// 2. Storing object numbers in candidate metadata and passing them along
 		// 3. Encoding object numbers in the candidate ID itself
-		return removeImagesByIDs(inFile, outFile, elementIDs)
+		return removeImagesByIDs(inFile, outFile, elementIDs, opts)
 	default:
 		return fmt.Errorf("unsupported element type: %s", elementType)
 	}
 }
 
 // removeImagesByIDs removes specific images by analyzing the PDF and matching IDs
-func removeImagesByIDs(inFile, outFile string, elementIDs []string) error {
-	// Re-analyze the PDF to get object numbers for selected IDs
-	analysis, err := AnalyzeUnwantedElements(inFile)
-	if err != nil {
-		return fmt.Errorf("failed to analyze PDF to find images: %v", err)
+func removeImagesByIDs(inFile, outFile string, elementIDs []string, opts RemovalOptions) error {
+	if err := ValidateMatchMode(opts.MatchMode); err != nil {
+		return err
+	}
+	strict := opts.MatchMode == MatchByObject || opts.MatchMode == MatchByDimensions
+
+	// Re-analyze the PDF to get object numbers for selected IDs, unless the analysis they
+	// were selected from is given; then only the image occurrences are listed. The
+	// analysis also returns every parsed image occurrence, which is reused below instead
+	// of running pdfcpu images list a second time
+	analysis := opts.Analysis
+	var images []rawImageData
+	var err error
+	if analysis != nil {
+		images, err = listImages(context.Background(), inFile, nil)
+		if err != nil {
+			return fmt.Errorf("failed to list PDF images: %w", err)
+		}
+	} else {
+		analysis, images, err = analyzeUnwantedElements(context.Background(), inFile, AnalysisOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to analyze PDF to find images: %w", err)
+		}
+	}
+
+	// Matching builds per-ID maps and scans every occurrence per selected candidate, so
+	// pathological documents are refused before any of that starts
+	maxOccurrences := opts.MaxImageOccurrences
+	if maxOccurrences <= 0 {
+		maxOccurrences = DefaultMaxImageOccurrences
+	}
+	if len(images) > maxOccurrences {
+		return fmt.Errorf("%w (%d occurrences, limit %d)", ErrTooManyImages, len(images), maxOccurrences)
 	}
 
 	// Create a set of selected IDs for quick lookup
@@ -111,77 +294,34 @@ func removeImagesByIDs(inFile, outFile string, elementIDs []string) error {
 		objNr  string
 		pageNr int
 		id     string
+		width  int
+		height int
 	}
 	imagesToRemove := []imageToRemove{}
 
-	// First, collect all images from the PDF to find all occurrences
-	// We'll use pdfcpu images list to get all image occurrences
-	output, err := execCommandWithTimeout(DefaultCLITimeout, "pdfcpu", "images", "list", inFile)
-	if err != nil {
-		return fmt.Errorf("failed to list images: %v", err)
-	}
-
-	// Parse the images list to build:
+	// Build from the analyzed image list:
 	// 1. A map of image_id -> []{page, object}
 	// 2. A list of all images with their metadata (for pattern matching)
 	type imageOccurrence struct {
-		page int
-		obj  string
-		id   string
+		page   int
+		obj    string
+		id     string
+		width  int
+		height int
 	}
 	imageOccurrences := make(map[string][]imageOccurrence) // image_id -> occurrences
 	allImageOccurrences := []imageOccurrence{}             // All images for pattern matching
 
-	lines := strings.Split(string(output), "\n")
-	inTable := false
-	headerFound := false
-
-	for _, line := range lines {
-		lineTrimmed := strings.TrimSpace(line)
-		if lineTrimmed == "" {
-			continue
-		}
-
-		// Look for table header
-		if !headerFound && (strings.Contains(lineTrimmed, "Page") || strings.Contains(lineTrimmed, "ID")) {
-			inTable = true
-			headerFound = true
-			continue
-		}
-
-		if !inTable {
-			continue
-		}
-
-		// Parse table rows
-		parts := strings.Fields(lineTrimmed)
-		if len(parts) < 3 {
-			continue
-		}
-
-		pageStr := parts[0]
-		objStr := ""
-		idStr := ""
-
-		if len(parts) > 1 {
-			objStr = parts[1]
-		}
-		if len(parts) > 2 {
-			idStr = parts[2]
-		}
-
-		page, err := strconv.Atoi(pageStr)
-		if err != nil || page == 0 || idStr == "" {
-			continue
-		}
-
+	for _, img := range images {
 		occ := imageOccurrence{
-			page: page,
-			obj:  objStr,
-			id:   idStr,
+			page:   img.page,
+			obj:    img.obj,
+			id:     img.id,
+			width:  img.width,
+			height: img.height,
 		}
 
-		imageOccurrences[idStr] = append(imageOccurrences[idStr], occ)
+		imageOccurrences[img.id] = append(imageOccurrences[img.id], occ)
 		allImageOccurrences = append(allImageOccurrences, occ)
 	}
 
@@ -197,6 +337,17 @@ func removeImagesByIDs(inFile, outFile string, elementIDs []string) error {
 		return ""
 	}
 
+	// Text is drawn by content stream operators, which pdfcpu cannot write back, so selected
+	// text candidates are reported rather than dropped silently
+	for _, candidate := range analysis.TextCandidates {
+		if selectedIDs[candidate.ID] {
+			log.Printf("Skipping text candidate %s: text cannot be removed by ID", candidate.ID)
+			if opts.Report != nil {
+				opts.Report.SkippedText = append(opts.Report.SkippedText, candidate.ID)
+			}
+		}
+	}
+
 	// Search through candidates
 	for _, candidate := range analysis.ImageCandidates {
 		if selectedIDs[candidate.ID] {
@@ -213,15 +364,29 @@ func removeImagesByIDs(inFile, outFile string, elementIDs []string) error {
 			foundOccurrences := []imageOccurrence{}
 
 			// Strategy 1: If we have an exact image_id, find all occurrences by ID
+			// Strict modes keep only occurrences of the same object or dimensions
 			if imgID != "" {
-				if occurrences, found := imageOccurrences[imgID]; found {
-					foundOccurrences = occurrences
-					log.Printf("Found %d occurrences of exact image ID %s for candidate %s", len(occurrences), imgID, candidate.ID)
+				for _, occ := range imageOccurrences[imgID] {
+					switch opts.MatchMode {
+					case MatchByObject:
+						if occ.obj != candidate.Metadata["object"] {
+							continue
+						}
+					case MatchByDimensions:
+						if strconv.Itoa(occ.width) != candidate.Metadata["width"] || strconv.Itoa(occ.height) != candidate.Metadata["height"] {
+							continue
+						}
+					}
+					foundOccurrences = append(foundOccurrences, occ)
+				}
+				if len(foundOccurrences) > 0 {
+					log.Printf("Found %d occurrences of exact image ID %s for candidate %s", len(foundOccurrences), imgID, candidate.ID)
 				}
 			}
 
+			// Strategies 2 and 3 match by ID prefix alone, so strict modes skip them
 			// Strategy 2: If no exact matches but we have a prefix, find all images with that prefix
-			if len(foundOccurrences) == 0 && prefix != "" {
+			if len(foundOccurrences) == 0 && prefix != "" && !strict {
 				for _, occ := range allImageOccurrences {
 					// Match if image ID starts with prefix (with or without dash/underscore)
 					// Also check extracted prefix for flexibility
@@ -239,7 +404,7 @@ func removeImagesByIDs(inFile, outFile string, elementIDs []string) error {
 			}
 
 			// Strategy 3: If we have signature in metadata, try to match by signature pattern
-			if len(foundOccurrences) == 0 {
+			if len(foundOccurrences) == 0 && !strict {
 				signature, hasSignature := candidate.Metadata["signature"]
 				if hasSignature && signature != "" {
 					log.Printf("Attempting signature-based matching for candidate %s (signature: %s, prefix: %s)", candidate.ID, signature, prefix)
@@ -342,13 +507,32 @@ func removeImagesByIDs(inFile, outFile string, elementIDs []string) error {
 				}
 			}
 
+			// Original dimensions are only needed when preserving placement or redacting, as a
+			// 1x1 fill would be stretched over the region. Every occurrence is sized on its own,
+			// as the images matched for one candidate need not share its size; the candidate's
+			// size is used when the listing has none.
+			candidateWidth, _ := strconv.Atoi(candidate.Metadata["width"])
+			candidateHeight, _ := strconv.Atoi(candidate.Metadata["height"])
+			replacementSize := func(occ imageOccurrence) (int, int) {
+				if !opts.PreserveDimensions && opts.RedactColor == nil {
+					return 1, 1
+				}
+				if occ.width > 0 && occ.height > 0 {
+					return occ.width, occ.height
+				}
+				return max(candidateWidth, 1), max(candidateHeight, 1)
+			}
+
 			// Add all found occurrences to removal list
 			if len(foundOccurrences) > 0 {
 				for _, occ := range foundOccurrences {
+					width, height := replacementSize(occ)
 					imagesToRemove = append(imagesToRemove, imageToRemove{
 						objNr:  occ.obj,
 						pageNr: occ.page,
 						id:     occ.id,
+						width:  width,
+						height: height,
 					})
 				}
 				log.Printf("Total occurrences to remove for candidate %s: %d", candidate.ID, len(foundOccurrences))
@@ -359,20 +543,98 @@ func removeImagesByIDs(inFile, outFile string, elementIDs []string) error {
 	}
 
 	if len(imagesToRemove) == 0 {
-		return fmt.Errorf("no matching images found for selected IDs. The images may be repeating watermarks that appear on multiple pages")
+		return fmt.Errorf("%w. The images may be repeating watermarks that appear on multiple pages", ErrNoMatchingImages)
+	}
+
+	// Matches made by page and ID may lack the object number; take it from any listing of the
+	// same image on that page, so protection cannot be bypassed by selecting an image by name
+	objByPageID := make(map[string]string)
+	for _, occ := range allImageOccurrences {
+		if occ.obj != "" {
+			objByPageID[fmt.Sprintf("%d %s", occ.page, occ.id)] = occ.obj
+		}
+	}
+	for i, img := range imagesToRemove {
+		if img.objNr == "" {
+			imagesToRemove[i].objNr = objByPageID[fmt.Sprintf("%d %s", img.pageNr, img.id)]
+		}
 	}
 
-	// Create a blank 1x1 transparent PNG to replace images with
-	blankImagePath, err := createBlankImage(filepath.Dir(outFile))
+	// Never touch protected image objects, even when selected
+	if len(opts.ProtectedObjects) > 0 {
+		protected := make(map[string]bool)
+		for _, obj := range opts.ProtectedObjects {
+			protected[strings.TrimSpace(obj)] = true
+		}
+		allowed := imagesToRemove[:0]
+		for _, img := range imagesToRemove {
+			if img.objNr == "" {
+				// Without an object number the image might be protected, so it is kept
+				pageID := fmt.Sprintf("%d %s", img.pageNr, img.id)
+				log.Printf("Skipping image without a resolvable object number (page %d, id %s)", img.pageNr, img.id)
+				if opts.Report != nil && !slices.Contains(opts.Report.SkippedUnresolved, pageID) {
+					opts.Report.SkippedUnresolved = append(opts.Report.SkippedUnresolved, pageID)
+				}
+				continue
+			}
+			if protected[img.objNr] {
+				log.Printf("Skipping protected image object %s (page %d, id %s)", img.objNr, img.pageNr, img.id)
+				if opts.Report != nil && !slices.Contains(opts.Report.SkippedProtected, img.objNr) {
+					opts.Report.SkippedProtected = append(opts.Report.SkippedProtected, img.objNr)
+				}
+				continue
+			}
+			allowed = append(allowed, img)
+		}
+		imagesToRemove = allowed
+		if len(imagesToRemove) == 0 {
+			return ErrAllImagesProtected
+		}
+	}
+	if opts.Report != nil {
+		opts.Report.Removed = len(imagesToRemove)
+	}
+
+	if opts.AuditDir != "" {
+		auditImages := make([]auditImage, len(imagesToRemove))
+		for i, img := range imagesToRemove {
+			auditImages[i] = auditImage{page: img.pageNr, obj: img.objNr, id: img.id}
+		}
+		saved, err := saveAuditImages(inFile, opts.AuditDir, auditImages)
+		if err != nil {
+			return err
+		}
+		if opts.Report != nil {
+			opts.Report.AuditFiles = saved
+		}
+	}
+
+	// Blank images and intermediate files live in a private directory so concurrent
+	// requests for same-named files never collide
+	workDir, err := os.MkdirTemp(filepath.Dir(outFile), "remove_")
 	if err != nil {
-		return fmt.Errorf("failed to create blank image: %v", err)
+		return fmt.Errorf("failed to create work directory: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	// Create the blank PNGs to replace images with (one per distinct size), transparent
+	// unless redacting
+	blankImages := make(map[string]string) // "WxH" -> path
+	for _, img := range imagesToRemove {
+		sizeKey := fmt.Sprintf("%dx%d", img.width, img.height)
+		if _, ok := blankImages[sizeKey]; ok {
+			continue
+		}
+		blankImagePath, err := createBlankImage(workDir, img.width, img.height, opts.RedactColor)
+		if err != nil {
+			return fmt.Errorf("failed to create blank image: %w", err)
+		}
+		blankImages[sizeKey] = blankImagePath
 	}
-	defer os.Remove(blankImagePath)
 
 	// Process images one by one
 	// For multiple images, we need to chain operations: inFile -> temp1 -> temp2 -> ... -> outFile
 	currentFile := inFile
-	tempFiles := []string{}
 
 	for i, img := range imagesToRemove {
 		var tempFile string
@@ -381,38 +643,38 @@ func removeImagesByIDs(inFile, outFile string, elementIDs []string) error {
 			tempFile = outFile
 		} else {
 			// Intermediate files
-			tempFile = filepath.Join(filepath.Dir(outFile), fmt.Sprintf("temp_%d_%s", i, filepath.Base(outFile)))
-			tempFiles = append(tempFiles, tempFile)
+			tempFile = filepath.Join(workDir, fmt.Sprintf("temp_%d.pdf", i))
 		}
 
 		var output []byte
 		var err error
+		blankImagePath := blankImages[fmt.Sprintf("%dx%d", img.width, img.height)]
 
 		if img.objNr != "" {
 			// Use object number
-			output, err = execCommandWithTimeout(DefaultCLITimeout, "pdfcpu", "images", "update", currentFile, blankImagePath, tempFile, img.objNr)
+			name, args := imagesUpdateCommand(currentFile, blankImagePath, tempFile, img.objNr)
+			output, err = execCommandWithTimeout(DefaultCLITimeout, name, args...)
 		} else if img.pageNr > 0 && img.id != "" {
 			// Use page number and ID: format is "pageNr Id"
 			pageIdArg := fmt.Sprintf("%d %s", img.pageNr, img.id)
-			output, err = execCommandWithTimeout(DefaultCLITimeout, "pdfcpu", "images", "update", currentFile, blankImagePath, tempFile, pageIdArg)
+			name, args := imagesUpdateCommand(currentFile, blankImagePath, tempFile, pageIdArg)
+			output, err = execCommandWithTimeout(DefaultCLITimeout, name, args...)
 		} else {
-			// Clean up temp files
-			for _, tf := range tempFiles {
-				os.Remove(tf)
-			}
 			return fmt.Errorf("cannot identify image for removal: missing object number and page/ID")
 		}
 
 		if err != nil {
-			// Clean up temp files
-			for _, tf := range tempFiles {
-				os.Remove(tf)
-			}
 			outputStr := string(output)
 			if outputStr != "" {
-				return fmt.Errorf("failed to remove image (obj:%s, page:%d, id:%s): %v\nOutput: %s", img.objNr, img.pageNr, img.id, err, outputStr)
+				return fmt.Errorf("failed to remove image (obj:%s, page:%d, id:%s): %w\nOutput: %s", img.objNr, img.pageNr, img.id, err, outputStr)
 			}
-			return fmt.Errorf("failed to remove image (obj:%s, page:%d, id:%s): %v", img.objNr, img.pageNr, img.id, err)
+			return fmt.Errorf("failed to remove image (obj:%s, page:%d, id:%s): %w", img.objNr, img.pageNr, img.id, err)
+		}
+
+		// The previous intermediate has been consumed; drop it now so at most two
+		// intermediates exist at once, however many occurrences are removed
+		if currentFile != inFile {
+			os.Remove(currentFile)
 		}
 
 		// If this wasn't the last image, update currentFile for next iteration
@@ -421,39 +683,55 @@ func removeImagesByIDs(inFile, outFile string, elementIDs []string) error {
 		}
 	}
 
-	// Clean up intermediate temp files
-	for _, tf := range tempFiles {
-		if tf != outFile {
-			os.Remove(tf)
-		}
-	}
-
 	return nil
 }
 
-// createBlankImage creates a 1x1 transparent PNG file
-func createBlankImage(dir string) (string, error) {
-	// Create a 1x1 transparent PNG
-	img := image.NewRGBA(image.Rect(0, 0, 1, 1))
+// capBlankImageSize clamps blank image dimensions to 1..MaxBlankImageDimension per side,
+// preserving the aspect ratio when scaling down
+func capBlankImageSize(width, height int) (int, int) {
+	width = max(width, 1)
+	height = max(height, 1)
+	if longest := max(width, height); longest > MaxBlankImageDimension {
+		scale := float64(MaxBlankImageDimension) / float64(longest)
+		width = max(int(float64(width)*scale), 1)
+		height = max(int(float64(height)*scale), 1)
+	}
+	return width, height
+}
+
+// createBlankImage creates a PNG file of the given dimensions, fully transparent or, with
+// a fill color, solid. Non-positive dimensions fall back to 1x1. Dimensions above MaxBlankImageDimension are
+// scaled down keeping the aspect ratio; the displayed size is set by the content stream
+// transform, not the pixel count, so the replacement still covers the same area.
+func createBlankImage(dir string, width, height int, fill *color.RGBA) (string, error) {
+	width, height = capBlankImageSize(width, height)
+
+	// Create a transparent PNG (RGBA zero value is fully transparent)
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	name := fmt.Sprintf("blank_%dx%d.png", width, height)
+	if fill != nil {
+		draw.Draw(img, img.Bounds(), &image.Uniform{C: *fill}, image.Point{}, draw.Src)
+		name = fmt.Sprintf("redact_%dx%d_%02x%02x%02x.png", width, height, fill.R, fill.G, fill.B)
+	}
 
 	// Encode as PNG
 	var buf bytes.Buffer
 	if err := png.Encode(&buf, img); err != nil {
-		return "", fmt.Errorf("failed to encode blank image: %v", err)
+		return "", fmt.Errorf("failed to encode blank image: %w", err)
 	}
 
 	// Save to file
-	filename := filepath.Join(dir, "blank_1x1.png")
+	filename := filepath.Join(dir, name)
 	file, err := os.Create(filename)
 	if err != nil {
-		return "", fmt.Errorf("failed to create blank image file: %v", err)
+		return "", fmt.Errorf("failed to create blank image file: %w", err)
 	}
 	defer file.Close()
 
 	_, err = file.Write(buf.Bytes())
 	if err != nil {
 		os.Remove(filename)
-		return "", fmt.Errorf("failed to write blank image: %v", err)
+		return "", fmt.Errorf("failed to write blank image: %w", err)
 	}
 
 	return filename, nil
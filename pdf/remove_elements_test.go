@@ -0,0 +1,706 @@
+package pdf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image/color"
+	"image/png"
+	"maps"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestCreateBlankImageDimensions(t *testing.T) {
+	red := &color.RGBA{R: 0xff, A: 0xff}
+	tests := []struct {
+		name          string
+		width, height int
+		fill          *color.RGBA
+		wantW, wantH  int
+	}{
+		{"target size", 640, 480, nil, 640, 480},
+		{"one pixel", 1, 1, nil, 1, 1},
+		{"non-positive falls back to 1x1", 0, -5, nil, 1, 1},
+		{"redaction fill keeps size", 300, 200, red, 300, 200},
+		{"oversized is scaled down keeping aspect", 4096, 2048, nil, MaxBlankImageDimension, MaxBlankImageDimension / 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := createBlankImage(t.TempDir(), tt.width, tt.height, tt.fill)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w, h := pngSize(t, path)
+			if w != tt.wantW || h != tt.wantH {
+				t.Errorf("createBlankImage(%d, %d) = %dx%d, want %dx%d", tt.width, tt.height, w, h, tt.wantW, tt.wantH)
+			}
+		})
+	}
+}
+
+func TestCreateBlankImageAbsurdDimensions(t *testing.T) {
+	// Each of these would need gigabytes as an uncapped RGBA buffer
+	tests := []struct {
+		width, height int
+		wantW, wantH  int
+	}{
+		{10000, 10000, MaxBlankImageDimension, MaxBlankImageDimension},
+		{1 << 20, 1 << 19, MaxBlankImageDimension, MaxBlankImageDimension / 2},
+		{math.MaxInt32, 3, MaxBlankImageDimension, 1},
+		{2, math.MaxInt, 1, MaxBlankImageDimension},
+	}
+	// A capped image needs at most one RGBA buffer plus its PNG encoding
+	const maxAlloc = 4 * 4 * MaxBlankImageDimension * MaxBlankImageDimension
+	for _, tt := range tests {
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		path, err := createBlankImage(t.TempDir(), tt.width, tt.height, &color.RGBA{A: 0xff})
+		runtime.ReadMemStats(&after)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if w, h := pngSize(t, path); w != tt.wantW || h != tt.wantH {
+			t.Errorf("createBlankImage(%d, %d) = %dx%d, want %dx%d", tt.width, tt.height, w, h, tt.wantW, tt.wantH)
+		}
+		if allocated := after.TotalAlloc - before.TotalAlloc; allocated > maxAlloc {
+			t.Errorf("createBlankImage(%d, %d) allocated %d bytes, want at most %d", tt.width, tt.height, allocated, maxAlloc)
+		}
+	}
+}
+
+func TestRemoveImagesPreserveDimensions(t *testing.T) {
+	for _, preserve := range []bool{false, true} {
+		fake := installFakeCLI(t, &fakePdfcpu{pages: 3, images: []fakeImage{
+			{Page: 1, Obj: 10, ID: "Im0", Width: 300, Height: 200, CS: "DeviceRGB", Size: 40000},
+			{Page: 2, Obj: 10, ID: "Im0", Width: 300, Height: 200, CS: "DeviceRGB", Size: 40000},
+			{Page: 3, Obj: 10, ID: "Im0", Width: 300, Height: 200, CS: "DeviceRGB", Size: 40000},
+		}})
+		var replacements [][2]int
+		fake.respond = func(args []string) (string, bool, error) {
+			if len(args) == 6 && args[0] == "images" && args[1] == "update" {
+				w, h := pngSize(t, args[3])
+				replacements = append(replacements, [2]int{w, h})
+			}
+			return "", false, nil
+		}
+
+		dir := t.TempDir()
+		inFile := writeFakePDF(t, dir, "in.pdf")
+		analysis, err := AnalyzeUnwantedElements(inFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(analysis.ImageCandidates) == 0 {
+			t.Fatal("expected an image candidate for an image on every page")
+		}
+		id := analysis.ImageCandidates[0].ID
+
+		err = RemoveElementsByIDsWithOptions(inFile, writeFakePDF(t, dir, "out.pdf"), "image", []string{id}, RemovalOptions{PreserveDimensions: preserve})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := [2]int{1, 1}
+		if preserve {
+			want = [2]int{300, 200}
+		}
+		if len(replacements) == 0 {
+			t.Fatal("no images were replaced")
+		}
+		for _, got := range replacements {
+			if got != want {
+				t.Errorf("PreserveDimensions=%v: replacement is %dx%d, want %dx%d", preserve, got[0], got[1], want[0], want[1])
+			}
+		}
+	}
+}
+
+func TestRemoveImagesPreserveDimensionsPerOccurrence(t *testing.T) {
+	// The candidate's "Im0" also names smaller images on pages 4 and 6, which removal by ID
+	// matches too: each must be blanked at its own size, not the candidate's
+	images := []fakeImage{}
+	for page := 1; page <= 6; page++ {
+		width, height, obj := 600, 400, 10
+		if page == 4 || page == 6 {
+			width, height, obj = 120, 80, 40+page
+		}
+		images = append(images, fakeImage{Page: page, Obj: obj, ID: "Im0", Width: width, Height: height, CS: "DeviceRGB", Size: 40000})
+	}
+	fake := installFakeCLI(t, &fakePdfcpu{pages: 6, images: images})
+	replacements := make(map[string][2]int) // object -> replacement size
+	fake.respond = func(args []string) (string, bool, error) {
+		if len(args) == 6 && args[0] == "images" && args[1] == "update" {
+			w, h := pngSize(t, args[3])
+			replacements[args[5]] = [2]int{w, h}
+		}
+		return "", false, nil
+	}
+
+	dir := t.TempDir()
+	inFile := writeFakePDF(t, dir, "in.pdf")
+	analysis, err := AnalyzeUnwantedElements(inFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(analysis.ImageCandidates) == 0 || analysis.ImageCandidates[0].Metadata["width"] != "600" {
+		t.Fatalf("candidates = %+v, want the 600x400 watermark", analysis.ImageCandidates)
+	}
+	err = RemoveElementsByIDsWithOptions(inFile, filepath.Join(dir, "out.pdf"), "image",
+		[]string{analysis.ImageCandidates[0].ID}, RemovalOptions{PreserveDimensions: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][2]int{"10": {600, 400}, "44": {120, 80}, "46": {120, 80}}
+	if !maps.Equal(replacements, want) {
+		t.Errorf("replacement sizes = %v, want %v", replacements, want)
+	}
+}
+
+// pngSize returns the pixel size of a PNG file
+func pngSize(t testing.TB, path string) (int, int) {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	config, err := png.DecodeConfig(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return config.Width, config.Height
+}
+
+// pngColors returns the distinct colors of a PNG file's pixels
+func pngColors(t testing.TB, path string) []color.RGBA {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	img, err := png.Decode(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var colors []color.RGBA
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+			if !slices.Contains(colors, c) {
+				colors = append(colors, c)
+			}
+		}
+	}
+	return colors
+}
+
+func TestParseRedactColor(t *testing.T) {
+	tests := []struct {
+		hex     string
+		want    color.RGBA
+		wantErr bool
+	}{
+		{hex: "#000000", want: color.RGBA{A: 0xff}},
+		{hex: "#ff8000", want: color.RGBA{R: 0xff, G: 0x80, A: 0xff}},
+		{hex: "1A2B3C", want: color.RGBA{R: 0x1a, G: 0x2b, B: 0x3c, A: 0xff}},
+		{hex: "#f80", want: color.RGBA{R: 0xff, G: 0x88, A: 0xff}},
+		{hex: " #FFFFFF ", want: color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}},
+		{hex: "", wantErr: true},
+		{hex: "#", wantErr: true},
+		{hex: "#ff80", wantErr: true},
+		{hex: "#ff80000", wantErr: true},
+		{hex: "#gg0000", wantErr: true},
+		{hex: "black", wantErr: true},
+		{hex: "##000000", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseRedactColor(tt.hex)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseRedactColor(%q) err = %v, want error %v", tt.hex, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseRedactColor(%q) = %v, want %v", tt.hex, got, tt.want)
+		}
+	}
+}
+
+func TestRedactionFill(t *testing.T) {
+	orange := color.RGBA{R: 0xff, G: 0x80, A: 0xff}
+	path, err := createBlankImage(t.TempDir(), 30, 20, &orange)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if colors := pngColors(t, path); !slices.Equal(colors, []color.RGBA{orange}) {
+		t.Errorf("redaction image colors = %v, want only %v", colors, orange)
+	}
+	path, err = createBlankImage(t.TempDir(), 30, 20, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if colors := pngColors(t, path); !slices.Equal(colors, []color.RGBA{{}}) {
+		t.Errorf("blank image colors = %v, want fully transparent", colors)
+	}
+
+	// Removal hands pdfcpu a solid image at the target size, with or without preserve_placement
+	for _, preserve := range []bool{true, false} {
+		fake := installFakeCLI(t, watermarkedPDF(3))
+		var replacements int
+		fake.respond = func(args []string) (string, bool, error) {
+			if len(args) == 6 && args[0] == "images" && args[1] == "update" {
+				replacements++
+				if w, h := pngSize(t, args[3]); w != 600 || h != 400 {
+					t.Errorf("preserve %v: redaction image is %dx%d, want 600x400", preserve, w, h)
+				}
+				if colors := pngColors(t, args[3]); !slices.Equal(colors, []color.RGBA{orange}) {
+					t.Errorf("preserve %v: redaction image colors = %v, want only %v", preserve, colors, orange)
+				}
+			}
+			return "", false, nil
+		}
+		dir := t.TempDir()
+		inFile := writeFakePDF(t, dir, "in.pdf")
+		analysis, err := AnalyzeUnwantedElements(inFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(analysis.ImageCandidates) == 0 {
+			t.Fatal("expected a candidate for the watermark")
+		}
+		err = RemoveElementsByIDsWithOptions(inFile, filepath.Join(dir, "out.pdf"), "image", []string{analysis.ImageCandidates[0].ID},
+			RemovalOptions{PreserveDimensions: preserve, RedactColor: &orange})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if replacements == 0 {
+			t.Errorf("preserve %v: no images were replaced", preserve)
+		}
+	}
+}
+
+func TestRemoveImagesTooManyOccurrences(t *testing.T) {
+	images := make([]fakeImage, 0, 500)
+	for page := 1; page <= 50; page++ {
+		for i := 0; i < 10; i++ {
+			images = append(images, fakeImage{Page: page, Obj: 100 + i, ID: fmt.Sprintf("Im%d", i), Width: 300, Height: 200, CS: "DeviceRGB", Size: 40000})
+		}
+	}
+	fake := installFakeCLI(t, &fakePdfcpu{pages: 50, images: images})
+	dir := t.TempDir()
+	inFile := writeFakePDF(t, dir, "in.pdf")
+
+	err := RemoveElementsByIDsWithOptions(inFile, filepath.Join(dir, "out.pdf"), "image", []string{"any"}, RemovalOptions{MaxImageOccurrences: 499})
+	if !errors.Is(err, ErrTooManyImages) {
+		t.Fatalf("err = %v, want ErrTooManyImages", err)
+	}
+	if calls := fake.callCount("images", "update"); calls != 0 {
+		t.Errorf("%d images were replaced after the guard triggered", calls)
+	}
+
+	// At the limit, removal goes ahead to matching
+	err = RemoveElementsByIDsWithOptions(inFile, filepath.Join(dir, "out.pdf"), "image", []string{"any"}, RemovalOptions{MaxImageOccurrences: 500})
+	if !errors.Is(err, ErrNoMatchingImages) {
+		t.Fatalf("err = %v, want ErrNoMatchingImages for an unknown candidate ID", err)
+	}
+}
+
+func TestRemoveImagesProtectedByPageAndID(t *testing.T) {
+	// Im0 is object 10 on pages 1 and 2, but page 2 is also listed without its object number,
+	// and page 3 never has one
+	img := fakeImage{ID: "Im0", Width: 300, Height: 200, CS: "DeviceRGB", Size: 40000}
+	at := func(page, obj int) fakeImage {
+		img.Page, img.Obj = page, obj
+		return img
+	}
+	fake := installFakeCLI(t, &fakePdfcpu{pages: 3, images: []fakeImage{at(1, 10), at(2, 0), at(2, 10), at(3, 0)}})
+	dir := t.TempDir()
+	inFile := writeFakePDF(t, dir, "in.pdf")
+
+	analysis, err := AnalyzeUnwantedElements(inFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(analysis.ImageCandidates) == 0 {
+		t.Fatal("expected an image candidate for an image on every page")
+	}
+	ids := []string{analysis.ImageCandidates[0].ID}
+
+	var report RemovalReport
+	err = RemoveElementsByIDsWithOptions(inFile, filepath.Join(dir, "out.pdf"), "image", ids, RemovalOptions{ProtectedObjects: []string{"10"}, Report: &report})
+	if !errors.Is(err, ErrAllImagesProtected) {
+		t.Fatalf("err = %v, want ErrAllImagesProtected", err)
+	}
+	if calls := fake.callsOf("images", "update"); len(calls) != 0 {
+		t.Errorf("images were replaced: %v", calls)
+	}
+	if !slices.Equal(report.SkippedProtected, []string{"10"}) {
+		t.Errorf("SkippedProtected = %v, want [10]", report.SkippedProtected)
+	}
+	if !slices.Equal(report.SkippedUnresolved, []string{"3 Im0"}) {
+		t.Errorf("SkippedUnresolved = %v, want [3 Im0]", report.SkippedUnresolved)
+	}
+
+	// Without protected objects the unresolved image is replaced by page and ID
+	report = RemovalReport{}
+	if err := RemoveElementsByIDsWithOptions(inFile, filepath.Join(dir, "out.pdf"), "image", ids, RemovalOptions{Report: &report}); err != nil {
+		t.Fatal(err)
+	}
+	var targets []string
+	for _, call := range fake.callsOf("images", "update") {
+		targets = append(targets, call[len(call)-1])
+	}
+	if !slices.Contains(targets, "10") || !slices.Contains(targets, "3 Im0") {
+		t.Errorf("replaced %v, want object 10 and 3 Im0", targets)
+	}
+}
+
+// TestRemovalOutputSize compares output sizes with and without the optimize rewrite, the
+// closest approximation of incremental output (see PLAN.md): images update stands in for a
+// small in-place change and optimize for a full rewrite of the document
+func TestRemoveImagesReportsSelectedText(t *testing.T) {
+	// Every page has the image watermark and a rotated DRAFT text watermark
+	fake := watermarkedPDF(5)
+	for page := range fake.contents {
+		fake.contents[page] += " " + rotatedDraft
+	}
+	installFakeCLI(t, fake)
+	dir := t.TempDir()
+	inFile := writeFakePDF(t, dir, "in.pdf")
+
+	analysis, err := AnalyzeUnwantedElements(inFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(analysis.ImageCandidates) == 0 || len(analysis.TextCandidates) == 0 {
+		t.Fatalf("want image and text candidates, got %+v and %+v", analysis.ImageCandidates, analysis.TextCandidates)
+	}
+	textID := analysis.TextCandidates[0].ID
+
+	var report RemovalReport
+	ids := []string{analysis.ImageCandidates[0].ID, textID}
+	if err := RemoveElementsByIDsWithOptions(inFile, filepath.Join(dir, "out.pdf"), "image", ids, RemovalOptions{Report: &report}); err != nil {
+		t.Fatal(err)
+	}
+	if report.Removed != 5 || !slices.Equal(report.SkippedText, []string{textID}) {
+		t.Errorf("report = %+v, want 5 images removed and %s skipped", report, textID)
+	}
+
+	// Text alone matches no image
+	report = RemovalReport{}
+	err = RemoveElementsByIDsWithOptions(inFile, filepath.Join(dir, "out.pdf"), "image", []string{textID}, RemovalOptions{Report: &report})
+	if !errors.Is(err, ErrNoMatchingImages) || !slices.Equal(report.SkippedText, []string{textID}) {
+		t.Errorf("err = %v, skipped text %v, want ErrNoMatchingImages with %s skipped", err, report.SkippedText, textID)
+	}
+}
+
+func TestRemoveDeepMatchCandidate(t *testing.T) {
+	fake := installFakeCLI(t, mixedImagesPDF())
+	dir := t.TempDir()
+	inFile := writeFakePDF(t, dir, "in.pdf")
+
+	analysis, err := AnalyzeUnwantedElementsWithOptions(inFile, AnalysisOptions{DeepMatch: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := slices.IndexFunc(analysis.ImageCandidates, func(c UnwantedElementCandidate) bool {
+		return c.Metadata[MetaType] == "identical_image"
+	})
+	if i < 0 {
+		t.Fatalf("no identical_image candidate in %+v", analysis.ImageCandidates)
+	}
+	ids := []string{analysis.ImageCandidates[i].ID}
+
+	// A default re-analysis does not find the candidate
+	err = RemoveElementsByIDsWithOptions(inFile, filepath.Join(dir, "out.pdf"), "image", ids, RemovalOptions{})
+	if !errors.Is(err, ErrNoMatchingImages) {
+		t.Fatalf("err = %v without the analysis, want ErrNoMatchingImages", err)
+	}
+
+	// The analysis it was selected from does, without analyzing again
+	analyses := fake.callCount("images", "list")
+	var report RemovalReport
+	opts := RemovalOptions{Analysis: analysis, Report: &report}
+	if err := RemoveElementsByIDsWithOptions(inFile, filepath.Join(dir, "out.pdf"), "image", ids, opts); err != nil {
+		t.Fatal(err)
+	}
+	// The logo is one object drawn under a new name on every page, so replacing it once
+	// removes it everywhere
+	updates := fake.callsOf("images", "update")
+	if report.Removed != 1 || len(updates) != 1 || updates[0][5] != "20" {
+		t.Errorf("removed %d images with %v, want object 20 replaced", report.Removed, updates)
+	}
+	if calls := fake.callCount("images", "list") - analyses; calls != 1 {
+		t.Errorf("%d images list calls, want only the one listing the occurrences", calls)
+	}
+	if extracts := fake.callCount("extract", "-mode", "image"); extracts != 1 {
+		t.Errorf("%d image extractions, want only the deep match of the analysis", extracts)
+	}
+}
+
+func TestRemovalOutputSize(t *testing.T) {
+	const inputSize, rewrittenSize = 64 * 1024, 40 * 1024
+	img := fakeImage{ID: "Im0", Width: 300, Height: 200, CS: "DeviceRGB", Size: 40000}
+	at := func(page, obj int) fakeImage {
+		img.Page, img.Obj = page, obj
+		return img
+	}
+	fake := installFakeCLI(t, &fakePdfcpu{pages: 2, images: []fakeImage{at(1, 10), at(2, 10)}})
+	fake.respond = func(args []string) (string, bool, error) {
+		if args[0] != "optimize" {
+			return "", false, nil
+		}
+		return "", true, os.WriteFile(args[len(args)-1], make([]byte, rewrittenSize), 0644)
+	}
+
+	dir := t.TempDir()
+	inFile := filepath.Join(dir, "in.pdf")
+	if err := os.WriteFile(inFile, append([]byte("%PDF-1.7\n"), make([]byte, inputSize-9)...), 0644); err != nil {
+		t.Fatal(err)
+	}
+	analysis, err := AnalyzeUnwantedElements(inFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(analysis.ImageCandidates) == 0 {
+		t.Fatal("expected an image candidate for an image on every page")
+	}
+	ids := []string{analysis.ImageCandidates[0].ID}
+
+	tests := []struct {
+		optimizeAfter bool
+		wantSize      int64
+		wantOptimize  int
+	}{
+		{optimizeAfter: false, wantSize: inputSize, wantOptimize: 0},
+		{optimizeAfter: true, wantSize: rewrittenSize, wantOptimize: 1},
+	}
+	for _, tt := range tests {
+		before := fake.callCount("optimize")
+		outFile := filepath.Join(dir, fmt.Sprintf("out_%v.pdf", tt.optimizeAfter))
+		if err := RemoveElementsByIDsWithOptions(inFile, outFile, "image", ids, RemovalOptions{OptimizeAfter: tt.optimizeAfter}); err != nil {
+			t.Fatal(err)
+		}
+		info, err := os.Stat(outFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Size() != tt.wantSize {
+			t.Errorf("OptimizeAfter=%v: output is %d bytes, want %d", tt.optimizeAfter, info.Size(), tt.wantSize)
+		}
+		if calls := fake.callCount("optimize") - before; calls != tt.wantOptimize {
+			t.Errorf("OptimizeAfter=%v: %d optimize rewrites, want %d", tt.optimizeAfter, calls, tt.wantOptimize)
+		}
+	}
+}
+
+func TestOptimizeInPlaceFailureKeepsOutput(t *testing.T) {
+	fake := installFakeCLI(t, &fakePdfcpu{pages: 1})
+	fake.respond = func(args []string) (string, bool, error) {
+		if args[0] != "optimize" {
+			return "", false, nil
+		}
+		// Fail after writing part of the rewrite
+		os.WriteFile(args[len(args)-1], []byte("partial"), 0644)
+		return "", true, errors.New("optimize: xref corrupt")
+	}
+	dir := t.TempDir()
+	outFile := writeFakePDF(t, dir, "out.pdf")
+
+	if err := optimizeInPlace(outFile); err == nil {
+		t.Fatal("failed optimize reported success")
+	}
+	if data, _ := os.ReadFile(outFile); string(data) != "%PDF-1.7\n%%EOF\n" {
+		t.Errorf("output changed by a failed optimize: %q", data)
+	}
+	if _, err := os.Stat(outFile + ".optimized"); !os.IsNotExist(err) {
+		t.Errorf("partial optimize output left behind: %v", err)
+	}
+}
+
+func TestRemoveImagesSameNamedFilesConcurrently(t *testing.T) {
+	img := fakeImage{ID: "Im0", Width: 300, Height: 200, CS: "DeviceRGB", Size: 40000}
+	at := func(page, obj int) fakeImage {
+		img.Page, img.Obj = page, obj
+		return img
+	}
+	installFakeCLI(t, &fakePdfcpu{pages: 3, images: []fakeImage{at(1, 10), at(2, 10), at(3, 11)}})
+
+	// Two uploads named doc.pdf with different content, written next to each other
+	outDir := t.TempDir()
+	var inFiles [2]string
+	for i := range inFiles {
+		inFiles[i] = filepath.Join(t.TempDir(), "doc.pdf")
+		if err := os.WriteFile(inFiles[i], []byte(fmt.Sprintf("%%PDF-1.7\n%% upload %d\n", i)), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	analysis, err := AnalyzeUnwantedElements(inFiles[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(analysis.ImageCandidates) == 0 {
+		t.Fatal("expected an image candidate for an image on every page")
+	}
+	ids := []string{analysis.ImageCandidates[0].ID}
+
+	var wg sync.WaitGroup
+	var errs [2]error
+	for i := range inFiles {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = RemoveElementsByIDsWithOptions(inFiles[i], filepath.Join(outDir, fmt.Sprintf("doc_%d.pdf", i)), "image", ids, RemovalOptions{})
+		}()
+	}
+	wg.Wait()
+
+	for i := range inFiles {
+		if errs[i] != nil {
+			t.Fatalf("removal %d: %v", i, errs[i])
+		}
+		want, _ := os.ReadFile(inFiles[i])
+		got, err := os.ReadFile(filepath.Join(outDir, fmt.Sprintf("doc_%d.pdf", i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("output %d has the content of the other upload: %q", i, got)
+		}
+	}
+	if entries, _ := os.ReadDir(outDir); len(entries) != 2 {
+		t.Errorf("output directory holds %d entries, want only the 2 outputs", len(entries))
+	}
+}
+
+func TestRemoveImagesListsImagesOnce(t *testing.T) {
+	fake := installFakeCLI(t, watermarkedPDF(5))
+	dir := t.TempDir()
+	inFile := writeFakePDF(t, dir, "in.pdf")
+
+	analysis, err := AnalyzeUnwantedElements(inFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(analysis.ImageCandidates) == 0 {
+		t.Fatal("expected an image candidate for an image on every page")
+	}
+	ids := []string{analysis.ImageCandidates[0].ID}
+
+	before := fake.callCount("images", "list")
+	if err := RemoveElementsByIDsWithOptions(inFile, filepath.Join(dir, "out.pdf"), "image", ids, RemovalOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if calls := fake.callCount("images", "list") - before; calls != 1 {
+		t.Errorf("removal listed images %d times, want 1", calls)
+	}
+	if calls := fake.callCount("images", "update"); calls == 0 {
+		t.Error("no image was replaced")
+	}
+}
+
+func TestRemoveImagesBoundsIntermediateFiles(t *testing.T) {
+	const pages = 30
+	f := &fakePdfcpu{pages: pages}
+	for page := 1; page <= pages; page++ {
+		// A separate object on every page, so each occurrence is its own update step
+		f.images = append(f.images, fakeImage{Page: page, Obj: 100 + page, ID: "Im0", Width: 300, Height: 200, CS: "DeviceRGB", Size: 40000})
+	}
+	fake := installFakeCLI(t, f)
+	dir := t.TempDir()
+	inFile := writeFakePDF(t, dir, "in.pdf")
+
+	peak := 0
+	fake.respond = func(args []string) (string, bool, error) {
+		if len(args) > 1 && args[0] == "images" && args[1] == "update" {
+			// Intermediates present while the next step runs, before it writes its output
+			existing, _ := filepath.Glob(filepath.Join(dir, "remove_*", "temp_*.pdf"))
+			peak = max(peak, len(existing))
+		}
+		return "", false, nil
+	}
+
+	analysis, err := AnalyzeUnwantedElements(inFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(analysis.ImageCandidates) == 0 {
+		t.Fatal("expected an image candidate for an image on every page")
+	}
+	outFile := filepath.Join(dir, "out.pdf")
+	err = RemoveElementsByIDsWithOptions(inFile, outFile, "image", []string{analysis.ImageCandidates[0].ID}, RemovalOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if calls := fake.callCount("images", "update"); calls != pages {
+		t.Fatalf("%d update steps, want %d", calls, pages)
+	}
+	if peak > 1 {
+		t.Errorf("%d intermediates existed at once, want at most the one being read", peak)
+	}
+	if leftover, _ := filepath.Glob(filepath.Join(dir, "remove_*")); len(leftover) != 0 {
+		t.Errorf("work directory left behind: %v", leftover)
+	}
+}
+
+func TestRemoveImagesMatchModes(t *testing.T) {
+	// "Im0" names the watermark (object 10) on pages 1-9, an unrelated figure (object 50)
+	// on page 10 and a re-encoded copy of the watermark (object 60) on page 11
+	images := []fakeImage{}
+	for page := 1; page <= 9; page++ {
+		images = append(images, fakeImage{Page: page, Obj: 10, ID: "Im0", Width: 600, Height: 400, CS: "DeviceRGB", Size: 40000})
+	}
+	images = append(images,
+		fakeImage{Page: 10, Obj: 50, ID: "Im0", Width: 200, Height: 100, CS: "DeviceRGB", Size: 9000},
+		fakeImage{Page: 11, Obj: 60, ID: "Im0", Width: 600, Height: 400, CS: "DeviceRGB", Size: 40000})
+
+	tests := []struct {
+		mode        string
+		wantObjects []string
+	}{
+		{mode: "", wantObjects: []string{"10", "50", "60"}},
+		{mode: MatchByID, wantObjects: []string{"10", "50", "60"}},
+		{mode: MatchByObject, wantObjects: []string{"10"}},
+		{mode: MatchByDimensions, wantObjects: []string{"10", "60"}},
+	}
+	for _, tt := range tests {
+		t.Run("mode "+tt.mode, func(t *testing.T) {
+			f := installFakeCLI(t, &fakePdfcpu{pages: 11, images: images})
+			dir := t.TempDir()
+			inFile := writeFakePDF(t, dir, "in.pdf")
+			analysis, err := AnalyzeUnwantedElements(inFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(analysis.ImageCandidates) != 1 || analysis.ImageCandidates[0].Metadata["object"] != "10" {
+				t.Fatalf("candidates = %+v, want the watermark", analysis.ImageCandidates)
+			}
+
+			err = RemoveElementsByIDsWithOptions(inFile, filepath.Join(dir, "out.pdf"), "image",
+				[]string{analysis.ImageCandidates[0].ID}, RemovalOptions{MatchMode: tt.mode})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var objects []string
+			for _, call := range f.callsOf("images", "update") {
+				objects = append(objects, call[len(call)-1])
+			}
+			slices.Sort(objects)
+			objects = slices.Compact(objects)
+			if !slices.Equal(objects, tt.wantObjects) {
+				t.Errorf("replaced objects %v, want %v", objects, tt.wantObjects)
+			}
+		})
+	}
+
+	if err := RemoveElementsByIDsWithOptions("in.pdf", "out.pdf", "image", []string{"x"}, RemovalOptions{MatchMode: "fuzzy"}); err == nil {
+		t.Error("unknown match mode accepted")
+	}
+}
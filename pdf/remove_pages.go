@@ -1,42 +1,58 @@
 package pdf
 
 import (
+	"errors"
 	"fmt"
-	"strings"
+	"os"
 )
 
+// ErrWouldEmptyDocument is returned when a page removal would leave no pages
+var ErrWouldEmptyDocument = errors.New("removing these pages would leave an empty document")
+
 // RemovePagesFromPDF removes specified pages from a PDF file using pdfcpu CLI
 func RemovePagesFromPDF(inFile, outFile, pages string) error {
-	// Parse page specification
-	pageNumbers, err := ParsePageSpecifier(pages)
-	if err != nil {
+	if err := checkDistinctFiles(inFile, outFile); err != nil {
 		return err
 	}
 
 	// Validate page numbers against PDF page count before processing
 	totalPages, err := getPageCount(inFile)
 	if err != nil {
-		return fmt.Errorf("failed to get page count: %v", err)
+		return fmt.Errorf("failed to get page count: %w", err)
+	}
+
+	// Parse page specification
+	pageNumbers, err := ParsePageSpecifierWithTotal(pages, totalPages)
+	if err != nil {
+		return err
 	}
 
 	if err := ValidatePageNumbers(pageNumbers, totalPages); err != nil {
 		return err
 	}
 
-	// Convert page numbers to strings for CLI
-	pageStrs := make([]string, len(pageNumbers))
-	for i, p := range pageNumbers {
-		pageStrs[i] = fmt.Sprintf("%d", p)
+	// pageNumbers is deduplicated and validated, so its length is the number of pages removed
+	if len(pageNumbers) >= totalPages {
+		return fmt.Errorf("%w: all %d pages selected", ErrWouldEmptyDocument, totalPages)
 	}
 
-	// pdfcpu pages remove command: pdfcpu pages remove -p pages -- inFile outFile
-	pagesArg := strings.Join(pageStrs, ",")
-	output, err := execCommandWithTimeout(DefaultCLITimeout, "pdfcpu", "pages", "remove", "-p", pagesArg, "--", inFile, outFile)
+	name, args := removePagesCommand(inFile, outFile, pageNumbers)
+	output, err := execCommandWithTimeout(DefaultCLITimeout, name, args...)
 	if err != nil {
-		return fmt.Errorf("pdfcpu remove failed: %v", err)
+		return fmt.Errorf("pdfcpu remove failed: %w", err)
 	}
 	
 	_ = output // Suppress unused variable warning
 
+	// Guard against pdfcpu writing a zero-page document
+	remaining, err := getPageCount(outFile)
+	if err != nil || remaining < 1 {
+		os.Remove(outFile)
+		if err != nil {
+			return fmt.Errorf("failed to verify output page count: %w", err)
+		}
+		return fmt.Errorf("%w: output has no pages", ErrWouldEmptyDocument)
+	}
+
 	return nil
 }
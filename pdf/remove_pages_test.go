@@ -0,0 +1,69 @@
+package pdf
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestRemovePagesEmptyDocument(t *testing.T) {
+	tests := []struct {
+		name       string
+		pages      string
+		wantErr    error
+		wantRemove string // -p argument of the pages remove call, "" if pdfcpu must not run
+	}{
+		{name: "all pages", pages: "1-5", wantErr: ErrWouldEmptyDocument},
+		{name: "all pages with duplicates", pages: "1-3,3,5,4,1", wantErr: ErrWouldEmptyDocument},
+		{name: "open range", pages: "1-", wantErr: ErrWouldEmptyDocument},
+		{name: "almost all pages", pages: "1-4", wantRemove: "1,2,3,4"},
+		{name: "all but the first", pages: "2-", wantRemove: "2,3,4,5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := installFakeCLI(t, &fakePdfcpu{pages: 5})
+			dir := t.TempDir()
+			inFile := writeFakePDF(t, dir, "in.pdf")
+			outFile := filepath.Join(dir, "out.pdf")
+
+			err := RemovePagesFromPDF(inFile, outFile, tt.pages)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			calls := fake.callsOf("pages", "remove")
+			if tt.wantRemove == "" {
+				if len(calls) != 0 {
+					t.Errorf("pdfcpu ran for an empty result: %v", calls)
+				}
+				return
+			}
+			if len(calls) != 1 || calls[0][3] != tt.wantRemove {
+				t.Errorf("remove calls = %v, want pages %s", calls, tt.wantRemove)
+			}
+		})
+	}
+}
+
+func TestRemovePagesZeroPageOutput(t *testing.T) {
+	fake := installFakeCLI(t, &fakePdfcpu{pages: 5})
+	dir := t.TempDir()
+	inFile := writeFakePDF(t, dir, "in.pdf")
+	outFile := filepath.Join(dir, "out.pdf")
+	// pdfcpu writes a document without pages
+	fake.respond = func(args []string) (string, bool, error) {
+		if args[0] == "info" && slices.Contains(args, outFile) {
+			return "PDF version: 1.7\nPage count: 0\n", true, nil
+		}
+		return "", false, nil
+	}
+
+	// A page count of 0 is reported as an unreadable count, which fails the check too
+	if err := RemovePagesFromPDF(inFile, outFile, "2"); err == nil {
+		t.Fatal("zero-page output accepted")
+	}
+	if _, err := os.Stat(outFile); !os.IsNotExist(err) {
+		t.Errorf("zero-page output left behind: %v", err)
+	}
+}
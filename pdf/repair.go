@@ -0,0 +1,41 @@
+package pdf
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrCorruptPDF is returned when a PDF is too damaged for pdfcpu to rewrite
+var ErrCorruptPDF = errors.New("PDF is corrupt and could not be repaired")
+
+// RepairPDF attempts to recover a damaged PDF by reading it with relaxed validation
+// and rewriting a clean copy using pdfcpu CLI
+func RepairPDF(inFile, outFile string) error {
+	// pdfcpu optimize parses the whole document and rewrites the xref table and
+	// object streams, which fixes most minor structural damage
+	name, args := optimizeCommand(inFile, outFile)
+	output, err := execCommandWithTimeout(DefaultCLITimeout, name, args...)
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, ErrCommandTimeout) || errors.Is(err, ErrUnsupportedEncryption) || errors.Is(err, ErrPasswordRequired) {
+		// A slow rewrite or an encrypted file says nothing about corruption
+		return fmt.Errorf("pdfcpu optimize failed: %w", err)
+	}
+
+	// Collect a diagnostic from relaxed validation to explain why the rewrite failed
+	diagnostic := strings.TrimSpace(string(output))
+	name, args = validateCommand(inFile, "relaxed")
+	validateOutput, validateErr := execCommandWithTimeout(DefaultCLITimeout, name, args...)
+	if validateErr != nil {
+		if validateStr := strings.TrimSpace(string(validateOutput)); validateStr != "" {
+			diagnostic = validateStr
+		}
+	}
+
+	if diagnostic != "" {
+		return fmt.Errorf("%w: %s", ErrCorruptPDF, diagnostic)
+	}
+	return fmt.Errorf("%w: %v", ErrCorruptPDF, err)
+}
@@ -0,0 +1,70 @@
+package pdf
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRepairPDF(t *testing.T) {
+	tests := []struct {
+		name         string
+		optimize     error  // failure of pdfcpu optimize, nil for success
+		validate     error  // failure of pdfcpu validate -mode relaxed
+		wantCorrupt  bool   // ErrCorruptPDF expected
+		wantErr      error  // other sentinel expected
+		wantMessage  string // text the error must contain
+		wantValidate bool   // relaxed validation expected to run
+	}{
+		{name: "rewritten"},
+		{name: "diagnostic from relaxed validation",
+			optimize:    errors.New("dict: corrupt object stream"),
+			validate:    errors.New("validation error: xref table broken at offset 1234"),
+			wantCorrupt: true, wantMessage: "xref table broken", wantValidate: true},
+		{name: "optimize output when validation passes",
+			optimize:    errors.New("dict: corrupt object stream"),
+			wantCorrupt: true, wantMessage: "corrupt object stream", wantValidate: true},
+		{name: "encrypted is not corrupt",
+			optimize: errors.New("pdfcpu: please provide the user password"),
+			wantErr:  ErrPasswordRequired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := installFakeCLI(t, &fakePdfcpu{pages: 1})
+			fake.respond = func(args []string) (string, bool, error) {
+				switch args[0] {
+				case "optimize":
+					if tt.optimize != nil {
+						return "", true, tt.optimize
+					}
+				case "validate":
+					return "", true, tt.validate
+				}
+				return "", false, nil
+			}
+
+			dir := t.TempDir()
+			err := RepairPDF(writeFakePDF(t, dir, "in.pdf"), filepath.Join(dir, "out.pdf"))
+			if tt.optimize == nil {
+				if err != nil {
+					t.Fatalf("err = %v, want success", err)
+				}
+			} else if err == nil {
+				t.Fatal("err = nil, want a failure")
+			}
+			if errors.Is(err, ErrCorruptPDF) != tt.wantCorrupt {
+				t.Errorf("err = %v, ErrCorruptPDF expected %v", err, tt.wantCorrupt)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantMessage != "" && !strings.Contains(err.Error(), tt.wantMessage) {
+				t.Errorf("err = %v, want it to contain %q", err, tt.wantMessage)
+			}
+			if ran := fake.callCount("validate") > 0; ran != tt.wantValidate {
+				t.Errorf("relaxed validation ran: %v, want %v", ran, tt.wantValidate)
+			}
+		})
+	}
+}
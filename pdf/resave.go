@@ -6,9 +6,14 @@ import (
 
 // ResavePDF optimizes and compresses a PDF file using pdfcpu CLI
 func ResavePDF(inFile, outFile string) error {
-	output, err := execCommandWithTimeout(DefaultCLITimeout, "pdfcpu", "optimize", inFile, outFile)
+	if err := checkDistinctFiles(inFile, outFile); err != nil {
+		return err
+	}
+
+	name, args := optimizeCommand(inFile, outFile)
+	output, err := execCommandWithTimeout(DefaultCLITimeout, name, args...)
 	if err != nil {
-		return fmt.Errorf("pdfcpu optimize failed: %v", err)
+		return fmt.Errorf("pdfcpu optimize failed: %w", err)
 	}
 	
 	// Log output only if there's something meaningful (optional)
@@ -0,0 +1,86 @@
+package pdf
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// RotateSpec describes one rotation applied to a set of pages
+type RotateSpec struct {
+	Pages   string `json:"pages"`   // page specification, e.g. "1-3"
+	Degrees int    `json:"degrees"` // clockwise rotation, a multiple of 90
+}
+
+// ValidateRotateSpecs checks the rotation angles and page specifications of every spec
+// against the document's page count
+func ValidateRotateSpecs(specs []RotateSpec, totalPages int) error {
+	if len(specs) == 0 {
+		return fmt.Errorf("no rotations specified")
+	}
+	for i, spec := range specs {
+		if spec.Degrees%90 != 0 {
+			return fmt.Errorf("rotation %d: degrees must be a multiple of 90, got %d", i+1, spec.Degrees)
+		}
+		pageNumbers, err := ParsePageSpecifierWithTotal(spec.Pages, totalPages)
+		if err != nil {
+			return fmt.Errorf("rotation %d: %w", i+1, err)
+		}
+		if err := ValidatePageNumbers(pageNumbers, totalPages); err != nil {
+			return fmt.Errorf("rotation %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// RotatePages rotates the specified pages of a PDF file by degrees using pdfcpu CLI
+func RotatePages(inFile, outFile, pages string, degrees int) error {
+	return RotatePagesMulti(inFile, outFile, []RotateSpec{{Pages: pages, Degrees: degrees}})
+}
+
+// RotatePagesMulti applies several (pages, degrees) rotations in order by chaining
+// pdfcpu rotate calls: inFile -> temp1 -> ... -> outFile
+func RotatePagesMulti(inFile, outFile string, specs []RotateSpec) error {
+	// Validate all page ranges up front so nothing runs on a bad request
+	totalPages, err := getPageCount(inFile)
+	if err != nil {
+		return fmt.Errorf("failed to get page count: %w", err)
+	}
+	if err := ValidateRotateSpecs(specs, totalPages); err != nil {
+		return err
+	}
+
+	if err := checkDistinctFiles(inFile, outFile); err != nil {
+		return err
+	}
+
+	// Intermediates live in a private directory so concurrent requests never collide
+	workDir, err := os.MkdirTemp(filepath.Dir(outFile), "rotate_")
+	if err != nil {
+		return fmt.Errorf("failed to create work directory: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	currentFile := inFile
+
+	for i, spec := range specs {
+		targetFile := outFile
+		if i < len(specs)-1 {
+			targetFile = filepath.Join(workDir, fmt.Sprintf("step_%d.pdf", i))
+		}
+
+		pageNumbers, _ := ParsePageSpecifierWithTotal(spec.Pages, totalPages)
+		name, args := rotateCommand(currentFile, targetFile, pageNumbers, spec.Degrees)
+		output, err := execCommandWithTimeout(DefaultCLITimeout, name, args...)
+		if err != nil {
+			if outputStr := string(output); outputStr != "" {
+				return fmt.Errorf("pdfcpu rotate failed for pages %s: %w\nOutput: %s", spec.Pages, err, outputStr)
+			}
+			return fmt.Errorf("pdfcpu rotate failed for pages %s: %w", spec.Pages, err)
+		}
+
+		currentFile = targetFile
+	}
+
+	return nil
+}
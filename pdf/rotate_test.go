@@ -0,0 +1,77 @@
+package pdf
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestRotatePagesMultiChainsCalls(t *testing.T) {
+	fake := installFakeCLI(t, &fakePdfcpu{pages: 6})
+	dir := t.TempDir()
+	inFile := writeFakePDF(t, dir, "in.pdf")
+	outFile := filepath.Join(dir, "out.pdf")
+
+	specs := []RotateSpec{{Pages: "1-3", Degrees: 90}, {Pages: "4-6", Degrees: 180}, {Pages: "even", Degrees: -90}}
+	if err := RotatePagesMulti(inFile, outFile, specs); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := fake.callsOf("rotate")
+	if len(calls) != len(specs) {
+		t.Fatalf("%d rotate calls, want %d: %v", len(calls), len(specs), calls)
+	}
+	wantPages := []string{"1,2,3", "4,5,6", "2,4,6"}
+	wantDegrees := []string{"90", "180", "-90"}
+	for i, call := range calls {
+		// rotate -p pages -- inFile degrees outFile
+		if len(call) != 7 || call[2] != wantPages[i] || call[5] != wantDegrees[i] {
+			t.Errorf("call %d = %v, want pages %s by %s", i, call, wantPages[i], wantDegrees[i])
+			continue
+		}
+		if i == 0 && call[4] != inFile {
+			t.Errorf("first call reads %s, want the input", call[4])
+		}
+		if i > 0 && call[4] != calls[i-1][6] {
+			t.Errorf("call %d reads %s, want the previous output %s", i, call[4], calls[i-1][6])
+		}
+	}
+	if last := calls[len(calls)-1][6]; last != outFile {
+		t.Errorf("last call writes %s, want the output", last)
+	}
+
+	// Intermediate files are removed
+	entries, _ := os.ReadDir(dir)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if !slices.Equal(names, []string{"in.pdf", "out.pdf"}) {
+		t.Errorf("directory holds %v after rotating", names)
+	}
+}
+
+func TestRotatePagesMultiValidatesUpFront(t *testing.T) {
+	tests := []struct {
+		name  string
+		specs []RotateSpec
+	}{
+		{"no specs", nil},
+		{"page out of range", []RotateSpec{{Pages: "1-3", Degrees: 90}, {Pages: "5-7", Degrees: 90}}},
+		{"angle", []RotateSpec{{Pages: "1", Degrees: 90}, {Pages: "2", Degrees: 45}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := installFakeCLI(t, &fakePdfcpu{pages: 6})
+			dir := t.TempDir()
+			inFile := writeFakePDF(t, dir, "in.pdf")
+			if err := RotatePagesMulti(inFile, filepath.Join(dir, "out.pdf"), tt.specs); err == nil {
+				t.Fatal("invalid rotations were accepted")
+			}
+			if calls := fake.callCount("rotate"); calls != 0 {
+				t.Errorf("%d rotate calls before validation failed", calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package pdf
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// SplitPDF writes one PDF per page range of inFile into outDir, named part_1.pdf,
+// part_2.pdf, ... in range order, and returns their paths. Every range is checked against
+// the page count before anything is written; ranges may overlap.
+func SplitPDF(inFile, outDir string, ranges [][2]int) ([]string, error) {
+	if len(ranges) == 0 {
+		return nil, fmt.Errorf("no page ranges to split at")
+	}
+	totalPages, err := getPageCount(inFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get page count: %w", err)
+	}
+	for _, r := range ranges {
+		if r[0] < 1 || r[0] > r[1] {
+			return nil, fmt.Errorf("invalid range %d-%d", r[0], r[1])
+		}
+		if r[1] > totalPages {
+			return nil, fmt.Errorf("%w: range %d-%d exceeds total pages (%d)", ErrPageOutOfRange, r[0], r[1], totalPages)
+		}
+	}
+
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	files := make([]string, 0, len(ranges))
+	for i, r := range ranges {
+		outFile := filepath.Join(outDir, fmt.Sprintf("part_%d.pdf", i+1))
+		if err := ExtractPages(inFile, outFile, fmt.Sprintf("%d-%d", r[0], r[1])); err != nil {
+			return nil, fmt.Errorf("failed to extract pages %d-%d: %w", r[0], r[1], err)
+		}
+		files = append(files, outFile)
+	}
+	return files, nil
+}
@@ -0,0 +1,87 @@
+package pdf
+
+import (
+	"errors"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Validation issue severities
+const (
+	IssueSeverityError   = "error"
+	IssueSeverityWarning = "warning"
+)
+
+// ValidationIssue is one problem reported by pdfcpu validate
+type ValidationIssue struct {
+	Severity string `json:"severity"`         // IssueSeverityError or IssueSeverityWarning
+	Message  string `json:"message"`          // pdfcpu's message without its "pdfcpu:" prefix
+	Object   int    `json:"object,omitempty"` // object number, if pdfcpu named one
+	Page     int    `json:"page,omitempty"`   // page number, if pdfcpu named one
+}
+
+var (
+	issueObjectPattern = regexp.MustCompile(`(?i)\bobj(?:ect|Nr|#)?[#:=\s]*(\d+)`)
+	issuePagePattern   = regexp.MustCompile(`(?i)\bpage(?:Nr)?[#:=\s]*(\d+)`)
+)
+
+// ValidateVerbose runs pdfcpu validate in strict mode and returns the reported issues.
+// A valid PDF yields an empty list; failures to run pdfcpu at all (timeouts, unsupported
+// encryption) are returned as errors rather than issues.
+func ValidateVerbose(inFile string) ([]ValidationIssue, error) {
+	// Warnings may be written to stderr even when validation passes, so both streams are read
+	name, args := validateCommand(inFile, "strict")
+	stdout, stderr, err := execCommandStreams(AnalysisTimeout, name, args...)
+	output := append(append(stdout, '\n'), stderr...)
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return nil, err
+		}
+	}
+
+	issues := parseValidateOutput(string(output))
+	if err != nil && len(issues) == 0 {
+		// pdfcpu failed without a recognizable message; report its output as one issue
+		message := strings.TrimSpace(string(output))
+		if message == "" {
+			message = err.Error()
+		}
+		issues = append(issues, ValidationIssue{Severity: IssueSeverityError, Message: message})
+	}
+	return issues, nil
+}
+
+// parseValidateOutput turns the error and warning lines of pdfcpu validate into issues
+func parseValidateOutput(output string) []ValidationIssue {
+	issues := []ValidationIssue{}
+	for _, line := range outputLines(output) {
+		line = strings.TrimSpace(line)
+		lower := strings.ToLower(line)
+
+		severity := ""
+		switch {
+		case strings.Contains(lower, "error"):
+			severity = IssueSeverityError
+		case strings.Contains(lower, "warning"):
+			severity = IssueSeverityWarning
+		default:
+			continue // progress lines ("validating(mode=strict) ...", "validation ok")
+		}
+
+		issue := ValidationIssue{
+			Severity: severity,
+			Message:  strings.TrimSpace(strings.TrimPrefix(line, "pdfcpu:")),
+		}
+		if m := issueObjectPattern.FindStringSubmatch(line); m != nil {
+			issue.Object, _ = strconv.Atoi(m[1])
+		}
+		if m := issuePagePattern.FindStringSubmatch(line); m != nil {
+			issue.Page, _ = strconv.Atoi(m[1])
+		}
+		issues = append(issues, issue)
+	}
+	return issues
+}
@@ -0,0 +1,50 @@
+package pdf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseValidateOutput(t *testing.T) {
+	// As printed by pdfcpu validate -mode strict for a damaged file, with progress lines
+	const output = `validating(mode=strict) broken.pdf ...
+pdfcpu: validateDateEntry: invalid date "D:2023" for key CreationDate
+Warning: page 3: missing required entry MediaBox, inheriting from parent
+pdfcpu: dereferenceObject: problem dereferencing object 12: error reading stream
+WARNING: obj#45 unexpected key "Foo" in font dict
+
+Error: validation error (obj#7): page 2: invalid annotation subtype
+validation ok
+`
+	want := []ValidationIssue{
+		{Severity: IssueSeverityWarning, Message: "Warning: page 3: missing required entry MediaBox, inheriting from parent", Page: 3},
+		{Severity: IssueSeverityError, Message: "dereferenceObject: problem dereferencing object 12: error reading stream", Object: 12},
+		{Severity: IssueSeverityWarning, Message: `WARNING: obj#45 unexpected key "Foo" in font dict`, Object: 45},
+		{Severity: IssueSeverityError, Message: "Error: validation error (obj#7): page 2: invalid annotation subtype", Object: 7, Page: 2},
+	}
+	if got := parseValidateOutput(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseValidateOutput =\n%+v\nwant\n%+v", got, want)
+	}
+
+	if got := parseValidateOutput("validating(mode=strict) ok.pdf ...\nvalidation ok\n"); got == nil || len(got) != 0 {
+		t.Errorf("issues for a valid file = %#v, want an empty list", got)
+	}
+}
+
+func TestValidateVerboseValidFile(t *testing.T) {
+	f := installFakeCLI(t, &fakePdfcpu{respond: func(args []string) (string, bool, error) {
+		return "validating(mode=strict) in.pdf ...\nvalidation ok\n", args[0] == "validate", nil
+	}})
+	inFile := writeFakePDF(t, t.TempDir(), "in.pdf")
+
+	issues, err := ValidateVerbose(inFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if issues == nil || len(issues) != 0 {
+		t.Errorf("issues = %#v, want an empty list", issues)
+	}
+	if calls := f.callsOf("validate", "-mode", "strict"); len(calls) != 1 {
+		t.Errorf("strict validation ran %d times, want 1: %v", len(calls), f.calls)
+	}
+}
@@ -0,0 +1,138 @@
+package pdf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// watermarkPositions are the pdfcpu anchors a watermark can be placed at
+var watermarkPositions = map[string]bool{
+	"tl": true, "tc": true, "tr": true,
+	"l": true, "c": true, "r": true,
+	"bl": true, "bc": true, "br": true,
+}
+
+// WatermarkOptions styles the text or image stamped by AddTextWatermark and AddImageWatermark
+type WatermarkOptions struct {
+	// Position is the pdfcpu anchor the watermark is centered on: "c" for the page center,
+	// "tl", "tc", "tr", "l", "r", "bl", "bc" or "br" for the corners and edges
+	Position string
+
+	// Scale is the watermark width as a fraction of the page width (0 < Scale <= 1)
+	Scale float64
+
+	// FontSize is the font size in points for text, overriding Scale; 0 uses Scale
+	FontSize int
+
+	// Diagonal runs the text from the lower left to the upper right corner, whatever the
+	// page's aspect ratio; Rotation is ignored when set
+	Diagonal bool
+
+	// Rotation is the angle of the text in degrees counterclockwise
+	Rotation float64
+
+	// Opacity is between 0 (invisible) and 1 (opaque)
+	Opacity float64
+
+	// Color is the text color as "#RRGGBB" or "#RGB"; images keep their colors
+	Color string
+}
+
+// DefaultWatermarkOptions returns the style used when a caller sets nothing: a diagonal,
+// half-transparent gray watermark in the page center, half as wide as the page
+func DefaultWatermarkOptions() WatermarkOptions {
+	return WatermarkOptions{
+		Position: "c",
+		Scale:    0.5,
+		Diagonal: true,
+		Opacity:  0.5,
+		Color:    "#808080",
+	}
+}
+
+// ValidateWatermarkOptions checks the position, scale, font size, opacity and color of opts
+func ValidateWatermarkOptions(opts WatermarkOptions) error {
+	if !watermarkPositions[opts.Position] {
+		return fmt.Errorf("invalid watermark position: %q (expected c, tl, tc, tr, l, r, bl, bc or br)", opts.Position)
+	}
+	if opts.Scale <= 0 || opts.Scale > 1 {
+		return fmt.Errorf("scale must be greater than 0 and at most 1, got %g", opts.Scale)
+	}
+	if opts.FontSize < 0 {
+		return fmt.Errorf("font size must not be negative, got %d", opts.FontSize)
+	}
+	if opts.Opacity < 0 || opts.Opacity > 1 {
+		return fmt.Errorf("opacity must be between 0 and 1, got %g", opts.Opacity)
+	}
+	if !hexColorPattern.MatchString(strings.TrimSpace(opts.Color)) {
+		return fmt.Errorf("invalid watermark color: %q (expected #RRGGBB or #RGB)", opts.Color)
+	}
+	return nil
+}
+
+// AddTextWatermark stamps text on every page using pdfcpu CLI, by default
+// (DefaultWatermarkOptions) diagonally across the page center. Stamps are drawn over the
+// page content, and a stamped PDF can be cleaned again with RemoveElementFromPDF(..., "watermark").
+func AddTextWatermark(inFile, outFile, text string, opts WatermarkOptions) error {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return fmt.Errorf("watermark text must not be empty")
+	}
+	if err := ValidateWatermarkOptions(opts); err != nil {
+		return err
+	}
+
+	return addStamp(inFile, outFile, "text", text, textWatermarkDescription(opts))
+}
+
+// AddImageWatermark stamps the PNG or JPEG image logoFile on every page using pdfcpu CLI,
+// placed and scaled by opts (Color and FontSize do not apply). The stamped image is what
+// AnalyzeUnwantedElements reports as a repeating watermark, so it can be removed again.
+func AddImageWatermark(inFile, logoFile, outFile string, opts WatermarkOptions) error {
+	if err := ValidateWatermarkOptions(opts); err != nil {
+		return err
+	}
+
+	description := append(watermarkDescription(opts), fmt.Sprintf("sc:%g rel", opts.Scale))
+	return addStamp(inFile, outFile, "image", logoFile, description)
+}
+
+// watermarkDescription returns the pdfcpu stamp description entries shared by text and
+// image watermarks: position, rotation and opacity
+func watermarkDescription(opts WatermarkOptions) []string {
+	description := []string{"pos:" + opts.Position}
+	if opts.Diagonal {
+		description = append(description, "d:1")
+	} else {
+		description = append(description, fmt.Sprintf("rot:%g", opts.Rotation))
+	}
+	return append(description, fmt.Sprintf("op:%g", opts.Opacity))
+}
+
+// textWatermarkDescription returns the pdfcpu stamp description of a text watermark
+func textWatermarkDescription(opts WatermarkOptions) []string {
+	fill, _ := ParseRedactColor(opts.Color)
+
+	description := watermarkDescription(opts)
+	if opts.FontSize > 0 {
+		// An absolute scale of 1 keeps the requested point size
+		description = append(description, fmt.Sprintf("points:%d", opts.FontSize), "sc:1 abs")
+	} else {
+		description = append(description, fmt.Sprintf("sc:%g rel", opts.Scale))
+	}
+	return append(description, fmt.Sprintf("fillc:#%02X%02X%02X", fill.R, fill.G, fill.B))
+}
+
+// addStamp runs pdfcpu stamp add -mode mode -- content description inFile outFile
+func addStamp(inFile, outFile, mode, content string, description []string) error {
+	name, args := stampCommand(inFile, outFile, mode, content, description)
+	output, err := execCommandWithTimeout(DefaultCLITimeout, name, args...)
+	if err != nil {
+		if outputStr := string(output); outputStr != "" {
+			return fmt.Errorf("pdfcpu stamp add failed: %w\nOutput: %s", err, outputStr)
+		}
+		return fmt.Errorf("pdfcpu stamp add failed: %w", err)
+	}
+
+	return nil
+}
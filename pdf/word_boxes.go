@@ -0,0 +1,231 @@
+package pdf
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// WordBox is one word drawn on a page with the box that encloses it in PDF user space
+// (points, origin bottom-left). For rotated text the box is the axis-aligned box around
+// the rotated word and Rotation gives the angle of its baseline.
+type WordBox struct {
+	Text string `json:"text"`
+	BBox
+	Rotation float64 `json:"rotation"` // degrees counterclockwise from horizontal
+}
+
+// textState holds the text state parameters that, like the CTM, are saved by q and restored by Q
+type textState struct {
+	charSpacing float64 // Tc
+	wordSpacing float64 // Tw
+	scale       float64 // Tz, as a fraction
+	rise        float64 // Ts
+	leading     float64 // TL
+	fontSize    float64 // Tf
+}
+
+// ExtractWordBoxes returns the words drawn on one page with their bounding boxes. Glyph
+// positions follow the text-showing operators and the text and transformation matrices;
+// glyph widths are not read from the fonts, so every glyph is assumed GlyphWidthEm wide
+// and boxes of proportional fonts are approximate. Text in XObjects is not included.
+func ExtractWordBoxes(inFile string, page int) ([]WordBox, error) {
+	totalPages, err := getPageCount(inFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get page count: %w", err)
+	}
+	if err := ValidatePageNumbers([]int{page}, totalPages); err != nil {
+		return nil, err
+	}
+
+	extractDir, err := os.MkdirTemp(filepath.Dir(inFile), "content_")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create content extract directory: %w", err)
+	}
+	defer os.RemoveAll(extractDir)
+
+	content, err := extractPageContent(context.Background(), inFile, extractDir, page)
+	if err != nil {
+		return nil, err
+	}
+	return findWordBoxes(content), nil
+}
+
+// findWordBoxes walks a page content stream and returns the box of every word, splitting
+// words at spaces, at large TJ adjustments and wherever the text is repositioned
+func findWordBoxes(content string) []WordBox {
+	words := []WordBox{}
+	ctm := identityMatrix
+	ts := textState{scale: 1}
+	type savedState struct {
+		ctm matrix
+		ts  textState
+	}
+	stack := []savedState{}
+	operands := []string{}
+
+	var tm, tlm matrix
+	var current WordBox
+	var text strings.Builder
+
+	flush := func() {
+		if text.Len() > 0 {
+			current.Text = text.String()
+			words = append(words, current)
+		}
+		current = WordBox{}
+		text.Reset()
+	}
+	advance := func(tx float64) {
+		tm = matrix{1, 0, 0, 1, tx, 0}.multiply(tm)
+	}
+	show := func(s string) {
+		for _, r := range s {
+			if unicode.IsSpace(r) {
+				flush()
+			} else {
+				trm := matrix{ts.fontSize * ts.scale, 0, 0, ts.fontSize, 0, ts.rise}.multiply(tm).multiply(ctm)
+				glyph := matrix{GlyphWidthEm, 0, 0, GlyphAscentEm + GlyphDescentEm, 0, -GlyphDescentEm}.multiply(trm).unitSquareBBox()
+				if text.Len() == 0 {
+					current.BBox = glyph
+					current.Rotation = math.Atan2(trm[1], trm[0]) * 180 / math.Pi
+				} else {
+					current.BBox = unionBBox(current.BBox, glyph)
+				}
+				text.WriteRune(r)
+			}
+			tx := GlyphWidthEm*ts.fontSize + ts.charSpacing
+			if r == ' ' {
+				tx += ts.wordSpacing
+			}
+			advance(tx * ts.scale)
+		}
+	}
+	nextLine := func(tx, ty float64) {
+		flush()
+		tlm = matrix{1, 0, 0, 1, tx, ty}.multiply(tlm)
+		tm = tlm
+	}
+
+	for _, tok := range tokenizeContent(content) {
+		if !tok.operator {
+			operands = append(operands, tok.value)
+			continue
+		}
+
+		switch tok.value {
+		case "q":
+			stack = append(stack, savedState{ctm: ctm, ts: ts})
+		case "Q":
+			if len(stack) > 0 {
+				ctm, ts = stack[len(stack)-1].ctm, stack[len(stack)-1].ts
+				stack = stack[:len(stack)-1]
+			}
+		case "cm":
+			if v, ok := operandFloats(operands, 6); ok {
+				ctm = matrix{v[0], v[1], v[2], v[3], v[4], v[5]}.multiply(ctm)
+			}
+		case "BT":
+			flush()
+			tm, tlm = identityMatrix, identityMatrix
+		case "ET":
+			flush()
+		case "Tf":
+			if v, ok := operandFloats(operands, 1); ok {
+				ts.fontSize = v[0]
+			}
+		case "Tc":
+			if v, ok := operandFloats(operands, 1); ok {
+				ts.charSpacing = v[0]
+			}
+		case "Tw":
+			if v, ok := operandFloats(operands, 1); ok {
+				ts.wordSpacing = v[0]
+			}
+		case "Tz":
+			if v, ok := operandFloats(operands, 1); ok {
+				ts.scale = v[0] / 100
+			}
+		case "Ts":
+			if v, ok := operandFloats(operands, 1); ok {
+				ts.rise = v[0]
+			}
+		case "TL":
+			if v, ok := operandFloats(operands, 1); ok {
+				ts.leading = v[0]
+			}
+		case "Tm":
+			if v, ok := operandFloats(operands, 6); ok {
+				flush()
+				tlm = matrix{v[0], v[1], v[2], v[3], v[4], v[5]}
+				tm = tlm
+			}
+		case "Td":
+			if v, ok := operandFloats(operands, 2); ok {
+				nextLine(v[0], v[1])
+			}
+		case "TD":
+			if v, ok := operandFloats(operands, 2); ok {
+				ts.leading = -v[1]
+				nextLine(v[0], v[1])
+			}
+		case "T*":
+			nextLine(0, -ts.leading)
+		case "Tj":
+			if len(operands) > 0 {
+				show(decodePDFString(operands[len(operands)-1]))
+			}
+		case "'":
+			nextLine(0, -ts.leading)
+			if len(operands) > 0 {
+				show(decodePDFString(operands[len(operands)-1]))
+			}
+		case "\"":
+			// aw ac (string) "
+			if v, ok := operandFloats(operands[:max(len(operands)-1, 0)], 2); ok {
+				ts.wordSpacing, ts.charSpacing = v[0], v[1]
+			}
+			nextLine(0, -ts.leading)
+			if len(operands) > 0 {
+				show(decodePDFString(operands[len(operands)-1]))
+			}
+		case "TJ":
+			if len(operands) == 0 {
+				break
+			}
+			array := strings.TrimSpace(operands[len(operands)-1])
+			array = strings.TrimSuffix(strings.TrimPrefix(array, "["), "]")
+			for _, element := range tokenizeContent(array) {
+				if strings.HasPrefix(element.value, "(") || strings.HasPrefix(element.value, "<") {
+					show(decodePDFString(element.value))
+					continue
+				}
+				if v, err := strconv.ParseFloat(element.value, 64); err == nil {
+					// Large negative adjustments are word spaces, as in decodeTJArray
+					if v < -200 {
+						flush()
+					}
+					advance(-v / 1000 * ts.fontSize * ts.scale)
+				}
+			}
+		}
+		operands = operands[:0]
+	}
+	flush()
+
+	return words
+}
+
+// unionBBox returns the smallest box enclosing both a and b
+func unionBBox(a, b BBox) BBox {
+	minX := math.Min(a.X, b.X)
+	minY := math.Min(a.Y, b.Y)
+	maxX := math.Max(a.X+a.Width, b.X+b.Width)
+	maxY := math.Max(a.Y+a.Height, b.Y+b.Height)
+	return BBox{X: minX, Y: minY, Width: maxX - minX, Height: maxY - minY}
+}
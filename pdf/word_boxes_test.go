@@ -0,0 +1,99 @@
+package pdf
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+// sameWordBoxes reports whether got and want list the same words with boxes within 1e-6 points
+func sameWordBoxes(got, want []WordBox) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-6 }
+	for i := range got {
+		g, w := got[i], want[i]
+		if g.Text != w.Text || !near(g.X, w.X) || !near(g.Y, w.Y) || !near(g.Width, w.Width) ||
+			!near(g.Height, w.Height) || !near(g.Rotation, w.Rotation) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestFindWordBoxes(t *testing.T) {
+	// With 10pt text a glyph is 5pt wide and spans 2pt below the baseline to 8pt above
+	tests := []struct {
+		name    string
+		content string
+		want    []WordBox
+	}{
+		{name: "words split at spaces", content: "BT /F1 10 Tf 100 700 Td (Hello World) Tj ET", want: []WordBox{
+			{Text: "Hello", BBox: BBox{X: 100, Y: 698, Width: 25, Height: 10}},
+			{Text: "World", BBox: BBox{X: 130, Y: 698, Width: 25, Height: 10}},
+		}},
+		{name: "next lines", content: "BT /F1 10 Tf 14 TL 72 720 Td (One) Tj T* (Two) Tj 0 -30 TD (Three) ' ET", want: []WordBox{
+			{Text: "One", BBox: BBox{X: 72, Y: 718, Width: 15, Height: 10}},
+			{Text: "Two", BBox: BBox{X: 72, Y: 704, Width: 15, Height: 10}},
+			// TD sets the leading to 30, then ' moves down by it again
+			{Text: "Three", BBox: BBox{X: 72, Y: 644, Width: 25, Height: 10}},
+		}},
+		{name: "large TJ adjustment is a word space", content: "BT /F1 20 Tf 50 500 Td [(Foo) -250 (Bar) -50 (Baz)] TJ ET", want: []WordBox{
+			{Text: "Foo", BBox: BBox{X: 50, Y: 496, Width: 30, Height: 20}},
+			// 250/1000 em at 20pt moves Bar 5pt right; the small kern keeps BarBaz one word
+			{Text: "BarBaz", BBox: BBox{X: 85, Y: 496, Width: 61, Height: 20}},
+		}},
+		{name: "CTM scales and Q restores it", content: "q 2 0 0 2 0 0 cm BT /F1 10 Tf 10 10 Td (A) Tj ET Q BT /F1 10 Tf 10 10 Td (B) Tj ET", want: []WordBox{
+			{Text: "A", BBox: BBox{X: 20, Y: 16, Width: 10, Height: 20}},
+			{Text: "B", BBox: BBox{X: 10, Y: 8, Width: 5, Height: 10}},
+		}},
+		{name: "horizontal scaling and character spacing", content: "BT /F1 10 Tf 50 Tz 1 Tc 0 0 Td (ab) Tj ET", want: []WordBox{
+			// Each glyph is 2.5pt wide and advances (5+1)*0.5 = 3pt
+			{Text: "ab", BBox: BBox{X: 0, Y: -2, Width: 5.5, Height: 10}},
+		}},
+		{name: "text rotated 90 degrees", content: "BT /F1 10 Tf 0 1 -1 0 300 100 Tm (Up) Tj ET", want: []WordBox{
+			// The baseline runs up from (300,100); ascenders point left
+			{Text: "Up", BBox: BBox{X: 292, Y: 100, Width: 10, Height: 10}, Rotation: 90},
+		}},
+		{name: "hex strings", content: "BT /F1 10 Tf 0 0 Td <4869> Tj ET", want: []WordBox{
+			{Text: "Hi", BBox: BBox{X: 0, Y: -2, Width: 10, Height: 10}},
+		}},
+		{name: "no text", content: "q 100 0 0 100 0 0 cm /Im0 Do Q", want: []WordBox{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := findWordBoxes(tt.content); !sameWordBoxes(got, tt.want) {
+				t.Errorf("findWordBoxes = %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractWordBoxes(t *testing.T) {
+	installFakeCLI(t, &fakePdfcpu{pages: 3, contents: map[int]string{
+		2: "BT /F1 12 Tf 72 720 Td (Page two) Tj ET",
+	}})
+	inFile := writeFakePDF(t, t.TempDir(), "in.pdf")
+
+	words, err := ExtractWordBoxes(inFile, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []WordBox{
+		{Text: "Page", BBox: BBox{X: 72, Y: 717.6, Width: 24, Height: 12}},
+		{Text: "two", BBox: BBox{X: 102, Y: 717.6, Width: 18, Height: 12}},
+	}
+	if !sameWordBoxes(words, want) {
+		t.Errorf("ExtractWordBoxes = %+v, want %+v", words, want)
+	}
+
+	for _, page := range []int{0, 4} {
+		if _, err := ExtractWordBoxes(inFile, page); err == nil {
+			t.Errorf("page %d of 3 was accepted", page)
+		}
+	}
+	if matches, _ := filepath.Glob(filepath.Join(filepath.Dir(inFile), "content_*")); len(matches) != 0 {
+		t.Errorf("extract directories left behind: %v", matches)
+	}
+}
@@ -0,0 +1,94 @@
+package pdf
+
+import (
+	"context"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// cliSlots bounds the number of external CLI processes (pdfcpu, OCR engine) running at once
+var cliSlots = make(chan struct{}, runtime.NumCPU())
+
+// SetMaxConcurrentCLI sets how many external CLI processes may run concurrently
+// Must be called at startup before any PDF operation runs
+func SetMaxConcurrentCLI(n int) {
+	if n > 0 {
+		cliSlots = make(chan struct{}, n)
+	}
+}
+
+// MaxConcurrentCLI returns the current limit on concurrent CLI processes
+func MaxConcurrentCLI() int {
+	return cap(cliSlots)
+}
+
+// acquireCLISlotContext blocks until a CLI process slot is free and returns its release
+// function, or gives up when ctx is cancelled first
+func acquireCLISlotContext(ctx context.Context) (func(), error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	slots := cliSlots
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// runPerPage calls fn for every page on a bounded pool of workers and collects the results
+// The pool is sized to the CLI concurrency limit, since each call typically runs one CLI
+// process. The first error (in page order) is returned after all workers have finished.
+func runPerPage[T any](pages []int, fn func(page int) (T, error)) (map[int]T, error) {
+	workers := min(cap(cliSlots), len(pages))
+
+	jobs := make(chan int)
+	var mu sync.Mutex
+	results := make(map[int]T, len(pages))
+	errs := make(map[int]error)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for page := range jobs {
+				result, err := fn(page)
+				mu.Lock()
+				if err != nil {
+					errs[page] = err
+				} else {
+					results[page] = result
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, page := range pages {
+		jobs <- page
+	}
+	close(jobs)
+	wg.Wait()
+
+	if len(errs) > 0 {
+		failed := make([]int, 0, len(errs))
+		for page := range errs {
+			failed = append(failed, page)
+		}
+		sort.Ints(failed)
+		return nil, errs[failed[0]]
+	}
+	return results, nil
+}
+
+// pageRange returns the page numbers 1..totalPages
+func pageRange(totalPages int) []int {
+	pages := make([]int, totalPages)
+	for i := range pages {
+		pages[i] = i + 1
+	}
+	return pages
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	pdfPkg "pdf_editor/pdf"
+)
+
+// selfTestPDF is a three-page sample with the same small image drawn on every page
+//
+//go:embed selftest/sample.pdf
+var selfTestPDF []byte
+
+// selfTestBaseline holds the results the operations must produce on selfTestPDF
+//
+//go:embed selftest/expected.json
+var selfTestBaseline []byte
+
+// selfTestExpected is the parsed selfTestBaseline
+type selfTestExpected struct {
+	Pages              int `json:"pages"`
+	PagesAfterRemove   int `json:"pages_after_remove"`
+	MinImageCandidates int `json:"min_image_candidates"`
+}
+
+// selfTestResult is the outcome of one operation of the self-test
+type selfTestResult struct {
+	Name     string
+	Duration time.Duration
+	Err      error
+}
+
+// runSelfTest runs every core operation against the embedded sample in a private
+// directory under tempDir and reports each one. It catches environment problems (missing
+// pdfcpu features, unwritable temp dirs) that the health check cannot see.
+func runSelfTest(tempDir string) ([]selfTestResult, error) {
+	var expected selfTestExpected
+	if err := json.Unmarshal(selfTestBaseline, &expected); err != nil {
+		return nil, fmt.Errorf("invalid self-test baseline: %v", err)
+	}
+
+	if err := os.MkdirAll(tempDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %v", err)
+	}
+	workDir, err := os.MkdirTemp(tempDir, "selftest_")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create work directory: %v", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	sample := filepath.Join(workDir, "sample.pdf")
+	if err := os.WriteFile(sample, selfTestPDF, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write sample: %v", err)
+	}
+	out := func(name string) string { return filepath.Join(workDir, name+".pdf") }
+
+	// expectPages checks the page count of an operation's output against the baseline
+	expectPages := func(file string, want int) error {
+		got, err := pdfPkg.PageCount(file)
+		if err != nil {
+			return err
+		}
+		if got != want {
+			return fmt.Errorf("expected %d pages, got %d", want, got)
+		}
+		return nil
+	}
+
+	var candidateID string
+	steps := []struct {
+		name string
+		run  func() error
+	}{
+		{"info", func() error { return expectPages(sample, expected.Pages) }},
+		{"remove-pages", func() error {
+			if err := pdfPkg.RemovePagesFromPDF(sample, out("remove_pages"), "1"); err != nil {
+				return err
+			}
+			return expectPages(out("remove_pages"), expected.PagesAfterRemove)
+		}},
+		{"resave", func() error {
+			if err := pdfPkg.ResavePDF(sample, out("resave")); err != nil {
+				return err
+			}
+			return expectPages(out("resave"), expected.Pages)
+		}},
+		{"analyze", func() error {
+			analysis, err := pdfPkg.AnalyzeUnwantedElements(sample)
+			if err != nil {
+				return err
+			}
+			if len(analysis.ImageCandidates) < expected.MinImageCandidates {
+				return fmt.Errorf("expected at least %d image candidates, got %d", expected.MinImageCandidates, len(analysis.ImageCandidates))
+			}
+			if len(analysis.ImageCandidates) > 0 {
+				candidateID = analysis.ImageCandidates[0].ID
+			}
+			return nil
+		}},
+		{"remove-elements", func() error {
+			if candidateID == "" {
+				return fmt.Errorf("skipped: analyze found no candidate to remove")
+			}
+			if err := pdfPkg.RemoveElementsByIDs(sample, out("remove_elements"), "image", []string{candidateID}); err != nil {
+				return err
+			}
+			return expectPages(out("remove_elements"), expected.Pages)
+		}},
+	}
+
+	results := make([]selfTestResult, 0, len(steps))
+	for _, step := range steps {
+		start := time.Now()
+		err := step.run()
+		results = append(results, selfTestResult{Name: step.name, Duration: time.Since(start), Err: err})
+	}
+	return results, nil
+}
+
+// runSelfTestCLI prints the self-test results and returns the process exit code
+func runSelfTestCLI(tempDir string) int {
+	results, err := runSelfTest(tempDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	code := 0
+	for _, result := range results {
+		status := "PASS"
+		if result.Err != nil {
+			status = "FAIL"
+			code = 1
+		}
+		fmt.Printf("%s  %-16s %8s", status, result.Name, result.Duration.Round(time.Millisecond))
+		if result.Err != nil {
+			fmt.Printf("  %v", result.Err)
+		}
+		fmt.Println()
+	}
+	return code
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"regexp"
+	"testing"
+)
+
+func TestSelfTestSampleMatchesBaseline(t *testing.T) {
+	var expected selfTestExpected
+	if err := json.Unmarshal(selfTestBaseline, &expected); err != nil {
+		t.Fatalf("baseline: %v", err)
+	}
+	if !bytes.HasPrefix(selfTestPDF, []byte("%PDF-")) {
+		t.Fatal("embedded sample is not a PDF")
+	}
+	pages := len(regexp.MustCompile(`/Type /Page\b[^s]`).FindAll(selfTestPDF, -1))
+	if pages != expected.Pages || expected.PagesAfterRemove != pages-1 {
+		t.Errorf("sample has %d pages, baseline expects %d and %d after removing one", pages, expected.Pages, expected.PagesAfterRemove)
+	}
+	if expected.MinImageCandidates < 1 {
+		t.Error("baseline does not expect the repeated image to be found")
+	}
+}
+
+func TestSelfTestPasses(t *testing.T) {
+	// Runs the real operations, so it needs the pdfcpu the server is deployed with
+	if _, err := exec.LookPath("pdfcpu"); err != nil {
+		t.Skip("pdfcpu is not installed")
+	}
+	results, err := runSelfTest(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"info", "remove-pages", "resave", "analyze", "remove-elements"}
+	if len(results) != len(want) {
+		t.Fatalf("%d results, want %d", len(results), len(want))
+	}
+	for i, result := range results {
+		if result.Name != want[i] {
+			t.Errorf("result %d is %s, want %s", i, result.Name, want[i])
+		}
+		if result.Err != nil {
+			t.Errorf("%s failed: %v", result.Name, result.Err)
+		}
+	}
+}
+
+func TestSelfTestReportsFailures(t *testing.T) {
+	// The fake pdfcpu only implements optimize, so every page count check fails
+	installFakePdfcpu(t)
+	tempDir := t.TempDir()
+	results, err := runSelfTest(tempDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, result := range results {
+		if result.Err == nil {
+			t.Errorf("%s passed without a working pdfcpu", result.Name)
+		}
+	}
+	if entries, _ := os.ReadDir(tempDir); len(entries) != 0 {
+		t.Errorf("work directory left behind: %v", entries)
+	}
+
+	redirectStdio(t, nil)
+	if code := runSelfTestCLI(tempDir); code != 1 {
+		t.Errorf("exit code %d, want 1", code)
+	}
+}
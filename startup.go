@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"pdf_editor/api"
+	pdfPkg "pdf_editor/pdf"
+)
+
+// configEnvVars are the environment variables read at startup, in the order they are reported
+var configEnvVars = []string{
+	"PORT", "MAX_FILE_SIZE", "TEMP_DIR", "DEBUG", "PDFCPU_CONFIG_DIR", "PDFCPU_GLOBAL_FLAGS",
+	"PROTECTED_OBJECTS", "CLI_MAX_CONCURRENCY", "OCR_ENGINE_PATH", "MAX_IMAGE_OCCURRENCES",
+	"REMOTE_FETCH", "REMOTE_FETCH_HEADERS", "AUDIT_DIR", "PREVIEW_MEMORY_CACHE",
+	"UPLOAD_DEDUP", "MAX_ANALYSIS_RESPONSE_SIZE", "MIN_CONFIDENCE_FLOOR",
+}
+
+// integerEnvVars are read with getEnvInt64, which silently falls back to the default on bad input
+var integerEnvVars = []string{"MAX_FILE_SIZE", "CLI_MAX_CONCURRENCY", "MAX_IMAGE_OCCURRENCES", "MAX_ANALYSIS_RESPONSE_SIZE"}
+
+// validateConfig rejects configurations the server cannot run with
+func validateConfig(config *api.Config) error {
+	if config.MaxFileSize <= 0 {
+		return fmt.Errorf("MAX_FILE_SIZE must be positive, got %d", config.MaxFileSize)
+	}
+	port, err := strconv.Atoi(config.Port)
+	if err != nil || port < 1 || port > 65535 {
+		return fmt.Errorf("PORT must be a number between 1 and 65535, got %q", config.Port)
+	}
+	if strings.TrimSpace(config.TempDir) == "" {
+		return fmt.Errorf("TEMP_DIR must not be empty")
+	}
+	if config.AuditDir != "" {
+		// Audit files must survive temp cleanup, so they may not live under TEMP_DIR
+		auditDir, auditErr := filepath.Abs(config.AuditDir)
+		tempDir, tempErr := filepath.Abs(config.TempDir)
+		if auditErr != nil || tempErr != nil {
+			return fmt.Errorf("AUDIT_DIR and TEMP_DIR must be valid paths")
+		}
+		if rel, err := filepath.Rel(tempDir, auditDir); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("AUDIT_DIR %s must not be inside TEMP_DIR %s", config.AuditDir, config.TempDir)
+		}
+	}
+	if config.MaxImageOccurrences <= 0 {
+		return fmt.Errorf("MAX_IMAGE_OCCURRENCES must be positive, got %d", config.MaxImageOccurrences)
+	}
+	if config.MinConfidenceFloor < 0 || config.MinConfidenceFloor > 1 {
+		return fmt.Errorf("MIN_CONFIDENCE_FLOOR must be between 0 and 1, got %g", config.MinConfidenceFloor)
+	}
+	if config.MaxAnalysisResponseSize <= 0 {
+		return fmt.Errorf("MAX_ANALYSIS_RESPONSE_SIZE must be positive, got %d", config.MaxAnalysisResponseSize)
+	}
+	if value := os.Getenv("CLI_MAX_CONCURRENCY"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n < 0 {
+			return fmt.Errorf("CLI_MAX_CONCURRENCY must not be negative, got %d", n)
+		}
+	}
+	return nil
+}
+
+// configWarnings lists settings that are valid but likely unintended
+func configWarnings(config *api.Config) []string {
+	warnings := []string{}
+	for _, key := range integerEnvVars {
+		if value := os.Getenv(key); value != "" {
+			if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+				warnings = append(warnings, fmt.Sprintf("%s=%q is not a number; the default is used", key, value))
+			}
+		}
+	}
+
+	// A world-writable temp dir without the sticky bit lets other local users replace uploads
+	if info, err := os.Stat(config.TempDir); err == nil && info.Mode().Perm()&0002 != 0 && info.Mode()&os.ModeSticky == 0 {
+		warnings = append(warnings, fmt.Sprintf("TEMP_DIR %s is world-writable", config.TempDir))
+	}
+	if abs, err := filepath.Abs(config.TempDir); err == nil && abs == filepath.Clean(os.TempDir()) {
+		warnings = append(warnings, fmt.Sprintf("TEMP_DIR is the shared system temp directory %s; use a dedicated subdirectory", abs))
+	}
+	if config.Debug {
+		warnings = append(warnings, "DEBUG is enabled; /api/pdf/config and debug logs are exposed")
+	}
+	return warnings
+}
+
+// remoteFetchHeaderHosts lists the hosts with configured fetch headers; the header
+// values are credentials and are never logged
+func remoteFetchHeaderHosts(config *api.Config) []string {
+	hosts := make([]string, 0, len(config.RemoteFetchHeaders))
+	for host := range config.RemoteFetchHeaders {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// pdfcpuVersion returns the path and first line of "pdfcpu version" for the startup summary
+func pdfcpuVersion() (string, string) {
+	path, err := exec.LookPath("pdfcpu")
+	if err != nil {
+		return "", "unknown"
+	}
+	output, err := exec.Command(path, "version").Output()
+	if err != nil {
+		return path, "unknown"
+	}
+	version, _, _ := strings.Cut(strings.TrimSpace(string(output)), "\n")
+	return path, version
+}
+
+// logStartupConfig logs every effective setting in a single entry, marking values that
+// come from defaults, followed by any warnings
+func logStartupConfig(config *api.Config) {
+	defaults := []string{}
+	for _, key := range configEnvVars {
+		if os.Getenv(key) == "" {
+			defaults = append(defaults, key)
+		}
+	}
+	pdfcpuPath, version := pdfcpuVersion()
+
+	fields := []string{
+		fmt.Sprintf("port=%s", config.Port),
+		fmt.Sprintf("max_file_size=%d", config.MaxFileSize),
+		fmt.Sprintf("temp_dir=%s", config.TempDir),
+		fmt.Sprintf("debug=%t", config.Debug),
+		fmt.Sprintf("cli_timeout=%s", pdfPkg.DefaultCLITimeout),
+		fmt.Sprintf("analysis_timeout=%s", pdfPkg.AnalysisTimeout),
+		fmt.Sprintf("server_timeouts=read:%s,write:%s,idle:%s", ServerReadTimeout, ServerWriteTimeout, ServerIdleTimeout),
+		fmt.Sprintf("cli_max_concurrency=%d", pdfPkg.MaxConcurrentCLI()),
+		fmt.Sprintf("min_page_coverage=%.2f", pdfPkg.MinPageCoverageThreshold),
+		fmt.Sprintf("max_deep_match_images=%d", pdfPkg.DefaultMaxDeepMatchImages),
+		fmt.Sprintf("pdfcpu=%s", pdfcpuPath),
+		fmt.Sprintf("pdfcpu_version=%q", version),
+		fmt.Sprintf("pdfcpu_config_dir=%s", config.PdfcpuConfigDir),
+		fmt.Sprintf("protected_objects=%s", strings.Join(config.ProtectedObjects, ",")),
+		fmt.Sprintf("max_image_occurrences=%d", config.MaxImageOccurrences),
+		fmt.Sprintf("max_analysis_response_size=%d", config.MaxAnalysisResponseSize),
+		fmt.Sprintf("min_confidence_floor=%g", config.MinConfidenceFloor),
+		fmt.Sprintf("ocr_enabled=%t", config.OCREnabled),
+		fmt.Sprintf("audit_dir=%s", config.AuditDir),
+		fmt.Sprintf("remote_fetch=%t", config.RemoteFetch),
+		fmt.Sprintf("preview_memory_cache=%t", config.PreviewMemoryCache),
+		fmt.Sprintf("upload_dedup=%t", config.UploadDedup),
+		fmt.Sprintf("remote_fetch_header_hosts=%s", strings.Join(remoteFetchHeaderHosts(config), ",")),
+		fmt.Sprintf("defaults=%s", strings.Join(defaults, ",")),
+	}
+	log.Printf("Startup configuration: %s", strings.Join(fields, " "))
+
+	for _, warning := range configWarnings(config) {
+		log.Printf("Configuration warning: %s", warning)
+	}
+}
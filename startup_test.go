@@ -0,0 +1,125 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"pdf_editor/api"
+)
+
+// validConfig returns a configuration validateConfig accepts
+func validConfig(t *testing.T) *api.Config {
+	t.Helper()
+	return &api.Config{
+		Port:                    "8080",
+		MaxFileSize:             50 << 20,
+		TempDir:                 t.TempDir(),
+		MaxImageOccurrences:     1000,
+		MinConfidenceFloor:      0.5,
+		MaxAnalysisResponseSize: 10 << 20,
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(c *api.Config)
+		wantErr string
+	}{
+		{name: "valid", change: func(c *api.Config) {}},
+		{name: "zero max file size", change: func(c *api.Config) { c.MaxFileSize = 0 }, wantErr: "MAX_FILE_SIZE"},
+		{name: "negative max file size", change: func(c *api.Config) { c.MaxFileSize = -1 }, wantErr: "MAX_FILE_SIZE"},
+		{name: "port not a number", change: func(c *api.Config) { c.Port = "http" }, wantErr: "PORT"},
+		{name: "port zero", change: func(c *api.Config) { c.Port = "0" }, wantErr: "PORT"},
+		{name: "port out of range", change: func(c *api.Config) { c.Port = "65536" }, wantErr: "PORT"},
+		{name: "highest port", change: func(c *api.Config) { c.Port = "65535" }},
+		{name: "empty temp dir", change: func(c *api.Config) { c.TempDir = "  " }, wantErr: "TEMP_DIR"},
+		{name: "audit dir inside temp dir", change: func(c *api.Config) { c.AuditDir = filepath.Join(c.TempDir, "audit") }, wantErr: "AUDIT_DIR"},
+		{name: "audit dir is temp dir", change: func(c *api.Config) { c.AuditDir = c.TempDir }, wantErr: "AUDIT_DIR"},
+		{name: "audit dir beside temp dir", change: func(c *api.Config) { c.AuditDir = c.TempDir + "-audit" }},
+		{name: "audit dir named like a parent", change: func(c *api.Config) { c.AuditDir = filepath.Join(c.TempDir, "..audit") }, wantErr: "AUDIT_DIR"},
+		{name: "zero max image occurrences", change: func(c *api.Config) { c.MaxImageOccurrences = 0 }, wantErr: "MAX_IMAGE_OCCURRENCES"},
+		{name: "negative confidence floor", change: func(c *api.Config) { c.MinConfidenceFloor = -0.1 }, wantErr: "MIN_CONFIDENCE_FLOOR"},
+		{name: "confidence floor above 1", change: func(c *api.Config) { c.MinConfidenceFloor = 1.5 }, wantErr: "MIN_CONFIDENCE_FLOOR"},
+		{name: "confidence floor of 1", change: func(c *api.Config) { c.MinConfidenceFloor = 1 }},
+		{name: "zero analysis response size", change: func(c *api.Config) { c.MaxAnalysisResponseSize = 0 }, wantErr: "MAX_ANALYSIS_RESPONSE_SIZE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := validConfig(t)
+			tt.change(config)
+			err := validateConfig(config)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateConfig: %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want an error about %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateConfigConcurrency(t *testing.T) {
+	t.Setenv("CLI_MAX_CONCURRENCY", "-2")
+	if err := validateConfig(validConfig(t)); err == nil || !strings.Contains(err.Error(), "CLI_MAX_CONCURRENCY") {
+		t.Errorf("err = %v, want an error about a negative CLI_MAX_CONCURRENCY", err)
+	}
+	t.Setenv("CLI_MAX_CONCURRENCY", "0")
+	if err := validateConfig(validConfig(t)); err != nil {
+		t.Errorf("CLI_MAX_CONCURRENCY=0 rejected: %v", err)
+	}
+}
+
+func TestConfigWarnings(t *testing.T) {
+	hasWarning := func(warnings []string, want string) bool {
+		for _, warning := range warnings {
+			if strings.Contains(warning, want) {
+				return true
+			}
+		}
+		return false
+	}
+
+	config := validConfig(t)
+	if err := os.Chmod(config.TempDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if warnings := configWarnings(config); len(warnings) != 0 {
+		t.Errorf("warnings for a sound configuration: %v", warnings)
+	}
+
+	t.Setenv("MAX_FILE_SIZE", "50MB")
+	if warnings := configWarnings(config); !hasWarning(warnings, "MAX_FILE_SIZE") {
+		t.Errorf("no warning for a non-numeric MAX_FILE_SIZE: %v", warnings)
+	}
+	t.Setenv("MAX_FILE_SIZE", "")
+
+	if err := os.Chmod(config.TempDir, 0777); err != nil {
+		t.Fatal(err)
+	}
+	if warnings := configWarnings(config); !hasWarning(warnings, "world-writable") {
+		t.Errorf("no warning for a world-writable temp dir: %v", warnings)
+	}
+	// The sticky bit keeps other users from replacing files, as in /tmp
+	if err := os.Chmod(config.TempDir, 0777|os.ModeSticky); err != nil {
+		t.Fatal(err)
+	}
+	if warnings := configWarnings(config); hasWarning(warnings, "world-writable") {
+		t.Errorf("warning for a sticky temp dir: %v", warnings)
+	}
+
+	config.TempDir = os.TempDir()
+	if warnings := configWarnings(config); !hasWarning(warnings, "shared system temp directory") {
+		t.Errorf("no warning for the system temp dir: %v", warnings)
+	}
+
+	config = validConfig(t)
+	os.Chmod(config.TempDir, 0700)
+	config.Debug = true
+	if warnings := configWarnings(config); !hasWarning(warnings, "DEBUG") {
+		t.Errorf("no warning for debug mode: %v", warnings)
+	}
+}